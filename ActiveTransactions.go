@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ActiveTransaction describes a transaction currently open in ExecuteInTransaction.
+type ActiveTransaction struct {
+	ID uint64
+	// Label is the label of the context the transaction has been started with (see WithLabel)
+	Label string
+	// Caller is the function that started the transaction and its position, e.g.
+	// "github.com/acme/shop.(*Orders).Place /src/shop/orders.go:42"
+	Caller   string
+	Started  time.Time
+	Duration time.Duration
+	// Statements is the number of statements executed via TxSession so far, statements executed
+	// on the *sql.Tx directly are not counted
+	Statements int64
+}
+
+// activeTransactions is the registry of all transactions open in ExecuteInTransaction.
+var activeTransactions = struct {
+	mu     sync.Mutex
+	nextId uint64
+	scopes map[uint64]*txScope
+}{scopes: map[uint64]*txScope{}}
+
+// ActiveTransactions returns the transactions currently open in ExecuteInTransaction (of all
+// connections of the process), longest running first, e.g. to debug hanging requests or
+// exhausted pools. The admin handler serves them as well (see dbadmin.NewHandler).
+func ActiveTransactions() []ActiveTransaction {
+	activeTransactions.mu.Lock()
+	scopes := make([]*txScope, 0, len(activeTransactions.scopes))
+	for _, scope := range activeTransactions.scopes {
+		scopes = append(scopes, scope)
+	}
+	activeTransactions.mu.Unlock()
+	now := time.Now()
+	result := make([]ActiveTransaction, len(scopes))
+	for i, scope := range scopes {
+		result[i] = ActiveTransaction{
+			ID:         scope.id,
+			Label:      scope.label,
+			Caller:     callerOf(scope.callers),
+			Started:    scope.started,
+			Duration:   now.Sub(scope.started),
+			Statements: scope.statements.Load(),
+		}
+	}
+	slices.SortFunc(result, func(a, b ActiveTransaction) int {
+		return a.Started.Compare(b.Started)
+	})
+	return result
+}
+
+// register adds the scope to the registry of active transactions, returning the function
+// removing it. The caller is resolved only when the transactions are inspected, keeping the
+// overhead per transaction low.
+func (s *txScope) register(ctx context.Context) func() {
+	s.started = time.Now()
+	s.label, _ = LabelFromContext(ctx)
+	s.callers = make([]uintptr, 16)
+	s.callers = s.callers[:runtime.Callers(3, s.callers)]
+	activeTransactions.mu.Lock()
+	defer activeTransactions.mu.Unlock()
+	activeTransactions.nextId++
+	s.id = activeTransactions.nextId
+	activeTransactions.scopes[s.id] = s
+	return func() {
+		activeTransactions.mu.Lock()
+		defer activeTransactions.mu.Unlock()
+		delete(activeTransactions.scopes, s.id)
+	}
+}
+
+// callerOf returns the first function outside of this package of a stack.
+func callerOf(callers []uintptr) string {
+	frames := runtime.CallersFrames(callers)
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/uoul/go-dbx.") {
+			return fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"sync"
+)
+
+type afterCommitHooks struct {
+	mu    sync.Mutex
+	hooks []func(ctx context.Context)
+}
+
+func (h *afterCommitHooks) run(ctx context.Context) {
+	h.mu.Lock()
+	hooks := h.hooks
+	h.hooks = nil
+	h.mu.Unlock()
+	for _, hook := range hooks {
+		hook(ctx)
+	}
+}
+
+// merge moves the hooks of a nested scope to h.
+func (h *afterCommitHooks) merge(nested *afterCommitHooks) {
+	nested.mu.Lock()
+	hooks := nested.hooks
+	nested.hooks = nil
+	nested.mu.Unlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, hooks...)
+}
+
+// AfterCommit registers a function to run after the transaction of the context has been
+// committed, e.g. to invalidate caches or publish events only for changes that persist.
+//
+// Within a function executed by ExecuteInTransaction, the hook runs after a successful commit
+// and is discarded on rollback, including the rollback of a nested call to its savepoint.
+// Outside of a transaction, the hook runs immediately.
+//
+// Parameters:
+//   - ctx: Context passed to the transaction function
+//   - hook: Function to run after commit
+func AfterCommit(ctx context.Context, hook func(ctx context.Context)) {
+	hooks, ok := ctx.Value(afterCommitContextKey).(*afterCommitHooks)
+	if !ok {
+		hook(ctx)
+		return
+	}
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.hooks = append(hooks.hooks, hook)
+}
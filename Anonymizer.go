@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// AnonymizeFunc computes the replacement of a column value. It is only invoked for non-NULL
+// values, NULL values are kept.
+type AnonymizeFunc func(value any) any
+
+var (
+	fakeFirstNames = []string{"Alex", "Chris", "Dana", "Eli", "Jordan", "Kim", "Lee", "Morgan", "Noah", "Robin", "Sam", "Taylor"}
+	fakeLastNames  = []string{"Baker", "Carter", "Fischer", "Garcia", "Jensen", "Kowalski", "Meyer", "Novak", "Rossi", "Silva", "Smith", "Weber"}
+)
+
+// FakeName replaces values by a fake full name. The name is derived from a hash of the
+// original value, so equal values are replaced consistently across tables and runs.
+func FakeName() AnonymizeFunc {
+	return func(value any) any {
+		h := anonymizeHash(value)
+		n := binary.BigEndian.Uint32(h)
+		return fakeFirstNames[n%uint32(len(fakeFirstNames))] + " " + fakeLastNames[(n>>16)%uint32(len(fakeLastNames))]
+	}
+}
+
+// HashEmail replaces values by an email address of the given domain, whose local part is a
+// hash of the original value. Equal addresses stay equal, so unique constraints still hold.
+func HashEmail(domain string) AnonymizeFunc {
+	return func(value any) any {
+		return hex.EncodeToString(anonymizeHash(value)[:8]) + "@" + domain
+	}
+}
+
+// HashValue replaces values by the hex encoded hash of the original value.
+func HashValue() AnonymizeFunc {
+	return func(value any) any {
+		return hex.EncodeToString(anonymizeHash(value)[:16])
+	}
+}
+
+// Nullify replaces values by NULL.
+func Nullify() AnonymizeFunc {
+	return func(value any) any {
+		return nil
+	}
+}
+
+// Constant replaces values by the given constant.
+func Constant(constant any) AnonymizeFunc {
+	return func(value any) any {
+		return constant
+	}
+}
+
+func anonymizeHash(value any) []byte {
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	h := sha256.Sum256(fmt.Appendf(nil, "%v", value))
+	return h[:]
+}
+
+// AnonymizeRule configures the anonymization of a single table.
+type AnonymizeRule struct {
+	Table string
+	// Key is the unique column rows are iterated and updated by (default: "id")
+	Key string
+	// Columns maps column names to their anonymization
+	Columns map[string]AnonymizeFunc
+	// BatchSize is the number of rows rewritten per transaction (default: 1000)
+	BatchSize int
+}
+
+// AnonymizeReport describes the outcome of anonymizing a table.
+type AnonymizeReport struct {
+	Table string
+	Rows  int64
+}
+
+// Anonymizer rewrites personal data of a database copy, e.g. after refreshing a staging
+// environment from production.
+type Anonymizer struct {
+	conn  IDbConnection
+	rules []AnonymizeRule
+}
+
+// NewAnonymizer creates an anonymizer applying the given rules on the connection, using the
+// connection's dialect. It must never be pointed at a production database.
+func NewAnonymizer(conn IDbConnection, rules ...AnonymizeRule) *Anonymizer {
+	return &Anonymizer{
+		conn:  conn,
+		rules: rules,
+	}
+}
+
+// Run anonymizes all configured tables.
+//
+// Rows are iterated in key order and rewritten in batches, each batch in its own transaction,
+// so the tables stay available and an interrupted run can simply be repeated.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//
+// Returns:
+//   - []AnonymizeReport: One report per rule
+//   - error: Joined errors of all failed rules
+func (a *Anonymizer) Run(ctx context.Context) ([]AnonymizeReport, error) {
+	reports := make([]AnonymizeReport, 0, len(a.rules))
+	var errs []error
+	for _, rule := range a.rules {
+		rows, err := a.anonymize(ctx, rule)
+		reports = append(reports, AnonymizeReport{Table: rule.Table, Rows: rows})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("anonymization of %s: %w", rule.Table, err))
+		}
+	}
+	return reports, errors.Join(errs...)
+}
+
+func (a *Anonymizer) anonymize(ctx context.Context, rule AnonymizeRule) (int64, error) {
+	if rule.Key == "" {
+		rule.Key = "id"
+	}
+	if rule.BatchSize <= 0 {
+		rule.BatchSize = 1000
+	}
+	d := dialectOf(a.conn)
+	columns := slices.Sorted(maps.Keys(rule.Columns))
+	selected := make([]string, len(columns)+1)
+	assignments := make([]string, len(columns))
+	selected[0] = d.QuoteIdentifier(rule.Key)
+	for i, col := range columns {
+		selected[i+1] = d.QuoteIdentifier(col)
+		assignments[i] = d.QuoteIdentifier(col) + " = " + d.Placeholder(i+1)
+	}
+	first := selectBatchStatement(d, rule.Table, selected, "", rule.Key, rule.BatchSize)
+	next := selectBatchStatement(d, rule.Table, selected, d.QuoteIdentifier(rule.Key)+" > "+d.Placeholder(1), rule.Key, rule.BatchSize)
+	update := fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s",
+		d.QuoteIdentifier(rule.Table), strings.Join(assignments, ", "), d.QuoteIdentifier(rule.Key), d.Placeholder(len(columns)+1))
+
+	var total int64
+	var last any
+	for {
+		n, err := ExecuteInTransaction(ctx, a.conn, func(ctx context.Context, tx *sql.Tx) (int, error) {
+			stmt, args := first, []any(nil)
+			if last != nil {
+				stmt, args = next, []any{last}
+			}
+			batch, err := queryRaw(ctx, tx, stmt, len(selected), args...)
+			if err != nil {
+				return 0, err
+			}
+			for _, row := range batch {
+				values := make([]any, len(columns)+1)
+				for i, col := range columns {
+					values[i] = row[i+1]
+					if values[i] != nil {
+						values[i] = rule.Columns[col](values[i])
+					}
+				}
+				values[len(columns)] = row[0]
+				if _, err := tx.ExecContext(ctx, update, values...); err != nil {
+					return 0, err
+				}
+			}
+			if len(batch) > 0 {
+				last = batch[len(batch)-1][0]
+			}
+			return len(batch), nil
+		})
+		total += int64(n)
+		if err != nil || n < rule.BatchSize {
+			return total, err
+		}
+	}
+}
+
+// selectBatchStatement renders a query selecting at most limit rows of table ordered by key.
+func selectBatchStatement(d IDialect, table string, columns []string, condition string, key string, limit int) string {
+	where := ""
+	if condition != "" {
+		where = " WHERE " + condition
+	}
+	cols, t, order := strings.Join(columns, ", "), d.QuoteIdentifier(table), d.QuoteIdentifier(key)
+	if d.Name() == DialectSQLServer {
+		return fmt.Sprintf("SELECT TOP (%d) %s FROM %s%s ORDER BY %s", limit, cols, t, where, order)
+	}
+	return fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT %d", cols, t, where, order, limit)
+}
+
+// queryRaw executes a query and returns the driver values of all rows.
+func queryRaw(ctx context.Context, conn IReadSession, query string, width int, args ...any) ([][]any, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var result [][]any
+	for rows.Next() {
+		row := make([]any, width)
+		dest := make([]any, width)
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// DistinctCount is the result of ApproxDistinct.
+type DistinctCount struct {
+	Count int64
+	// Approximate reports whether Count is an estimate
+	Approximate bool
+}
+
+// ApproxDistinct counts the distinct values of a column, using approximate algorithms of the
+// database where available, which are considerably cheaper on large tables than an exact count.
+//
+// SQL Server uses APPROX_COUNT_DISTINCT, Postgres uses HyperLogLog if the hll extension is
+// installed. All other dialects, as well as databases where the approximate count is not
+// available (e.g. older versions), fall back to an exact COUNT(DISTINCT ...).
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to execute the count on
+//   - table: Table containing the column
+//   - column: Column whose distinct values are counted
+//
+// Returns:
+//   - DistinctCount: Number of distinct values and whether it is an estimate
+//   - error: Non-nil if the exact count fails
+func ApproxDistinct(ctx context.Context, conn IReadSession, table string, column string) (DistinctCount, error) {
+	d := dialectOf(conn)
+	table, column = d.QuoteIdentifier(table), d.QuoteIdentifier(column)
+	var approx string
+	switch d.Name() {
+	case DialectSQLServer:
+		approx = fmt.Sprintf("SELECT CAST(APPROX_COUNT_DISTINCT(%s) AS BIGINT) FROM %s", column, table)
+	case DialectPostgres:
+		installed, err := Query[bool](ctx, conn, "SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'hll')")
+		if err == nil && len(installed) > 0 && installed[0] {
+			approx = fmt.Sprintf("SELECT COALESCE(hll_cardinality(hll_add_agg(hll_hash_any(%s))), 0)::bigint FROM %s", column, table)
+		}
+	}
+	if approx != "" {
+		count, err := Query[int64](ctx, conn, approx)
+		if err == nil && len(count) > 0 {
+			return DistinctCount{Count: count[0], Approximate: true}, nil
+		}
+		if ctx.Err() != nil {
+			return DistinctCount{}, ctx.Err()
+		}
+	}
+	count, err := Query[int64](ctx, conn, fmt.Sprintf("SELECT COUNT(DISTINCT %s) FROM %s", column, table))
+	if err != nil || len(count) == 0 {
+		return DistinctCount{}, err
+	}
+	return DistinctCount{Count: count[0]}, nil
+}
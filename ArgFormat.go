@@ -0,0 +1,152 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ArgFormat limits how statement arguments are rendered for logs and traces.
+type ArgFormat struct {
+	// MaxLength is the maximum length of a rendered argument in bytes; longer values are
+	// truncated (default: 64)
+	MaxLength int
+	// MaxArgs is the maximum number of rendered arguments; further arguments are counted only
+	// (default: 32)
+	MaxArgs int
+	// Redact reports arguments to render as <redacted>, in addition to the arguments marked
+	// using Sensitive (e.g. to redact all strings looking like e-mail addresses)
+	Redact func(arg any) bool
+}
+
+// DefaultArgFormat is the format used when no format is configured explicitly.
+var DefaultArgFormat = ArgFormat{MaxLength: 64, MaxArgs: 32}
+
+// WithArgFormat sets how statement arguments are rendered in log messages (default:
+// DefaultArgFormat).
+func WithArgFormat(format ArgFormat) ClientOption {
+	return func(c *Client) {
+		c.argFormat = format
+	}
+}
+
+// FormatArgs renders statement arguments for log messages and traces, without allocating
+// memory proportional to the size of the arguments (except for String methods):
+//   - arguments marked using Sensitive or matched by Redact are rendered as <redacted>
+//   - strings are quoted and truncated to MaxLength
+//   - byte slices are rendered as their length and a SHA-256 prefix, never as content
+//   - time.Time is rendered in RFC 3339 format
+//   - driver.Valuer values are rendered as the value they pass to the driver
+//   - slices and maps are rendered as their type and length, never as elements
+//   - other values are rendered using their String method (truncated to MaxLength), or as
+//     their type
+//
+// Parameters:
+//   - args: Arguments to render
+//   - format: Optional limits (default: DefaultArgFormat)
+//
+// Returns:
+//   - string: Rendered arguments, e.g. [42, "Ann", <1024 bytes sha256:5f3a...>]
+func FormatArgs(args []any, format ...ArgFormat) string {
+	f := DefaultArgFormat
+	if len(format) > 0 {
+		f = format[0]
+	}
+	if f.MaxLength <= 0 {
+		f.MaxLength = DefaultArgFormat.MaxLength
+	}
+	if f.MaxArgs <= 0 {
+		f.MaxArgs = DefaultArgFormat.MaxArgs
+	}
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i, arg := range args {
+		if i == f.MaxArgs {
+			fmt.Fprintf(&sb, ", ... %d more", len(args)-i)
+			break
+		}
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(formatArg(arg, f))
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+func formatArg(arg any, f ArgFormat) string {
+	maxLength := f.MaxLength
+	if f.Redact != nil && f.Redact(arg) {
+		return redactedArg
+	}
+	switch v := arg.(type) {
+	case nil:
+		return "NULL"
+	case sensitiveArg:
+		return redactedArg
+	case sql.NamedArg:
+		return v.Name + "=" + formatArg(v.Value, f)
+	case string:
+		return strconv.Quote(truncate(v, maxLength))
+	case []byte:
+		if v == nil {
+			return "NULL"
+		}
+		sum := sha256.Sum256(v)
+		return fmt.Sprintf("<%d bytes sha256:%s>", len(v), hex.EncodeToString(sum[:4]))
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v)
+	case driver.Valuer:
+		value, err := v.Value()
+		if err != nil {
+			return fmt.Sprintf("<%T: %v>", v, err)
+		}
+		if _, ok := value.(driver.Valuer); ok {
+			// Guard against values returning themselves
+			return fmt.Sprintf("<%T>", v)
+		}
+		return formatArg(value, f)
+	}
+	val := reflect.ValueOf(arg)
+	switch val.Kind() {
+	case reflect.Pointer:
+		if val.IsNil() {
+			return "NULL"
+		}
+		return formatArg(val.Elem().Interface(), f)
+	case reflect.String:
+		return strconv.Quote(truncate(val.String(), maxLength))
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(arg)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		// Elements are not rendered, since collections may be arbitrarily large
+		return fmt.Sprintf("<%s len=%d>", val.Type(), val.Len())
+	}
+	if stringer, ok := arg.(fmt.Stringer); ok {
+		return truncate(stringer.String(), maxLength)
+	}
+	return fmt.Sprintf("<%T>", arg)
+}
+
+// truncate shortens s to at most maxLength bytes (at a rune boundary), noting the omitted length.
+func truncate(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	cut := maxLength
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "...(" + strconv.Itoa(len(s)) + " bytes)"
+}
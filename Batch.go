@@ -0,0 +1,207 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// BatchStatement is a statement queued in a Batch.
+type BatchStatement struct {
+	Query string
+	Args  []any
+}
+
+// BatchResult is the outcome of a statement of a Batch.
+type BatchResult struct {
+	// Result is the result of the statement (nil if it failed or has been skipped). The result
+	// of a statement of a multi-statement call (see BatchOptions.MultiStatement) other than the
+	// last one fails with ErrUnsupportedDialect, unless the driver reports the results of all
+	// statements of the call (AllRowsAffected and AllLastInsertIds of go-sql-driver/mysql,
+	// which database/sql hides unless the session returns the driver's result itself).
+	Result sql.Result
+	// Err is the error of the statement
+	Err error
+	// Skipped reports whether the statement has not been executed, since a previous statement
+	// failed
+	Skipped bool
+}
+
+// IBatchExecutor is implemented by sessions executing several statements in one round trip,
+// e.g. adapters to pgx.Batch. Implementations return one result per statement.
+type IBatchExecutor interface {
+	ExecBatch(ctx context.Context, statements []BatchStatement) ([]BatchResult, error)
+}
+
+// BatchOptions configures the execution of a Batch.
+type BatchOptions struct {
+	// MultiStatement executes all statements as a single multi-statement call on MySQL. The
+	// DSN of go-sql-driver/mysql requires multiStatements=true, and interpolateParams=true if
+	// the statements have arguments, since the server can't prepare multiple statements.
+	// Each statement reports a result of its own, but database/sql exposes the affected rows
+	// and last insert id of the last statement only (see BatchResult); the error of the call
+	// is reported by every statement, as the failing one is unknown.
+	MultiStatement bool
+	// ContinueOnError executes the remaining statements after a statement failed, if the
+	// statements are executed one by one (default: the remaining statements are skipped)
+	ContinueOnError bool
+}
+
+// Batch queues statements not returning rows to execute them together, in one round trip
+// where the session supports it:
+//
+//	batch := db.NewBatch()
+//	batch.Queue("UPDATE accounts SET balance = balance - $1 WHERE id = $2", amount, from)
+//	batch.Queue("UPDATE accounts SET balance = balance + $1 WHERE id = $2", amount, to)
+//	results, err := batch.Execute(ctx, tx)
+//
+// Sessions implementing IBatchExecutor execute the batch themselves. Otherwise, the
+// statements are executed as one multi-statement call (see BatchOptions.MultiStatement), or
+// one by one. Batches are not safe for concurrent use.
+type Batch struct {
+	opts       BatchOptions
+	statements []BatchStatement
+	builders   map[int]IStatementBuilder
+}
+
+// NewBatch creates an empty batch.
+//
+// Parameters:
+//   - opts: Optional execution options (first element used)
+//
+// Returns:
+//   - *Batch: The batch
+func NewBatch(opts ...BatchOptions) *Batch {
+	b := &Batch{builders: map[int]IStatementBuilder{}}
+	if len(opts) > 0 {
+		b.opts = opts[0]
+	}
+	return b
+}
+
+// Queue appends a statement to the batch, returning the index of its result.
+func (b *Batch) Queue(query string, args ...any) int {
+	b.statements = append(b.statements, BatchStatement{Query: query, Args: args})
+	return len(b.statements) - 1
+}
+
+// QueueStatement appends a statement built for the dialect of the session the batch is
+// executed on, returning the index of its result.
+func (b *Batch) QueueStatement(builder IStatementBuilder) int {
+	b.statements = append(b.statements, BatchStatement{})
+	b.builders[len(b.statements)-1] = builder
+	return len(b.statements) - 1
+}
+
+// Len returns the number of queued statements.
+func (b *Batch) Len() int {
+	return len(b.statements)
+}
+
+// Execute executes the queued statements in queue order.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (connection or transaction) to execute the statements on
+//
+// Returns:
+//   - []BatchResult: The result of every statement, by queue index
+//   - error: *BatchError reporting the failed statements (or the failing builder) by queue
+//     index, or ErrUnsupportedDialect for multi-statement calls on dialects other than MySQL
+func (b *Batch) Execute(ctx context.Context, conn IWriteSession) ([]BatchResult, error) {
+	d := dialectOf(conn)
+	statements := make([]BatchStatement, len(b.statements))
+	for i, stmt := range b.statements {
+		if builder, ok := b.builders[i]; ok {
+			if insert, ok := builder.(*InsertBuilder); ok && insert.err == nil {
+				mapped, err := insert.mapStructs(nameMapperOf(conn))
+				if err != nil {
+					return nil, &BatchError{Items: []BatchItemError{{Index: i, Err: err}}}
+				}
+				builder = mapped
+			}
+			query, args, err := builder.Build(d)
+			if err != nil {
+				return nil, &BatchError{Items: []BatchItemError{{Index: i, Err: err}}}
+			}
+			stmt = BatchStatement{Query: query, Args: args}
+		}
+		statements[i] = stmt
+	}
+	if len(statements) == 0 {
+		return nil, nil
+	}
+	var results []BatchResult
+	switch executor, ok := conn.(IBatchExecutor); {
+	case ok:
+		var err error
+		if results, err = executor.ExecBatch(ctx, statements); err != nil {
+			return results, err
+		}
+	case b.opts.MultiStatement:
+		if d.Name() != DialectMySQL {
+			return nil, NewErrUnsupportedDialect("multi-statement batches are not supported by %s", d.Name())
+		}
+		queries := make([]string, len(statements))
+		var args []any
+		for i, stmt := range statements {
+			queries[i] = strings.TrimRight(strings.TrimSpace(stmt.Query), ";")
+			args = append(args, stmt.Args...)
+		}
+		result, err := conn.ExecContext(ctx, strings.Join(queries, "; "), args...)
+		results = make([]BatchResult, len(statements))
+		for i := range results {
+			results[i].Err = err
+			if err == nil {
+				results[i].Result = multiStatementResult{result: result, index: i, last: i == len(statements)-1}
+			}
+		}
+	default:
+		results = make([]BatchResult, len(statements))
+		failed := false
+		for i, stmt := range statements {
+			if failed && !b.opts.ContinueOnError {
+				results[i].Skipped = true
+				continue
+			}
+			results[i].Result, results[i].Err = conn.ExecContext(ctx, stmt.Query, stmt.Args...)
+			failed = failed || results[i].Err != nil
+		}
+	}
+	var batchErr BatchError
+	for i, result := range results {
+		batchErr.Add(i, result.Err)
+	}
+	return results, batchErr.ErrOrNil()
+}
+
+// multiStatementResult is the result of one statement of a multi-statement call.
+type multiStatementResult struct {
+	result sql.Result
+	index  int
+	// last reports whether the statement is the last one, whose values the result of the call
+	// reports
+	last bool
+}
+
+// LastInsertId implements sql.Result.
+func (r multiStatementResult) LastInsertId() (int64, error) {
+	if all, ok := r.result.(interface{ AllLastInsertIds() []int64 }); ok && r.index < len(all.AllLastInsertIds()) {
+		return all.AllLastInsertIds()[r.index], nil
+	}
+	if r.last {
+		return r.result.LastInsertId()
+	}
+	return 0, NewErrUnsupportedDialect("last insert id of statement %d of a multi-statement call is not available", r.index)
+}
+
+// RowsAffected implements sql.Result.
+func (r multiStatementResult) RowsAffected() (int64, error) {
+	if all, ok := r.result.(interface{ AllRowsAffected() []int64 }); ok && r.index < len(all.AllRowsAffected()) {
+		return all.AllRowsAffected()[r.index], nil
+	}
+	if r.last {
+		return r.result.RowsAffected()
+	}
+	return 0, NewErrUnsupportedDialect("rows affected by statement %d of a multi-statement call are not available", r.index)
+}
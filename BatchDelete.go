@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// batchDeleteStatement renders a statement deleting at most limit rows of table matching the
+// condition. Dialects without DELETE ... LIMIT delete via a limited sub-select of row ids. On
+// Postgres, a ctid is unique only within one partition, so rows are identified by (tableoid,
+// ctid), and the condition is repeated, so rows updated since the sub-select are kept.
+func batchDeleteStatement(d IDialect, table string, condition string, limit int) (string, error) {
+	t := d.QuoteIdentifier(table)
+	switch d.Name() {
+	case DialectPostgres:
+		return fmt.Sprintf("DELETE FROM %s WHERE (tableoid, ctid) IN (SELECT tableoid, ctid FROM %s WHERE %s LIMIT %d) AND (%s)", t, t, condition, limit, condition), nil
+	case DialectMySQL:
+		return fmt.Sprintf("DELETE FROM %s WHERE %s LIMIT %d", t, condition, limit), nil
+	case DialectSQLite:
+		return fmt.Sprintf("DELETE FROM %s WHERE rowid IN (SELECT rowid FROM %s WHERE %s LIMIT %d)", t, t, condition, limit), nil
+	case DialectSQLServer:
+		return fmt.Sprintf("DELETE TOP (%d) FROM %s WHERE %s", limit, t, condition), nil
+	}
+	return "", NewErrUnsupportedDialect("batched deletes are not supported by %s", d.Name())
+}
+
+// deleteInBatches deletes all rows matching the condition in batches of the given size,
+// so locks are held only briefly. It returns the total number of deleted rows.
+func deleteInBatches(ctx context.Context, conn IWriteSession, table string, condition string, batchSize int, args ...any) (int64, error) {
+	stmt, err := batchDeleteStatement(dialectOf(conn), table, condition, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for {
+		result, err := conn.ExecContext(ctx, stmt, args...)
+		if err != nil {
+			return total, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += affected
+		if affected < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// failingSession fails the statements of the given queries.
+type failingSession map[string]error
+
+func (s failingSession) ExecContext(_ context.Context, query string, _ ...any) (sql.Result, error) {
+	return nil, s[query]
+}
+
+func TestBatchExecuteReportsFailedStatementsByIndex(t *testing.T) {
+	failure := errors.New("constraint violated")
+	batch := NewBatch(BatchOptions{ContinueOnError: true})
+	batch.Queue("UPDATE a SET x = 1")
+	batch.Queue("UPDATE b SET x = 1")
+	batch.Queue("UPDATE c SET x = 1")
+	results, err := batch.Execute(context.Background(), failingSession{"UPDATE b SET x = 1": failure})
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("expected *BatchError, got %v", err)
+	}
+	if indices := batchErr.FailedIndices(); len(indices) != 1 || indices[0] != 1 {
+		t.Fatalf("failed indices %v, expected [1]", indices)
+	}
+	if !errors.Is(err, failure) || !errors.Is(results[1].Err, failure) {
+		t.Fatalf("expected the error of the statement, got %v", err)
+	}
+}
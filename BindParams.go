@@ -0,0 +1,166 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const (
+	param_tag = "param"
+)
+
+// BoundStatement is a query with named parameters bound from a struct (see BindParams).
+//
+// BoundStatement implements IStatementBuilder, so it can be executed using QueryStatement or
+// ExecStatement.
+type BoundStatement struct {
+	query  string
+	params map[string]any
+}
+
+// Build implements IStatementBuilder.
+func (s *BoundStatement) Build(dialect IDialect) (string, []any, error) {
+	return bindNamed(dialect, s.query, s.params, nil)
+}
+
+// Params returns the bound values by parameter name.
+func (s *BoundStatement) Params() map[string]any {
+	return s.params
+}
+
+// BindParams binds the named parameters (:name or @name, see QueryNamed) of a query to the
+// fields of a struct, catching parameter mistakes before the query hits the database:
+//
+//	type UserFilter struct {
+//		Tenant string `param:"tenant"`
+//		MinAge int    `param:"min_age,default=18"`
+//		Limit  int    `param:"limit,default=50"`
+//	}
+//	stmt, err := db.BindParams("SELECT * FROM users WHERE tenant = :tenant AND age >= :min_age LIMIT :limit", filter)
+//	users, err := db.QueryStatement[User](ctx, conn, stmt)
+//
+// Fields are named by their `param` tag, their `db` tag or DefaultNameMapper, in this order.
+// Fields tagged `param:"-"` are ignored. Tag options:
+//   - default=value: Value bound if the field has its zero value, parsed into the field type
+//   - sensitive: Marks the value as sensitive (see Sensitive)
+//
+// Parameters:
+//   - query: SQL query string with named parameters
+//   - params: Struct (pointer) with the parameter values
+//
+// Returns:
+//   - *BoundStatement: The query with its bound parameters
+//   - error: ErrInvalidDataType if params is no struct, a default value cannot be parsed, or
+//     parameters of the query have no field or fields have no parameter in the query
+func BindParams(query string, params any) (*BoundStatement, error) {
+	val := reflect.ValueOf(params)
+	for val.Kind() == reflect.Pointer && !val.IsNil() {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, NewErrInvalidDataType("expected struct parameters, got %T", params)
+	}
+	values := map[string]any{}
+	if err := collectParams(val, values); err != nil {
+		return nil, err
+	}
+	var missing []string
+	used := map[string]bool{}
+	_, _, err := rewriteNamed(DefaultDialect, query, func(name string) (any, bool) {
+		if _, ok := values[name]; !ok && !slices.Contains(missing, name) {
+			missing = append(missing, name)
+		}
+		used[name] = true
+		return nil, true
+	})
+	if err != nil {
+		return nil, err
+	}
+	var unused []string
+	for name := range values {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	slices.Sort(unused)
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "no field for parameters "+strings.Join(missing, ", "))
+	}
+	if len(unused) > 0 {
+		problems = append(problems, "no parameter for fields "+strings.Join(unused, ", "))
+	}
+	if len(problems) > 0 {
+		return nil, NewErrInvalidDataType("binding %s: %s", val.Type(), strings.Join(problems, "; "))
+	}
+	return &BoundStatement{query: query, params: values}, nil
+}
+
+// collectParams adds the parameter values of the fields of a struct (including embedded
+// structs) to values.
+func collectParams(val reflect.Value, values map[string]any) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+		tag := fieldType.Tag.Get(param_tag)
+		if tag == "-" {
+			continue
+		}
+		if fieldType.Anonymous && fieldType.Type.Kind() == reflect.Struct && tag == "" {
+			if err := collectParams(val.Field(i), values); err != nil {
+				return err
+			}
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = columnNameOf(fieldType, nil)
+		}
+		if _, ok := values[name]; ok {
+			return NewErrInvalidDataType("duplicate parameter %q in %s", name, typ)
+		}
+		field := val.Field(i)
+		var sensitive bool
+		for options != "" {
+			var opt string
+			opt, options, _ = strings.Cut(options, ",")
+			switch key, value, _ := strings.Cut(strings.TrimSpace(opt), "="); key {
+			case "default":
+				if !field.IsZero() {
+					continue
+				}
+				parsed, err := parseDefault(fieldType.Type, value)
+				if err != nil {
+					return fmt.Errorf("default of parameter %q: %w", name, err)
+				}
+				field = parsed
+			case "sensitive":
+				sensitive = true
+			}
+		}
+		values[name] = field.Interface()
+		if sensitive {
+			values[name] = Sensitive(values[name])
+		}
+	}
+	return nil
+}
+
+// parseDefault parses the default value of a parameter into the given type.
+func parseDefault(typ reflect.Type, s string) (reflect.Value, error) {
+	value := reflect.New(typ).Elem()
+	if typ.Kind() == reflect.Pointer {
+		elem := reflect.New(typ.Elem())
+		if err := assignString(elem.Elem(), s); err != nil {
+			return value, err
+		}
+		value.Set(elem)
+		return value, nil
+	}
+	return value, assignString(value, s)
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ICache is the cache interface used for caching query results.
+//
+// Implementations may be in-process (see MemoryCache) or adapters to external caches.
+type ICache interface {
+	Get(ctx context.Context, key string) (any, bool)
+	Set(ctx context.Context, key string, value any, ttl time.Duration)
+	Delete(ctx context.Context, key string)
+}
+
+type memoryCacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+// MemoryCache is an in-process ICache with per entry expiration.
+// Expired entries are removed lazily on access. MemoryCache is safe for concurrent use.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	clock   IClock
+	entries map[string]memoryCacheEntry
+	hits    atomic.Int64
+	misses  atomic.Int64
+}
+
+// CacheStats contains the hit rate of a cache.
+type CacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+}
+
+// HitRate returns the share of hits of all lookups (0 if there were no lookups).
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// NewMemoryCache creates an empty in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		clock:   DefaultClock,
+		entries: map[string]memoryCacheEntry{},
+	}
+}
+
+// WithClock sets the clock providing the time entries expire by (default: DefaultClock).
+func (c *MemoryCache) WithClock(clock IClock) *MemoryCache {
+	c.clock = clock
+	return c
+}
+
+// Get implements ICache.
+func (c *MemoryCache) Get(ctx context.Context, key string) (any, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+	if !entry.expires.IsZero() && !c.clock.Now().Before(entry.expires) {
+		c.Delete(ctx, key)
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	return entry.value, true
+}
+
+// Set implements ICache. A ttl of 0 keeps the entry until it is deleted.
+func (c *MemoryCache) Set(ctx context.Context, key string, value any, ttl time.Duration) {
+	entry := memoryCacheEntry{value: value}
+	if ttl > 0 {
+		entry.expires = c.clock.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+}
+
+// Delete implements ICache.
+func (c *MemoryCache) Delete(ctx context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// Stats returns the hit rate and size of the cache. Entries may include expired entries not
+// removed yet.
+func (c *MemoryCache) Stats() CacheStats {
+	c.mu.RLock()
+	entries := len(c.entries)
+	c.mu.RUnlock()
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Entries: entries}
+}
@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// IRepository is the interface of repositories managing entities of type T identified by keys
+// of type K.
+type IRepository[T any, K comparable] interface {
+	Get(ctx context.Context, id K) (T, error)
+	List(ctx context.Context) ([]T, error)
+	Create(ctx context.Context, item *T) error
+	Update(ctx context.Context, item T) error
+	Delete(ctx context.Context, id K) error
+}
+
+// CachedRepositoryOptions configures a CachedRepository.
+type CachedRepositoryOptions[T any, K comparable] struct {
+	// Key returns the key of an entity, required to invalidate cached entities on Update
+	Key func(item T) K
+	// TTL is the time entities stay cached (0 = until invalidated)
+	TTL time.Duration
+	// Namespace prefixes all cache keys (default: name of T), so repositories of different
+	// types can share a cache
+	Namespace string
+}
+
+// CachedRepository is a read-through cache over a repository.
+//
+// Get and List are served from the cache, loading missed entries from the repository. Within a
+// transaction (see ExecuteInTransaction), they bypass the cache, since the transaction may read
+// its own uncommitted writes or a snapshot older than the cached entries. Create,
+// Update and Delete invalidate the affected entries, both immediately and (if executed within
+// a transaction, see AfterCommit) after commit, so concurrent readers cannot repopulate the
+// cache with the state before the commit. Other writers of the same process invalidate entries
+// using Invalidate, typically from an AfterCommit hook.
+//
+// CachedRepository implements IRepository, cached slices and values are shared between
+// callers and must not be modified.
+type CachedRepository[T any, K comparable] struct {
+	repo  IRepository[T, K]
+	cache ICache
+	opts  CachedRepositoryOptions[T, K]
+}
+
+// NewCachedRepository creates a read-through cache over the repository.
+//
+// Parameters:
+//   - repo: Repository to load entities from and write entities to
+//   - cache: Cache storing the entities, e.g. MemoryCache or an adapter to an external cache
+//   - opts: Options of the cache layer
+//
+// Returns:
+//   - *CachedRepository[T, K]: The cached repository
+func NewCachedRepository[T any, K comparable](repo IRepository[T, K], cache ICache, opts CachedRepositoryOptions[T, K]) *CachedRepository[T, K] {
+	if opts.Namespace == "" {
+		opts.Namespace = reflect.TypeFor[T]().String()
+	}
+	return &CachedRepository[T, K]{
+		repo:  repo,
+		cache: cache,
+		opts:  opts,
+	}
+}
+
+// Get implements IRepository.
+func (r *CachedRepository[T, K]) Get(ctx context.Context, id K) (T, error) {
+	if inTransaction(ctx) {
+		return r.repo.Get(ctx, id)
+	}
+	key := r.entityKey(id)
+	if cached, ok := r.cache.Get(ctx, key); ok {
+		if item, ok := cached.(T); ok {
+			return item, nil
+		}
+	}
+	item, err := r.repo.Get(ctx, id)
+	if err != nil {
+		return item, err
+	}
+	r.cache.Set(ctx, key, item, r.opts.TTL)
+	return item, nil
+}
+
+// List implements IRepository.
+func (r *CachedRepository[T, K]) List(ctx context.Context) ([]T, error) {
+	if inTransaction(ctx) {
+		return r.repo.List(ctx)
+	}
+	key := r.listKey()
+	if cached, ok := r.cache.Get(ctx, key); ok {
+		if items, ok := cached.([]T); ok {
+			return items, nil
+		}
+	}
+	items, err := r.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.Set(ctx, key, items, r.opts.TTL)
+	return items, nil
+}
+
+// Create implements IRepository.
+func (r *CachedRepository[T, K]) Create(ctx context.Context, item *T) error {
+	if err := r.repo.Create(ctx, item); err != nil {
+		return err
+	}
+	r.invalidate(ctx, *item)
+	return nil
+}
+
+// Update implements IRepository.
+func (r *CachedRepository[T, K]) Update(ctx context.Context, item T) error {
+	if err := r.repo.Update(ctx, item); err != nil {
+		return err
+	}
+	r.invalidate(ctx, item)
+	return nil
+}
+
+// Delete implements IRepository.
+func (r *CachedRepository[T, K]) Delete(ctx context.Context, id K) error {
+	if err := r.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.Invalidate(ctx, id)
+	AfterCommit(ctx, func(ctx context.Context) { r.Invalidate(ctx, id) })
+	return nil
+}
+
+// Invalidate removes the entity with the given key and all cached lists from the cache.
+func (r *CachedRepository[T, K]) Invalidate(ctx context.Context, id K) {
+	r.cache.Delete(ctx, r.entityKey(id))
+	r.cache.Delete(ctx, r.listKey())
+}
+
+// invalidate removes the given entity (if its key is known) and all cached lists from the cache.
+func (r *CachedRepository[T, K]) invalidate(ctx context.Context, item T) {
+	run := func(ctx context.Context) {
+		if r.opts.Key != nil {
+			r.Invalidate(ctx, r.opts.Key(item))
+		} else {
+			r.cache.Delete(ctx, r.listKey())
+		}
+	}
+	run(ctx)
+	AfterCommit(ctx, run)
+}
+
+func (r *CachedRepository[T, K]) entityKey(id K) string {
+	return fmt.Sprintf("%s|entity|%v", r.opts.Namespace, id)
+}
+
+func (r *CachedRepository[T, K]) listKey() string {
+	return r.opts.Namespace + "|list"
+}
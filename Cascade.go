@@ -0,0 +1,223 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+)
+
+// CascadeAction is the action applied to the rows referencing deleted rows (see Cascade).
+type CascadeAction int
+
+const (
+	// CascadeDelete deletes the referencing rows, cascading further
+	CascadeDelete CascadeAction = iota
+	// CascadeSetNull sets the foreign key of the referencing rows to NULL
+	CascadeSetNull
+	// CascadeRestrict rejects the delete with ErrCascadeRestricted if referencing rows exist
+	CascadeRestrict
+)
+
+// String returns the name of the action.
+func (a CascadeAction) String() string {
+	switch a {
+	case CascadeDelete:
+		return "delete"
+	case CascadeSetNull:
+		return "set null"
+	case CascadeRestrict:
+		return "restrict"
+	}
+	return "unknown"
+}
+
+// CascadeRule declares the action applied to the rows of Table referencing deleted rows of
+// References via Column.
+type CascadeRule struct {
+	// Table is the referencing table
+	Table string
+	// Column is the foreign key column of the referencing table
+	Column string
+	// References is the referenced table
+	References string
+	// ReferencedColumn is the column of the referenced table (default: "id")
+	ReferencedColumn string
+	Action           CascadeAction
+}
+
+// CascadeStep is a step of a cascading delete.
+type CascadeStep struct {
+	Table string
+	// Column is the foreign key column the rows have been selected by (empty for the deleted
+	// table itself)
+	Column string
+	Action CascadeAction
+	// Rows is the number of affected rows (the number of referencing rows for CascadeRestrict)
+	Rows int64
+	// where selects the affected rows
+	where []Expr
+}
+
+// statement returns the statement modifying the affected rows of the step.
+func (s CascadeStep) statement() IStatementBuilder {
+	if s.Action == CascadeSetNull {
+		return Update(s.Table).Set(s.Column, nil).Where(s.where...)
+	}
+	return Delete(s.Table).Where(s.where...)
+}
+
+// count returns the number of affected rows of the step.
+func (s CascadeStep) count(ctx context.Context, conn IReadSession) (int64, error) {
+	counts, err := QueryStatement[int64](ctx, conn, Select(Raw("COUNT(*)")).From(s.Table).Where(s.where...))
+	if err != nil || len(counts) == 0 {
+		return 0, err
+	}
+	return counts[0], nil
+}
+
+// Cascade enforces cascade rules when deleting rows in the repository layer, e.g. where the
+// schema has no (or different) ON DELETE actions:
+//
+//	cascade := db.NewCascade(
+//		db.CascadeRule{Table: "order_items", Column: "order_id", References: "orders", Action: db.CascadeDelete},
+//		db.CascadeRule{Table: "invoices", Column: "order_id", References: "orders", Action: db.CascadeRestrict},
+//		db.CascadeRule{Table: "reviews", Column: "order_id", References: "orders", Action: db.CascadeSetNull},
+//	)
+//	steps, err := cascade.Delete(ctx, conn, "orders", db.Eq("id", id))
+//
+// Rules of deleted referencing rows apply recursively. Cyclic rules (including self-referencing
+// tables) are rejected with ErrInvalidStatement.
+type Cascade struct {
+	// rules maps referenced tables to the rules referencing them, in declaration order
+	rules map[string][]CascadeRule
+}
+
+// NewCascade creates a cascade enforcing the given rules.
+func NewCascade(rules ...CascadeRule) *Cascade {
+	c := &Cascade{rules: map[string][]CascadeRule{}}
+	for _, rule := range rules {
+		if rule.ReferencedColumn == "" {
+			rule.ReferencedColumn = "id"
+		}
+		c.rules[rule.References] = append(c.rules[rule.References], rule)
+	}
+	return c
+}
+
+// Delete deletes the rows of a table matching all predicates within a transaction (see
+// ExecuteInTransaction), applying the cascade rules to the referencing rows first. Restrictions
+// are checked before any row is modified.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database connection to execute the delete on, or the session of a running
+//     transaction (see TxSession) to execute the delete within
+//   - table: Table to delete rows of
+//   - predicates: Predicates selecting the rows, combined with AND
+//
+// Returns:
+//   - []CascadeStep: Executed steps with their affected rows, the delete of table last
+//   - error: ErrCascadeRestricted if a restricting rule matches rows, ErrInvalidStatement if
+//     the rules are cyclic, or the error of the first failing statement (rolled back)
+func (c *Cascade) Delete(ctx context.Context, conn IReadWriteSession, table string, predicates ...Expr) ([]CascadeStep, error) {
+	steps, err := c.plan(table, predicates)
+	if err != nil {
+		return nil, err
+	}
+	if connection, ok := conn.(IDbConnection); ok {
+		return ExecuteInTransaction(ctx, connection, func(ctx context.Context, tx *sql.Tx) ([]CascadeStep, error) {
+			return executeCascade(ctx, TxSession(ctx, tx), table, steps)
+		})
+	}
+	return executeCascade(ctx, conn, table, steps)
+}
+
+// executeCascade executes the steps of a cascading delete of table, checking the restrictions first.
+func executeCascade(ctx context.Context, session IReadWriteSession, table string, steps []CascadeStep) ([]CascadeStep, error) {
+	for i, step := range steps {
+		if step.Action != CascadeRestrict {
+			continue
+		}
+		rows, err := step.count(ctx, session)
+		if err != nil {
+			return nil, err
+		}
+		if rows > 0 {
+			return nil, NewErrCascadeRestricted("cannot delete from %s: %d row(s) of %s reference it via %s", table, rows, step.Table, step.Column)
+		}
+		steps[i].Rows = rows
+	}
+	for i, step := range steps {
+		if step.Action == CascadeRestrict {
+			continue
+		}
+		result, err := ExecStatement(ctx, session, step.statement())
+		if err != nil {
+			return nil, err
+		}
+		if steps[i].Rows, err = result.RowsAffected(); err != nil {
+			return nil, err
+		}
+	}
+	return steps, nil
+}
+
+// DryRun reports the steps Delete would execute with the number of rows each step would affect,
+// without modifying any row.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to count the rows on
+//   - table: Table to delete rows of
+//   - predicates: Predicates selecting the rows, combined with AND
+//
+// Returns:
+//   - []CascadeStep: Steps in execution order, the delete of table last
+//   - error: ErrInvalidStatement if the rules are cyclic, or the error of a failing count
+func (c *Cascade) DryRun(ctx context.Context, conn IReadSession, table string, predicates ...Expr) ([]CascadeStep, error) {
+	steps, err := c.plan(table, predicates)
+	if err != nil {
+		return nil, err
+	}
+	for i, step := range steps {
+		if steps[i].Rows, err = step.count(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+	return steps, nil
+}
+
+// plan returns the steps deleting the rows of table matching the predicates.
+func (c *Cascade) plan(table string, predicates []Expr) ([]CascadeStep, error) {
+	var steps []CascadeStep
+	var path []string
+	var visit func(table, column string, where []Expr) error
+	visit = func(table, column string, where []Expr) error {
+		if slices.Contains(path, table) {
+			cycle := append(path[slices.Index(path, table):], table)
+			return NewErrInvalidStatement("cyclic cascade rules between tables %s", strings.Join(cycle, " -> "))
+		}
+		path = append(path, table)
+		for _, rule := range c.rules[table] {
+			referencing := []Expr{InSubquery(rule.Column, Select(rule.ReferencedColumn).From(table).Where(where...))}
+			switch rule.Action {
+			case CascadeDelete:
+				if err := visit(rule.Table, rule.Column, referencing); err != nil {
+					return err
+				}
+			case CascadeSetNull, CascadeRestrict:
+				steps = append(steps, CascadeStep{Table: rule.Table, Column: rule.Column, Action: rule.Action, where: referencing})
+			default:
+				return NewErrInvalidStatement("unknown cascade action %d of %s.%s", rule.Action, rule.Table, rule.Column)
+			}
+		}
+		path = path[:len(path)-1]
+		steps = append(steps, CascadeStep{Table: table, Column: column, Action: CascadeDelete, where: where})
+		return nil
+	}
+	if err := visit(table, "", predicates); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
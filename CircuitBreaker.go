@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a CircuitBreaker.
+type BreakerState string
+
+const (
+	// BreakerClosed permits all calls
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen rejects all calls with ErrCircuitOpen
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen permits a single probe call, deciding whether the breaker closes again
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// CircuitBreakerOptions configures a CircuitBreaker.
+type CircuitBreakerOptions struct {
+	// Dialect classifies the errors of calls to detect connection failures (default:
+	// DefaultDialect)
+	Dialect IDialect
+	// FailureThreshold is the number of consecutive connection failures opening the breaker
+	// (default: 5)
+	FailureThreshold int
+	// OpenTimeout is the time the breaker stays open before it permits a probe call (default:
+	// 30s)
+	OpenTimeout time.Duration
+}
+
+// CircuitBreaker rejects calls while the database is unreachable, instead of letting every
+// call wait for its connection timeout:
+//
+//	breaker := db.NewCircuitBreaker(db.CircuitBreakerOptions{Dialect: db.Postgres})
+//	client := db.NewClient(database, db.WithInterceptors(breaker.Interceptor()))
+//
+// After FailureThreshold consecutive calls failed with a connection error (see ErrConnection),
+// the breaker opens and rejects calls with ErrCircuitOpen. Once OpenTimeout has elapsed, it
+// permits a single probe call: the breaker closes if it succeeds, and opens again if it fails
+// with a connection error. CircuitBreaker is safe for concurrent use.
+type CircuitBreaker struct {
+	opts     CircuitBreakerOptions
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker creates a closed circuit breaker.
+//
+// Parameters:
+//   - opts: Optional options (first element used)
+//
+// Returns:
+//   - *CircuitBreaker: The circuit breaker, install its Interceptor using WithInterceptors
+func NewCircuitBreaker(opts ...CircuitBreakerOptions) *CircuitBreaker {
+	b := &CircuitBreaker{state: BreakerClosed}
+	if len(opts) > 0 {
+		b.opts = opts[0]
+	}
+	if b.opts.Dialect == nil {
+		b.opts.Dialect = DefaultDialect
+	}
+	if b.opts.FailureThreshold <= 0 {
+		b.opts.FailureThreshold = 5
+	}
+	if b.opts.OpenTimeout <= 0 {
+		b.opts.OpenTimeout = 30 * time.Second
+	}
+	return b
+}
+
+// State returns the current state of the breaker. An open breaker whose OpenTimeout has
+// elapsed is reported as half-open, since it permits the next call.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.opts.OpenTimeout {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// Interceptor returns the interceptor enforcing the breaker, to install using WithInterceptors.
+func (b *CircuitBreaker) Interceptor() Interceptor {
+	return func(ctx context.Context, stmt StatementInfo, next func(ctx context.Context) error) error {
+		probe, err := b.permit()
+		if err != nil {
+			return err
+		}
+		err = next(ctx)
+		b.record(probe, err)
+		return err
+	}
+}
+
+// permit decides whether a call may be executed, and whether it is the probe of a half-open
+// breaker.
+func (b *CircuitBreaker) permit() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if wait := b.opts.OpenTimeout - time.Since(b.openedAt); wait > 0 {
+			return false, NewErrCircuitOpen("database unreachable, retrying in %s", wait.Round(time.Millisecond))
+		}
+		b.state = BreakerHalfOpen
+		return true, nil
+	case BreakerHalfOpen:
+		return false, NewErrCircuitOpen("database unreachable, probing")
+	}
+	return false, nil
+}
+
+// record updates the state with the outcome of a call.
+func (b *CircuitBreaker) record(probe bool, err error) {
+	failed := err != nil && errors.Is(ClassifyError(b.opts.Dialect, err), &ErrConnection{})
+	canceled := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case probe && canceled && !failed:
+		// The probe has been canceled by its caller, the next call probes again
+		b.state = BreakerOpen
+	case !failed:
+		// Calls failing otherwise have reached the database
+		b.failures = 0
+		if probe {
+			b.state = BreakerClosed
+		}
+	case probe:
+		b.state, b.openedAt = BreakerOpen, time.Now()
+	case b.state == BreakerClosed:
+		if b.failures++; b.failures >= b.opts.FailureThreshold {
+			b.state, b.openedAt = BreakerOpen, time.Now()
+		}
+	}
+}
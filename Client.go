@@ -0,0 +1,427 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithDialect sets the SQL dialect of the database (default: DefaultDialect).
+func WithDialect(dialect IDialect) ClientOption {
+	return func(c *Client) {
+		c.dialect = dialect
+	}
+}
+
+// WithDriverName sets the database/sql driver name, used to translate driver errors
+// via the registered error translators (see RegisterErrorTranslator). Without a driver
+// name, errors are classified by the dialect (see ClassifyError).
+func WithDriverName(driverName string) ClientOption {
+	return func(c *Client) {
+		c.driverName = driverName
+	}
+}
+
+// WithLogger sets the logger (default: DefaultLogger).
+func WithLogger(logger ILogger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithInterceptors appends interceptors wrapping every query, statement and transaction
+// begin. The first interceptor is the outermost one. Statements within transactions of
+// ExecuteInTransaction are intercepted if they are executed using TxSession (or the client,
+// see WithAmbientTransactions); statements executed on the *sql.Tx itself are not.
+func WithInterceptors(interceptors ...Interceptor) ClientOption {
+	return func(c *Client) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// WithRetryPolicy sets the policy for retrying operations failing with transient errors
+// (default: NoRetry). Queries, transaction begins and whole transactions (see
+// Client.ExecuteInTransaction) are retried; statements modifying data only if the context marks
+// them idempotent (see ContextWithIdempotent). Connection losses after a write or commit has been
+// sent are not retried, as the write may have been applied (see ErrConnection).
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithCache sets the cache used by QueryCached.
+func WithCache(cache ICache) ClientOption {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// WithNameMapper sets the name mapper for struct fields without `db` tag (default: DefaultNameMapper).
+func WithNameMapper(mapper NameMapper) ClientOption {
+	return func(c *Client) {
+		c.nameMapper = mapper
+	}
+}
+
+// WithTxOptions sets the transaction options used when a transaction is started without options.
+func WithTxOptions(opts sql.TxOptions) ClientOption {
+	return func(c *Client) {
+		c.txOptions = &opts
+	}
+}
+
+// WithAmbientTransactions executes queries and statements of the client within the transaction
+// carried by their context, if it has been started on the client by ExecuteInTransaction, instead
+// of on another pooled connection. Code called from a transaction function then joins the
+// transaction without passing it along, e.g. in tests using dbtest.RunInRollbackTx.
+func WithAmbientTransactions() ClientOption {
+	return func(c *Client) {
+		c.ambientTx = true
+	}
+}
+
+// Client is a database connection carrying cross-cutting settings, so they do not have to
+// be passed at every call site.
+//
+// Client implements IDbConnection, so it can be used with all free functions of this package
+// (Query, Exec, ExecuteInTransaction, ...), which pick up its dialect, name mapper and
+// default transaction options. Since Go does not support generic methods, only the
+// non-generic operations are available as methods.
+type Client struct {
+	conn         IDbConnection
+	dialect      IDialect
+	driverName   string
+	logger       ILogger
+	interceptors []Interceptor
+	retry        RetryPolicy
+	cache        ICache
+	results      cachedResults
+	clock        IClock
+	nameMapper   NameMapper
+	txOptions    *sql.TxOptions
+	txTracer     TxTracer
+	columnPolicy *ColumnPolicy
+	metrics      IMetrics
+	queryLog     ILogger
+	argFormat    ArgFormat
+	statements   *StatementCache
+	ambientTx    bool
+
+	labels             labelAccounting
+	operations         operationRegistry
+	labelComments      bool
+	slowQueryThreshold time.Duration
+	leakThreshold      time.Duration
+	maintenance        atomic.Pointer[MaintenanceMode]
+}
+
+// NewClient creates a client on top of the given connection.
+//
+// Parameters:
+//   - conn: Underlying database connection, typically *sql.DB
+//   - opts: Functional options configuring the client
+//
+// Returns:
+//   - *Client: The configured client
+func NewClient(conn IDbConnection, opts ...ClientOption) *Client {
+	c := &Client{
+		conn:      conn,
+		dialect:   DefaultDialect,
+		logger:    DefaultLogger,
+		retry:     NoRetry,
+		argFormat: DefaultArgFormat,
+		clock:     DefaultClock,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Dialect returns the SQL dialect of the client.
+func (c *Client) Dialect() IDialect {
+	return c.dialect
+}
+
+// NameMapper returns the name mapper of the client (nil if not configured).
+func (c *Client) NameMapper() NameMapper {
+	return c.nameMapper
+}
+
+// Cache returns the cache of the client (nil if not configured).
+func (c *Client) Cache() ICache {
+	return c.cache
+}
+
+// Logger returns the logger of the client.
+func (c *Client) Logger() ILogger {
+	return c.logger
+}
+
+// QueryContext implements IDbConnection.
+func (c *Client) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	stmt := StatementInfo{Operation: OperationQuery, Query: query, Args: args}
+	if err := c.checkMaintenance(ctx, stmt, false); err != nil {
+		return nil, err
+	}
+	if err := c.columnPolicy.checkStatement(ctx, c.dialect, query); err != nil {
+		return nil, err
+	}
+	stmt.Query = c.labelComment(ctx, query)
+	// Arguments marked as sensitive are passed to the driver unwrapped
+	args = driverArgs(args)
+	var rows *sql.Rows
+	start := time.Now()
+	err := c.invoke(ctx, stmt, func(ctx context.Context) error {
+		var err error
+		switch scope := c.ambientTransaction(ctx); {
+		case scope != nil:
+			scope.statements.Add(1)
+			err = scope.trace.statement(ctx, stmt, func() error {
+				rows, err = scope.tx.QueryContext(ctx, stmt.Query, args...)
+				return err
+			})
+		case c.statements != nil:
+			rows, err = c.statements.QueryContext(ctx, c.conn, stmt.Query, args...)
+		default:
+			rows, err = c.conn.QueryContext(ctx, stmt.Query, args...)
+		}
+		return err
+	})
+	c.statementLog().record(ctx, stmt, time.Since(start), -1, err)
+	if err == nil {
+		c.invalidate(ctx, query)
+	}
+	if err == nil && c.leakThreshold > 0 {
+		c.trackRows(rows, query)
+	}
+	return rows, err
+}
+
+// ExecContext implements IWriteSession.
+func (c *Client) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	stmt := StatementInfo{Operation: OperationExec, Query: query, Args: args}
+	if err := c.checkMaintenance(ctx, stmt, false); err != nil {
+		return nil, err
+	}
+	if err := c.columnPolicy.checkStatement(ctx, c.dialect, query); err != nil {
+		return nil, err
+	}
+	stmt.Query = c.labelComment(ctx, query)
+	// Arguments marked as sensitive are passed to the driver unwrapped
+	args = driverArgs(args)
+	var result sql.Result
+	start := time.Now()
+	err := c.invoke(ctx, stmt, func(ctx context.Context) error {
+		var err error
+		switch scope := c.ambientTransaction(ctx); {
+		case scope != nil:
+			scope.statements.Add(1)
+			err = scope.trace.statement(ctx, stmt, func() error {
+				result, err = scope.tx.ExecContext(ctx, stmt.Query, args...)
+				return err
+			})
+		default:
+			var session IReadWriteSession
+			if session, err = writeSession(c.conn); err != nil {
+				return err
+			}
+			if c.statements != nil {
+				result, err = c.statements.ExecContext(ctx, session, stmt.Query, args...)
+			} else {
+				result, err = session.ExecContext(ctx, stmt.Query, args...)
+			}
+		}
+		return err
+	})
+	c.statementLog().record(ctx, stmt, time.Since(start), affectedRows(result, err), err)
+	if err == nil {
+		c.invalidate(ctx, query)
+	}
+	return result, err
+}
+
+// BeginTx implements IDbConnection. If opts is nil, the client's default transaction options are used.
+func (c *Client) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if opts == nil {
+		opts = c.txOptions
+	}
+	stmt := StatementInfo{Operation: OperationBegin}
+	if err := c.checkMaintenance(ctx, stmt, opts != nil && opts.ReadOnly); err != nil {
+		return nil, err
+	}
+	var tx *sql.Tx
+	err := c.invoke(ctx, stmt, func(ctx context.Context) error {
+		var err error
+		tx, err = c.conn.BeginTx(ctx, opts)
+		return err
+	})
+	if err == nil && c.leakThreshold > 0 {
+		c.trackTx(tx)
+	}
+	return tx, err
+}
+
+// Exec executes a SQL statement that does not return rows (see Exec).
+func (c *Client) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.ExecContext(ctx, query, args...)
+}
+
+// ExecuteInTransaction executes fn within a transaction using the client's default
+// transaction options (see ExecuteInTransaction). If the transaction fails with a transient
+// error, the whole transaction is retried according to the client's retry policy. Operations
+// of the client within fn are not retried on their own, the transaction is the only retry layer.
+func (c *Client) ExecuteInTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	ctx = context.WithValue(ctx, retryScopeContextKey, true)
+	return c.retry.Do(ctx, func(ctx context.Context) error {
+		committing := false
+		_, err := ExecuteInTransaction(ctx, c, func(ctx context.Context, tx *sql.Tx) (struct{}, error) {
+			err := fn(ctx, tx)
+			committing = err == nil
+			return struct{}{}, err
+		}, c.txOptionsOrDefault())
+		err = c.translate(err)
+		if committing {
+			// The commit may have been applied before the connection has been lost
+			markUnacknowledged(err)
+		}
+		return err
+	})
+}
+
+func (c *Client) txOptionsOrDefault() sql.TxOptions {
+	if c.txOptions == nil {
+		return sql.TxOptions{}
+	}
+	return *c.txOptions
+}
+
+// invoke executes call wrapped by the interceptors, translating and retrying errors. Statements
+// modifying data (including queries with RETURNING) are only retried if they are idempotent,
+// and no operation is retried within a retried transaction.
+func (c *Client) invoke(ctx context.Context, stmt StatementInfo, call func(ctx context.Context) error) error {
+	writes := stmt.Operation == OperationExec || stmt.Operation == OperationQuery && ClassifyStatement(c.dialect, stmt.Query).Writes
+	policy := c.retry
+	if writes && !IsIdempotent(ctx) || ctx.Value(retryScopeContextKey) != nil || c.ambientTransaction(ctx) != nil {
+		policy = NoRetry
+	}
+	attempt := 0
+	return policy.Do(ctx, func(ctx context.Context) error {
+		attempt++
+		if attempt > 1 {
+			c.logger.Warn("retrying database operation", "operation", stmt.Operation, "attempt", attempt)
+		}
+		label, _ := LabelFromContext(ctx)
+		done := c.labels.start(label)
+		deregister := c.operations.start(stmt, label)
+		start := time.Now()
+		err := c.translate(chainInterceptors(ctx, c.interceptors, stmt, call))
+		if writes && !IsIdempotent(ctx) {
+			markUnacknowledged(err)
+		}
+		duration := time.Since(start)
+		// A query occupies its connection until its rows have been read
+		release := func() {
+			deregister()
+			done(time.Since(start), err)
+		}
+		if err != nil {
+			release()
+		} else {
+			DeferRelease(ctx, stmt, release)
+		}
+		if c.metrics != nil {
+			labels := metricLabelsOf(ctx, c.dialect, stmt.Operation, stmt.Query)
+			c.metrics.ObserveQueryDuration(labels, duration)
+			if err != nil {
+				c.metrics.IncErrors(labels)
+			}
+		}
+		// Failures are logged by the statement log already, if configured
+		if err != nil && !errors.Is(err, context.Canceled) && (c.queryLog == nil || stmt.Operation == OperationBegin) {
+			c.logger.Debug("database operation failed", "operation", stmt.Operation, "label", label, "query", stmt.Query, "args", FormatArgs(stmt.Args, c.argFormat), "duration", duration, "error", err)
+		}
+		if c.slowQueryThreshold > 0 && duration >= c.slowQueryThreshold {
+			c.operations.recordSlow(stmt, label, duration)
+			c.logger.Warn("slow database operation", "operation", stmt.Operation, "label", label, "query", stmt.Query, "args", FormatArgs(stmt.Args, c.argFormat), "duration", duration, "error", err)
+		}
+		return err
+	})
+}
+
+// invalidate drops what a statement executed successfully invalidated, judged by its
+// classification (see ClassifyStatement): the results cached by QueryCached read from the
+// tables it modified (once its transaction has been committed, see AfterCommit), and the
+// prepared statements referencing the tables whose schema it changed.
+func (c *Client) invalidate(ctx context.Context, query string) {
+	if c.cache == nil && c.statements == nil {
+		return
+	}
+	class := ClassifyStatement(c.dialect, query)
+	if !class.Writes || len(class.Written) == 0 {
+		return
+	}
+	if c.statements != nil && class.Type == StatementDDL {
+		c.statements.invalidateTables(c.dialect, class.Written)
+	}
+	if c.cache != nil {
+		AfterCommit(ctx, func(ctx context.Context) {
+			for _, key := range c.results.take(class.Written) {
+				c.cache.Delete(ctx, key)
+			}
+		})
+	}
+}
+
+// interceptorsOf returns the interceptors of the given session (see WithInterceptors), or nil.
+func interceptorsOf(conn any) []Interceptor {
+	if c, ok := conn.(*Client); ok {
+		return c.interceptors
+	}
+	return nil
+}
+
+// ambientTransaction returns the transaction of the client carried by the context, if ambient
+// transactions are enabled (see WithAmbientTransactions).
+func (c *Client) ambientTransaction(ctx context.Context) *txScope {
+	if !c.ambientTx {
+		return nil
+	}
+	if scope, ok := ctx.Value(transactionContextKey).(*txScope); ok && scope.owns(c) {
+		return scope
+	}
+	return nil
+}
+
+// markUnacknowledged marks a connection error of a write as unacknowledged, so it is not retried.
+func markUnacknowledged(err error) {
+	var connErr *ErrConnection
+	if errors.As(err, &connErr) {
+		connErr.Unacknowledged = true
+	}
+}
+
+func (c *Client) translate(err error) error {
+	if err == nil {
+		return nil
+	}
+	if c.driverName == "" {
+		return ClassifyError(c.dialect, err)
+	}
+	return TranslateError(c.driverName, err)
+}
+
+// dialectOf returns the dialect configured for the given session (see Client),
+// or DefaultDialect if the session has no dialect configured.
+func dialectOf(conn any) IDialect {
+	if provider, ok := conn.(interface{ Dialect() IDialect }); ok {
+		return provider.Dialect()
+	}
+	return DefaultDialect
+}
@@ -0,0 +1,33 @@
+package db
+
+import "time"
+
+// IClock provides the current time to all features that compare or generate timestamps.
+//
+// Features such as cache expiry (MemoryCache, QueryCached), retention cutoffs, reaping,
+// maintenance windows and the eviction of idle tenants never call time.Now directly, but use
+// the configured clock (DefaultClock unless overridden, e.g. by WithClock), so tests can
+// substitute a deterministic implementation. Durations of calls are measured using the
+// monotonic clock.
+type IClock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts an ordinary function to the IClock interface.
+type ClockFunc func() time.Time
+
+// Now implements IClock.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// DefaultClock is the clock used when no clock is configured explicitly.
+var DefaultClock IClock = ClockFunc(time.Now)
+
+// WithClock sets the clock of the client, used to expire the index of results cached by
+// QueryCached (default: DefaultClock).
+func WithClock(clock IClock) ClientOption {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
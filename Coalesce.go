@@ -0,0 +1,230 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/uoul/go-async"
+)
+
+// coalesceKey identifies the lookups merged into one query: lookups of the same rows on the same
+// session, for the same tenant and shard key (see ContextWithTenant and ContextWithShardKey,
+// nil if absent).
+type coalesceKey struct {
+	conn     IReadSession
+	tenant   any
+	shardKey any
+	table    string
+	column   string
+	typ      reflect.Type
+}
+
+type lookupGroup[T any] struct {
+	conn IReadSession
+	keys []any
+	// seen maps the lookup keys (see lookupKey) to the index of their key
+	seen map[any]int
+	done chan struct{}
+	rows map[any][]T
+	err  error
+}
+
+// maxCoalescedKeys is the maximum number of keys of a single IN query, further keys are looked
+// up by further queries.
+const maxCoalescedKeys = 1000
+
+// bytesKey is the lookup key of a []byte value, which is not comparable.
+type bytesKey string
+
+// lookupKey returns the key a lookup value is matched by: the value converted like database/sql
+// converts arguments, so e.g. int and int64 keys match, but 1 and "1" don't. It returns nil
+// for NULL values, which never match.
+func lookupKey(value any) (any, error) {
+	converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+	if err != nil {
+		return nil, err
+	}
+	switch v := converted.(type) {
+	case []byte:
+		return bytesKey(v), nil
+	case time.Time:
+		// Equal instants match regardless of their location
+		return v.UTC(), nil
+	}
+	return converted, nil
+}
+
+// Coalescer collects single-key lookups issued during one request and executes all lookups
+// against the same table and column as a single IN query when flushed.
+//
+// Coalescer is safe for concurrent use.
+type Coalescer struct {
+	mu      sync.Mutex
+	groups  map[coalesceKey]any
+	flushes []func(ctx context.Context)
+}
+
+// Coalesce creates a request-scoped Coalescer and attaches it to the returned context.
+//
+// All Lookup calls using the returned context are deferred until Flush is called on the
+// coalescer, at which point identical lookups are merged into one query per session, table and
+// column (or several, of up to 1000 keys each). Lookups for different tenants or shard keys are
+// not merged, and their queries are executed with the tenant or shard key of the lookups. This avoids N+1 query patterns when resolving related
+// entities one by one. Keys are matched by their value converted like database/sql converts
+// arguments, so they must have the type of the column (e.g. any integer type for integer
+// columns).
+//
+// Parameters:
+//   - ctx: Parent context, typically the request context
+//   - dialect: Ignored, the queries are rendered using the dialect of the session of the
+//     lookups (kept for compatibility)
+//
+// Returns:
+//   - context.Context: Context carrying the coalescer
+//   - *Coalescer: Coalescer to flush once all lookups have been registered
+func Coalesce(ctx context.Context, dialect IDialect) (context.Context, *Coalescer) {
+	c := &Coalescer{
+		groups: map[coalesceKey]any{},
+	}
+	return context.WithValue(ctx, coalescerContextKey, c), c
+}
+
+// Flush executes all pending lookups and resolves their results.
+// Lookups registered after Flush are collected for the next flush.
+func (c *Coalescer) Flush(ctx context.Context) {
+	c.mu.Lock()
+	flushes := c.flushes
+	c.flushes = nil
+	c.groups = map[coalesceKey]any{}
+	c.mu.Unlock()
+	for _, flush := range flushes {
+		flush(ctx)
+	}
+}
+
+// Lookup returns all rows of the table where column equals key.
+//
+// If the context carries a Coalescer (see Coalesce), the lookup is deferred and merged with
+// all other lookups against the same table and column on the same session until the coalescer
+// is flushed. Otherwise, or if the session is not comparable, the lookup is executed
+// immediately as a single query.
+//
+// Type parameter T must be a struct mapping the lookup column.
+//
+// Parameters:
+//   - ctx: Context for cancellation, optionally carrying a Coalescer
+//   - conn: Database session to execute the lookup on
+//   - table: Table to look up rows in
+//   - column: Column to compare against the key
+//   - key: Value to look up
+//
+// Returns:
+//   - async.Result[[]T]: An async result containing the matching rows or an error
+func Lookup[T any](ctx context.Context, conn IReadSession, table string, column string, key any) async.Result[[]T] {
+	c, ok := CoalescerFromContext(ctx)
+	if !ok || !reflect.TypeOf(conn).Comparable() {
+		return async.Do(ctx, func(ctx context.Context) ([]T, error) {
+			d := dialectOf(conn)
+			query := fmt.Sprintf("SELECT * FROM %s WHERE %s = %s", d.QuoteIdentifier(table), d.QuoteIdentifier(column), d.Placeholder(1))
+			return Query[T](ctx, conn, query, key, WithProjection())
+		})
+	}
+	k, err := lookupKey(key)
+	if err != nil {
+		return async.Do(ctx, func(ctx context.Context) ([]T, error) {
+			return nil, err
+		})
+	}
+	group := addLookup[T](ctx, c, conn, table, column, key, k)
+	return async.Do(ctx, func(ctx context.Context) ([]T, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-group.done:
+		}
+		if group.err != nil {
+			return nil, group.err
+		}
+		return group.rows[k], nil
+	})
+}
+
+func addLookup[T any](ctx context.Context, c *Coalescer, conn IReadSession, table string, column string, key, k any) *lookupGroup[T] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	gk := coalesceKey{
+		conn:     conn,
+		tenant:   ctx.Value(tenantContextKey),
+		shardKey: ctx.Value(shardKeyContextKey),
+		table:    table,
+		column:   column,
+		typ:      reflect.TypeFor[T](),
+	}
+	group, ok := c.groups[gk].(*lookupGroup[T])
+	if !ok {
+		group = &lookupGroup[T]{
+			conn: conn,
+			seen: map[any]int{},
+			done: make(chan struct{}),
+		}
+		c.groups[gk] = group
+		c.flushes = append(c.flushes, func(ctx context.Context) {
+			defer close(group.done)
+			if gk.tenant != nil {
+				ctx = context.WithValue(ctx, tenantContextKey, gk.tenant)
+			}
+			if gk.shardKey != nil {
+				ctx = context.WithValue(ctx, shardKeyContextKey, gk.shardKey)
+			}
+			group.rows, group.err = group.execute(ctx, dialectOf(conn), table, column)
+		})
+	}
+	if _, ok := group.seen[k]; !ok {
+		group.seen[k] = len(group.keys)
+		group.keys = append(group.keys, key)
+	}
+	return group
+}
+
+func (g *lookupGroup[T]) execute(ctx context.Context, d IDialect, table string, column string) (map[any][]T, error) {
+	rows := make(map[any][]T, len(g.keys))
+	chunkSize := min(maxInsertRows(d, 1), maxCoalescedKeys)
+	for start := 0; start < len(g.keys); start += chunkSize {
+		chunk := g.keys[start:min(start+chunkSize, len(g.keys))]
+		query := fmt.Sprintf(
+			"SELECT * FROM %s WHERE %s IN (%s)",
+			d.QuoteIdentifier(table), d.QuoteIdentifier(column), placeholders(d, 1, len(chunk)),
+		)
+		items, err := Query[T](ctx, g.conn, query, append(slices.Clone(chunk), WithProjection())...)
+		if err != nil {
+			return nil, err
+		}
+		// Distribute rows to their keys
+		for _, item := range items {
+			values, err := columnValues(item, nameMapperOf(g.conn))
+			if err != nil {
+				return nil, err
+			}
+			value, ok := values[column]
+			if !ok {
+				return nil, NewErrColumnMismatch("column %q is not mapped by %T", column, item)
+			}
+			key, err := lookupKey(value)
+			if err != nil {
+				return nil, err
+			}
+			// Rows matching keys of other chunks by conversions of the database (e.g. '1' = 1)
+			// are distributed by the query of their own key
+			if i, ok := g.seen[key]; !ok || i < start || i >= start+len(chunk) {
+				continue
+			}
+			rows[key] = append(rows[key], item)
+		}
+	}
+	return rows, nil
+}
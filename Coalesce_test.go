@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/uoul/go-async"
+)
+
+// recordingSession records the tenants of the queries executed on a database of the arrowstub
+// driver.
+type recordingSession struct {
+	database *sql.DB
+	mu       *sync.Mutex
+	tenants  *[]string
+}
+
+func (s recordingSession) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	tenant, _ := TenantFromContext(ctx)
+	s.mu.Lock()
+	*s.tenants = append(*s.tenants, tenant)
+	s.mu.Unlock()
+	return s.database.QueryContext(ctx, query, args...)
+}
+
+func newRecordingSession(t *testing.T) recordingSession {
+	database, err := sql.Open("arrowstub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return recordingSession{database: database, mu: &sync.Mutex{}, tenants: &[]string{}}
+}
+
+type coalescedRow struct {
+	Id *int64 `db:"id"`
+}
+
+func TestCoalesceSeparatesSessionsAndTenants(t *testing.T) {
+	a, b := newRecordingSession(t), newRecordingSession(t)
+	ctx, coalescer := Coalesce(context.Background(), nil)
+	results := []async.Result[[]coalescedRow]{
+		Lookup[coalescedRow](ctx, a, "t", "id", 7),
+		Lookup[coalescedRow](ctx, a, "t", "id", int64(7)),
+		Lookup[coalescedRow](ctx, b, "t", "id", 7),
+		Lookup[coalescedRow](ContextWithTenant(ctx, "acme"), a, "t", "id", 7),
+	}
+	coalescer.Flush(context.Background())
+	for i, result := range results {
+		r := <-result
+		rows, err := r.Value, r.Error
+		if err != nil {
+			t.Fatalf("lookup %d: %v", i, err)
+		}
+		if len(rows) != 1 || *rows[0].Id != 7 {
+			t.Fatalf("lookup %d returned %+v, expected the row with id 7", i, rows)
+		}
+	}
+	// a: one query without tenant and one for the tenant, b: one query
+	if len(*a.tenants) != 2 || len(*b.tenants) != 1 {
+		t.Fatalf("queries on a for tenants %q, on b for tenants %q, expected 2 and 1", *a.tenants, *b.tenants)
+	}
+	if !(((*a.tenants)[0] == "" && (*a.tenants)[1] == "acme") || ((*a.tenants)[0] == "acme" && (*a.tenants)[1] == "")) {
+		t.Fatalf("queries on a for tenants %q, expected \"\" and \"acme\"", *a.tenants)
+	}
+}
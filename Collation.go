@@ -0,0 +1,119 @@
+package db
+
+import "context"
+
+// Case-insensitive collations used by FoldCase on engines comparing case-sensitively by
+// default or where lowering a column would not use its indexes.
+const (
+	sqliteFoldCollation    = "NOCASE"
+	sqlServerFoldCollation = "Latin1_General_CI_AS"
+)
+
+type collateExpr struct {
+	expr      Expr
+	collation string
+}
+
+func (e collateExpr) writeSQL(w *sqlWriter) error {
+	if err := e.expr.writeSQL(w); err != nil {
+		return err
+	}
+	if w.d.Name() == DialectSQLServer {
+		// SQL Server does not accept delimited collation names
+		w.write(" COLLATE " + e.collation)
+	} else {
+		w.write(" COLLATE " + w.d.QuoteIdentifier(e.collation))
+	}
+	return nil
+}
+
+// Collate returns the expression column COLLATE collation, e.g. to compare or sort using a
+// specific collation. The collation name is engine specific (e.g. "und-x-icu" on Postgres,
+// "utf8mb4_unicode_ci" on MySQL).
+func Collate(column any, collation string) Expr {
+	return collateExpr{expr: toExpr(column), collation: collation}
+}
+
+type foldExpr struct {
+	column string
+}
+
+func (e foldExpr) writeSQL(w *sqlWriter) error {
+	writeFolded(w, e.column)
+	return nil
+}
+
+// FoldCase returns the column compared case-insensitively, for use in comparisons (see EqFold,
+// OrderByFold). Selected as value, it is lowered on engines using LOWER only:
+//   - Postgres, MySQL: LOWER(column)
+//   - SQLite: column COLLATE NOCASE (ASCII letters only)
+//   - SQL Server: column COLLATE Latin1_General_CI_AS
+//
+// Postgres columns of type citext compare case-insensitively by themselves (see
+// CitextColumns); comparing them using Eq keeps their indexes usable.
+func FoldCase(column string) Expr {
+	return foldExpr{column: column}
+}
+
+// writeFolded writes a column compared case-insensitively (see FoldCase).
+func writeFolded(w *sqlWriter, column string) {
+	switch w.d.Name() {
+	case DialectSQLite:
+		w.column(column)
+		w.write(" COLLATE " + sqliteFoldCollation)
+	case DialectSQLServer:
+		w.column(column)
+		w.write(" COLLATE " + sqlServerFoldCollation)
+	default:
+		w.write("LOWER(")
+		w.column(column)
+		w.write(")")
+	}
+}
+
+type eqFoldExpr struct {
+	column string
+	value  any
+}
+
+func (e eqFoldExpr) writeSQL(w *sqlWriter) error {
+	writeFolded(w, e.column)
+	w.write(" = ")
+	switch w.d.Name() {
+	case DialectSQLite, DialectSQLServer:
+		// The collation of the column applies to the comparison
+		return w.arg(e.value)
+	}
+	w.write("LOWER(")
+	if err := w.arg(e.value); err != nil {
+		return err
+	}
+	w.write(")")
+	return nil
+}
+
+// EqFold returns the case-insensitive predicate column = value (see FoldCase), behaving
+// consistently across engines regardless of column collations.
+func EqFold(column string, value any) Expr {
+	return eqFoldExpr{column: column, value: value}
+}
+
+// CitextColumns returns the columns of a table having the case-insensitive Postgres type
+// citext, which are compared case-insensitively using Eq. Other dialects have no such columns.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session of the table
+//   - table: Name of the table (in the current schema)
+//
+// Returns:
+//   - []string: Names of the citext columns in column order
+//   - error: Non-nil if the catalog query fails
+func CitextColumns(ctx context.Context, conn IReadSession, table string) ([]string, error) {
+	if dialectOf(conn).Name() != DialectPostgres {
+		return nil, nil
+	}
+	return Query[string](ctx, conn, `SELECT column_name FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1 AND udt_name = 'citext'
+		ORDER BY ordinal_position`, table)
+}
@@ -0,0 +1,537 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// ColumnPolicyMode defines how statements accessing disallowed columns are treated.
+type ColumnPolicyMode int
+
+const (
+	// ColumnPolicyReject rejects statements accessing disallowed columns with ErrAccessDenied
+	ColumnPolicyReject ColumnPolicyMode = iota
+	// ColumnPolicyMask selects NULL instead of disallowed columns and drops disallowed columns
+	// from inserts, updates and RETURNING clauses
+	ColumnPolicyMask
+)
+
+// ColumnPolicy restricts the columns the roles of a caller (see ContextWithRoles) may select or
+// write per table, enabling least-privilege data access inside a shared service:
+//
+//	policy := db.NewColumnPolicy(db.ColumnPolicyMask).
+//		Allow("users", "support", "id", "name", "email").
+//		Allow("users", "admin", "*")
+//	client := db.NewClient(database, db.WithColumnPolicy(policy))
+//
+// Tables without rules are unrestricted. On restricted tables, a column is allowed if any role
+// of the context allows it; callers without roles may access no column.
+//
+// The policy is enforced for the statement builders (Select, Insert, Update, Delete) executed
+// using QueryStatement or ExecStatement, including their subqueries (CTEs, FROM, compounds and
+// select list entries), and for InsertMany, Upsert and UpdateVersioned. Select list entries on
+// restricted tables other than (aliased) column references cannot be verified and are rejected.
+// Predicates (WHERE, JOIN ... ON) are not inspected, but statements referencing restricted tables
+// anywhere else than in the enforced clauses are rejected, as well as all other statements
+// executed by the client referencing restricted tables (e.g. raw SQL passed to Query or Exec,
+// see ClassifyStatement). INSERT ... SELECT is never masked, as masking would change the selected columns.
+//
+// Table names are matched without quotes and case-insensitively, as unquoted names are folded
+// by the databases (on PostgreSQL, a quoted name differing in case is restricted as well). A
+// rule for an unqualified table applies to the table in every schema (users restricts
+// public.users), a rule for a qualified table to its unqualified name as well.
+// Configure the policy before using it; it is read concurrently afterwards.
+type ColumnPolicy struct {
+	mode ColumnPolicyMode
+	// tables maps table -> role -> allowed columns, unqualified maps the unqualified names of
+	// qualified tables to the first of them (all names normalized, see policyTableName)
+	tables      map[string]map[string][]string
+	unqualified map[string]string
+}
+
+// NewColumnPolicy creates an empty policy (all tables unrestricted).
+func NewColumnPolicy(mode ColumnPolicyMode) *ColumnPolicy {
+	return &ColumnPolicy{mode: mode, tables: map[string]map[string][]string{}, unqualified: map[string]string{}}
+}
+
+// Allow restricts the table and allows the role to access the given columns ("*" = all).
+func (p *ColumnPolicy) Allow(table, role string, columns ...string) *ColumnPolicy {
+	table = policyTableName(table)
+	roles, ok := p.tables[table]
+	if !ok {
+		roles = map[string][]string{}
+		p.tables[table] = roles
+		if i := strings.LastIndexByte(table, '.'); i >= 0 {
+			if _, ok := p.unqualified[table[i+1:]]; !ok {
+				p.unqualified[table[i+1:]] = table
+			}
+		}
+	}
+	roles[role] = append(roles[role], columns...)
+	return p
+}
+
+// Restricted reports whether the policy restricts the table.
+func (p *ColumnPolicy) Restricted(table string) bool {
+	return p.rules(table) != nil
+}
+
+// rules returns the rules (role -> allowed columns) of a table, or nil if it is unrestricted.
+func (p *ColumnPolicy) rules(table string) map[string][]string {
+	table = policyTableName(table)
+	if roles, ok := p.tables[table]; ok {
+		return roles
+	}
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		return p.tables[table[i+1:]]
+	}
+	return p.tables[p.unqualified[table]]
+}
+
+// policyTableName normalizes a (qualified, optionally quoted) table name for matching it
+// against the rules: quotes are removed and the name is lower-cased.
+func policyTableName(table string) string {
+	return strings.ToLower(unquoteName(strings.TrimSpace(table)))
+}
+
+// Allowed reports whether the roles of the context may access the column of the table.
+func (p *ColumnPolicy) Allowed(ctx context.Context, table, column string) bool {
+	allowed, all, restricted := p.allowedColumns(ctx, table)
+	return !restricted || all || slices.Contains(allowed, column)
+}
+
+// allowedColumns returns the columns of the table allowed for the roles of the context.
+func (p *ColumnPolicy) allowedColumns(ctx context.Context, table string) (allowed []string, all bool, restricted bool) {
+	roles := p.rules(table)
+	if roles == nil {
+		return nil, true, false
+	}
+	for _, role := range RolesFromContext(ctx) {
+		for _, col := range roles[role] {
+			if col == "*" {
+				return nil, true, true
+			}
+			if !slices.Contains(allowed, col) {
+				allowed = append(allowed, col)
+			}
+		}
+	}
+	return allowed, false, true
+}
+
+// denied returns the error of a disallowed access.
+func (p *ColumnPolicy) denied(ctx context.Context, table, column string) error {
+	return NewErrAccessDenied("column %s.%s is not allowed for roles %v", table, column, RolesFromContext(ctx))
+}
+
+// writableColumns checks the written columns of a table, returning the columns to write.
+func (p *ColumnPolicy) writableColumns(ctx context.Context, table string, columns []string) ([]string, error) {
+	var result []string
+	for _, col := range columns {
+		if p.Allowed(ctx, table, col) {
+			result = append(result, col)
+		} else if p.mode == ColumnPolicyReject {
+			return nil, p.denied(ctx, table, col)
+		}
+	}
+	if len(result) == 0 && len(columns) > 0 {
+		return nil, NewErrAccessDenied("no column of %s is allowed for roles %v", table, RolesFromContext(ctx))
+	}
+	return result, nil
+}
+
+// WithColumnPolicy sets the column policy enforced for the statements of the client.
+func WithColumnPolicy(policy *ColumnPolicy) ClientOption {
+	return func(c *Client) {
+		c.columnPolicy = policy
+	}
+}
+
+// ColumnPolicy returns the column policy of the client (nil if none is configured).
+func (c *Client) ColumnPolicy() *ColumnPolicy {
+	return c.columnPolicy
+}
+
+// withPolicyVerified returns a context marking the statements executed with it as verified
+// against the column policy by the helper executing them.
+func withPolicyVerified(ctx context.Context) context.Context {
+	return context.WithValue(ctx, policyVerifiedContextKey, true)
+}
+
+// checkStatement rejects a statement executed by a client if it references restricted tables,
+// unless it has been verified against the policy (see withPolicyVerified). A nil policy
+// permits all statements.
+func (p *ColumnPolicy) checkStatement(ctx context.Context, d IDialect, query string) error {
+	if p == nil || ctx.Value(policyVerifiedContextKey) != nil {
+		return nil
+	}
+	for _, table := range ClassifyStatement(d, query).Tables {
+		if p.Restricted(table) {
+			return NewErrAccessDenied("table %s is restricted by the column policy, access it using the statement builders", table)
+		}
+	}
+	return nil
+}
+
+// columnPolicyOf returns the column policy configured for the given session, or nil.
+func columnPolicyOf(conn any) *ColumnPolicy {
+	if provider, ok := conn.(interface{ ColumnPolicy() *ColumnPolicy }); ok {
+		return provider.ColumnPolicy()
+	}
+	return nil
+}
+
+// enforceColumnPolicy applies the column policy of the session (if any) to a statement,
+// returning the statement to execute. Builders are not modified, masking works on copies.
+func enforceColumnPolicy(ctx context.Context, conn any, builder IStatementBuilder) (IStatementBuilder, error) {
+	p := columnPolicyOf(conn)
+	if p == nil {
+		return builder, nil
+	}
+	var enforced IStatementBuilder
+	switch b := builder.(type) {
+	case *SelectBuilder:
+		c, err := p.enforceSelect(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		enforced = c
+	case *InsertBuilder:
+		c, err := p.enforceInsert(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		enforced = c
+	case *UpdateBuilder:
+		c, err := p.enforceUpdate(ctx, b)
+		if err != nil {
+			return nil, err
+		}
+		enforced = c
+	case *DeleteBuilder:
+		c := *b
+		var err error
+		if c.returning, err = p.enforceReturning(ctx, b.table, b.returning); err != nil {
+			return nil, err
+		}
+		enforced = &c
+	default:
+		return builder, nil
+	}
+	if err := p.verifyTables(dialectOf(conn), enforced); err != nil {
+		return nil, err
+	}
+	return enforced, nil
+}
+
+// verifyTables rejects statements referencing restricted tables outside the clauses the policy
+// has been enforced on, e.g. in raw SQL of a select list or a subquery without FROM.
+func (p *ColumnPolicy) verifyTables(d IDialect, builder IStatementBuilder) error {
+	query, _, err := builder.Build(d)
+	if err != nil {
+		return err
+	}
+	verified := map[string]bool{}
+	verifiedTables(builder, verified)
+	for _, table := range ClassifyStatement(d, query).Tables {
+		if p.Restricted(table) && !verified[policyTableName(table)] {
+			return NewErrAccessDenied("table %s is referenced outside the clauses verified against the column policy", table)
+		}
+	}
+	return nil
+}
+
+// verifiedTables collects the tables the policy is enforced on by the builder: the tables of
+// FROM and JOIN clauses of its (nested) selects and the written table (normalized, see
+// policyTableName).
+func verifiedTables(builder any, tables map[string]bool) {
+	switch b := builder.(type) {
+	case *SelectBuilder:
+		for _, cte := range b.ctes {
+			verifiedTables(cte.query, tables)
+		}
+		verifiedTables(b.from, tables)
+		for _, j := range b.joins {
+			verifiedTables(j.table, tables)
+		}
+		for _, member := range b.compounds {
+			verifiedTables(member.query, tables)
+		}
+		for _, col := range b.columns {
+			verifiedTables(col, tables)
+		}
+	case *InsertBuilder:
+		tables[policyTableName(b.table)] = true
+		if b.query != nil {
+			verifiedTables(b.query, tables)
+		}
+	case *UpdateBuilder:
+		tables[policyTableName(b.table)] = true
+	case *DeleteBuilder:
+		tables[policyTableName(b.table)] = true
+	case tableExpr:
+		table, _, _ := strings.Cut(strings.TrimSpace(string(b)), " ")
+		tables[policyTableName(table)] = true
+	case subqueryExpr:
+		verifiedTables(b.query, tables)
+	case aliasExpr:
+		verifiedTables(b.expr, tables)
+	case rawExpr:
+		for _, arg := range b.args {
+			verifiedTables(arg, tables)
+		}
+	}
+}
+
+func (p *ColumnPolicy) enforceInsert(ctx context.Context, b *InsertBuilder) (*InsertBuilder, error) {
+	if b.query != nil && p.mode != ColumnPolicyReject {
+		// Masking would change the selected columns, so they would no longer match the inserted ones
+		reject := *p
+		reject.mode = ColumnPolicyReject
+		return reject.enforceInsert(ctx, b)
+	}
+	c := *b
+	var err error
+	if c.query != nil {
+		if c.query, err = p.enforceSelect(ctx, c.query); err != nil {
+			return nil, err
+		}
+	}
+	if c.returning, err = p.enforceReturning(ctx, b.table, b.returning); err != nil {
+		return nil, err
+	}
+	if c.columns, err = p.writableColumns(ctx, b.table, b.columns); err != nil {
+		return nil, err
+	}
+	if len(c.columns) == len(b.columns) {
+		return &c, nil
+	}
+	// Drop the values of masked columns
+	c.rows = make([][]any, len(b.rows))
+	for i, row := range b.rows {
+		for j, value := range row {
+			if j < len(b.columns) && slices.Contains(c.columns, b.columns[j]) {
+				c.rows[i] = append(c.rows[i], value)
+			}
+		}
+	}
+	return &c, nil
+}
+
+func (p *ColumnPolicy) enforceUpdate(ctx context.Context, b *UpdateBuilder) (*UpdateBuilder, error) {
+	c := *b
+	var err error
+	if c.returning, err = p.enforceReturning(ctx, b.table, b.returning); err != nil {
+		return nil, err
+	}
+	c.set = nil
+	for _, a := range b.set {
+		if p.Allowed(ctx, b.table, a.column) {
+			c.set = append(c.set, a)
+		} else if p.mode == ColumnPolicyReject {
+			return nil, p.denied(ctx, b.table, a.column)
+		}
+	}
+	if len(c.set) == 0 && len(b.set) > 0 {
+		return nil, NewErrAccessDenied("no column of %s is allowed for roles %v", b.table, RolesFromContext(ctx))
+	}
+	return &c, nil
+}
+
+func (p *ColumnPolicy) enforceReturning(ctx context.Context, table string, r returning) (returning, error) {
+	var result returning
+	for _, col := range r {
+		if p.Allowed(ctx, table, col) {
+			result = append(result, col)
+		} else if p.mode == ColumnPolicyReject {
+			return nil, p.denied(ctx, table, col)
+		}
+	}
+	return result, nil
+}
+
+// selectScope describes the tables a select list refers to.
+type selectScope struct {
+	// qualifiers maps table names and aliases to table names
+	qualifiers map[string]string
+	// sources are the qualifiers of the joined tables, in order
+	sources []string
+}
+
+func (s *selectScope) add(e Expr) {
+	t, ok := e.(tableExpr)
+	if !ok {
+		return
+	}
+	table, alias, _ := strings.Cut(strings.TrimSpace(string(t)), " ")
+	alias = strings.TrimSpace(alias)
+	s.qualifiers[table] = table
+	qualifier := table
+	if alias != "" {
+		s.qualifiers[alias] = table
+		qualifier = alias
+	}
+	s.sources = append(s.sources, qualifier)
+}
+
+func (p *ColumnPolicy) enforceSelect(ctx context.Context, b *SelectBuilder) (*SelectBuilder, error) {
+	c := *b
+	// Enforce nested statements
+	c.ctes = slices.Clone(b.ctes)
+	for i, cte := range c.ctes {
+		if query, ok := cte.query.(*SelectBuilder); ok {
+			enforced, err := p.enforceSelect(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			c.ctes[i].query = enforced
+		}
+	}
+	if sub, ok := c.from.(subqueryExpr); ok {
+		if query, ok := sub.query.(*SelectBuilder); ok {
+			enforced, err := p.enforceSelect(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			c.from = subqueryExpr{query: enforced, alias: sub.alias}
+		}
+	}
+	c.compounds = slices.Clone(b.compounds)
+	for i, member := range c.compounds {
+		enforced, err := p.enforceSelect(ctx, member.query)
+		if err != nil {
+			return nil, err
+		}
+		c.compounds[i].query = enforced
+	}
+	c.columns = slices.Clone(b.columns)
+	for i, col := range c.columns {
+		enforced, err := p.enforceSubqueries(ctx, col)
+		if err != nil {
+			return nil, err
+		}
+		c.columns[i] = enforced
+	}
+	// Enforce the select list
+	scope := selectScope{qualifiers: map[string]string{}}
+	scope.add(c.from)
+	for _, j := range c.joins {
+		scope.add(j.table)
+	}
+	restricted := slices.ContainsFunc(scope.sources, func(q string) bool {
+		return p.Restricted(scope.qualifiers[q])
+	})
+	if !restricted {
+		return &c, nil
+	}
+	columns := c.columns
+	if len(columns) == 0 {
+		columns = []Expr{Col("*")}
+	}
+	c.columns = nil
+	for _, col := range columns {
+		enforced, err := p.enforceSelectColumn(ctx, col, scope)
+		if err != nil {
+			return nil, err
+		}
+		c.columns = append(c.columns, enforced...)
+	}
+	if len(c.columns) == 0 {
+		return nil, NewErrAccessDenied("no selected column is allowed for roles %v", RolesFromContext(ctx))
+	}
+	return &c, nil
+}
+
+// enforceSubqueries enforces the subqueries of a select list entry, e.g. As(Select(...), "n") or
+// Raw("(?)", Select(...)), returning the entry to select instead.
+func (p *ColumnPolicy) enforceSubqueries(ctx context.Context, expr Expr) (Expr, error) {
+	switch e := expr.(type) {
+	case *SelectBuilder:
+		return p.enforceSelect(ctx, e)
+	case aliasExpr:
+		enforced, err := p.enforceSubqueries(ctx, e.expr)
+		if err != nil {
+			return nil, err
+		}
+		return aliasExpr{expr: enforced, alias: e.alias}, nil
+	case rawExpr:
+		args := slices.Clone(e.args)
+		for i, arg := range args {
+			if query, ok := arg.(*SelectBuilder); ok {
+				enforced, err := p.enforceSelect(ctx, query)
+				if err != nil {
+					return nil, err
+				}
+				args[i] = enforced
+			}
+		}
+		return rawExpr{sql: e.sql, args: args}, nil
+	}
+	return expr, nil
+}
+
+// enforceSelectColumn checks an entry of a select list, returning the entries to select instead.
+func (p *ColumnPolicy) enforceSelectColumn(ctx context.Context, expr Expr, scope selectScope) ([]Expr, error) {
+	alias := ""
+	if a, ok := expr.(aliasExpr); ok {
+		alias = a.alias
+		expr = a.expr
+	}
+	col, ok := expr.(columnExpr)
+	if !ok || !columnPattern.MatchString(string(col)) {
+		return nil, NewErrAccessDenied("select list entries other than columns can't be verified against the column policy")
+	}
+	name := string(col)
+	qualifier, column := "", name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		qualifier, column = name[:i], name[i+1:]
+	}
+	sources := scope.sources
+	if qualifier != "" {
+		sources = []string{qualifier}
+	}
+	if column == "*" {
+		// Expand to the allowed columns of restricted tables
+		var expanded []Expr
+		for _, source := range sources {
+			table := scope.qualifiers[source]
+			allowed, all, _ := p.allowedColumns(ctx, table)
+			if all && qualifier == "" && len(scope.sources) == 1 {
+				return []Expr{col}, nil
+			}
+			if all {
+				expanded = append(expanded, Col(source+".*"))
+				continue
+			}
+			if p.mode == ColumnPolicyReject {
+				return nil, NewErrAccessDenied("%s.* includes columns not allowed for roles %v", table, RolesFromContext(ctx))
+			}
+			for _, allowedCol := range allowed {
+				expanded = append(expanded, Col(source+"."+allowedCol))
+			}
+		}
+		return expanded, nil
+	}
+	// Unqualified columns of joins must be allowed for all tables they may belong to
+	for _, source := range sources {
+		table, ok := scope.qualifiers[source]
+		if !ok {
+			table = source
+		}
+		if p.Allowed(ctx, table, column) {
+			continue
+		}
+		if p.mode == ColumnPolicyReject {
+			return nil, p.denied(ctx, table, column)
+		}
+		if alias == "" {
+			alias = column
+		}
+		return []Expr{As(Raw("NULL"), alias)}, nil
+	}
+	if alias != "" {
+		return []Expr{As(col, alias)}, nil
+	}
+	return []Expr{col}, nil
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestColumnPolicyMatchesTableNames(t *testing.T) {
+	policy := NewColumnPolicy(ColumnPolicyReject).
+		Allow("users", "admin", "*").
+		Allow("public.accounts", "admin", "*")
+	cases := []struct {
+		table      string
+		restricted bool
+	}{
+		{"users", true},
+		{"USERS", true},
+		{`"Users"`, true},
+		{"`users`", true},
+		{"[users]", true},
+		{"public.users", true},
+		{`"public"."users"`, true},
+		{"accounts", true},
+		{"PUBLIC.Accounts", true},
+		{`"public".accounts`, true},
+		{"audit.accounts", false},
+		{"orders", false},
+		{"users_archive", false},
+	}
+	for _, c := range cases {
+		if restricted := policy.Restricted(c.table); restricted != c.restricted {
+			t.Errorf("Restricted(%q) = %v, expected %v", c.table, restricted, c.restricted)
+		}
+	}
+}
+
+func TestColumnPolicyRejectsRawStatementsOnRestrictedTables(t *testing.T) {
+	policy := NewColumnPolicy(ColumnPolicyReject).Allow("users", "admin", "*")
+	cases := []struct {
+		dialect IDialect
+		query   string
+	}{
+		{Postgres, "SELECT * FROM public.users"},
+		{Postgres, `SELECT * FROM "Users"`},
+		{Postgres, `SELECT * FROM "public"."users"`},
+		{Postgres, "SELECT * FROM USERS"},
+		{Postgres, "DELETE FROM Public.Users WHERE id = $1"},
+		{MySQL, "SELECT * FROM `app`.`users`"},
+		{MySQL, "UPDATE USERS SET name = ? WHERE id = ?"},
+		{SQLite, "SELECT o.id FROM orders o JOIN Users u ON u.id = o.user_id"},
+	}
+	for _, c := range cases {
+		err := policy.checkStatement(context.Background(), c.dialect, c.query)
+		var denied *ErrAccessDenied
+		if !errors.As(err, &denied) {
+			t.Errorf("%s: expected ErrAccessDenied, got %v", c.query, err)
+		}
+	}
+	if err := policy.checkStatement(context.Background(), Postgres, "SELECT * FROM orders"); err != nil {
+		t.Errorf("unrestricted table: %v", err)
+	}
+}
+
+func TestColumnPolicyRejectsUnverifiedTablesOfBuilders(t *testing.T) {
+	policy := NewColumnPolicy(ColumnPolicyReject).Allow("users", "admin", "*")
+	client := NewClient(nil, WithDialect(Postgres), WithColumnPolicy(policy))
+	ctx := ContextWithRoles(context.Background(), "admin")
+	builders := []IStatementBuilder{
+		Select("id", Raw("(SELECT max(id) FROM PUBLIC.USERS) AS last")).From("orders"),
+		Select("id", Raw(`(SELECT max(id) FROM "Users") AS last`)).From("orders"),
+	}
+	for _, builder := range builders {
+		_, err := enforceColumnPolicy(ctx, client, builder)
+		var denied *ErrAccessDenied
+		if !errors.As(err, &denied) {
+			t.Errorf("expected ErrAccessDenied, got %v", err)
+		}
+	}
+	// Tables the policy has been enforced on are verified, however they are spelled
+	if _, err := enforceColumnPolicy(ctx, client, Select("id", "name").From("Public.Users")); err != nil {
+		t.Errorf("verified table: %v", err)
+	}
+}
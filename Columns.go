@@ -0,0 +1,117 @@
+package db
+
+import (
+	"cmp"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ColumnValues returns the values of all mapped fields of the given struct keyed by column name.
+//
+// The column names are resolved exactly like parseDbResult resolves them when scanning rows,
+// including `db` tags, lower-cased field names, embedded structs and prefixed nested structs.
+// This makes the function suitable for comparing or serializing items in terms of their
+// database representation.
+//
+// Parameters:
+//   - item: Struct value (or pointer to struct) to read the column values from
+//   - opts: Query options, WithFieldNameMapper is respected
+//
+// Returns:
+//   - map[string]any: Column name to field value mapping
+//   - error: ErrInvalidDataType if item is not a struct or pointer to struct
+func ColumnValues(item any, opts ...QueryOption) (map[string]any, error) {
+	var o queryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return columnValues(item, o.nameMapper)
+}
+
+func columnValues(item any, mapper NameMapper) (map[string]any, error) {
+	val := reflect.ValueOf(item)
+	for val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil, NewErrInvalidDataType("expected struct, got nil %s", val.Type())
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, NewErrInvalidDataType("expected struct, got %s", val.Type())
+	}
+	// Copy into an addressable value, since createFieldMap works on field pointers
+	addressable := reflect.New(val.Type()).Elem()
+	addressable.Set(val)
+	fieldMap, err := createFieldMap(addressable, "", mapper)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]any, len(fieldMap))
+	for col, ptr := range fieldMap {
+		values[col] = reflect.ValueOf(ptr).Elem().Interface()
+	}
+	return values, nil
+}
+
+// Columns returns the names of all columns mapped by the struct type T in field declaration order.
+//
+// Parameters:
+//   - T: Struct type to inspect
+//
+// Returns:
+//   - []string: Column names as used when scanning rows into T
+//   - error: ErrInvalidDataType if T is not a struct
+func Columns[T any]() ([]string, error) {
+	return columnsOf(reflect.TypeFor[T](), nil)
+}
+
+func columnsOf(typ reflect.Type, mapper NameMapper) ([]string, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, NewErrInvalidDataType("expected struct, got %s", typ)
+	}
+	fieldMap, err := createFieldMap(reflect.New(typ).Elem(), "", mapper)
+	if err != nil {
+		return nil, err
+	}
+	// Fields are laid out in declaration order, so sorting by address restores it
+	columns := slices.Collect(maps.Keys(fieldMap))
+	slices.SortFunc(columns, func(a, b string) int {
+		return cmp.Compare(reflect.ValueOf(fieldMap[a]).Pointer(), reflect.ValueOf(fieldMap[b]).Pointer())
+	})
+	return columns, nil
+}
+
+var selectStarPattern = regexp.MustCompile(`(?is)^\s*SELECT\s+\*\s+FROM\s`)
+
+// projectColumns rewrites a leading "SELECT *" to the columns mapped by T.
+func projectColumns[T any](query string, mapper NameMapper) (string, error) {
+	if isPrimitiveType(reflect.TypeFor[T]()) || !selectStarPattern.MatchString(query) {
+		return query, nil
+	}
+	columns, err := columnsOf(reflect.TypeFor[T](), mapper)
+	if err != nil {
+		return "", err
+	}
+	return selectStarPattern.ReplaceAllLiteralString(query, "SELECT "+strings.Join(columns, ", ")+" FROM "), nil
+}
+
+// validateColumns checks that every column of the result set is mapped by T.
+func validateColumns[T any](columns []string, mapper NameMapper) error {
+	typ := reflect.TypeFor[T]()
+	if isPrimitiveType(typ) {
+		return nil
+	}
+	mapped, err := columnsOf(typ, mapper)
+	if err != nil {
+		return err
+	}
+	for _, col := range columns {
+		if !slices.Contains(mapped, col) {
+			return NewErrColumnMismatch("column %q is not mapped by %s", col, typ)
+		}
+	}
+	return nil
+}
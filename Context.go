@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"maps"
+)
+
+// contextKey is the type of all context keys of this package.
+//
+// Values follow the usual context precedence: a value attached to a derived context
+// overrides the value of its parent. Feature flags are the only exception, they are merged,
+// with flags of the derived context overriding flags of the same name in the parent.
+type contextKey int
+
+const (
+	actorContextKey contextKey = iota
+	tenantContextKey
+	shardKeyContextKey
+	workloadContextKey
+	featureFlagsContextKey
+	coalescerContextKey
+	queryBudgetContextKey
+	nPlusOneContextKey
+	afterCommitContextKey
+	transactionContextKey
+	labelContextKey
+	maintenanceBypassContextKey
+	timeoutTierContextKey
+	rolesContextKey
+	statementLogContextKey
+	scanCheckContextKey
+	idempotentContextKey
+	retryScopeContextKey
+	releaseScopeContextKey
+	policyVerifiedContextKey
+)
+
+// ContextWithActor returns a context carrying the actor (user or service) performing the operation.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey, actor)
+}
+
+// ActorFromContext returns the actor attached to the context.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorContextKey).(string)
+	return actor, ok
+}
+
+// ContextWithTenant returns a context carrying the tenant the operation is executed for.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey, tenant)
+}
+
+// TenantFromContext returns the tenant attached to the context.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey).(string)
+	return tenant, ok
+}
+
+// ContextWithShardKey returns a context carrying the key used to select a database shard.
+func ContextWithShardKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, shardKeyContextKey, key)
+}
+
+// ShardKeyFromContext returns the shard key attached to the context.
+func ShardKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(shardKeyContextKey).(string)
+	return key, ok
+}
+
+// ContextWithWorkload returns a context carrying the workload class of the operation
+// (e.g. "interactive", "batch", "reporting").
+func ContextWithWorkload(ctx context.Context, workload string) context.Context {
+	return context.WithValue(ctx, workloadContextKey, workload)
+}
+
+// WorkloadFromContext returns the workload class attached to the context.
+func WorkloadFromContext(ctx context.Context) (string, bool) {
+	workload, ok := ctx.Value(workloadContextKey).(string)
+	return workload, ok
+}
+
+// ContextWithRoles returns a context carrying the roles of the caller, e.g. to enforce a
+// ColumnPolicy.
+func ContextWithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesContextKey, roles)
+}
+
+// RolesFromContext returns the roles attached to the context.
+func RolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesContextKey).([]string)
+	return roles
+}
+
+// WithLabel returns a context carrying a label naming the feature an operation belongs
+// to (e.g. "checkout"). Clients account their pool usage per label, attach it to logs and
+// optionally to the SQL text (see WithLabelComments).
+func WithLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, labelContextKey, label)
+}
+
+// LabelFromContext returns the label attached to the context.
+func LabelFromContext(ctx context.Context) (string, bool) {
+	label, ok := ctx.Value(labelContextKey).(string)
+	return label, ok
+}
+
+// ContextWithIdempotent returns a context marking the statements executed with it as
+// idempotent, so clients retry them on transient errors (see WithRetryPolicy). Statements are
+// not retried otherwise, as a statement failing after it has been applied would be applied twice.
+func ContextWithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentContextKey, true)
+}
+
+// IsIdempotent reports whether the context marks statements as idempotent.
+func IsIdempotent(ctx context.Context) bool {
+	idempotent, _ := ctx.Value(idempotentContextKey).(bool)
+	return idempotent
+}
+
+// ContextWithFeatureFlags returns a context carrying the given feature flags merged with
+// the flags already attached to ctx. Flags given here override inherited flags of the same name.
+func ContextWithFeatureFlags(ctx context.Context, flags map[string]bool) context.Context {
+	merged := FeatureFlagsFromContext(ctx)
+	maps.Copy(merged, flags)
+	return context.WithValue(ctx, featureFlagsContextKey, merged)
+}
+
+// FeatureFlagsFromContext returns a copy of all feature flags attached to the context.
+func FeatureFlagsFromContext(ctx context.Context) map[string]bool {
+	flags, _ := ctx.Value(featureFlagsContextKey).(map[string]bool)
+	result := make(map[string]bool, len(flags))
+	maps.Copy(result, flags)
+	return result
+}
+
+// FeatureEnabled reports whether the feature flag with the given name is enabled in the context.
+func FeatureEnabled(ctx context.Context, name string) bool {
+	flags, _ := ctx.Value(featureFlagsContextKey).(map[string]bool)
+	return flags[name]
+}
+
+// CoalescerFromContext returns the Coalescer attached to the context by Coalesce.
+func CoalescerFromContext(ctx context.Context) (*Coalescer, bool) {
+	c, ok := ctx.Value(coalescerContextKey).(*Coalescer)
+	return c, ok
+}
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// convertAssign assigns a raw driver value to the destination pointer.
+//
+// It mirrors the conversions database/sql performs in Rows.Scan for the values drivers
+// return (nil, int64, float64, bool, []byte, string and time.Time), so values that were
+// fetched into *any can be mapped later, e.g. on a different goroutine.
+func convertAssign(dest any, src any) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return NewErrInvalidDataType("destination is not a non-nil pointer: %T", dest)
+	}
+	return assignValue(dv.Elem(), src)
+}
+
+func assignValue(dv reflect.Value, src any) error {
+	// Handle NULL
+	if src == nil {
+		switch dv.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			dv.SetZero()
+			return nil
+		}
+		return NewErrNullIntoNonNullable("converting NULL to %s is unsupported", dv.Type())
+	}
+	// Allocate pointer destinations and assign to their element
+	if dv.Kind() == reflect.Pointer {
+		elem := reflect.New(dv.Type().Elem())
+		if scanner, ok := elem.Interface().(sql.Scanner); ok {
+			if err := scanner.Scan(src); err != nil {
+				return err
+			}
+		} else if err := assignValue(elem.Elem(), src); err != nil {
+			return err
+		}
+		dv.Set(elem)
+		return nil
+	}
+	sv := reflect.ValueOf(src)
+	// Copy byte slices, since drivers may reuse their buffers
+	if b, ok := src.([]byte); ok {
+		switch {
+		case dv.Kind() == reflect.String:
+			dv.SetString(string(b))
+			return nil
+		case dv.Kind() == reflect.Slice && dv.Type().Elem().Kind() == reflect.Uint8:
+			dv.SetBytes(append([]byte(nil), b...))
+			return nil
+		case dv.Kind() == reflect.Interface:
+			dv.Set(reflect.ValueOf(append([]byte(nil), b...)))
+			return nil
+		}
+		return assignString(dv, string(b))
+	}
+	if sv.Type().AssignableTo(dv.Type()) {
+		dv.Set(sv)
+		return nil
+	}
+	switch {
+	case isNumeric(sv.Kind()) && isNumeric(dv.Kind()):
+		return assignNumber(dv, sv)
+	case sv.Kind() == reflect.String:
+		return assignString(dv, sv.String())
+	case dv.Kind() == reflect.String:
+		switch v := src.(type) {
+		case time.Time:
+			dv.SetString(v.Format(time.RFC3339Nano))
+		case bool:
+			dv.SetString(strconv.FormatBool(v))
+		default:
+			dv.SetString(fmt.Sprint(src))
+		}
+		return nil
+	case dv.Kind() == reflect.Bool && isNumeric(sv.Kind()):
+		// Like driver.Bool, only 1 and 0 are booleans
+		switch {
+		case sv.Equal(reflect.ValueOf(1).Convert(sv.Type())):
+			dv.SetBool(true)
+		case sv.IsZero():
+			dv.SetBool(false)
+		default:
+			return NewErrInvalidDataType("converting %v to %s: not a boolean", src, dv.Type())
+		}
+		return nil
+	}
+	return NewErrInvalidDataType("converting %T to %s is unsupported", src, dv.Type())
+}
+
+// assignNumber assigns a number to a numeric destination. Like database/sql, numbers which do
+// not fit into the destination (out of range, negative into unsigned, fractional into integer)
+// are rejected instead of being truncated.
+func assignNumber(dv, sv reflect.Value) error {
+	switch {
+	case sv.CanInt() && dv.CanInt() && !dv.OverflowInt(sv.Int()):
+		dv.SetInt(sv.Int())
+		return nil
+	case sv.CanUint() && dv.CanUint() && !dv.OverflowUint(sv.Uint()):
+		dv.SetUint(sv.Uint())
+		return nil
+	}
+	// Other conversions parse the formatted number, as database/sql does
+	switch {
+	case sv.CanInt():
+		return assignString(dv, strconv.FormatInt(sv.Int(), 10))
+	case sv.CanUint():
+		return assignString(dv, strconv.FormatUint(sv.Uint(), 10))
+	}
+	return assignString(dv, strconv.FormatFloat(sv.Float(), 'g', -1, sv.Type().Bits()))
+}
+
+func assignString(dv reflect.Value, s string) error {
+	var err error
+	switch dv.Kind() {
+	case reflect.String:
+		dv.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(s, 10, dv.Type().Bits()); err == nil {
+			dv.SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		if u, err = strconv.ParseUint(s, 10, dv.Type().Bits()); err == nil {
+			dv.SetUint(u)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(s, dv.Type().Bits()); err == nil {
+			dv.SetFloat(f)
+		}
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			dv.SetBool(b)
+		}
+	case reflect.Slice:
+		if dv.Type().Elem().Kind() != reflect.Uint8 {
+			return NewErrInvalidDataType("converting string to %s is unsupported", dv.Type())
+		}
+		dv.SetBytes([]byte(s))
+	default:
+		if dv.Type() == reflect.TypeFor[time.Time]() {
+			var t time.Time
+			if t, err = time.Parse(time.RFC3339Nano, s); err == nil {
+				dv.Set(reflect.ValueOf(t))
+			}
+			break
+		}
+		return NewErrInvalidDataType("converting string to %s is unsupported", dv.Type())
+	}
+	if err != nil {
+		return NewErrInvalidDataType("converting %q to %s: %v", s, dv.Type(), err)
+	}
+	return nil
+}
+
+func isNumeric(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
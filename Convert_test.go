@@ -0,0 +1,66 @@
+package db
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestConvertAssignNumbers(t *testing.T) {
+	cases := []struct {
+		name     string
+		dest     any
+		src      any
+		expected any
+	}{
+		{"int64 into int", new(int), int64(-42), -42},
+		{"int64 into int8", new(int8), int64(127), int8(127)},
+		{"int64 into uint16", new(uint16), int64(65535), uint16(65535)},
+		{"uint64 into uint8", new(uint8), uint64(255), uint8(255)},
+		{"int64 into float64", new(float64), int64(3), 3.0},
+		{"whole float64 into int", new(int), 3.0, 3},
+		{"float64 into float32", new(float32), 1.5, float32(1.5)},
+		{"int64 into bool", new(bool), int64(1), true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := convertAssign(c.dest, c.src); err != nil {
+				t.Fatal(err)
+			}
+			if actual := reflect.ValueOf(c.dest).Elem().Interface(); actual != c.expected {
+				t.Fatalf("got %v (%T), expected %v (%T)", actual, actual, c.expected, c.expected)
+			}
+		})
+	}
+}
+
+func TestConvertAssignRejectsOutOfRange(t *testing.T) {
+	cases := []struct {
+		name string
+		dest any
+		src  any
+	}{
+		{"int64 overflowing int8", new(int8), int64(128)},
+		{"int64 underflowing int16", new(int16), int64(math.MinInt16 - 1)},
+		{"negative int64 into uint", new(uint), int64(-1)},
+		{"negative int64 into uint32", new(uint32), int64(-1)},
+		{"uint64 overflowing int64", new(int64), uint64(math.MaxUint64)},
+		{"uint64 overflowing uint8", new(uint8), uint64(256)},
+		{"fractional float64 into int", new(int), 1.5},
+		{"float64 overflowing int64", new(int64), 1e20},
+		{"negative float64 into uint", new(uint), -1.0},
+		{"float64 overflowing float32", new(float32), math.MaxFloat64},
+		{"int64 into bool", new(bool), int64(2)},
+		{"int64 overflowing *int8", new(*int8), int64(300)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := convertAssign(c.dest, c.src)
+			var invalid *ErrInvalidDataType
+			if !errors.As(err, &invalid) {
+				t.Fatalf("expected ErrInvalidDataType, got %v (value %v)", err, reflect.ValueOf(c.dest).Elem())
+			}
+		})
+	}
+}
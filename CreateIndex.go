@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// IndexProgress reports the progress of an index build.
+type IndexProgress struct {
+	Phase string
+	Done  int64
+	Total int64
+}
+
+// Fraction returns the completed fraction (0..1), or 0 if the total work is unknown.
+func (p IndexProgress) Fraction() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.Done) / float64(p.Total)
+}
+
+// IndexSpec describes an index to build online.
+type IndexSpec struct {
+	Name    string
+	Table   string
+	Columns []string
+	Unique  bool
+	// Where restricts the index to matching rows (partial index, not supported by MySQL)
+	Where string
+	// Retries is the number of additional attempts after a failed build
+	Retries int
+	// PollInterval is the interval progress is polled with (default: 1s)
+	PollInterval time.Duration
+	// Progress is invoked with the build progress (nil = no polling)
+	Progress func(IndexProgress)
+}
+
+// CreateIndexConcurrently builds an index without blocking writes to the table.
+//
+// On Postgres the index is built using CREATE INDEX CONCURRENTLY, progress is polled from
+// pg_stat_progress_create_index, and invalid indexes left behind by failed builds are dropped
+// before retrying. On MySQL the index is built using ALGORITHM=INPLACE, LOCK=NONE and progress
+// is polled from performance_schema. Other dialects use a plain CREATE INDEX. The session must
+// not be a transaction, since concurrent builds cannot run inside transactions.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (not a transaction) to build the index on
+//   - spec: Index to build
+//
+// Returns:
+//   - error: Error of the last attempt if all attempts fail
+func CreateIndexConcurrently(ctx context.Context, conn IReadWriteSession, spec IndexSpec) error {
+	d := dialectOf(conn)
+	stmt := createIndexStatement(d, spec)
+	var err error
+	for attempt := 0; attempt <= spec.Retries; attempt++ {
+		if err = dropInvalidIndex(ctx, conn, d, spec.Name); err != nil {
+			return err
+		}
+		err = execWithProgress(ctx, conn, d, spec, stmt)
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	if err != nil {
+		// Do not leave an invalid index behind
+		if cleanupErr := dropInvalidIndex(context.WithoutCancel(ctx), conn, d, spec.Name); cleanupErr != nil {
+			return fmt.Errorf("%w (cleanup failed: %v)", err, cleanupErr)
+		}
+	}
+	return err
+}
+
+func createIndexStatement(d IDialect, spec IndexSpec) string {
+	columns := make([]string, len(spec.Columns))
+	for i, col := range spec.Columns {
+		columns[i] = d.QuoteIdentifier(col)
+	}
+	unique := ""
+	if spec.Unique {
+		unique = "UNIQUE "
+	}
+	where := ""
+	if spec.Where != "" {
+		where = " WHERE " + spec.Where
+	}
+	name, table, cols := d.QuoteIdentifier(spec.Name), d.QuoteIdentifier(spec.Table), strings.Join(columns, ", ")
+	switch d.Name() {
+	case DialectPostgres:
+		return fmt.Sprintf("CREATE %sINDEX CONCURRENTLY IF NOT EXISTS %s ON %s (%s)%s", unique, name, table, cols, where)
+	case DialectMySQL:
+		return fmt.Sprintf("ALTER TABLE %s ADD %sINDEX %s (%s), ALGORITHM=INPLACE, LOCK=NONE", table, unique, name, cols)
+	case DialectSQLServer:
+		return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)%s WITH (ONLINE = ON)", unique, name, table, cols, where)
+	}
+	return fmt.Sprintf("CREATE %sINDEX IF NOT EXISTS %s ON %s (%s)%s", unique, name, table, cols, where)
+}
+
+// dropInvalidIndex drops the index if it has been left invalid by a failed concurrent build (Postgres only).
+func dropInvalidIndex(ctx context.Context, conn IReadWriteSession, d IDialect, name string) error {
+	if d.Name() != DialectPostgres {
+		return nil
+	}
+	invalid, err := Query[bool](ctx, conn,
+		"SELECT NOT i.indisvalid FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid WHERE c.relname = $1",
+		unqualified(name))
+	if err != nil || len(invalid) == 0 || !invalid[0] {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, "DROP INDEX CONCURRENTLY IF EXISTS "+d.QuoteIdentifier(name))
+	return err
+}
+
+// execWithProgress executes the build statement while polling its progress. The statement is
+// marked by a unique comment, so the progress is read for the session executing it only (the
+// statement runs on any connection of the pool).
+func execWithProgress(ctx context.Context, conn IReadWriteSession, d IDialect, spec IndexSpec, stmt string) error {
+	marker := fmt.Sprintf("dbx-index-build-%016x", rand.Uint64())
+	var progressQuery string
+	var progressArgs []any
+	switch d.Name() {
+	case DialectPostgres:
+		progressQuery = "SELECT p.phase, COALESCE(p.blocks_done, 0), COALESCE(p.blocks_total, 0) FROM pg_stat_progress_create_index p " +
+			"JOIN pg_stat_activity a ON a.pid = p.pid WHERE a.query LIKE $1"
+		progressArgs = []any{"%" + marker + "%"}
+	case DialectMySQL:
+		progressQuery = "SELECT s.EVENT_NAME, COALESCE(s.WORK_COMPLETED, 0), COALESCE(s.WORK_ESTIMATED, 0) FROM performance_schema.events_stages_current s " +
+			"JOIN performance_schema.events_statements_current t ON t.THREAD_ID = s.THREAD_ID WHERE s.EVENT_NAME LIKE 'stage/innodb/alter%' AND t.SQL_TEXT LIKE ?"
+		progressArgs = []any{"%" + marker + "%"}
+	}
+	if spec.Progress == nil || progressQuery == "" {
+		_, err := conn.ExecContext(ctx, stmt)
+		return err
+	}
+	interval := spec.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	stmt = "/* " + marker + " */ " + stmt
+	pollCtx, stopPolling := context.WithCancel(ctx)
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-pollCtx.Done():
+				return
+			case <-ticker.C:
+			}
+			rows, err := conn.QueryContext(pollCtx, progressQuery, progressArgs...)
+			if err != nil {
+				continue
+			}
+			for rows.Next() {
+				var p IndexProgress
+				if rows.Scan(&p.Phase, &p.Done, &p.Total) == nil {
+					spec.Progress(p)
+				}
+			}
+			rows.Close()
+		}
+	}()
+	_, err := conn.ExecContext(ctx, stmt)
+	stopPolling()
+	<-polled
+	if err == nil {
+		spec.Progress(IndexProgress{Phase: "done", Done: 1, Total: 1})
+	}
+	return err
+}
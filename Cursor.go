@@ -0,0 +1,192 @@
+package db
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// CursorKey is the value of one sort key of the row a page ends (or starts) at.
+type CursorKey struct {
+	Column string
+	Value  any
+}
+
+// Cursor is the position of keyset pagination: the sort key values of the boundary row and
+// the direction of the next page.
+type Cursor struct {
+	Keys []CursorKey
+	// Backward reports whether the page before the boundary row is requested
+	Backward bool
+}
+
+// Value returns the value of the given sort key.
+func (c Cursor) Value(column string) (any, bool) {
+	for _, key := range c.Keys {
+		if key.Column == column {
+			return key.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Values returns the values of all sort keys, in order, e.g. to pass them as query arguments.
+func (c Cursor) Values() []any {
+	values := make([]any, len(c.Keys))
+	for i, key := range c.Keys {
+		values[i] = key.Value
+	}
+	return values
+}
+
+type cursorValue struct {
+	Column string          `json:"c"`
+	Type   string          `json:"t"`
+	Value  json.RawMessage `json:"v"`
+}
+
+type cursorPayload struct {
+	Keys     []cursorValue `json:"k"`
+	Backward bool          `json:"b,omitempty"`
+}
+
+// EncodeCursor encodes a cursor as opaque, URL safe token.
+//
+// Sort key values keep their type when decoded: all integer types are decoded as int64,
+// floats as float64, and string, bool, []byte, time.Time and nil as themselves. The token is
+// signed using HMAC-SHA256, so DecodeCursor rejects tokens modified by clients.
+//
+// Parameters:
+//   - cursor: Cursor to encode
+//   - secret: Key used to sign the token
+//
+// Returns:
+//   - string: The encoded token
+//   - error: Non-nil if a sort key has an unsupported type
+func EncodeCursor(cursor Cursor, secret []byte) (string, error) {
+	payload := cursorPayload{Backward: cursor.Backward, Keys: make([]cursorValue, len(cursor.Keys))}
+	for i, key := range cursor.Keys {
+		typ, value, err := encodeCursorValue(key.Value)
+		if err != nil {
+			return "", fmt.Errorf("cursor key %s: %w", key.Column, err)
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("cursor key %s: %w", key.Column, err)
+		}
+		payload.Keys[i] = cursorValue{Column: key.Column, Type: typ, Value: raw}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(append(cursorSignature(data, secret), data...)), nil
+}
+
+// DecodeCursor decodes and verifies a token created by EncodeCursor.
+//
+// Parameters:
+//   - token: Token to decode
+//   - secret: Key the token has been signed with
+//
+// Returns:
+//   - Cursor: The decoded cursor
+//   - error: ErrInvalidCursor if the token is malformed or its signature does not match
+func DecodeCursor(token string, secret []byte) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) < sha256.Size {
+		return Cursor{}, NewErrInvalidCursor("malformed token")
+	}
+	signature, data := raw[:sha256.Size], raw[sha256.Size:]
+	if !hmac.Equal(signature, cursorSignature(data, secret)) {
+		return Cursor{}, NewErrInvalidCursor("signature mismatch")
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return Cursor{}, NewErrInvalidCursor("malformed payload: %v", err)
+	}
+	cursor := Cursor{Backward: payload.Backward, Keys: make([]CursorKey, len(payload.Keys))}
+	for i, key := range payload.Keys {
+		value, err := decodeCursorValue(key.Type, key.Value)
+		if err != nil {
+			return Cursor{}, NewErrInvalidCursor("cursor key %s: %v", key.Column, err)
+		}
+		cursor.Keys[i] = CursorKey{Column: key.Column, Value: value}
+	}
+	return cursor, nil
+}
+
+func cursorSignature(data []byte, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func encodeCursorValue(value any) (string, any, error) {
+	switch v := value.(type) {
+	case nil:
+		return "nil", nil, nil
+	case string:
+		return "string", v, nil
+	case bool:
+		return "bool", v, nil
+	case []byte:
+		return "bytes", v, nil
+	case time.Time:
+		return "time", v.Format(time.RFC3339Nano), nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int", rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int", int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return "float", rv.Float(), nil
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return "nil", nil, nil
+		}
+		return encodeCursorValue(rv.Elem().Interface())
+	}
+	return "", nil, fmt.Errorf("unsupported type %T", value)
+}
+
+func decodeCursorValue(typ string, raw json.RawMessage) (any, error) {
+	var err error
+	switch typ {
+	case "nil":
+		return nil, nil
+	case "string":
+		var v string
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case "bool":
+		var v bool
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case "bytes":
+		var v []byte
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case "int":
+		var v int64
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case "float":
+		var v float64
+		err = json.Unmarshal(raw, &v)
+		return v, err
+	case "time":
+		var s string
+		if err = json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	}
+	return nil, fmt.Errorf("unknown type %q", typ)
+}
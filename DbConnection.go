@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DbConnection decorates a *sql.DB, invoking interceptors around every QueryContext,
+// ExecContext and BeginTx. It enables query logging, latency metrics, tracing and slow query
+// detection without support of the driver:
+//
+//	conn := db.NewDbConnection(database, db.SlowQueryInterceptor(200*time.Millisecond, logger))
+//	users, err := db.Query[User](ctx, conn, "SELECT * FROM users")
+//
+// The interceptors are the same a Client installs using WithInterceptors, the first one being
+// the outermost. Statements executed within a transaction are executed on the *sql.Tx and are
+// not intercepted; use a Client and its TxSession to observe them.
+type DbConnection struct {
+	db           *sql.DB
+	interceptors []Interceptor
+}
+
+// NewDbConnection creates a connection decorating db with the given interceptors.
+//
+// Parameters:
+//   - db: Underlying database
+//   - interceptors: Interceptors invoked around every call
+//
+// Returns:
+//   - *DbConnection: The decorated connection
+func NewDbConnection(db *sql.DB, interceptors ...Interceptor) *DbConnection {
+	return &DbConnection{db: db, interceptors: interceptors}
+}
+
+// DB returns the underlying database.
+func (c *DbConnection) DB() *sql.DB {
+	return c.db
+}
+
+// QueryContext implements IDbConnection.
+func (c *DbConnection) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := chainInterceptors(ctx, c.interceptors, StatementInfo{Operation: OperationQuery, Query: query, Args: args}, func(ctx context.Context) error {
+		var err error
+		rows, err = c.db.QueryContext(ctx, query, driverArgs(args)...)
+		return err
+	})
+	return rows, err
+}
+
+// ExecContext implements IWriteSession.
+func (c *DbConnection) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	err := chainInterceptors(ctx, c.interceptors, StatementInfo{Operation: OperationExec, Query: query, Args: args}, func(ctx context.Context) error {
+		var err error
+		result, err = c.db.ExecContext(ctx, query, driverArgs(args)...)
+		return err
+	})
+	return result, err
+}
+
+// BeginTx implements IDbConnection.
+func (c *DbConnection) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	var tx *sql.Tx
+	err := chainInterceptors(ctx, c.interceptors, StatementInfo{Operation: OperationBegin}, func(ctx context.Context) error {
+		var err error
+		tx, err = c.db.BeginTx(ctx, opts)
+		return err
+	})
+	return tx, err
+}
+
+// LoggingInterceptor logs every call like WithQueryLog logs statements: at debug level, or at
+// error level if it failed.
+func LoggingInterceptor(logger ILogger) Interceptor {
+	return func(ctx context.Context, stmt StatementInfo, next func(ctx context.Context) error) error {
+		start := time.Now()
+		err := next(ctx)
+		attrs := operationAttrs(ctx, stmt, time.Since(start))
+		if err != nil {
+			logger.Error("database operation failed", append(attrs, "error", err)...)
+			return err
+		}
+		logger.Debug("database operation", attrs...)
+		return nil
+	}
+}
+
+// SlowQueryInterceptor logs calls taking at least threshold as warning, like a Client does (see
+// WithSlowQueryThreshold).
+func SlowQueryInterceptor(threshold time.Duration, logger ILogger) Interceptor {
+	return func(ctx context.Context, stmt StatementInfo, next func(ctx context.Context) error) error {
+		start := time.Now()
+		err := next(ctx)
+		if duration := time.Since(start); duration >= threshold {
+			logger.Warn("slow database operation", append(operationAttrs(ctx, stmt, duration), "error", err)...)
+		}
+		return err
+	}
+}
+
+// operationAttrs returns the log attributes of a call.
+func operationAttrs(ctx context.Context, stmt StatementInfo, duration time.Duration) []any {
+	label, _ := LabelFromContext(ctx)
+	return []any{"operation", stmt.Operation, "label", label, "query", stmt.Query, "args", FormatArgs(stmt.Args), "duration", duration}
+}
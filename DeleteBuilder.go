@@ -0,0 +1,50 @@
+package db
+
+// DeleteBuilder builds DELETE statements.
+//
+//	stmt := db.Delete("sessions").Where(db.Lt("expires_at", now)).Returning("id")
+//
+// DeleteBuilder implements IStatementBuilder, so it can be executed using ExecStatement, or
+// QueryStatement if it returns rows.
+type DeleteBuilder struct {
+	table     string
+	where     []Expr
+	returning returning
+}
+
+// Delete starts a DELETE statement of the given table.
+func Delete(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// Where adds predicates, combined with AND. Without predicates, all rows are deleted.
+func (b *DeleteBuilder) Where(predicates ...Expr) *DeleteBuilder {
+	b.where = append(b.where, predicates...)
+	return b
+}
+
+// Returning returns the given columns of the deleted rows (RETURNING, or OUTPUT on SQL Server).
+// Not supported by MySQL.
+func (b *DeleteBuilder) Returning(columns ...string) *DeleteBuilder {
+	b.returning = columns
+	return b
+}
+
+// Build implements IStatementBuilder.
+func (b *DeleteBuilder) Build(dialect IDialect) (string, []any, error) {
+	if err := b.returning.check(dialect); err != nil {
+		return "", nil, err
+	}
+	w := newSqlWriter(dialect)
+	w.write("DELETE FROM " + dialect.QuoteIdentifier(b.table))
+	b.returning.writeOutput(w, "DELETED")
+	if len(b.where) > 0 {
+		w.write(" WHERE ")
+		if err := And(b.where...).writeSQL(w); err != nil {
+			return "", nil, err
+		}
+	}
+	b.returning.writeReturning(w)
+	query, args := w.result()
+	return query, args, nil
+}
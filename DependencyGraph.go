@@ -0,0 +1,175 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// TableGraph is the graph of the tables of a database and the foreign keys between them (see
+// DependencyGraph). It orders tables so that referenced tables come before the tables
+// referencing them, e.g. to load fixtures, truncate tables or plan cascades. TableGraph is
+// immutable and safe for concurrent use.
+type TableGraph struct {
+	tables       []string
+	foreignKeys  map[string][]ForeignKeySchema
+	references   map[string][]string
+	referencedBy map[string][]string
+}
+
+// DependencyGraph reads the foreign keys of all tables of the current schema (Postgres) or
+// database (MySQL, SQLite) into a table graph:
+//
+//	graph, err := db.DependencyGraph(ctx, conn)
+//	order, err := graph.InsertOrder() // e.g. [users orders order_items]
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to read the catalog from
+//
+// Returns:
+//   - *TableGraph: The tables and their foreign keys
+//   - error: ErrUnsupportedDialect for SQL Server, or the error of a catalog query
+func DependencyGraph(ctx context.Context, conn IReadSession) (*TableGraph, error) {
+	d := dialectOf(conn)
+	catalog, ok := schemaCatalog[d.Name()]
+	if !ok {
+		return nil, NewErrUnsupportedDialect("dependency graphs are not supported by %s", d.Name())
+	}
+	tables, err := Query[string](ctx, conn, catalog.tables)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := SchemaSnapshot{Dialect: d.Name(), Tables: make([]TableSchema, 0, len(tables))}
+	for _, table := range tables {
+		foreignKeys, err := foreignKeysOf(ctx, conn, catalog, table)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Tables = append(snapshot.Tables, TableSchema{Name: table, ForeignKeys: foreignKeys})
+	}
+	return NewTableGraph(snapshot), nil
+}
+
+// NewTableGraph creates the table graph of a schema snapshot (see DumpSchema). Foreign keys
+// referencing tables missing in the snapshot are kept, but do not affect the order of tables.
+func NewTableGraph(snapshot SchemaSnapshot) *TableGraph {
+	g := &TableGraph{
+		foreignKeys:  map[string][]ForeignKeySchema{},
+		references:   map[string][]string{},
+		referencedBy: map[string][]string{},
+	}
+	for _, table := range snapshot.Tables {
+		g.tables = append(g.tables, table.Name)
+		g.foreignKeys[table.Name] = table.ForeignKeys
+	}
+	slices.Sort(g.tables)
+	for _, table := range g.tables {
+		for _, fk := range g.foreignKeys[table] {
+			if _, ok := g.foreignKeys[fk.ReferencedTable]; !ok || slices.Contains(g.references[table], fk.ReferencedTable) {
+				continue
+			}
+			g.references[table] = append(g.references[table], fk.ReferencedTable)
+			g.referencedBy[fk.ReferencedTable] = append(g.referencedBy[fk.ReferencedTable], table)
+		}
+		slices.Sort(g.references[table])
+	}
+	return g
+}
+
+// Tables returns the names of all tables in alphabetical order.
+func (g *TableGraph) Tables() []string {
+	return slices.Clone(g.tables)
+}
+
+// ForeignKeys returns the foreign keys of a table.
+func (g *TableGraph) ForeignKeys(table string) []ForeignKeySchema {
+	return slices.Clone(g.foreignKeys[table])
+}
+
+// References returns the tables referenced by the foreign keys of a table (including the table
+// itself, if it references its own rows).
+func (g *TableGraph) References(table string) []string {
+	return slices.Clone(g.references[table])
+}
+
+// ReferencedBy returns the tables with foreign keys referencing a table.
+func (g *TableGraph) ReferencedBy(table string) []string {
+	return slices.Clone(g.referencedBy[table])
+}
+
+// InsertOrder returns all tables ordered so that every table follows the tables it references,
+// i.e. the order to insert fixtures in. Tables referencing their own rows are ordered like any
+// other table; their rows must be inserted parents first. Tables of the same rank are ordered
+// alphabetically.
+//
+// Returns:
+//   - []string: The ordered tables
+//   - error: ErrCyclicDependency if tables reference each other
+func (g *TableGraph) InsertOrder() ([]string, error) {
+	order := make([]string, 0, len(g.tables))
+	placed := map[string]bool{}
+	for len(order) < len(g.tables) {
+		var ready []string
+		for _, table := range g.tables {
+			blocked := slices.ContainsFunc(g.references[table], func(referenced string) bool {
+				return referenced != table && !placed[referenced]
+			})
+			if !placed[table] && !blocked {
+				ready = append(ready, table)
+			}
+		}
+		for _, table := range ready {
+			order = append(order, table)
+			placed[table] = true
+		}
+		if len(ready) == 0 {
+			var remaining []string
+			for _, table := range g.tables {
+				if !placed[table] {
+					remaining = append(remaining, table)
+				}
+			}
+			return nil, NewErrCyclicDependency("foreign keys of tables %s form a cycle", strings.Join(remaining, ", "))
+		}
+	}
+	return order, nil
+}
+
+// DeleteOrder returns all tables ordered so that every table precedes the tables it
+// references, i.e. the order to delete or truncate tables in (the reverse of InsertOrder).
+//
+// Returns:
+//   - []string: The ordered tables
+//   - error: ErrCyclicDependency if tables reference each other
+func (g *TableGraph) DeleteOrder() ([]string, error) {
+	order, err := g.InsertOrder()
+	slices.Reverse(order)
+	return order, err
+}
+
+// CascadeRules returns a rule applying the action to the referencing rows for every single
+// column foreign key between the tables of the graph, to create a Cascade from:
+//
+//	cascade := db.NewCascade(graph.CascadeRules(db.CascadeDelete)...)
+//
+// Foreign keys of tables referencing their own rows are skipped, since cascades can't be
+// cyclic.
+func (g *TableGraph) CascadeRules(action CascadeAction) []CascadeRule {
+	var rules []CascadeRule
+	for _, table := range g.tables {
+		for _, fk := range g.foreignKeys[table] {
+			if len(fk.Columns) != 1 || fk.ReferencedTable == table || !slices.Contains(g.tables, fk.ReferencedTable) {
+				continue
+			}
+			rules = append(rules, CascadeRule{
+				Table:            table,
+				Column:           fk.Columns[0],
+				References:       fk.ReferencedTable,
+				ReferencedColumn: fk.ReferencedColumns[0],
+				Action:           action,
+			})
+		}
+	}
+	return rules
+}
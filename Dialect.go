@@ -0,0 +1,77 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Names of the built-in dialects
+const (
+	DialectPostgres  = "postgres"
+	DialectMySQL     = "mysql"
+	DialectSQLite    = "sqlite"
+	DialectSQLServer = "sqlserver"
+)
+
+// IDialect describes the SQL syntax differences between database engines that matter
+// when the package generates statements on its own.
+type IDialect interface {
+	// Name returns the name of the dialect (e.g. DialectPostgres)
+	Name() string
+	// Placeholder returns the positional parameter placeholder for the n-th argument (1-based)
+	Placeholder(n int) string
+	// QuoteIdentifier quotes a (optionally schema qualified) identifier
+	QuoteIdentifier(name string) string
+}
+
+// Built-in dialects
+var (
+	Postgres  IDialect = dialect{name: DialectPostgres, placeholder: "$", quoteOpen: `"`, quoteClose: `"`}
+	MySQL     IDialect = dialect{name: DialectMySQL, placeholder: "?", quoteOpen: "`", quoteClose: "`"}
+	SQLite    IDialect = dialect{name: DialectSQLite, placeholder: "?", quoteOpen: `"`, quoteClose: `"`}
+	SQLServer IDialect = dialect{name: DialectSQLServer, placeholder: "@p", quoteOpen: "[", quoteClose: "]"}
+)
+
+// DefaultDialect is the dialect used when no dialect is configured explicitly.
+var DefaultDialect = MySQL
+
+type dialect struct {
+	name        string
+	placeholder string
+	quoteOpen   string
+	quoteClose  string
+}
+
+// Name implements IDialect.
+func (d dialect) Name() string {
+	return d.name
+}
+
+// Placeholder implements IDialect.
+func (d dialect) Placeholder(n int) string {
+	if d.placeholder == "?" {
+		return "?"
+	}
+	return d.placeholder + strconv.Itoa(n)
+}
+
+// QuoteIdentifier implements IDialect.
+func (d dialect) QuoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = d.quoteOpen + strings.ReplaceAll(part, d.quoteClose, d.quoteClose+d.quoteClose) + d.quoteClose
+	}
+	return strings.Join(parts, ".")
+}
+
+// placeholders returns a comma separated list of count placeholders, starting with the given index.
+func placeholders(d IDialect, start int, count int) string {
+	var sb strings.Builder
+	for i := range count {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(d.Placeholder(start + i))
+	}
+	return sb.String()
+}
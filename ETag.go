@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ResultETag identifies the content of a result set for HTTP cache validation.
+type ResultETag struct {
+	// ETag is the quoted hash of the result set, usable as ETag header
+	ETag string
+	// LastModified is the maximum updated_at of the result set (zero if there is no such column),
+	// usable as Last-Modified header
+	LastModified time.Time
+}
+
+// Matches reports whether the value of an If-None-Match header matches the ETag, so the
+// request can be answered with 304 Not Modified.
+func (e ResultETag) Matches(ifNoneMatch string) bool {
+	for tag := range strings.SplitSeq(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == e.ETag {
+			return true
+		}
+	}
+	return false
+}
+
+// QueryWithETag executes a SQL query like Query and computes an ETag of the results.
+//
+// The ETag is a hash over the column values of all rows in result order, so it only changes
+// if the results change. Queries should therefore specify an ORDER BY clause. If T maps an
+// updated_at column, its maximum is returned as LastModified.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to execute the query on
+//   - query: SQL query string to execute
+//   - args: Query parameters and QueryOption values
+//
+// Returns:
+//   - []T: Slice of results parsed from the query
+//   - ResultETag: ETag and last modification of the results
+//   - error: Non-nil if query execution or result parsing fails
+func QueryWithETag[T any](ctx context.Context, conn IReadSession, query string, args ...any) ([]T, ResultETag, error) {
+	result, err := Query[T](ctx, conn, query, args...)
+	if err != nil {
+		return nil, ResultETag{}, err
+	}
+	var etag ResultETag
+	h := sha256.New()
+	mapper := nameMapperOf(conn)
+	isStruct := !isPrimitiveType(reflect.TypeFor[T]())
+	for _, item := range result {
+		if !isStruct {
+			hashValue(h, item)
+			continue
+		}
+		values, err := columnValues(item, mapper)
+		if err != nil {
+			return nil, ResultETag{}, err
+		}
+		for _, col := range slices.Sorted(maps.Keys(values)) {
+			fmt.Fprintf(h, "%s=", col)
+			hashValue(h, values[col])
+		}
+		if updatedAt, ok := timeValue(values["updated_at"]); ok && updatedAt.After(etag.LastModified) {
+			etag.LastModified = updatedAt
+		}
+		h.Write([]byte{'\n'})
+	}
+	etag.ETag = `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+	return result, etag, nil
+}
+
+// hashValue writes a type tagged, stable representation of the value to the hash.
+func hashValue(h hash.Hash, value any) {
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Kind() == reflect.Pointer {
+		h.Write([]byte("nil;"))
+		return
+	}
+	switch v := rv.Interface().(type) {
+	case time.Time:
+		fmt.Fprintf(h, "time:%s;", v.UTC().Format(time.RFC3339Nano))
+	case []byte:
+		fmt.Fprintf(h, "bytes:%x;", v)
+	default:
+		fmt.Fprintf(h, "%T:%v;", v, v)
+	}
+}
+
+// timeValue returns the time held by a time.Time, *time.Time or sql.NullTime value.
+func timeValue(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v != nil {
+			return *v, true
+		}
+	case sql.NullTime:
+		return v.Time, v.Valid
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,620 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithArrowBatchSize sets the number of rows per record batch written by EncodeArrow (default:
+// 10000).
+func WithArrowBatchSize(rows int) QueryOption {
+	return func(o *queryOptions) {
+		o.arrowBatchSize = rows
+	}
+}
+
+// arrowType is the Arrow type of a column (the value of the Type union of the Arrow schema).
+type arrowType byte
+
+const (
+	arrowInt       arrowType = 2
+	arrowFloat     arrowType = 3
+	arrowBinary    arrowType = 4
+	arrowUtf8      arrowType = 5
+	arrowBool      arrowType = 6
+	arrowTimestamp arrowType = 10
+)
+
+// Arrow IPC message header types and metadata version
+const (
+	arrowHeaderSchema      = 1
+	arrowHeaderRecordBatch = 3
+	arrowMetadataV5        = 4
+)
+
+// EncodeArrow executes a query and streams its rows to w in the Apache Arrow IPC streaming
+// format (application/vnd.apache.arrow.stream), so analytical consumers (pandas, Polars,
+// DuckDB, Spark, ...) can read them without parsing:
+//
+//	w.Header().Set("Content-Type", db.ExportArrow.ContentType())
+//	_, err := db.EncodeArrow(ctx, conn, w, "SELECT * FROM events WHERE day = ?", day)
+//
+// Rows are collected into record batches of columnar buffers (see WithArrowBatchSize), so
+// only one batch is held in memory. Column types are derived from the scan types reported by
+// the driver: integers as Int64 (unsigned 64-bit integers as UInt64, as they may exceed
+// Int64), floating point numbers as Float64, booleans as Bool, times as Timestamp
+// (microseconds, UTC), binary columns as Binary and all other columns as Utf8. All columns are
+// nullable.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to execute the query on
+//   - w: Writer to stream the rows to
+//   - query: SQL query string
+//   - args: Query arguments and QueryOption values
+//
+// Returns:
+//   - int: Number of written rows
+//   - error: ErrInvalidDataType if a value does not match the type of its column, or the
+//     error of the query, a scan or a write. The written output is incomplete then.
+func EncodeArrow(ctx context.Context, conn IReadSession, w io.Writer, query string, args ...any) (returned int, err error) {
+	opts, args := splitQueryOptions(args)
+	batchSize := opts.arrowBatchSize
+	if batchSize <= 0 {
+		batchSize = 10000
+	}
+	ctx, logRows := deferStatementLog(ctx, conn)
+	ctx, release := withReleaseScope(ctx, OperationQuery)
+	defer release()
+	defer func() { logRows(returned, err) }()
+	rows, err := conn.QueryContext(opts.context(ctx), query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+	columns := make([]*arrowColumn, len(columnTypes))
+	for i, ct := range columnTypes {
+		typ, unsigned := arrowTypeOf(ct)
+		columns[i] = &arrowColumn{name: ct.Name(), typ: typ, unsigned: unsigned}
+	}
+	out := bufio.NewWriter(w)
+	if err := writeArrowMessage(out, arrowSchemaMessage(columns), nil); err != nil {
+		return 0, err
+	}
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	length := 0
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return returned, err
+		}
+		for i, value := range values {
+			if err := columns[i].append(length, value); err != nil {
+				return returned, err
+			}
+		}
+		length++
+		if length == batchSize {
+			if err := writeArrowBatch(out, columns, length); err != nil {
+				return returned, err
+			}
+			returned += length
+			length = 0
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return returned, err
+	}
+	if length > 0 {
+		if err := writeArrowBatch(out, columns, length); err != nil {
+			return returned, err
+		}
+		returned += length
+	}
+	// End of stream marker
+	if _, err := out.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}); err != nil {
+		return returned, err
+	}
+	if err := out.Flush(); err != nil {
+		return returned, err
+	}
+	if flusher, ok := w.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+	observeRows(opts.context(ctx), conn, query, returned)
+	return returned, nil
+}
+
+// arrowTypeOf returns the Arrow type of a column, derived from its scan type (or its database
+// type, if the driver reports no specific scan type), and whether it is an unsigned 64-bit
+// integer, which does not fit into Int64.
+func arrowTypeOf(ct *sql.ColumnType) (arrowType, bool) {
+	name := strings.ToUpper(ct.DatabaseTypeName())
+	temporal := strings.Contains(name, "DATE") || strings.Contains(name, "TIME")
+	if typ := ct.ScanType(); typ != nil {
+		switch typ {
+		case reflect.TypeFor[sql.NullInt64](), reflect.TypeFor[sql.NullInt32](), reflect.TypeFor[sql.NullInt16](), reflect.TypeFor[sql.NullByte]():
+			return arrowInt, false
+		case reflect.TypeFor[sql.NullFloat64]():
+			return arrowFloat, false
+		case reflect.TypeFor[sql.NullBool]():
+			return arrowBool, false
+		case reflect.TypeFor[sql.NullTime](), reflect.TypeFor[time.Time]():
+			return arrowTimestamp, false
+		}
+		switch typ.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			return arrowInt, false
+		case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+			return arrowInt, true
+		case reflect.Float32, reflect.Float64:
+			return arrowFloat, false
+		case reflect.Bool:
+			return arrowBool, false
+		case reflect.String:
+			// Some drivers scan times into strings
+			if temporal {
+				return arrowTimestamp, false
+			}
+			return arrowUtf8, false
+		}
+		if typ == reflect.TypeFor[sql.NullString]() {
+			return arrowUtf8, false
+		}
+	}
+	switch {
+	case isBinaryColumn(ct):
+		return arrowBinary, false
+	case strings.Contains(name, "INT") && !strings.Contains(name, "INTERVAL") && !strings.Contains(name, "POINT"):
+		return arrowInt, strings.Contains(name, "UNSIGNED") && strings.Contains(name, "BIGINT")
+	case strings.Contains(name, "REAL") || strings.Contains(name, "FLOA") || strings.Contains(name, "DOUB"):
+		return arrowFloat, false
+	case strings.Contains(name, "BOOL"):
+		return arrowBool, false
+	case temporal:
+		return arrowTimestamp, false
+	}
+	return arrowUtf8, false
+}
+
+// arrowColumn collects the values of a column for a record batch.
+type arrowColumn struct {
+	name  string
+	typ   arrowType
+	nulls int
+	// unsigned reports whether an Int column holds unsigned 64-bit integers
+	unsigned bool
+	// validity is the bitmap of non-null values, values the fixed width values (bitmap for
+	// Bool), offsets and data the variable width values
+	validity []byte
+	values   []byte
+	offsets  []byte
+	data     []byte
+}
+
+// append adds the value of the row at the given index of the batch.
+func (c *arrowColumn) append(row int, value any) error {
+	if row%8 == 0 {
+		c.validity = append(c.validity, 0)
+		if c.typ == arrowBool {
+			c.values = append(c.values, 0)
+		}
+	}
+	if c.typ == arrowUtf8 || c.typ == arrowBinary {
+		if row == 0 {
+			c.offsets = binary.LittleEndian.AppendUint32(c.offsets, 0)
+		}
+		defer func() { c.offsets = binary.LittleEndian.AppendUint32(c.offsets, uint32(len(c.data))) }()
+	}
+	if value == nil {
+		c.nulls++
+		if c.typ == arrowInt || c.typ == arrowFloat || c.typ == arrowTimestamp {
+			c.values = binary.LittleEndian.AppendUint64(c.values, 0)
+		}
+		return nil
+	}
+	c.validity[row/8] |= 1 << (row % 8)
+	var bits uint64
+	switch c.typ {
+	case arrowInt:
+		if c.unsigned {
+			u, err := arrowUint64(value)
+			if err != nil {
+				return NewErrInvalidDataType("column %s: %v", c.name, err)
+			}
+			bits = u
+			break
+		}
+		i, err := arrowInt64(value)
+		if err != nil {
+			return NewErrInvalidDataType("column %s: %v", c.name, err)
+		}
+		bits = uint64(i)
+	case arrowFloat:
+		f, err := arrowFloat64(value)
+		if err != nil {
+			return NewErrInvalidDataType("column %s: %v", c.name, err)
+		}
+		bits = math.Float64bits(f)
+	case arrowTimestamp:
+		t, err := arrowTime(value)
+		if err != nil {
+			return NewErrInvalidDataType("column %s: %v", c.name, err)
+		}
+		bits = uint64(t.UnixMicro())
+	case arrowBool:
+		b, err := arrowBoolean(value)
+		if err != nil {
+			return NewErrInvalidDataType("column %s: %v", c.name, err)
+		}
+		if b {
+			c.values[row/8] |= 1 << (row % 8)
+		}
+		return nil
+	default:
+		switch v := value.(type) {
+		case []byte:
+			c.data = append(c.data, v...)
+		case string:
+			c.data = append(c.data, v...)
+		case time.Time:
+			c.data = v.AppendFormat(c.data, time.RFC3339Nano)
+		default:
+			c.data = fmt.Append(c.data, v)
+		}
+		return nil
+	}
+	c.values = binary.LittleEndian.AppendUint64(c.values, bits)
+	return nil
+}
+
+// buffers returns the buffers of the column in the order of the Arrow layout of its type.
+func (c *arrowColumn) buffers() [][]byte {
+	switch c.typ {
+	case arrowUtf8, arrowBinary:
+		return [][]byte{c.validity, c.offsets, c.data}
+	}
+	return [][]byte{c.validity, c.values}
+}
+
+// reset discards the values of the column after its batch has been written.
+func (c *arrowColumn) reset() {
+	c.nulls = 0
+	c.validity, c.values, c.offsets, c.data = c.validity[:0], c.values[:0], c.offsets[:0], c.data[:0]
+}
+
+func arrowInt64(value any) (int64, error) {
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case []byte:
+		return strconv.ParseInt(string(v), 10, 64)
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.CanInt():
+		return rv.Int(), nil
+	case rv.CanUint():
+		if rv.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("%d overflows int64", rv.Uint())
+		}
+		return int64(rv.Uint()), nil
+	}
+	return 0, fmt.Errorf("expected integer, got %T", value)
+}
+
+func arrowUint64(value any) (uint64, error) {
+	switch v := value.(type) {
+	case uint64:
+		return v, nil
+	case []byte:
+		return strconv.ParseUint(string(v), 10, 64)
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	}
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.CanUint():
+		return rv.Uint(), nil
+	case rv.CanInt():
+		if rv.Int() < 0 {
+			return 0, fmt.Errorf("%d is negative, expected unsigned integer", rv.Int())
+		}
+		return uint64(rv.Int()), nil
+	}
+	return 0, fmt.Errorf("expected unsigned integer, got %T", value)
+}
+
+func arrowFloat64(value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("expected floating point number, got %T", value)
+}
+
+func arrowBoolean(value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case int64:
+		return v != 0, nil
+	case []byte:
+		return strconv.ParseBool(string(v))
+	case string:
+		return strconv.ParseBool(v)
+	}
+	return false, fmt.Errorf("expected boolean, got %T", value)
+}
+
+// arrowTimeLayouts are the layouts of times returned as text (e.g. by MySQL without parseTime).
+var arrowTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", time.DateOnly}
+
+func arrowTime(value any) (time.Time, error) {
+	var s string
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	default:
+		return time.Time{}, fmt.Errorf("expected time, got %T", value)
+	}
+	for _, layout := range arrowTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected time, got %q", s)
+}
+
+// writeArrowBatch writes the collected values of the columns as record batch and resets them.
+func writeArrowBatch(w io.Writer, columns []*arrowColumn, length int) error {
+	var nodes, buffers, body []byte
+	for _, c := range columns {
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(length))
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(c.nulls))
+		for _, buf := range c.buffers() {
+			buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(body)))
+			buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(buf)))
+			body = append(body, buf...)
+			body = append(body, make([]byte, padding(len(body), 8))...)
+		}
+		c.reset()
+	}
+	batch := &fbTable{fields: []fbField{
+		fbScalar(int64(length)),
+		fbRef(fbStructs{data: nodes, count: len(columns)}),
+		fbRef(fbStructs{data: buffers, count: len(buffers) / 16}),
+	}}
+	return writeArrowMessage(w, arrowMessage(arrowHeaderRecordBatch, batch, len(body)), body)
+}
+
+// arrowSchemaMessage returns the schema message describing the columns.
+func arrowSchemaMessage(columns []*arrowColumn) *fbTable {
+	fields := make(fbTables, len(columns))
+	for i, c := range columns {
+		var typ *fbTable
+		switch c.typ {
+		case arrowInt:
+			// bitWidth, is_signed
+			typ = &fbTable{fields: []fbField{fbScalar(int32(64)), fbScalar(!c.unsigned)}}
+		case arrowFloat:
+			// precision: DOUBLE
+			typ = &fbTable{fields: []fbField{fbScalar(int16(2))}}
+		case arrowTimestamp:
+			// unit: MICROSECOND, timezone
+			typ = &fbTable{fields: []fbField{fbScalar(int16(2)), fbRef(fbString("UTC"))}}
+		default:
+			typ = &fbTable{}
+		}
+		// name, nullable, type_type, type, dictionary, children
+		fields[i] = &fbTable{fields: []fbField{
+			fbRef(fbString(c.name)), fbScalar(true), fbScalar(byte(c.typ)), fbRef(typ), {}, fbRef(fbTables{}),
+		}}
+	}
+	// endianness: Little, fields
+	schema := &fbTable{fields: []fbField{fbScalar(int16(0)), fbRef(fields)}}
+	return arrowMessage(arrowHeaderSchema, schema, 0)
+}
+
+// arrowMessage returns the message table with the given header.
+func arrowMessage(headerType byte, header *fbTable, bodyLength int) *fbTable {
+	// version, header_type, header, bodyLength
+	return &fbTable{fields: []fbField{
+		fbScalar(int16(arrowMetadataV5)), fbScalar(headerType), fbRef(header), fbScalar(int64(bodyLength)),
+	}}
+}
+
+// writeArrowMessage writes an encapsulated message: continuation marker, metadata length,
+// metadata padded to 8 bytes and body.
+func writeArrowMessage(w io.Writer, message *fbTable, body []byte) error {
+	metadata := encodeFlatbuffer(message)
+	metadata = append(metadata, make([]byte, padding(len(metadata), 8))...)
+	prefix := binary.LittleEndian.AppendUint32([]byte{0xff, 0xff, 0xff, 0xff}, uint32(len(metadata)))
+	for _, b := range [][]byte{prefix, metadata, body} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// padding returns the number of bytes to append to n bytes to reach a multiple of align.
+func padding(n, align int) int {
+	return (align - n%align) % align
+}
+
+// ----------------------------------------------------------------------
+// Minimal flatbuffers encoder for the Arrow IPC metadata
+// ----------------------------------------------------------------------
+
+// fbObject is a flatbuffers object referenced by an offset (table, string or vector).
+type fbObject interface {
+	// write appends the object and the objects it references, returning its position
+	write(w *fbWriter) int
+}
+
+// fbField is a field of a table: an inline scalar or a reference to an object. Unset fields
+// (zero value) are omitted.
+type fbField struct {
+	scalar []byte
+	ref    fbObject
+}
+
+func fbScalar(value any) fbField {
+	buf, err := binary.Append(nil, binary.LittleEndian, value)
+	if err != nil {
+		panic(err)
+	}
+	return fbField{scalar: buf}
+}
+
+func fbRef(object fbObject) fbField {
+	return fbField{ref: object}
+}
+
+// size returns the inline size (and alignment) of the field.
+func (f fbField) size() int {
+	if f.ref != nil {
+		return 4
+	}
+	return len(f.scalar)
+}
+
+// fbTable is a table with its fields in the order of their ids.
+type fbTable struct {
+	fields []fbField
+}
+
+// fbString is a string.
+type fbString string
+
+// fbTables is a vector of tables.
+type fbTables []*fbTable
+
+// fbStructs is a vector of structs with 8 byte alignment.
+type fbStructs struct {
+	data  []byte
+	count int
+}
+
+// fbWriter writes flatbuffers front to back: objects precede the objects they reference, so
+// all offsets point forward.
+type fbWriter struct {
+	buf []byte
+}
+
+// encodeFlatbuffer encodes the root table.
+func encodeFlatbuffer(root *fbTable) []byte {
+	w := &fbWriter{buf: make([]byte, 4)}
+	w.patch(0, root.write(w))
+	return w.buf
+}
+
+func (w *fbWriter) align(n int) {
+	w.buf = append(w.buf, make([]byte, padding(len(w.buf), n))...)
+}
+
+// patch sets the offset at position at to the object at position target.
+func (w *fbWriter) patch(at, target int) {
+	binary.LittleEndian.PutUint32(w.buf[at:], uint32(target-at))
+}
+
+func (t *fbTable) write(w *fbWriter) int {
+	// Layout of the table: offset to the vtable, followed by the fields aligned to their size
+	offsets := make([]int, len(t.fields))
+	size := 4
+	for i, f := range t.fields {
+		if n := f.size(); n > 0 {
+			size += padding(size, n)
+			offsets[i] = size
+			size += n
+		}
+	}
+	w.align(2)
+	vtable := len(w.buf)
+	w.buf = binary.LittleEndian.AppendUint16(w.buf, uint16(4+2*len(t.fields)))
+	w.buf = binary.LittleEndian.AppendUint16(w.buf, uint16(size))
+	for _, offset := range offsets {
+		w.buf = binary.LittleEndian.AppendUint16(w.buf, uint16(offset))
+	}
+	w.align(8)
+	table := len(w.buf)
+	w.buf = append(w.buf, make([]byte, size)...)
+	binary.LittleEndian.PutUint32(w.buf[table:], uint32(int32(table-vtable)))
+	for i, f := range t.fields {
+		if f.scalar != nil {
+			copy(w.buf[table+offsets[i]:], f.scalar)
+		}
+	}
+	for i, f := range t.fields {
+		if f.ref != nil {
+			w.patch(table+offsets[i], f.ref.write(w))
+		}
+	}
+	return table
+}
+
+func (s fbString) write(w *fbWriter) int {
+	w.align(4)
+	pos := len(w.buf)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(len(s)))
+	w.buf = append(append(w.buf, s...), 0)
+	return pos
+}
+
+func (v fbTables) write(w *fbWriter) int {
+	w.align(4)
+	pos := len(w.buf)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(len(v)))
+	w.buf = append(w.buf, make([]byte, 4*len(v))...)
+	for i, table := range v {
+		w.patch(pos+4+4*i, table.write(w))
+	}
+	return pos
+}
+
+func (v fbStructs) write(w *fbWriter) int {
+	// The structs are 8 byte aligned, following the 4 byte length
+	w.align(8)
+	w.buf = append(w.buf, 0, 0, 0, 0)
+	pos := len(w.buf)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(v.count))
+	w.buf = append(w.buf, v.data...)
+	return pos
+}
@@ -0,0 +1,285 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// arrowStubColumns are the columns returned by the arrowstub driver: name, database type and
+// scan type.
+var arrowStubColumns = []struct {
+	name, databaseType string
+	scanType           reflect.Type
+}{
+	{"id", "BIGINT", reflect.TypeFor[int64]()},
+	{"big", "UNSIGNED BIGINT", reflect.TypeFor[uint64]()},
+	{"name", "VARCHAR", reflect.TypeFor[string]()},
+	{"score", "DOUBLE", reflect.TypeFor[float64]()},
+	{"active", "BOOLEAN", reflect.TypeFor[bool]()},
+}
+
+// arrowStubRows are the rows returned by the arrowstub driver for any query.
+var arrowStubRows = [][]driver.Value{
+	{int64(math.MinInt64), uint64(math.MaxUint64), "first", 1.5, true},
+	{nil, uint64(42), nil, nil, false},
+	{int64(7), nil, "", -0.25, nil},
+}
+
+func init() {
+	sql.Register("arrowstub", arrowStubDriver{})
+}
+
+type arrowStubDriver struct{}
+
+func (arrowStubDriver) Open(string) (driver.Conn, error) { return arrowStubConn{}, nil }
+
+type arrowStubConn struct{}
+
+func (arrowStubConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (arrowStubConn) Close() error                        { return nil }
+func (arrowStubConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+// QueryContext returns the stub rows, or a signed column holding an unsigned value for the
+// query "overflow".
+func (arrowStubConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if query == "overflow" {
+		return &arrowStubResult{columns: []int{0}, rows: [][]driver.Value{{uint64(math.MaxUint64)}}}, nil
+	}
+	return &arrowStubResult{columns: []int{0, 1, 2, 3, 4}, rows: arrowStubRows}, nil
+}
+
+type arrowStubResult struct {
+	columns []int
+	rows    [][]driver.Value
+}
+
+func (r *arrowStubResult) Columns() []string {
+	names := make([]string, len(r.columns))
+	for i, c := range r.columns {
+		names[i] = arrowStubColumns[c].name
+	}
+	return names
+}
+
+func (r *arrowStubResult) Close() error { return nil }
+
+func (r *arrowStubResult) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func (r *arrowStubResult) ColumnTypeScanType(i int) reflect.Type {
+	return arrowStubColumns[r.columns[i]].scanType
+}
+
+func (r *arrowStubResult) ColumnTypeDatabaseTypeName(i int) string {
+	return arrowStubColumns[r.columns[i]].databaseType
+}
+
+// fbReader reads the tables of a flatbuffer.
+type fbReader []byte
+
+// table returns the position of the table referenced by the offset at pos.
+func (b fbReader) table(pos int) int {
+	return pos + int(binary.LittleEndian.Uint32(b[pos:]))
+}
+
+// field returns the position of a field of the table at pos, or 0 if it is absent.
+func (b fbReader) field(table, field int) int {
+	vtable := table - int(int32(binary.LittleEndian.Uint32(b[table:])))
+	if 4+2*field >= int(binary.LittleEndian.Uint16(b[vtable:])) {
+		return 0
+	}
+	if offset := int(binary.LittleEndian.Uint16(b[vtable+4+2*field:])); offset != 0 {
+		return table + offset
+	}
+	return 0
+}
+
+// vector returns the position of the first element and the length of the vector referenced by
+// a field of the table at pos.
+func (b fbReader) vector(table, field int) (int, int) {
+	pos := b.table(b.field(table, field))
+	return pos + 4, int(binary.LittleEndian.Uint32(b[pos:]))
+}
+
+func (b fbReader) string(table, field int) string {
+	pos, n := b.vector(table, field)
+	return string(b[pos : pos+n])
+}
+
+func (b fbReader) byte(table, field int) byte {
+	if pos := b.field(table, field); pos != 0 {
+		return b[pos]
+	}
+	return 0
+}
+
+func (b fbReader) int64(table, field int) int64 {
+	if pos := b.field(table, field); pos != 0 {
+		return int64(binary.LittleEndian.Uint64(b[pos:]))
+	}
+	return 0
+}
+
+// arrowDecodedField is a field of a decoded Arrow schema.
+type arrowDecodedField struct {
+	name     string
+	typ      arrowType
+	bitWidth int32
+	signed   bool
+}
+
+// arrowDecodedBatch is a decoded record batch: number of rows, null counts and buffers of the
+// columns.
+type arrowDecodedBatch struct {
+	length  int64
+	nulls   []int64
+	buffers [][]byte
+}
+
+// decodeArrowStream decodes a stream written by EncodeArrow.
+func decodeArrowStream(t *testing.T, stream []byte) ([]arrowDecodedField, []arrowDecodedBatch) {
+	t.Helper()
+	var fields []arrowDecodedField
+	var batches []arrowDecodedBatch
+	for {
+		if len(stream) < 8 || binary.LittleEndian.Uint32(stream) != 0xffffffff {
+			t.Fatalf("expected continuation marker, got % x", stream[:min(len(stream), 8)])
+		}
+		size := int(binary.LittleEndian.Uint32(stream[4:]))
+		stream = stream[8:]
+		if size == 0 {
+			if len(stream) > 0 {
+				t.Fatalf("%d bytes after end of stream marker", len(stream))
+			}
+			return fields, batches
+		}
+		metadata := fbReader(stream[:size])
+		message := metadata.table(0)
+		bodyLength := int(metadata.int64(message, 3))
+		body := stream[size : size+bodyLength]
+		stream = stream[size+bodyLength:]
+		header := metadata.table(metadata.field(message, 2))
+		switch metadata.byte(message, 1) {
+		case arrowHeaderSchema:
+			pos, n := metadata.vector(header, 1)
+			for i := range n {
+				field := metadata.table(pos + 4*i)
+				decoded := arrowDecodedField{name: metadata.string(field, 0), typ: arrowType(metadata.byte(field, 2))}
+				if decoded.typ == arrowInt {
+					typ := metadata.table(metadata.field(field, 3))
+					decoded.bitWidth = int32(binary.LittleEndian.Uint32(metadata[metadata.field(typ, 0):]))
+					decoded.signed = metadata.byte(typ, 1) != 0
+				}
+				fields = append(fields, decoded)
+			}
+		case arrowHeaderRecordBatch:
+			batch := arrowDecodedBatch{length: metadata.int64(header, 0)}
+			pos, n := metadata.vector(header, 1)
+			for i := range n {
+				batch.nulls = append(batch.nulls, int64(binary.LittleEndian.Uint64(metadata[pos+16*i+8:])))
+			}
+			pos, n = metadata.vector(header, 2)
+			for i := range n {
+				offset := binary.LittleEndian.Uint64(metadata[pos+16*i:])
+				length := binary.LittleEndian.Uint64(metadata[pos+16*i+8:])
+				batch.buffers = append(batch.buffers, body[offset:offset+length])
+			}
+			batches = append(batches, batch)
+		default:
+			t.Fatalf("unexpected message header type %d", metadata.byte(message, 1))
+		}
+	}
+}
+
+func TestEncodeArrowRoundTrip(t *testing.T) {
+	database, err := sql.Open("arrowstub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	var out bytes.Buffer
+	returned, err := EncodeArrow(context.Background(), database, &out, "SELECT * FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if returned != len(arrowStubRows) {
+		t.Fatalf("returned %d rows, expected %d", returned, len(arrowStubRows))
+	}
+	fields, batches := decodeArrowStream(t, out.Bytes())
+
+	expectedFields := []arrowDecodedField{
+		{name: "id", typ: arrowInt, bitWidth: 64, signed: true},
+		{name: "big", typ: arrowInt, bitWidth: 64, signed: false},
+		{name: "name", typ: arrowUtf8},
+		{name: "score", typ: arrowFloat},
+		{name: "active", typ: arrowBool},
+	}
+	if !reflect.DeepEqual(fields, expectedFields) {
+		t.Fatalf("schema %+v, expected %+v", fields, expectedFields)
+	}
+	if len(batches) != 1 {
+		t.Fatalf("got %d record batches, expected 1", len(batches))
+	}
+	batch := batches[0]
+	if batch.length != 3 || !reflect.DeepEqual(batch.nulls, []int64{1, 1, 1, 1, 1}) {
+		t.Fatalf("batch of %d rows with nulls %v, expected 3 rows with one null per column", batch.length, batch.nulls)
+	}
+	// validity and values per fixed width column, validity, offsets and data per Utf8 column
+	if len(batch.buffers) != 11 {
+		t.Fatalf("got %d buffers, expected 11", len(batch.buffers))
+	}
+	valid := func(buffer []byte, row int) bool { return buffer[row/8]&(1<<(row%8)) != 0 }
+	word := func(buffer []byte, row int) uint64 { return binary.LittleEndian.Uint64(buffer[8*row:]) }
+
+	id, big := batch.buffers[0:2], batch.buffers[2:4]
+	if !valid(id[0], 0) || valid(id[0], 1) || !valid(id[0], 2) ||
+		int64(word(id[1], 0)) != math.MinInt64 || int64(word(id[1], 2)) != 7 {
+		t.Errorf("id column: validity % x, values % x", id[0], id[1])
+	}
+	if !valid(big[0], 0) || !valid(big[0], 1) || valid(big[0], 2) ||
+		word(big[1], 0) != math.MaxUint64 || word(big[1], 1) != 42 {
+		t.Errorf("big column: validity % x, values % x", big[0], big[1])
+	}
+	name := batch.buffers[4:7]
+	if !valid(name[0], 0) || valid(name[0], 1) || !valid(name[0], 2) ||
+		!bytes.Equal(name[1][:16], []byte{0, 0, 0, 0, 5, 0, 0, 0, 5, 0, 0, 0, 5, 0, 0, 0}) ||
+		!bytes.Equal(name[2][:5], []byte("first")) {
+		t.Errorf("name column: validity % x, offsets % x, data %q", name[0], name[1], name[2])
+	}
+	score := batch.buffers[7:9]
+	if !valid(score[0], 0) || valid(score[0], 1) || !valid(score[0], 2) ||
+		math.Float64frombits(word(score[1], 0)) != 1.5 || math.Float64frombits(word(score[1], 2)) != -0.25 {
+		t.Errorf("score column: validity % x, values % x", score[0], score[1])
+	}
+	active := batch.buffers[9:11]
+	if active[0][0]&0b111 != 0b011 || active[1][0]&0b111 != 0b001 {
+		t.Errorf("active column: validity % x, values % x", active[0], active[1])
+	}
+}
+
+func TestEncodeArrowRejectsIntegerOverflow(t *testing.T) {
+	database, err := sql.Open("arrowstub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	_, err = EncodeArrow(context.Background(), database, io.Discard, "overflow")
+	var invalid *ErrInvalidDataType
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected ErrInvalidDataType, got %v", err)
+	}
+}
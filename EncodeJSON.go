@@ -0,0 +1,160 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WithNDJSON makes EncodeJSON write newline delimited JSON (one object per line) instead of a
+// JSON array.
+func WithNDJSON() QueryOption {
+	return func(o *queryOptions) {
+		o.ndjson = true
+	}
+}
+
+// WithJSONKeys maps the column names of the result to the keys of the objects written by
+// EncodeJSON (default: the column names), e.g. to produce camel case keys.
+func WithJSONKeys(mapper NameMapper) QueryOption {
+	return func(o *queryOptions) {
+		o.jsonKeys = mapper
+	}
+}
+
+// EncodeJSON executes a query and streams its rows to w as a JSON array of objects, keyed by
+// column name, without mapping them into structs or collecting them in memory. It is meant for
+// high-volume read APIs writing to an http.ResponseWriter:
+//
+//	w.Header().Set("Content-Type", "application/json")
+//	_, err := db.EncodeJSON(ctx, conn, w, "SELECT id, name FROM users WHERE tenant = ?", tenant, db.WithJSONKeys(strings.ToUpper))
+//
+// Values are encoded as returned by the driver: numbers, booleans, strings and timestamps
+// (RFC 3339) natively, binary columns (BLOB, BYTEA, ...) base64 encoded and NULL as null.
+// Textual values the driver returns as bytes (e.g. DECIMAL on MySQL) are encoded as strings.
+// Writers implementing Flush (http.Flusher) are flushed after the last row. With WithNDJSON,
+// the rows are written as newline delimited JSON.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to execute the query on
+//   - w: Writer to stream the rows to
+//   - query: SQL query string
+//   - args: Query arguments and QueryOption values
+//
+// Returns:
+//   - int: Number of written rows
+//   - error: Non-nil if the query, a scan or a write fails. The written output is incomplete
+//     then.
+func EncodeJSON(ctx context.Context, conn IReadSession, w io.Writer, query string, args ...any) (returned int, err error) {
+	opts, args := splitQueryOptions(args)
+	ctx, logRows := deferStatementLog(ctx, conn)
+	ctx, release := withReleaseScope(ctx, OperationQuery)
+	defer release()
+	defer func() { logRows(returned, err) }()
+	rows, err := conn.QueryContext(opts.context(ctx), query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+	keys := make([][]byte, len(columnTypes))
+	binary := make([]bool, len(columnTypes))
+	for i, ct := range columnTypes {
+		name := ct.Name()
+		if opts.jsonKeys != nil {
+			name = opts.jsonKeys(name)
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return 0, err
+		}
+		keys[i] = append(key, ':')
+		binary[i] = isBinaryColumn(ct)
+	}
+	values := make([]any, len(columnTypes))
+	pointers := make([]any, len(columnTypes))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	out := bufio.NewWriter(w)
+	if !opts.ndjson {
+		out.WriteByte('[')
+	}
+	var line []byte
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return returned, err
+		}
+		line = line[:0]
+		if returned > 0 && !opts.ndjson {
+			line = append(line, ',')
+		}
+		line = append(line, '{')
+		for i, value := range values {
+			if i > 0 {
+				line = append(line, ',')
+			}
+			line = append(line, keys[i]...)
+			if line, err = appendJSONValue(line, value, binary[i]); err != nil {
+				return returned, err
+			}
+		}
+		line = append(line, '}')
+		if opts.ndjson {
+			line = append(line, '\n')
+		}
+		if _, err := out.Write(line); err != nil {
+			return returned, err
+		}
+		returned++
+	}
+	if err := rows.Err(); err != nil {
+		return returned, err
+	}
+	if !opts.ndjson {
+		out.WriteByte(']')
+	}
+	if err := out.Flush(); err != nil {
+		return returned, err
+	}
+	if flusher, ok := w.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+	observeRows(opts.context(ctx), conn, query, returned)
+	return returned, nil
+}
+
+// isBinaryColumn reports whether a column contains binary data, which is base64 encoded.
+func isBinaryColumn(ct *sql.ColumnType) bool {
+	typ := strings.ToUpper(ct.DatabaseTypeName())
+	return strings.Contains(typ, "BLOB") || strings.Contains(typ, "BINARY") || typ == "BYTEA" || typ == "IMAGE"
+}
+
+// appendJSONValue appends the JSON encoding of a value returned by the driver.
+func appendJSONValue(buf []byte, value any, binary bool) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, "null"...), nil
+	case int64:
+		return strconv.AppendInt(buf, v, 10), nil
+	case bool:
+		return strconv.AppendBool(buf, v), nil
+	case []byte:
+		if !binary {
+			value = string(v)
+		}
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return buf, err
+	}
+	return append(buf, encoded...), nil
+}
@@ -1,6 +1,11 @@
 package db
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // ----------------------------------------------------------------------
 // ErrInvalidDataType
@@ -19,3 +24,876 @@ func NewErrInvalidDataType(format string, args ...any) error {
 		Message: fmt.Sprintf(format, args...),
 	}
 }
+
+// ----------------------------------------------------------------------
+// ErrColumnMismatch
+// ----------------------------------------------------------------------
+type ErrColumnMismatch struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrColumnMismatch) Error() string {
+	return fmt.Sprintf("ErrColumnMismatch: %s", e.Message)
+}
+
+func NewErrColumnMismatch(format string, args ...any) error {
+	return &ErrColumnMismatch{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrNullIntoNonNullable
+// ----------------------------------------------------------------------
+type ErrNullIntoNonNullable struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrNullIntoNonNullable) Error() string {
+	return fmt.Sprintf("ErrNullIntoNonNullable: %s", e.Message)
+}
+
+func NewErrNullIntoNonNullable(format string, args ...any) error {
+	return &ErrNullIntoNonNullable{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// BatchError
+// ----------------------------------------------------------------------
+
+// BatchItemError is the error of a single item of a batch operation.
+type BatchItemError struct {
+	// Index of the failed item within the batch
+	Index int
+	Err   error
+}
+
+// Error implements error.
+func (e BatchItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the item.
+func (e BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError aggregates the errors of all failed items of a batch operation, so callers
+// can retry only the failed items. errors.Is and errors.As inspect all item errors.
+type BatchError struct {
+	Items []BatchItemError
+}
+
+// Error implements error.
+func (e BatchError) Error() string {
+	msgs := make([]string, len(e.Items))
+	for i, item := range e.Items {
+		msgs[i] = item.Error()
+	}
+	return fmt.Sprintf("BatchError: %d item(s) failed: %s", len(e.Items), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of all failed items.
+func (e BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Items))
+	for i, item := range e.Items {
+		errs[i] = item
+	}
+	return errs
+}
+
+// FailedIndices returns the indices of all failed items.
+func (e BatchError) FailedIndices() []int {
+	indices := make([]int, len(e.Items))
+	for i, item := range e.Items {
+		indices[i] = item.Index
+	}
+	return indices
+}
+
+// Add records the error of the item at the given index. Nil errors are ignored.
+func (e *BatchError) Add(index int, err error) {
+	if err != nil {
+		e.Items = append(e.Items, BatchItemError{Index: index, Err: err})
+	}
+}
+
+// ErrOrNil returns the batch error if at least one item failed, nil otherwise.
+func (e *BatchError) ErrOrNil() error {
+	if len(e.Items) == 0 {
+		return nil
+	}
+	return e
+}
+
+// ----------------------------------------------------------------------
+// ErrTransient
+// ----------------------------------------------------------------------
+
+// ErrTransient wraps an error that may succeed when the operation is retried,
+// optionally after a server provided delay.
+type ErrTransient struct {
+	Message string
+	Cause   error
+	Delay   time.Duration
+}
+
+// Error implements error.
+func (e ErrTransient) Error() string {
+	return fmt.Sprintf("ErrTransient: %s", e.Message)
+}
+
+// Unwrap returns the underlying error.
+func (e ErrTransient) Unwrap() error {
+	return e.Cause
+}
+
+// Transient implements ITransientError.
+func (e ErrTransient) Transient() bool {
+	return true
+}
+
+// RetryAfter implements ITransientError.
+func (e ErrTransient) RetryAfter() time.Duration {
+	return e.Delay
+}
+
+func NewErrTransient(cause error, retryAfter time.Duration) error {
+	return &ErrTransient{
+		Message: cause.Error(),
+		Cause:   cause,
+		Delay:   retryAfter,
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrMissingTenant
+// ----------------------------------------------------------------------
+type ErrMissingTenant struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrMissingTenant) Error() string {
+	return fmt.Sprintf("ErrMissingTenant: %s", e.Message)
+}
+
+func NewErrMissingTenant(format string, args ...any) error {
+	return &ErrMissingTenant{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrMissingShardKey
+// ----------------------------------------------------------------------
+type ErrMissingShardKey struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrMissingShardKey) Error() string {
+	return fmt.Sprintf("ErrMissingShardKey: %s", e.Message)
+}
+
+func NewErrMissingShardKey(format string, args ...any) error {
+	return &ErrMissingShardKey{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrUnsupportedDialect
+// ----------------------------------------------------------------------
+type ErrUnsupportedDialect struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrUnsupportedDialect) Error() string {
+	return fmt.Sprintf("ErrUnsupportedDialect: %s", e.Message)
+}
+
+func NewErrUnsupportedDialect(format string, args ...any) error {
+	return &ErrUnsupportedDialect{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrQueryBudgetExceeded
+// ----------------------------------------------------------------------
+type ErrQueryBudgetExceeded struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrQueryBudgetExceeded) Error() string {
+	return fmt.Sprintf("ErrQueryBudgetExceeded: %s", e.Message)
+}
+
+func NewErrQueryBudgetExceeded(format string, args ...any) error {
+	return &ErrQueryBudgetExceeded{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrQuotaExceeded
+// ----------------------------------------------------------------------
+type ErrQuotaExceeded struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrQuotaExceeded) Error() string {
+	return fmt.Sprintf("ErrQuotaExceeded: %s", e.Message)
+}
+
+func NewErrQuotaExceeded(format string, args ...any) error {
+	return &ErrQuotaExceeded{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrInvalidCursor
+// ----------------------------------------------------------------------
+type ErrInvalidCursor struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrInvalidCursor) Error() string {
+	return fmt.Sprintf("ErrInvalidCursor: %s", e.Message)
+}
+
+func NewErrInvalidCursor(format string, args ...any) error {
+	return &ErrInvalidCursor{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrNotFound
+// ----------------------------------------------------------------------
+type ErrNotFound struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrNotFound) Error() string {
+	return fmt.Sprintf("ErrNotFound: %s", e.Message)
+}
+
+func NewErrNotFound(format string, args ...any) error {
+	return &ErrNotFound{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrTooManyRows
+// ----------------------------------------------------------------------
+type ErrTooManyRows struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrTooManyRows) Error() string {
+	return fmt.Sprintf("ErrTooManyRows: %s", e.Message)
+}
+
+func NewErrTooManyRows(format string, args ...any) error {
+	return &ErrTooManyRows{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrBufferFull
+// ----------------------------------------------------------------------
+type ErrBufferFull struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrBufferFull) Error() string {
+	return fmt.Sprintf("ErrBufferFull: %s", e.Message)
+}
+
+func NewErrBufferFull(format string, args ...any) error {
+	return &ErrBufferFull{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrBufferClosed
+// ----------------------------------------------------------------------
+type ErrBufferClosed struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrBufferClosed) Error() string {
+	return fmt.Sprintf("ErrBufferClosed: %s", e.Message)
+}
+
+func NewErrBufferClosed(format string, args ...any) error {
+	return &ErrBufferClosed{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrInvalidStatement
+// ----------------------------------------------------------------------
+type ErrInvalidStatement struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrInvalidStatement) Error() string {
+	return fmt.Sprintf("ErrInvalidStatement: %s", e.Message)
+}
+
+func NewErrInvalidStatement(format string, args ...any) error {
+	return &ErrInvalidStatement{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrTransactionPanicked
+// ----------------------------------------------------------------------
+
+// ErrTransactionPanicked is returned by ExecuteInTransactionSafe if the transaction function
+// panicked. errors.Is and errors.As inspect the panic value (if it is an error) and the
+// error of the rollback.
+type ErrTransactionPanicked struct {
+	Message string
+	// Value is the recovered panic value
+	Value any
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+	// Cause joins the panic value (as error) and the rollback error, if any
+	Cause error
+}
+
+// Error implements error.
+func (e ErrTransactionPanicked) Error() string {
+	return fmt.Sprintf("ErrTransactionPanicked: %s", e.Message)
+}
+
+// Unwrap returns the underlying errors.
+func (e ErrTransactionPanicked) Unwrap() error {
+	return e.Cause
+}
+
+func NewErrTransactionPanicked(value any, stack []byte, rollbackErr error) error {
+	panicErr, ok := value.(error)
+	if !ok {
+		panicErr = fmt.Errorf("%v", value)
+	}
+	cause := errors.Join(panicErr, rollbackErr)
+	return &ErrTransactionPanicked{
+		Message: strings.ReplaceAll(cause.Error(), "\n", "; "),
+		Value:   value,
+		Stack:   stack,
+		Cause:   cause,
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrMaintenanceMode
+// ----------------------------------------------------------------------
+type ErrMaintenanceMode struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrMaintenanceMode) Error() string {
+	return fmt.Sprintf("ErrMaintenanceMode: %s", e.Message)
+}
+
+func NewErrMaintenanceMode(format string, args ...any) error {
+	return &ErrMaintenanceMode{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrInvalidConfig
+// ----------------------------------------------------------------------
+type ErrInvalidConfig struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrInvalidConfig) Error() string {
+	return fmt.Sprintf("ErrInvalidConfig: %s", e.Message)
+}
+
+func NewErrInvalidConfig(format string, args ...any) error {
+	return &ErrInvalidConfig{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrOptimisticLock
+// ----------------------------------------------------------------------
+type ErrOptimisticLock struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrOptimisticLock) Error() string {
+	return fmt.Sprintf("ErrOptimisticLock: %s", e.Message)
+}
+
+func NewErrOptimisticLock(format string, args ...any) error {
+	return &ErrOptimisticLock{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrAccessDenied
+// ----------------------------------------------------------------------
+type ErrAccessDenied struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrAccessDenied) Error() string {
+	return fmt.Sprintf("ErrAccessDenied: %s", e.Message)
+}
+
+func NewErrAccessDenied(format string, args ...any) error {
+	return &ErrAccessDenied{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrCascadeRestricted
+// ----------------------------------------------------------------------
+type ErrCascadeRestricted struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrCascadeRestricted) Error() string {
+	return fmt.Sprintf("ErrCascadeRestricted: %s", e.Message)
+}
+
+func NewErrCascadeRestricted(format string, args ...any) error {
+	return &ErrCascadeRestricted{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrCyclicDependency
+// ----------------------------------------------------------------------
+type ErrCyclicDependency struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrCyclicDependency) Error() string {
+	return fmt.Sprintf("ErrCyclicDependency: %s", e.Message)
+}
+
+func NewErrCyclicDependency(format string, args ...any) error {
+	return &ErrCyclicDependency{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrUniqueViolation
+// ----------------------------------------------------------------------
+
+// ErrUniqueViolation is returned if a statement violates a unique constraint or primary key.
+type ErrUniqueViolation struct {
+	Message string
+	// Code is the SQLSTATE or vendor error code reported by the database
+	Code string
+	// Constraint is the name of the violated constraint (or column), if reported
+	Constraint string
+	Cause      error
+}
+
+// Error implements error.
+func (e ErrUniqueViolation) Error() string {
+	return fmt.Sprintf("ErrUniqueViolation: %s", e.Message)
+}
+
+// Unwrap returns the underlying driver error.
+func (e ErrUniqueViolation) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is a ErrUniqueViolation, so errors.Is(err, &ErrUniqueViolation{}) matches errors of
+// this class regardless of their fields.
+func (e ErrUniqueViolation) Is(target error) bool {
+	switch target.(type) {
+	case ErrUniqueViolation, *ErrUniqueViolation:
+		return true
+	}
+	return false
+}
+
+func NewErrUniqueViolation(cause error, code, constraint string) error {
+	return &ErrUniqueViolation{
+		Message:    cause.Error(),
+		Code:       code,
+		Constraint: constraint,
+		Cause:      cause,
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrForeignKeyViolation
+// ----------------------------------------------------------------------
+
+// ErrForeignKeyViolation is returned if a statement violates a foreign key constraint, i.e. it
+// references a missing row or deletes a referenced row.
+type ErrForeignKeyViolation struct {
+	Message string
+	// Code is the SQLSTATE or vendor error code reported by the database
+	Code string
+	// Constraint is the name of the violated constraint (or column), if reported
+	Constraint string
+	Cause      error
+}
+
+// Error implements error.
+func (e ErrForeignKeyViolation) Error() string {
+	return fmt.Sprintf("ErrForeignKeyViolation: %s", e.Message)
+}
+
+// Unwrap returns the underlying driver error.
+func (e ErrForeignKeyViolation) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is a ErrForeignKeyViolation, so errors.Is(err, &ErrForeignKeyViolation{}) matches errors of
+// this class regardless of their fields.
+func (e ErrForeignKeyViolation) Is(target error) bool {
+	switch target.(type) {
+	case ErrForeignKeyViolation, *ErrForeignKeyViolation:
+		return true
+	}
+	return false
+}
+
+func NewErrForeignKeyViolation(cause error, code, constraint string) error {
+	return &ErrForeignKeyViolation{
+		Message:    cause.Error(),
+		Code:       code,
+		Constraint: constraint,
+		Cause:      cause,
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrCheckViolation
+// ----------------------------------------------------------------------
+
+// ErrCheckViolation is returned if a statement violates a check constraint.
+type ErrCheckViolation struct {
+	Message string
+	// Code is the SQLSTATE or vendor error code reported by the database
+	Code string
+	// Constraint is the name of the violated constraint (or column), if reported
+	Constraint string
+	Cause      error
+}
+
+// Error implements error.
+func (e ErrCheckViolation) Error() string {
+	return fmt.Sprintf("ErrCheckViolation: %s", e.Message)
+}
+
+// Unwrap returns the underlying driver error.
+func (e ErrCheckViolation) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is a ErrCheckViolation, so errors.Is(err, &ErrCheckViolation{}) matches errors of
+// this class regardless of their fields.
+func (e ErrCheckViolation) Is(target error) bool {
+	switch target.(type) {
+	case ErrCheckViolation, *ErrCheckViolation:
+		return true
+	}
+	return false
+}
+
+func NewErrCheckViolation(cause error, code, constraint string) error {
+	return &ErrCheckViolation{
+		Message:    cause.Error(),
+		Code:       code,
+		Constraint: constraint,
+		Cause:      cause,
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrNotNullViolation
+// ----------------------------------------------------------------------
+
+// ErrNotNullViolation is returned if a statement writes NULL to a NOT NULL column.
+type ErrNotNullViolation struct {
+	Message string
+	// Code is the SQLSTATE or vendor error code reported by the database
+	Code string
+	// Constraint is the name of the violated constraint (or column), if reported
+	Constraint string
+	Cause      error
+}
+
+// Error implements error.
+func (e ErrNotNullViolation) Error() string {
+	return fmt.Sprintf("ErrNotNullViolation: %s", e.Message)
+}
+
+// Unwrap returns the underlying driver error.
+func (e ErrNotNullViolation) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is a ErrNotNullViolation, so errors.Is(err, &ErrNotNullViolation{}) matches errors of
+// this class regardless of their fields.
+func (e ErrNotNullViolation) Is(target error) bool {
+	switch target.(type) {
+	case ErrNotNullViolation, *ErrNotNullViolation:
+		return true
+	}
+	return false
+}
+
+func NewErrNotNullViolation(cause error, code, constraint string) error {
+	return &ErrNotNullViolation{
+		Message:    cause.Error(),
+		Code:       code,
+		Constraint: constraint,
+		Cause:      cause,
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrSerializationFailure
+// ----------------------------------------------------------------------
+
+// ErrSerializationFailure is returned if the database aborted a transaction to resolve a
+// serialization conflict or deadlock. The transaction may succeed when it is retried.
+type ErrSerializationFailure struct {
+	Message string
+	// Code is the SQLSTATE or vendor error code reported by the database
+	Code  string
+	Cause error
+}
+
+// Error implements error.
+func (e ErrSerializationFailure) Error() string {
+	return fmt.Sprintf("ErrSerializationFailure: %s", e.Message)
+}
+
+// Unwrap returns the underlying driver error.
+func (e ErrSerializationFailure) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is a ErrSerializationFailure, so errors.Is(err, &ErrSerializationFailure{}) matches errors of
+// this class regardless of their fields.
+func (e ErrSerializationFailure) Is(target error) bool {
+	switch target.(type) {
+	case ErrSerializationFailure, *ErrSerializationFailure:
+		return true
+	}
+	return false
+}
+
+// Transient implements ITransientError.
+func (e ErrSerializationFailure) Transient() bool {
+	return true
+}
+
+// RetryAfter implements ITransientError.
+func (e ErrSerializationFailure) RetryAfter() time.Duration {
+	return 0
+}
+
+func NewErrSerializationFailure(cause error, code string) error {
+	return &ErrSerializationFailure{
+		Message: cause.Error(),
+		Code:    code,
+		Cause:   cause,
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrConnection
+// ----------------------------------------------------------------------
+
+// ErrConnection is returned if the connection to the database failed or has been lost.
+// The operation may succeed when it is retried on a new connection, unless the connection has
+// been lost after a write has been sent (see Unacknowledged).
+type ErrConnection struct {
+	Message string
+	// Code is the SQLSTATE or vendor error code reported by the database
+	Code  string
+	Cause error
+	// Unacknowledged reports that the connection has been lost after a statement modifying
+	// data or a commit has been sent, so it may have been applied. Such errors are not
+	// transient, since a retry could apply the write twice.
+	Unacknowledged bool
+}
+
+// Error implements error.
+func (e ErrConnection) Error() string {
+	return fmt.Sprintf("ErrConnection: %s", e.Message)
+}
+
+// Unwrap returns the underlying driver error.
+func (e ErrConnection) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is a ErrConnection, so errors.Is(err, &ErrConnection{}) matches errors of
+// this class regardless of their fields.
+func (e ErrConnection) Is(target error) bool {
+	switch target.(type) {
+	case ErrConnection, *ErrConnection:
+		return true
+	}
+	return false
+}
+
+// Transient implements ITransientError.
+func (e ErrConnection) Transient() bool {
+	return !e.Unacknowledged
+}
+
+// RetryAfter implements ITransientError.
+func (e ErrConnection) RetryAfter() time.Duration {
+	return 0
+}
+
+func NewErrConnection(cause error, code string) error {
+	return &ErrConnection{
+		Message: cause.Error(),
+		Code:    code,
+		Cause:   cause,
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrTimeout
+// ----------------------------------------------------------------------
+
+// ErrTimeout is returned if the database canceled a statement, since it exceeded the
+// statement or lock timeout.
+type ErrTimeout struct {
+	Message string
+	// Code is the SQLSTATE or vendor error code reported by the database
+	Code  string
+	Cause error
+}
+
+// Error implements error.
+func (e ErrTimeout) Error() string {
+	return fmt.Sprintf("ErrTimeout: %s", e.Message)
+}
+
+// Unwrap returns the underlying driver error.
+func (e ErrTimeout) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is a ErrTimeout, so errors.Is(err, &ErrTimeout{}) matches errors of
+// this class regardless of their fields.
+func (e ErrTimeout) Is(target error) bool {
+	switch target.(type) {
+	case ErrTimeout, *ErrTimeout:
+		return true
+	}
+	return false
+}
+
+func NewErrTimeout(cause error, code string) error {
+	return &ErrTimeout{
+		Message: cause.Error(),
+		Code:    code,
+		Cause:   cause,
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrFullTableScan
+// ----------------------------------------------------------------------
+type ErrFullTableScan struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrFullTableScan) Error() string {
+	return fmt.Sprintf("ErrFullTableScan: %s", e.Message)
+}
+
+func NewErrFullTableScan(format string, args ...any) error {
+	return &ErrFullTableScan{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrCircuitOpen
+// ----------------------------------------------------------------------
+
+// ErrCircuitOpen is returned by calls rejected by an open CircuitBreaker, without reaching the
+// database.
+type ErrCircuitOpen struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("ErrCircuitOpen: %s", e.Message)
+}
+
+func NewErrCircuitOpen(format string, args ...any) error {
+	return &ErrCircuitOpen{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrReadOnly
+// ----------------------------------------------------------------------
+
+// ErrReadOnly is returned by read-only sessions (see ReadOnly) for statements which may modify
+// data or schema, without executing them.
+type ErrReadOnly struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrReadOnly) Error() string {
+	return fmt.Sprintf("ErrReadOnly: %s", e.Message)
+}
+
+func NewErrReadOnly(format string, args ...any) error {
+	return &ErrReadOnly{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
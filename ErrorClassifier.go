@@ -0,0 +1,238 @@
+package db
+
+import (
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// errorClasses are the typed errors ClassifyError wraps driver errors into.
+var errorClasses = []error{
+	&ErrUniqueViolation{},
+	&ErrForeignKeyViolation{},
+	&ErrCheckViolation{},
+	&ErrNotNullViolation{},
+	&ErrSerializationFailure{},
+	&ErrConnection{},
+	&ErrTimeout{},
+}
+
+// errorClassifiers classify the errors of the drivers of a dialect by their SQLSTATE or vendor
+// code. They return nil if the error is not recognized.
+var errorClassifiers = map[string]ErrorTranslator{
+	DialectPostgres:  classifyPostgresError,
+	DialectMySQL:     classifyMySQLError,
+	DialectSQLite:    classifySQLiteError,
+	DialectSQLServer: classifySQLServerError,
+}
+
+func init() {
+	// built-in translators of the common drivers, see RegisterErrorTranslator
+	for driverName, dialect := range map[string]string{
+		"pgx":       DialectPostgres,
+		"postgres":  DialectPostgres,
+		"mysql":     DialectMySQL,
+		"sqlite":    DialectSQLite,
+		"sqlite3":   DialectSQLite,
+		"sqlserver": DialectSQLServer,
+		"mssql":     DialectSQLServer,
+	} {
+		RegisterErrorTranslator(driverName, classifierOf(dialect))
+	}
+}
+
+// ClassifyError wraps a driver error into the typed error of its class, so callers can handle
+// constraint violations and retryable failures independent of the driver:
+//
+//	if _, err := conn.ExecContext(ctx, "INSERT INTO users (email) VALUES (?)", email); errors.Is(err, &db.ErrUniqueViolation{}) {
+//		return ErrEmailTaken
+//	}
+//
+// Errors are classified by their SQLSTATE (Postgres drivers exposing SQLState(), e.g. pgx and
+// lib/pq), their vendor error number (MySQL, SQL Server) or their extended result code and
+// message (SQLite). driver.ErrBadConn is classified as ErrConnection on every dialect. The
+// driver error remains available via errors.As. ErrSerializationFailure and ErrConnection are
+// transient (see IsTransient), so they are retried by a RetryPolicy.
+//
+// Clients classify errors automatically: via the translators registered for their driver name
+// (see WithDriverName), or else by the classifier of their dialect.
+//
+// Parameters:
+//   - d: Dialect of the database that returned the error
+//   - err: Error to classify
+//
+// Returns:
+//   - error: The classified error, or err itself if it is not recognized or already classified
+func ClassifyError(d IDialect, err error) error {
+	if err == nil {
+		return nil
+	}
+	if classified := classifierOf(d.Name())(err); classified != nil {
+		return classified
+	}
+	return err
+}
+
+// classifierOf returns an error translator classifying the errors of a dialect.
+func classifierOf(dialect string) ErrorTranslator {
+	classify := errorClassifiers[dialect]
+	return func(err error) error {
+		if slices.ContainsFunc(errorClasses, func(class error) bool { return errors.Is(err, class) }) {
+			return err
+		}
+		if errors.Is(err, driver.ErrBadConn) {
+			return NewErrConnection(err, "")
+		}
+		if classify == nil {
+			return nil
+		}
+		return classify(err)
+	}
+}
+
+// classifyPostgresError classifies an error by its SQLSTATE.
+func classifyPostgresError(err error) error {
+	var state interface{ SQLState() string }
+	if !errors.As(err, &state) {
+		return nil
+	}
+	code := state.SQLState()
+	switch code {
+	case "23505":
+		return NewErrUniqueViolation(err, code, constraintOf(err))
+	case "23503":
+		return NewErrForeignKeyViolation(err, code, constraintOf(err))
+	case "23514":
+		return NewErrCheckViolation(err, code, constraintOf(err))
+	case "23502":
+		return NewErrNotNullViolation(err, code, constraintOf(err))
+	case "40001", "40P01":
+		return NewErrSerializationFailure(err, code)
+	case "57014", "55P03", "25P03":
+		return NewErrTimeout(err, code)
+	case "57P01", "57P02", "57P03":
+		return NewErrConnection(err, code)
+	}
+	if strings.HasPrefix(code, "08") {
+		return NewErrConnection(err, code)
+	}
+	return nil
+}
+
+// mysqlErrorPattern matches the error number in messages of the MySQL driver, e.g.
+// "Error 1062 (23000): Duplicate entry 'a' for key 'users.email'".
+var mysqlErrorPattern = regexp.MustCompile(`\bError (\d+)(?: \([0-9A-Z]{5}\))?:`)
+
+// classifyMySQLError classifies an error by its MySQL error number.
+func classifyMySQLError(err error) error {
+	match := mysqlErrorPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return nil
+	}
+	code := match[1]
+	switch code {
+	case "1062", "1586":
+		return NewErrUniqueViolation(err, code, constraintOf(err))
+	case "1216", "1217", "1451", "1452":
+		return NewErrForeignKeyViolation(err, code, constraintOf(err))
+	case "3819":
+		return NewErrCheckViolation(err, code, constraintOf(err))
+	case "1048":
+		return NewErrNotNullViolation(err, code, constraintOf(err))
+	case "1213":
+		return NewErrSerializationFailure(err, code)
+	case "1205", "3024", "3572":
+		return NewErrTimeout(err, code)
+	case "1040", "1053", "2002", "2003", "2006", "2013":
+		return NewErrConnection(err, code)
+	}
+	return nil
+}
+
+// classifySQLiteError classifies an error by its extended result code, or by its message if
+// the driver does not expose the code (e.g. mattn/go-sqlite3).
+func classifySQLiteError(err error) error {
+	var extended int
+	var coded interface{ Code() int }
+	if errors.As(err, &coded) {
+		extended = coded.Code()
+	}
+	code := ""
+	if extended != 0 {
+		code = strconv.Itoa(extended)
+	}
+	message := err.Error()
+	switch {
+	case extended == 2067 || extended == 1555 || strings.Contains(message, "UNIQUE constraint failed"):
+		return NewErrUniqueViolation(err, code, constraintOf(err))
+	case extended == 787 || strings.Contains(message, "FOREIGN KEY constraint failed"):
+		return NewErrForeignKeyViolation(err, code, "")
+	case extended == 275 || strings.Contains(message, "CHECK constraint failed"):
+		return NewErrCheckViolation(err, code, constraintOf(err))
+	case extended == 1299 || strings.Contains(message, "NOT NULL constraint failed"):
+		return NewErrNotNullViolation(err, code, constraintOf(err))
+	case extended == 517:
+		return NewErrSerializationFailure(err, code)
+	case extended&0xff == 5 || extended&0xff == 6 || strings.Contains(message, "database is locked"):
+		return NewErrTimeout(err, code)
+	case extended&0xff == 14:
+		return NewErrConnection(err, code)
+	}
+	return nil
+}
+
+// classifySQLServerError classifies an error by its SQL Server error number.
+func classifySQLServerError(err error) error {
+	var numbered interface{ SQLErrorNumber() int32 }
+	if !errors.As(err, &numbered) {
+		return nil
+	}
+	number := numbered.SQLErrorNumber()
+	code := strconv.Itoa(int(number))
+	switch number {
+	case 2601, 2627:
+		return NewErrUniqueViolation(err, code, constraintOf(err))
+	case 547:
+		// constraint conflicts share the number, the message names the kind of constraint
+		if strings.Contains(err.Error(), "CHECK constraint") {
+			return NewErrCheckViolation(err, code, constraintOf(err))
+		}
+		return NewErrForeignKeyViolation(err, code, constraintOf(err))
+	case 515:
+		return NewErrNotNullViolation(err, code, constraintOf(err))
+	case 1205, 3960:
+		return NewErrSerializationFailure(err, code)
+	case 1222:
+		return NewErrTimeout(err, code)
+	case 233, 10053, 10054, 10060, 40613:
+		return NewErrConnection(err, code)
+	}
+	return nil
+}
+
+// constraintPatterns extract the name of the violated constraint (or column) from the
+// messages of the supported databases, in order of precedence.
+var constraintPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`constraint "([^"]+)"`),
+	regexp.MustCompile(`(?i)constraint '([^']+)'`),
+	regexp.MustCompile("CONSTRAINT `([^`]+)`"),
+	regexp.MustCompile(`for key '([^']+)'`),
+	regexp.MustCompile(`unique index '([^']+)'`),
+	regexp.MustCompile(`(?:UNIQUE|CHECK|NOT NULL) constraint failed: (.+?)(?: \(\d+\))?$`),
+	regexp.MustCompile(`(?i)column ["']([^"']+)["']`),
+}
+
+// constraintOf returns the name of the constraint violated according to the error message,
+// or an empty string.
+func constraintOf(err error) string {
+	message := err.Error()
+	for _, pattern := range constraintPatterns {
+		if match := pattern.FindStringSubmatch(message); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package db
+
+import "sync"
+
+// ErrorTranslator converts a driver specific error into one of the typed errors of this
+// package. It returns nil if the error is not recognized.
+type ErrorTranslator func(err error) error
+
+var (
+	errorTranslatorsMu sync.RWMutex
+	errorTranslators   = map[string][]ErrorTranslator{}
+)
+
+// RegisterErrorTranslator registers a translator for the driver with the given name, so
+// in-house drivers and proxies can participate in the typed error taxonomy.
+//
+// Multiple translators may be registered per driver. They are consulted in reverse order
+// of registration, so custom translators take precedence over the built-in ones.
+//
+// Parameters:
+//   - driverName: Name the driver is registered with in database/sql (e.g. "pgx", "mysql")
+//   - translator: Function translating errors of that driver
+func RegisterErrorTranslator(driverName string, translator ErrorTranslator) {
+	errorTranslatorsMu.Lock()
+	defer errorTranslatorsMu.Unlock()
+	errorTranslators[driverName] = append(errorTranslators[driverName], translator)
+}
+
+// TranslateError translates a driver error using the translators registered for the driver.
+//
+// Parameters:
+//   - driverName: Name of the driver that returned the error
+//   - err: Error to translate
+//
+// Returns:
+//   - error: The translated error, or err itself if no translator recognized it
+func TranslateError(driverName string, err error) error {
+	if err == nil {
+		return nil
+	}
+	errorTranslatorsMu.RLock()
+	translators := errorTranslators[driverName]
+	errorTranslatorsMu.RUnlock()
+	for i := len(translators) - 1; i >= 0; i-- {
+		if translated := translators[i](err); translated != nil {
+			return translated
+		}
+	}
+	return err
+}
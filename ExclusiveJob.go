@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// IJobLock grants exclusive execution of named jobs, so periodic maintenance work runs on
+// only one instance at a time.
+type IJobLock interface {
+	// TryLock acquires the lock without waiting. If ok is false, the lock is held elsewhere.
+	TryLock(ctx context.Context, name string) (unlock func() error, ok bool, err error)
+}
+
+// RunExclusive executes fn if the job lock with the given name can be acquired.
+//
+// Parameters:
+//   - ctx: Context for cancellation, passed to fn
+//   - lock: Lock coordinating the job, nil executes fn unconditionally
+//   - name: Name of the job
+//   - fn: Job to execute
+//
+// Returns:
+//   - bool: True if fn was executed
+//   - error: Non-nil if acquiring or releasing the lock, or fn itself fails
+func RunExclusive(ctx context.Context, lock IJobLock, name string, fn func(ctx context.Context) error) (bool, error) {
+	if lock == nil {
+		return true, fn(ctx)
+	}
+	unlock, ok, err := lock.TryLock(ctx, name)
+	if err != nil || !ok {
+		return false, err
+	}
+	err = fn(ctx)
+	if unlockErr := unlock(); err == nil {
+		err = unlockErr
+	}
+	return true, err
+}
+
+// LocalJobLock is an in-process IJobLock, suitable for single instance deployments and tests.
+type LocalJobLock struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+// NewLocalJobLock creates an in-process job lock.
+func NewLocalJobLock() *LocalJobLock {
+	return &LocalJobLock{held: map[string]bool{}}
+}
+
+// TryLock implements IJobLock.
+func (l *LocalJobLock) TryLock(ctx context.Context, name string) (func() error, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[name] {
+		return nil, false, nil
+	}
+	l.held[name] = true
+	return func() error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, name)
+		return nil
+	}, true, nil
+}
+
+// AdvisoryJobLock is an IJobLock based on database advisory locks, coordinating all
+// instances connected to the same database (Postgres and MySQL).
+type AdvisoryJobLock struct {
+	db      *sql.DB
+	dialect IDialect
+}
+
+// NewAdvisoryJobLock creates a job lock using the advisory locks of the given database.
+func NewAdvisoryJobLock(db *sql.DB, dialect IDialect) *AdvisoryJobLock {
+	return &AdvisoryJobLock{db: db, dialect: dialect}
+}
+
+// TryLock implements IJobLock. The lock is bound to a dedicated connection, which is held
+// until the lock is released.
+func (l *AdvisoryJobLock) TryLock(ctx context.Context, name string) (func() error, bool, error) {
+	var lockQuery, unlockQuery string
+	switch l.dialect.Name() {
+	case DialectPostgres:
+		lockQuery, unlockQuery = "SELECT pg_try_advisory_lock(hashtext($1))", "SELECT pg_advisory_unlock(hashtext($1))"
+	case DialectMySQL:
+		lockQuery, unlockQuery = "SELECT GET_LOCK(?, 0) = 1", "SELECT RELEASE_LOCK(?)"
+	default:
+		return nil, false, NewErrUnsupportedDialect("advisory locks are not supported by %s", l.dialect.Name())
+	}
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, lockQuery, name).Scan(&ok); err != nil || !ok {
+		conn.Close()
+		return nil, false, err
+	}
+	return func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(), unlockQuery, name)
+		return err
+	}, true, nil
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/uoul/go-async"
+)
+
+// Exec executes a SQL statement that does not return rows (INSERT, UPDATE, DELETE, DDL).
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (connection or transaction) to execute the statement on
+//   - query: SQL statement to execute
+//   - args: Variadic arguments to be used as statement parameters (prevents SQL injection)
+//
+// Returns:
+//   - sql.Result: Result providing the number of affected rows and the last insert id
+//   - error: Non-nil if statement execution fails
+func Exec(ctx context.Context, conn IWriteSession, query string, args ...any) (sql.Result, error) {
+	return conn.ExecContext(ctx, query, args...)
+}
+
+// ExecAsync executes a SQL statement asynchronously.
+//
+// This function wraps the synchronous Exec function in an asynchronous execution context,
+// allowing the statement to run concurrently without blocking the caller.
+func ExecAsync(ctx context.Context, conn IWriteSession, query string, args ...any) async.Result[sql.Result] {
+	return async.Do(
+		ctx,
+		func(ctx context.Context) (sql.Result, error) {
+			return Exec(ctx, conn, query, args...)
+		},
+	)
+}
@@ -3,6 +3,8 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"runtime/debug"
 
 	"github.com/uoul/go-async"
 )
@@ -30,7 +32,16 @@ type TransactionScopeFunction[T any] func(ctx context.Context, tx *sql.Tx) (T, e
 // This function creates a new transaction using the provided database connection and
 // executes the given TransactionScopeFunction within that transaction context. If the
 // function completes successfully, the transaction is committed; otherwise, it is rolled back.
-// The transaction is also rolled back if a panic occurs during execution (via deferred rollback).
+// If the rollback fails as well, its error is joined with the error of the function (see
+// errors.Join), so connection-level failures can be detected.
+// The transaction is also rolled back if a panic occurs during execution (via deferred rollback);
+// the panic is propagated (see ExecuteInTransactionSafe to recover it).
+// Hooks registered by the function using AfterCommit are run after a successful commit.
+//
+// Calls nested within the function (using the context passed to the function and the same
+// connection) join the running transaction using a savepoint instead of starting a new
+// transaction: if the nested function fails, only its changes are rolled back, and the
+// error is returned to the enclosing function. Options of nested calls are ignored.
 //
 // Type parameter T represents the return type of the transaction function, allowing for
 // flexible return values based on the specific business logic requirements.
@@ -46,25 +57,106 @@ type TransactionScopeFunction[T any] func(ctx context.Context, tx *sql.Tx) (T, e
 //   - T: The result returned by the transaction function
 //   - error: Non-nil if transaction creation, execution, or commit fails
 func ExecuteInTransaction[T any](ctx context.Context, db IDbConnection, tsf TransactionScopeFunction[T], opts ...sql.TxOptions) (T, error) {
+	return executeInTransaction(ctx, db, tsf, false, opts...)
+}
+
+// ExecuteInTransactionSafe executes the provided function within a database transaction like
+// ExecuteInTransaction, but recovers panics of the function instead of propagating them.
+//
+// If the function panics, the transaction (or the savepoint of a nested call) is rolled back
+// and ErrTransactionPanicked is returned, carrying the panic value, the stack trace and the
+// error of the rollback, if it failed.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control, propagated to the transaction
+//   - db: Database connection to use for creating the transaction
+//   - tsf: Function to execute within the transaction scope
+//   - opts: Optional transaction options (isolation level, read-only mode, etc.).
+//
+// Returns:
+//   - T: The result returned by the transaction function
+//   - error: ErrTransactionPanicked if the function panicked, non-nil if transaction creation,
+//     execution, or commit fails
+func ExecuteInTransactionSafe[T any](ctx context.Context, db IDbConnection, tsf TransactionScopeFunction[T], opts ...sql.TxOptions) (T, error) {
+	return executeInTransaction(ctx, db, tsf, true, opts...)
+}
+
+// inTransaction reports whether the context carries a running transaction of ExecuteInTransaction.
+func inTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(transactionContextKey).(*txScope)
+	return ok
+}
+
+func executeInTransaction[T any](ctx context.Context, db IDbConnection, tsf TransactionScopeFunction[T], recoverPanics bool, opts ...sql.TxOptions) (result T, err error) {
+	// Join a running transaction of the same connection
+	if scope, ok := ctx.Value(transactionContextKey).(*txScope); ok && scope.owns(db) {
+		return executeInSavepoint(ctx, scope, tsf, recoverPanics)
+	}
 	var txOpts *sql.TxOptions = nil
 	if len(opts) > 0 {
 		txOpts = &opts[0]
 	}
-	// Create transaction
-	tx, err := db.BeginTx(ctx, txOpts)
+	trace := newTxTrace(txTracerOf(db), txOpts)
+	// Create transaction, interceptors deferring their release (see DeferRelease) hold it until
+	// the transaction is finished
+	beginCtx, release := withReleaseScope(ctx, OperationBegin)
+	defer release()
+	var tx *sql.Tx
+	err = trace.step(ctx, TxTracer.BeginAttempt, TxTracer.Began, func() error {
+		var err error
+		tx, err = db.BeginTx(beginCtx, txOpts)
+		return err
+	})
 	if err != nil {
 		return *new(T), err
 	}
-	defer tx.Rollback()
+	scope := &txScope{conn: db, tx: tx, dialect: dialectOf(db), trace: trace}
+	defer scope.register(ctx)()
+	metrics := metricsOf(db)
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		var recovered any
+		if recoverPanics {
+			recovered = recover()
+		}
+		if metrics != nil {
+			metrics.IncTxRollback()
+		}
+		rollbackErr := trace.step(ctx, nil, TxTracer.RolledBack, tx.Rollback)
+		if errors.Is(rollbackErr, sql.ErrTxDone) {
+			// Already rolled back, e.g. by the driver after the context has been canceled
+			rollbackErr = nil
+		}
+		if recovered != nil {
+			result, err = *new(T), NewErrTransactionPanicked(recovered, debug.Stack(), rollbackErr)
+		} else if rollbackErr != nil {
+			err = errors.Join(err, rollbackErr)
+		}
+	}()
 	// Execute TransactionScopeFunction
-	r, err := tsf(ctx, tx)
+	hooks := &afterCommitHooks{}
+	txCtx := context.WithValue(context.WithValue(ctx, afterCommitContextKey, hooks), transactionContextKey, scope)
+	r, err := tsf(txCtx, tx)
 	if err != nil {
 		return *new(T), err
 	}
 	// Commit changes
-	if err := tx.Commit(); err != nil {
+	committed = true
+	if err := trace.step(ctx, TxTracer.CommitAttempt, TxTracer.Committed, tx.Commit); err != nil {
+		if metrics != nil {
+			// A failed commit leaves the changes rolled back
+			metrics.IncTxRollback()
+		}
 		return *new(T), err
 	}
+	if metrics != nil {
+		metrics.IncTxCommit()
+	}
+	// Run hooks registered using AfterCommit
+	hooks.run(ctx)
 	// Return result
 	return r, nil
 }
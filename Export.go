@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"io"
+)
+
+// ExportFormat is an output format of Export.
+type ExportFormat int
+
+const (
+	// ExportJSON writes a JSON array of objects (see EncodeJSON)
+	ExportJSON ExportFormat = iota
+	// ExportNDJSON writes newline delimited JSON objects (see EncodeJSON and WithNDJSON)
+	ExportNDJSON
+	// ExportArrow writes an Apache Arrow IPC stream (see EncodeArrow)
+	ExportArrow
+)
+
+// String returns the name of the format.
+func (f ExportFormat) String() string {
+	switch f {
+	case ExportJSON:
+		return "json"
+	case ExportNDJSON:
+		return "ndjson"
+	case ExportArrow:
+		return "arrow"
+	}
+	return "unknown"
+}
+
+// ContentType returns the media type of the format, e.g. for the Content-Type header.
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	case ExportArrow:
+		return "application/vnd.apache.arrow.stream"
+	}
+	return "application/json"
+}
+
+// Export executes a query and streams its rows to w in the given format, e.g. to let the
+// consumers of a data service choose their format:
+//
+//	format := db.ExportJSON
+//	if strings.Contains(r.Header.Get("Accept"), db.ExportArrow.ContentType()) {
+//		format = db.ExportArrow
+//	}
+//	w.Header().Set("Content-Type", format.ContentType())
+//	_, err := db.Export(ctx, conn, w, format, "SELECT * FROM events WHERE day = ?", day)
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to execute the query on
+//   - w: Writer to stream the rows to
+//   - format: Output format
+//   - query: SQL query string
+//   - args: Query arguments and QueryOption values
+//
+// Returns:
+//   - int: Number of written rows
+//   - error: ErrInvalidDataType for unknown formats, otherwise the error of EncodeJSON or
+//     EncodeArrow
+func Export(ctx context.Context, conn IReadSession, w io.Writer, format ExportFormat, query string, args ...any) (int, error) {
+	switch format {
+	case ExportJSON:
+		return EncodeJSON(ctx, conn, w, query, args...)
+	case ExportNDJSON:
+		return EncodeJSON(ctx, conn, w, query, append(args[:len(args):len(args)], WithNDJSON())...)
+	case ExportArrow:
+		return EncodeArrow(ctx, conn, w, query, args...)
+	}
+	return 0, NewErrInvalidDataType("unknown export format %d", format)
+}
@@ -0,0 +1,459 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Expr is a SQL expression (column, predicate, function call, subquery, ...) composed by the
+// statement builders. Expressions are rendered for the dialect of the statement, so
+// identifiers are quoted and placeholders numbered according to the dialect.
+type Expr interface {
+	writeSQL(w *sqlWriter) error
+}
+
+// sqlWriter renders expressions into SQL text, collecting their arguments. Placeholders are
+// numbered across the whole statement, so composed expressions and subqueries can be
+// rendered independently of their position.
+type sqlWriter struct {
+	d    IDialect
+	sb   strings.Builder
+	args []any
+}
+
+func newSqlWriter(d IDialect) *sqlWriter {
+	return &sqlWriter{d: d}
+}
+
+func (w *sqlWriter) write(s string) {
+	w.sb.WriteString(s)
+}
+
+// arg adds an argument and writes its placeholder, or renders the value if it is an Expr.
+func (w *sqlWriter) arg(value any) error {
+	if expr, ok := value.(Expr); ok {
+		return expr.writeSQL(w)
+	}
+	w.args = append(w.args, value)
+	w.sb.WriteString(w.d.Placeholder(len(w.args)))
+	return nil
+}
+
+// column writes a column reference (see quoteColumn).
+func (w *sqlWriter) column(name string) {
+	w.sb.WriteString(quoteColumn(w.d, name))
+}
+
+// raw writes a SQL fragment, translating its "?" placeholders to placeholders of the dialect.
+// Question marks within quoted strings and identifiers are kept.
+func (w *sqlWriter) raw(sql string, args []any) error {
+	n := 0
+	var quote rune
+	for _, r := range sql {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '[':
+			quote = ']'
+		case r == '?':
+			if n >= len(args) {
+				return NewErrInvalidStatement("fragment %q has more placeholders than arguments (%d)", sql, len(args))
+			}
+			if err := w.arg(args[n]); err != nil {
+				return err
+			}
+			n++
+			continue
+		}
+		w.sb.WriteRune(r)
+	}
+	if n != len(args) {
+		return NewErrInvalidStatement("fragment %q has %d placeholders, but %d arguments", sql, n, len(args))
+	}
+	return nil
+}
+
+// list renders the expressions separated by sep.
+func (w *sqlWriter) list(exprs []Expr, sep string) error {
+	for i, expr := range exprs {
+		if i > 0 {
+			w.write(sep)
+		}
+		if err := expr.writeSQL(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *sqlWriter) result() (string, []any) {
+	return w.sb.String(), w.args
+}
+
+// columnPattern matches (optionally qualified) column references, which are quoted by the builders
+var columnPattern = regexp.MustCompile(`^(\*|[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*(\.\*)?)$`)
+
+// quoteColumn quotes a column reference like "name", "u.name" or "u.*". Anything else
+// (function calls, expressions, already quoted names) is returned unchanged.
+func quoteColumn(d IDialect, name string) string {
+	if !columnPattern.MatchString(name) {
+		return name
+	}
+	if name == "*" {
+		return name
+	}
+	if qualifier, found := strings.CutSuffix(name, ".*"); found {
+		return d.QuoteIdentifier(qualifier) + ".*"
+	}
+	return d.QuoteIdentifier(name)
+}
+
+// toExpr converts a column given as string (see Col) or Expr into an Expr.
+func toExpr(column any) Expr {
+	switch c := column.(type) {
+	case Expr:
+		return c
+	case string:
+		return Col(c)
+	}
+	return Value(column)
+}
+
+// ----------------------------------------------------------------------
+// Basic expressions
+// ----------------------------------------------------------------------
+
+type rawExpr struct {
+	sql  string
+	args []any
+}
+
+func (e rawExpr) writeSQL(w *sqlWriter) error {
+	return w.raw(e.sql, e.args)
+}
+
+// Raw returns a SQL fragment used verbatim. Its "?" placeholders are translated to the
+// placeholders of the dialect, arguments may be expressions (e.g. subqueries) themselves.
+func Raw(sql string, args ...any) Expr {
+	return rawExpr{sql: sql, args: args}
+}
+
+type columnExpr string
+
+func (e columnExpr) writeSQL(w *sqlWriter) error {
+	w.column(string(e))
+	return nil
+}
+
+// Col returns a column reference like "name", "u.name" or "u.*", quoted per dialect.
+// Strings that are no plain column references (e.g. "COUNT(*)") are used verbatim.
+func Col(name string) Expr {
+	return columnExpr(name)
+}
+
+type valueExpr struct {
+	value any
+}
+
+func (e valueExpr) writeSQL(w *sqlWriter) error {
+	w.args = append(w.args, e.value)
+	w.write(w.d.Placeholder(len(w.args)))
+	return nil
+}
+
+// Value returns a bound parameter, e.g. to select a constant.
+func Value(value any) Expr {
+	return valueExpr{value: value}
+}
+
+type aliasExpr struct {
+	expr  Expr
+	alias string
+}
+
+func (e aliasExpr) writeSQL(w *sqlWriter) error {
+	if err := e.expr.writeSQL(w); err != nil {
+		return err
+	}
+	w.write(" AS " + w.d.QuoteIdentifier(e.alias))
+	return nil
+}
+
+// As names an expression within a select list, e.g. As(Raw("COUNT(*)"), "total"). The
+// expression may be given as column (string) or Expr.
+func As(expr any, alias string) Expr {
+	return aliasExpr{expr: toExpr(expr), alias: alias}
+}
+
+// ----------------------------------------------------------------------
+// Predicates
+// ----------------------------------------------------------------------
+
+type compareExpr struct {
+	column string
+	op     string
+	value  any
+}
+
+func (e compareExpr) writeSQL(w *sqlWriter) error {
+	w.column(e.column)
+	w.write(" " + e.op + " ")
+	return w.arg(e.value)
+}
+
+// Eq returns the predicate column = value. The value may be an Expr, e.g. Col("other").
+func Eq(column string, value any) Expr { return compareExpr{column, "=", value} }
+
+// Ne returns the predicate column <> value.
+func Ne(column string, value any) Expr { return compareExpr{column, "<>", value} }
+
+// Lt returns the predicate column < value.
+func Lt(column string, value any) Expr { return compareExpr{column, "<", value} }
+
+// Le returns the predicate column <= value.
+func Le(column string, value any) Expr { return compareExpr{column, "<=", value} }
+
+// Gt returns the predicate column > value.
+func Gt(column string, value any) Expr { return compareExpr{column, ">", value} }
+
+// Ge returns the predicate column >= value.
+func Ge(column string, value any) Expr { return compareExpr{column, ">=", value} }
+
+type inExpr struct {
+	column string
+	values []any
+	not    bool
+}
+
+func (e inExpr) writeSQL(w *sqlWriter) error {
+	if len(e.values) == 0 {
+		// An empty list matches nothing (or everything, if negated)
+		if e.not {
+			w.write("1 = 1")
+		} else {
+			w.write("1 = 0")
+		}
+		return nil
+	}
+	w.column(e.column)
+	if e.not {
+		w.write(" NOT IN (")
+	} else {
+		w.write(" IN (")
+	}
+	for i, value := range e.values {
+		if i > 0 {
+			w.write(", ")
+		}
+		if err := w.arg(value); err != nil {
+			return err
+		}
+	}
+	w.write(")")
+	return nil
+}
+
+// In returns the predicate column IN (values...). An empty list matches no rows.
+func In(column string, values ...any) Expr { return inExpr{column: column, values: values} }
+
+// NotIn returns the predicate column NOT IN (values...). An empty list matches all rows.
+func NotIn(column string, values ...any) Expr {
+	return inExpr{column: column, values: values, not: true}
+}
+
+type nullExpr struct {
+	column string
+	not    bool
+}
+
+func (e nullExpr) writeSQL(w *sqlWriter) error {
+	w.column(e.column)
+	if e.not {
+		w.write(" IS NOT NULL")
+	} else {
+		w.write(" IS NULL")
+	}
+	return nil
+}
+
+// IsNull returns the predicate column IS NULL.
+func IsNull(column string) Expr { return nullExpr{column: column} }
+
+// IsNotNull returns the predicate column IS NOT NULL.
+func IsNotNull(column string) Expr { return nullExpr{column: column, not: true} }
+
+// likeEscape is the escape character of the patterns rendered by likeExpr. A character
+// without special meaning in string literals is used, so the ESCAPE clause is portable.
+const likeEscape = '!'
+
+type likeExpr struct {
+	column string
+	prefix string
+	value  string
+	suffix string
+}
+
+func (e likeExpr) writeSQL(w *sqlWriter) error {
+	pattern := e.prefix + escapeLike(w.d, e.value) + e.suffix
+	if w.d.Name() == DialectPostgres {
+		w.column(e.column)
+		w.write(" ILIKE ")
+	} else {
+		w.write("LOWER(")
+		w.column(e.column)
+		w.write(") LIKE ")
+		pattern = strings.ToLower(pattern)
+	}
+	if err := w.arg(pattern); err != nil {
+		return err
+	}
+	w.write(" ESCAPE '" + string(likeEscape) + "'")
+	return nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern (and character classes on SQL Server).
+func escapeLike(d IDialect, s string) string {
+	special := "%_" + string(likeEscape)
+	if d.Name() == DialectSQLServer {
+		special += "["
+	}
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(special, r) {
+			sb.WriteRune(likeEscape)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Contains returns a case-insensitive predicate matching rows whose column contains s.
+// Wildcards in s (e.g. user input of a search filter) are escaped, so they match literally.
+// Postgres uses ILIKE, other dialects compare LOWER(column) using LIKE.
+func Contains(column string, s string) Expr {
+	return likeExpr{column: column, prefix: "%", value: s, suffix: "%"}
+}
+
+// StartsWith returns a case-insensitive predicate matching rows whose column starts with s
+// (see Contains).
+func StartsWith(column string, s string) Expr {
+	return likeExpr{column: column, value: s, suffix: "%"}
+}
+
+// EndsWith returns a case-insensitive predicate matching rows whose column ends with s
+// (see Contains).
+func EndsWith(column string, s string) Expr {
+	return likeExpr{column: column, prefix: "%", value: s}
+}
+
+type junctionExpr struct {
+	op    string
+	exprs []Expr
+}
+
+func (e junctionExpr) writeSQL(w *sqlWriter) error {
+	switch len(e.exprs) {
+	case 0:
+		// Neutral element: AND of nothing is true, OR of nothing is false
+		if e.op == " AND " {
+			w.write("1 = 1")
+		} else {
+			w.write("1 = 0")
+		}
+		return nil
+	case 1:
+		return e.exprs[0].writeSQL(w)
+	}
+	w.write("(")
+	if err := w.list(e.exprs, e.op); err != nil {
+		return err
+	}
+	w.write(")")
+	return nil
+}
+
+// And combines predicates with AND.
+func And(exprs ...Expr) Expr { return junctionExpr{op: " AND ", exprs: exprs} }
+
+// Or combines predicates with OR.
+func Or(exprs ...Expr) Expr { return junctionExpr{op: " OR ", exprs: exprs} }
+
+type notExpr struct {
+	expr Expr
+}
+
+func (e notExpr) writeSQL(w *sqlWriter) error {
+	w.write("NOT (")
+	if err := e.expr.writeSQL(w); err != nil {
+		return err
+	}
+	w.write(")")
+	return nil
+}
+
+// Not negates a predicate.
+func Not(expr Expr) Expr { return notExpr{expr: expr} }
+
+// ----------------------------------------------------------------------
+// Subqueries
+// ----------------------------------------------------------------------
+
+type existsExpr struct {
+	query IStatementBuilder
+	not   bool
+}
+
+func (e existsExpr) writeSQL(w *sqlWriter) error {
+	if e.not {
+		w.write("NOT ")
+	}
+	w.write("EXISTS (")
+	if err := writeStatement(w, e.query); err != nil {
+		return err
+	}
+	w.write(")")
+	return nil
+}
+
+// Exists returns the predicate EXISTS (subquery). Parameters of the subquery are numbered
+// within the enclosing statement.
+func Exists(query IStatementBuilder) Expr { return existsExpr{query: query} }
+
+// NotExists returns the predicate NOT EXISTS (subquery).
+func NotExists(query IStatementBuilder) Expr { return existsExpr{query: query, not: true} }
+
+type inSubqueryExpr struct {
+	column string
+	query  IStatementBuilder
+	not    bool
+}
+
+func (e inSubqueryExpr) writeSQL(w *sqlWriter) error {
+	w.column(e.column)
+	if e.not {
+		w.write(" NOT IN (")
+	} else {
+		w.write(" IN (")
+	}
+	if err := writeStatement(w, e.query); err != nil {
+		return err
+	}
+	w.write(")")
+	return nil
+}
+
+// InSubquery returns the predicate column IN (subquery), e.g.
+//
+//	db.InSubquery("customer_id", db.Select("id").From("customers").Where(db.Eq("country", "AT")))
+func InSubquery(column string, query IStatementBuilder) Expr {
+	return inSubqueryExpr{column: column, query: query}
+}
+
+// NotInSubquery returns the predicate column NOT IN (subquery).
+func NotInSubquery(column string, query IStatementBuilder) Expr {
+	return inSubqueryExpr{column: column, query: query, not: true}
+}
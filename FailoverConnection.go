@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// IReconnectNotifier is implemented by failover-aware connections, notifying listeners once
+// they reconnected to a (possibly different) server, e.g. after a replica has been promoted.
+// FailoverConnection implements it.
+type IReconnectNotifier interface {
+	// OnReconnect registers fn to be invoked after every reconnect. fn must not block.
+	OnReconnect(fn func())
+}
+
+// FailoverOptions configures a FailoverConnection.
+type FailoverOptions struct {
+	// Dialect classifies the errors of calls to detect lost connections (default: DefaultDialect)
+	Dialect IDialect
+	// PingTimeout bounds the ping of each database while failing over (default: 5s)
+	PingTimeout time.Duration
+}
+
+// FailoverConnection executes all calls on the active one of several databases, e.g. a primary
+// and its standbys or the same cluster reached by different addresses:
+//
+//	conn, err := db.NewFailoverConnection([]*sql.DB{primary, standby}, db.FailoverOptions{Dialect: db.Postgres})
+//	client := db.NewClient(conn, db.WithDialect(db.Postgres), db.WithStatementCache(256))
+//
+// Once a call fails with a connection error (see ErrConnection) and the active database does
+// not respond to a ping anymore, the connection fails over to the next database responding, in
+// the order they were given (wrapping around), and notifies the listeners registered by
+// OnReconnect (e.g. the statement cache of a client, see WithStatementCache). The failed call
+// itself is not repeated; its error is returned, and a retry policy may retry it on the new
+// database. Transactions remain on the database they were begun on. FailoverConnection
+// implements IDbConnection and IDbPreparer and is safe for concurrent use.
+type FailoverConnection struct {
+	databases []*sql.DB
+	opts      FailoverOptions
+	active    atomic.Int64
+
+	// mu serializes failovers and guards listeners
+	mu        sync.Mutex
+	listeners []func()
+}
+
+// NewFailoverConnection creates a connection executing calls on the first database, failing
+// over to the others if it is lost.
+//
+// Parameters:
+//   - databases: Databases in order of preference
+//   - opts: Optional options (first element used)
+//
+// Returns:
+//   - *FailoverConnection: The connection
+//   - error: ErrInvalidConfig if no database is given
+func NewFailoverConnection(databases []*sql.DB, opts ...FailoverOptions) (*FailoverConnection, error) {
+	if len(databases) == 0 {
+		return nil, NewErrInvalidConfig("failover connection requires at least one database")
+	}
+	c := &FailoverConnection{databases: databases}
+	if len(opts) > 0 {
+		c.opts = opts[0]
+	}
+	if c.opts.Dialect == nil {
+		c.opts.Dialect = DefaultDialect
+	}
+	if c.opts.PingTimeout <= 0 {
+		c.opts.PingTimeout = 5 * time.Second
+	}
+	return c, nil
+}
+
+// Active returns the database calls are currently executed on.
+func (c *FailoverConnection) Active() *sql.DB {
+	return c.databases[c.active.Load()]
+}
+
+// OnReconnect implements IReconnectNotifier, fn is invoked after every failover.
+func (c *FailoverConnection) OnReconnect(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, fn)
+}
+
+// QueryContext implements IDbConnection.
+func (c *FailoverConnection) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	database := c.Active()
+	rows, err := database.QueryContext(ctx, query, args...)
+	return rows, c.check(database, err)
+}
+
+// ExecContext implements IWriteSession.
+func (c *FailoverConnection) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	database := c.Active()
+	result, err := database.ExecContext(ctx, query, args...)
+	return result, c.check(database, err)
+}
+
+// BeginTx implements IDbConnection.
+func (c *FailoverConnection) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	database := c.Active()
+	tx, err := database.BeginTx(ctx, opts)
+	return tx, c.check(database, err)
+}
+
+// PrepareContext implements IDbPreparer, preparing the statement on the active database.
+func (c *FailoverConnection) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	database := c.Active()
+	stmt, err := database.PrepareContext(ctx, query)
+	return stmt, c.check(database, err)
+}
+
+// Failover pings the active database and switches to the next database responding if it does
+// not, notifying the listeners registered by OnReconnect. It is invoked by calls failing with a
+// connection error, and may be invoked by health checks.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//
+// Returns:
+//   - bool: Whether the connection failed over to another database
+//   - error: ErrConnection of the active database if no database responds
+func (c *FailoverConnection) Failover(ctx context.Context) (bool, error) {
+	return c.failover(ctx, c.Active())
+}
+
+// check fails over if a call on database failed with a connection error and returns err.
+func (c *FailoverConnection) check(database *sql.DB, err error) error {
+	if err == nil || !errors.Is(ClassifyError(c.opts.Dialect, err), &ErrConnection{}) {
+		return err
+	}
+	// The context of the call may be canceled already, the failover is not bound to it
+	c.failover(context.Background(), database)
+	return err
+}
+
+// failover switches from database to the next database responding, unless it has been
+// switched meanwhile or database still responds.
+func (c *FailoverConnection) failover(ctx context.Context, database *sql.DB) (bool, error) {
+	c.mu.Lock()
+	current := c.active.Load()
+	if c.databases[current] != database {
+		c.mu.Unlock()
+		return false, nil
+	}
+	var pingErr error
+	for i := range c.databases {
+		candidate := (int(current) + i) % len(c.databases)
+		pingCtx, cancel := context.WithTimeout(ctx, c.opts.PingTimeout)
+		err := c.databases[candidate].PingContext(pingCtx)
+		cancel()
+		if i == 0 {
+			if err == nil {
+				c.mu.Unlock()
+				return false, nil
+			}
+			pingErr = err
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		c.active.Store(int64(candidate))
+		listeners := c.listeners
+		c.mu.Unlock()
+		for _, fn := range listeners {
+			fn()
+		}
+		return true, nil
+	}
+	c.mu.Unlock()
+	return false, NewErrConnection(pingErr, "")
+}
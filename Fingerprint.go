@@ -0,0 +1,167 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var inListPattern = regexp.MustCompile(`\bin \(\?(?:, \?)+\)`)
+
+// parenthesisKeywords are the keywords followed by a parenthesis with a space, unlike the names
+// of functions (e.g. "in (?)", but "count(*)").
+var parenthesisKeywords = map[string]bool{
+	"all": true, "and": true, "any": true, "as": true, "between": true, "by": true, "case": true,
+	"check": true, "else": true, "except": true, "exists": true, "filter": true, "from": true,
+	"having": true, "in": true, "intersect": true, "into": true, "is": true, "join": true,
+	"key": true, "lateral": true, "like": true, "not": true, "on": true, "or": true, "over": true,
+	"recursive": true, "references": true, "returning": true, "select": true, "set": true,
+	"some": true, "table": true, "then": true, "union": true, "unique": true, "using": true,
+	"values": true, "when": true, "where": true, "with": true, "within": true,
+}
+
+// tableKeywords are the keywords followed by the name of a table, which is no function even if
+// a parenthesis follows (e.g. "insert into users (a, b)").
+var tableKeywords = map[string]bool{
+	"into": true, "references": true, "table": true, "update": true,
+}
+
+// Fingerprint normalizes a SQL statement, so that all executions of the same statement
+// produce the same fingerprint regardless of their parameters.
+//
+// The normalization removes comments, collapses whitespace, lower-cases keywords and
+// unquoted identifiers, replaces string and numeric literals as well as all placeholder
+// styles ($1, ?, @p1, :name) with "?" and collapses IN lists to a single element. Function
+// calls are rendered without space before their parenthesis (e.g. "count(*)").
+// The same fingerprints are used for metrics, logs and N+1 detection within this package,
+// so users can aggregate their own data consistently.
+//
+// Parameters:
+//   - sql: SQL statement to normalize
+//
+// Returns:
+//   - string: The normalized statement
+func Fingerprint(sql string) string {
+	return inListPattern.ReplaceAllString(normalizeStatement(sql, true), "in (?)")
+}
+
+// normalizeStatement removes comments, collapses whitespace and replaces literals and
+// placeholders with "?". Unquoted identifiers and keywords are lower-cased if foldCase is set.
+func normalizeStatement(sql string, foldCase bool) string {
+	var sb strings.Builder
+	runes := []rune(sql)
+	// needsSpace records whether the previous token requires a separating space, function
+	// whether it is an unquoted name other than a keyword, i.e. the name of a function if a
+	// parenthesis follows, and table whether the next name is the name of a table
+	needsSpace, function, table := false, false, false
+	emit := func(token string) {
+		switch {
+		case token == "," || token == ")" || token == ";" || token == ".":
+		case token == "(" && function:
+		default:
+			if needsSpace {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(token)
+		needsSpace = token != "(" && token != "."
+		function, table = false, table && token == "."
+	}
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			// Line comment
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			// Block comment
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				i++
+			}
+			i += 2
+		case r == '\'':
+			// String literal, '' escapes a quote
+			i++
+			for i < len(runes) {
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+			emit("?")
+		case r == '"' || r == '`' || r == '[':
+			// Quoted identifier, kept verbatim
+			closing := r
+			if r == '[' {
+				closing = ']'
+			}
+			start := i
+			i++
+			for i < len(runes) && runes[i] != closing {
+				i++
+			}
+			i++
+			emit(string(runes[start:min(i, len(runes))]))
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			// Numeric literal
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				((runes[i] == '+' || runes[i] == '-') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+			emit("?")
+		case r == '?' || ((r == '$' || r == '@' || r == ':') && i+1 < len(runes) && isIdentRune(runes[i+1]) && !(r == ':' && i > 0 && runes[i-1] == ':')):
+			// Placeholder
+			i++
+			for i < len(runes) && isIdentRune(runes[i]) {
+				i++
+			}
+			emit("?")
+		case r == ':' && i+1 < len(runes) && runes[i+1] == ':':
+			// Postgres type cast
+			i += 2
+			sb.WriteString("::")
+			needsSpace = false
+		case isIdentRune(r):
+			start := i
+			for i < len(runes) && (isIdentRune(runes[i]) || runes[i] == '$') {
+				i++
+			}
+			word, tableName := string(runes[start:i]), table
+			if foldCase {
+				emit(strings.ToLower(word))
+			} else {
+				emit(word)
+			}
+			keyword := strings.ToLower(word)
+			function = !tableName && !parenthesisKeywords[keyword]
+			// The name of a table may be qualified by its schema
+			table = tableKeywords[keyword] || tableName && i < len(runes) && runes[i] == '.'
+		default:
+			// Operators and punctuation (multi character operators are kept together)
+			start := i
+			i++
+			if !strings.ContainsRune("(),;.", r) {
+				for i < len(runes) && strings.ContainsRune("<>=!|&+-*/%^~", runes[i]) &&
+					!(runes[i] == '-' && i+1 < len(runes) && runes[i+1] == '-') {
+					i++
+				}
+			}
+			emit(string(runes[start:i]))
+		}
+	}
+	return sb.String()
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -2,8 +2,10 @@ package db
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -11,68 +13,102 @@ const (
 	field_tag = "db"
 )
 
-func parseDbResult[T any](rows *sql.Rows) ([]T, error) {
+// scanDestPool recycles scan destination slices between queries
+var scanDestPool = sync.Pool{
+	New: func() any {
+		return new([]any)
+	},
+}
+
+func getScanDest(n int) *[]any {
+	dest := scanDestPool.Get().(*[]any)
+	if cap(*dest) < n {
+		*dest = make([]any, n)
+	}
+	*dest = (*dest)[:n]
+	return dest
+}
+
+func putScanDest(dest *[]any) {
+	// Drop references to scanned items, so they can be garbage collected
+	clear(*dest)
+	scanDestPool.Put(dest)
+}
+
+func parseDbResult[T any](rows *sql.Rows, opts queryOptions) ([]T, error) {
 	// Get column names from the result set
 	columns, err := rows.Columns()
 	if err != nil {
 		return nil, err
 	}
-	result := []T{}
-	for rows.Next() {
-		// Create item
-		var item T
-		// Handle non structure types
-		if reflect.TypeFor[T]().Kind() != reflect.Struct {
-			// Handle primitive types directly
-			if len(columns) != 1 {
-				return nil, NewErrInvalidDataType("expected 1 column for primitive type, got %d", len(columns))
-			}
-			if err := rows.Scan(&item); err != nil {
-				return nil, err
-			}
-			result = append(result, item)
-			continue
-		}
-		// Create map of all fields from row (if struct)
-		fieldMap, err := createFieldMap(reflect.ValueOf(&item).Elem(), "")
-		if err != nil {
+	if opts.validateColumns {
+		if err := validateColumns[T](columns, opts.nameMapper); err != nil {
 			return nil, err
 		}
-		// Create scan destinations using any typed interface
-		scanDest := make([]any, len(columns))
-		for i, col := range columns {
-			if ptr, ok := fieldMap[col]; ok {
-				scanDest[i] = ptr
-			} else {
-				// Skip unmapped fields into dummy variable
-				var dummy any
-				scanDest[i] = &dummy
-			}
-		}
-		// Scan row
-		if err := rows.Scan(scanDest...); err != nil {
+	}
+	if opts.workers > 1 {
+		return scanParallel[T](rows, columns, opts)
+	}
+	defer opts.scanReport.sort()
+	result := make([]T, 0, opts.capacity)
+	scan, release := newRowScanner[T](rows, columns, opts)
+	defer release()
+	for row := 0; rows.Next(); row++ {
+		// Scan directly into the result slice
+		result = append(result, *new(T))
+		if keep, err := scan(&result[len(result)-1], row); err != nil {
 			return nil, err
+		} else if !keep {
+			result = result[:len(result)-1]
 		}
-		result = append(result, item)
 	}
 	return result, rows.Err()
 }
 
-func createFieldMap(val reflect.Value, prefix string) (map[string]any, error) {
+// rowScanner scans the current row into item. keep is false if the row has been skipped
+// (see WithScanRecovery).
+type rowScanner[T any] func(item *T, row int) (keep bool, err error)
+
+// newRowScanner returns the scanner mapping rows with the given columns into T. The scan
+// destinations are pooled, release returns them to the pool once scanning has finished.
+func newRowScanner[T any](rows *sql.Rows, columns []string, opts queryOptions) (scan rowScanner[T], release func()) {
+	pooled := getScanDest(len(columns))
+	release = func() { putScanDest(pooled) }
+	scanDest := *pooled
+	var dummy any
+	// Handle primitive types (including leaf structs like time.Time)
+	if isPrimitiveType(reflect.TypeFor[T]()) {
+		return func(item *T, row int) (bool, error) {
+			// Handle primitive types directly
+			if len(columns) != 1 {
+				return false, NewErrInvalidDataType("expected 1 column for primitive type, got %d", len(columns))
+			}
+			scanDest[0] = item
+			return scanRow(rows, scanDest, columns, row, opts)
+		}, release
+	}
+	// Use the cached field indices, so no reflection over the struct is done per row
+	plan := scanPlanOf(reflect.TypeFor[T](), columns, opts.nameMapper)
+	return func(item *T, row int) (bool, error) {
+		plan.scanDestinations(reflect.ValueOf(item).Elem(), scanDest, &dummy)
+		return scanRow(rows, scanDest, columns, row, opts)
+	}, release
+}
+
+func createFieldMap(val reflect.Value, prefix string, mapper NameMapper) (map[string]any, error) {
 	fieldMap := make(map[string]any)
 	typ := val.Type()
 	// Inspect all fields of type
 	for i := 0; i < val.NumField(); i++ {
 		field := val.Field(i)
 		fieldType := typ.Field(i)
-		fieldTag := fieldType.Tag.Get(field_tag)
 		// Skip unexported fields
 		if !field.CanSet() {
 			continue
 		}
 		// Handle embedded structs
 		if field.Kind() == reflect.Struct && fieldType.Anonymous {
-			nestedMap, err := createFieldMap(field, prefix)
+			nestedMap, err := createFieldMap(field, prefix, mapper)
 			if err != nil {
 				return nil, err
 			}
@@ -81,18 +117,15 @@ func createFieldMap(val reflect.Value, prefix string) (map[string]any, error) {
 			}
 			continue
 		}
-		// Handle non-embedded nested structs (except time.Time)
-		if field.Kind() == reflect.Struct && fieldType.Type != reflect.TypeFor[time.Time]() {
-			nestedPrefix := fieldTag
-			if nestedPrefix == "" {
-				nestedPrefix = strings.ToLower(fieldType.Name)
-			}
+		// Handle non-embedded nested structs (except leaf types like time.Time)
+		if field.Kind() == reflect.Struct && !isLeafType(fieldType.Type) {
+			nestedPrefix := columnNameOf(fieldType, mapper)
 			// Add separator if there's already a prefix
 			if prefix != "" {
 				nestedPrefix = prefix + "_" + nestedPrefix
 			}
 			// Recursively process nested struct
-			nestedMap, err := createFieldMap(field, nestedPrefix)
+			nestedMap, err := createFieldMap(field, nestedPrefix, mapper)
 			if err != nil {
 				return nil, err
 			}
@@ -102,10 +135,7 @@ func createFieldMap(val reflect.Value, prefix string) (map[string]any, error) {
 			continue
 		}
 		// Handle regular fields
-		columnName := fieldTag
-		if columnName == "" {
-			columnName = strings.ToLower(fieldType.Name)
-		}
+		columnName := columnNameOf(fieldType, mapper)
 		// Add prefix if exists
 		if prefix != "" {
 			columnName = prefix + "_" + columnName
@@ -115,3 +145,55 @@ func createFieldMap(val reflect.Value, prefix string) (map[string]any, error) {
 	}
 	return fieldMap, nil
 }
+
+// isLeafType reports whether a struct type is scanned as a single column instead of being
+// flattened into prefixed columns: time.Time and types implementing sql.Scanner (on the value
+// or pointer receiver) or driver.Valuer, such as sql.NullTime or UUID types.
+func isLeafType(typ reflect.Type) bool {
+	return typ == reflect.TypeFor[time.Time]() ||
+		typ.Implements(scannerType) || reflect.PointerTo(typ).Implements(scannerType) ||
+		typ.Implements(valuerType)
+}
+
+// isPrimitiveType reports whether a result type is scanned from a single column (ints, strings,
+// []byte, time.Time, ...) instead of being mapped to columns field by field.
+func isPrimitiveType(typ reflect.Type) bool {
+	return typ.Kind() != reflect.Struct || isLeafType(typ)
+}
+
+// IsPrimitiveType reports whether Query maps values of the type from a single column (basic
+// types, time.Time and implementations of sql.Scanner or driver.Valuer such as sql.NullString
+// or UUID types), rather than from one column per struct field.
+func IsPrimitiveType(typ reflect.Type) bool {
+	return isPrimitiveType(typ)
+}
+
+var (
+	scannerType = reflect.TypeFor[sql.Scanner]()
+	valuerType  = reflect.TypeFor[driver.Valuer]()
+)
+
+// columnNameOf returns the column name of a struct field (db tag or mapped field name).
+// Options following the name in the tag (e.g. `db:"version,version"`) are ignored.
+func columnNameOf(fieldType reflect.StructField, mapper NameMapper) string {
+	if name, _, _ := strings.Cut(fieldType.Tag.Get(field_tag), ","); name != "" {
+		return name
+	}
+	if mapper == nil {
+		mapper = DefaultNameMapper
+	}
+	return mapper(fieldType.Name)
+}
+
+// hasTagOption reports whether the db tag of a struct field carries the given option.
+func hasTagOption(fieldType reflect.StructField, option string) bool {
+	_, options, _ := strings.Cut(fieldType.Tag.Get(field_tag), ",")
+	for options != "" {
+		var opt string
+		opt, options, _ = strings.Cut(options, ",")
+		if strings.TrimSpace(opt) == option {
+			return true
+		}
+	}
+	return false
+}
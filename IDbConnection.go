@@ -9,3 +9,12 @@ type IDbConnection interface {
 	IDbSession
 	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 }
+
+// writeSession returns the session as IReadWriteSession, since connections are not required to
+// execute statements not returning rows (e.g. *sql.DB does, query-only implementations don't).
+func writeSession(session IReadSession) (IReadWriteSession, error) {
+	if s, ok := session.(IReadWriteSession); ok {
+		return s, nil
+	}
+	return nil, NewErrInvalidConfig("session %T does not implement ExecContext", session)
+}
@@ -1,10 +1,16 @@
 package db
 
-import (
-	"context"
-	"database/sql"
-)
-
+// IDbSession is a database session that can execute queries.
+//
+// It is kept for compatibility and equivalent to IReadSession. APIs executing statements
+// accept IReadWriteSession instead, so existing implementations of IDbSession remain valid.
 type IDbSession interface {
-	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	IReadSession
+}
+
+// IReadWriteSession is a database session that can execute queries and statements not
+// returning rows, e.g. *sql.DB, *sql.Tx and Client.
+type IReadWriteSession interface {
+	IReadSession
+	IWriteSession
 }
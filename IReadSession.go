@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// IReadSession is a database session that can only execute queries.
+//
+// APIs that must not modify data should accept IReadSession, so they cannot execute
+// statements at compile time. Use ReadOnly to restrict an existing session.
+type IReadSession interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// ReadOnly restricts the given session to read access.
+//
+// Unlike a plain interface conversion, the returned session cannot be converted back to
+// IReadWriteSession using a type assertion. Queries which may modify data or schema (e.g.
+// INSERT ... RETURNING, data modifying CTEs or procedure calls, see ClassifyStatement) are
+// rejected with ErrReadOnly. Settings of the session (dialect, name mapper, cache) are
+// preserved.
+func ReadOnly(session IReadSession) IReadSession {
+	return readOnlySession{session: session}
+}
+
+type readOnlySession struct {
+	session IReadSession
+}
+
+// QueryContext implements IReadSession, rejecting statements which may modify data or schema.
+func (s readOnlySession) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if ClassifyStatement(dialectOf(s.session), query).Writes {
+		return nil, NewErrReadOnly("session is read-only, statement may modify data: %s", Fingerprint(query))
+	}
+	return s.session.QueryContext(ctx, query, args...)
+}
+
+// Dialect returns the dialect of the underlying session.
+func (s readOnlySession) Dialect() IDialect {
+	return dialectOf(s.session)
+}
+
+// NameMapper returns the name mapper of the underlying session.
+func (s readOnlySession) NameMapper() NameMapper {
+	return nameMapperOf(s.session)
+}
+
+// Cache returns the cache of the underlying session.
+func (s readOnlySession) Cache() ICache {
+	if provider, ok := s.session.(interface{ Cache() ICache }); ok {
+		return provider.Cache()
+	}
+	return nil
+}
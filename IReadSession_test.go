@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestReadOnlyRejectsWrites(t *testing.T) {
+	database, err := sql.Open("arrowstub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	session := ReadOnly(NewClient(database, WithDialect(Postgres)))
+	writes := []string{
+		"INSERT INTO users (name) VALUES ($1) RETURNING id",
+		"UPDATE users SET name = $1 WHERE id = $2 RETURNING id",
+		"DELETE FROM users WHERE id = $1 RETURNING id",
+		"WITH gone AS (DELETE FROM users RETURNING id) SELECT count(*) FROM gone",
+		"SELECT * INTO archive FROM users",
+	}
+	for _, query := range writes {
+		_, err := session.QueryContext(context.Background(), query)
+		var readOnly *ErrReadOnly
+		if !errors.As(err, &readOnly) {
+			t.Errorf("%s: expected ErrReadOnly, got %v", query, err)
+		}
+	}
+	rows, err := session.QueryContext(context.Background(), "SELECT * FROM users WHERE id = $1", 1)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	rows.Close()
+}
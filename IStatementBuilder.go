@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// IStatementBuilder is implemented by all statement builders (select, insert, update, delete, upsert).
+//
+// Building a statement is separate from executing it, so the generated SQL can be logged,
+// asserted in tests or passed to foreign execution layers.
+type IStatementBuilder interface {
+	// Build renders the statement for the given dialect
+	Build(dialect IDialect) (query string, args []any, err error)
+}
+
+// QueryStatement builds the statement using the dialect of the session and executes it as query
+// (see Query), enforcing the column policy of the session (see ColumnPolicy).
+func QueryStatement[T any](ctx context.Context, conn IReadSession, builder IStatementBuilder, opts ...QueryOption) ([]T, error) {
+	builder, err := prepareStatement(ctx, conn, builder)
+	if err != nil {
+		return nil, err
+	}
+	query, args, err := builder.Build(dialectOf(conn))
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		args = append(args, opt)
+	}
+	return Query[T](withPolicyVerified(ctx), conn, query, args...)
+}
+
+// ExecStatement builds the statement using the dialect of the session and executes it (see Exec),
+// enforcing the column policy of the session (see ColumnPolicy).
+func ExecStatement(ctx context.Context, conn IWriteSession, builder IStatementBuilder) (sql.Result, error) {
+	builder, err := prepareStatement(ctx, conn, builder)
+	if err != nil {
+		return nil, err
+	}
+	query, args, err := builder.Build(dialectOf(conn))
+	if err != nil {
+		return nil, err
+	}
+	return Exec(withPolicyVerified(ctx), conn, query, args...)
+}
+
+// prepareStatement prepares a builder for execution on a session: the structs of inserts are
+// mapped using the name mapper of the session, and the column policy of the session is enforced.
+func prepareStatement(ctx context.Context, conn any, builder IStatementBuilder) (IStatementBuilder, error) {
+	if b, ok := builder.(*InsertBuilder); ok && b.err == nil {
+		mapped, err := b.mapStructs(nameMapperOf(conn))
+		if err != nil {
+			return nil, err
+		}
+		builder = mapped
+	}
+	return enforceColumnPolicy(ctx, conn, builder)
+}
@@ -0,0 +1,11 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// IWriteSession is a database session that can execute statements not returning rows.
+type IWriteSession interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"database/sql"
+	"slices"
+	"sync"
+	"time"
+)
+
+// InFlightOperation describes an operation currently executed by a Client.
+type InFlightOperation struct {
+	Operation Operation
+	// Fingerprint is the normalized statement (see Fingerprint), free of literal values
+	Fingerprint string
+	Label       string
+	Started     time.Time
+	Duration    time.Duration
+}
+
+// SlowQuery describes an operation exceeding the slow query threshold of a Client (see
+// WithSlowQueryThreshold).
+type SlowQuery struct {
+	Operation   Operation
+	Fingerprint string
+	Label       string
+	Finished    time.Time
+	Duration    time.Duration
+}
+
+// maxSlowQueries is the number of recent slow queries kept by a client
+const maxSlowQueries = 100
+
+type inFlightOperation struct {
+	stmt    StatementInfo
+	label   string
+	started time.Time
+}
+
+// operationRegistry tracks the in-flight operations and recent slow queries of a client.
+type operationRegistry struct {
+	mu       sync.Mutex
+	nextId   uint64
+	inFlight map[uint64]inFlightOperation
+	slow     []SlowQuery
+}
+
+// start registers an operation, returning the function deregistering it. Fingerprints are
+// computed only when the operations are inspected, keeping the overhead per call low.
+func (r *operationRegistry) start(stmt StatementInfo, label string) func() {
+	op := inFlightOperation{stmt: stmt, label: label, started: time.Now()}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.inFlight == nil {
+		r.inFlight = map[uint64]inFlightOperation{}
+	}
+	r.nextId++
+	id := r.nextId
+	r.inFlight[id] = op
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.inFlight, id)
+	}
+}
+
+func (r *operationRegistry) recordSlow(stmt StatementInfo, label string, duration time.Duration) {
+	query := SlowQuery{Operation: stmt.Operation, Fingerprint: Fingerprint(stmt.Query), Label: label, Finished: time.Now(), Duration: duration}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.slow) == maxSlowQueries {
+		r.slow = slices.Delete(r.slow, 0, 1)
+	}
+	r.slow = append(r.slow, query)
+}
+
+// InFlight returns the operations currently executed by the client, longest running first.
+func (c *Client) InFlight() []InFlightOperation {
+	c.operations.mu.Lock()
+	ops := make([]inFlightOperation, 0, len(c.operations.inFlight))
+	for _, op := range c.operations.inFlight {
+		ops = append(ops, op)
+	}
+	c.operations.mu.Unlock()
+	now := time.Now()
+	result := make([]InFlightOperation, len(ops))
+	for i, op := range ops {
+		result[i] = InFlightOperation{
+			Operation:   op.stmt.Operation,
+			Fingerprint: Fingerprint(op.stmt.Query),
+			Label:       op.label,
+			Started:     op.started,
+			Duration:    now.Sub(op.started),
+		}
+	}
+	slices.SortFunc(result, func(a, b InFlightOperation) int {
+		return a.Started.Compare(b.Started)
+	})
+	return result
+}
+
+// SlowQueries returns the most recent operations exceeding the slow query threshold (see
+// WithSlowQueryThreshold), most recent last.
+func (c *Client) SlowQueries() []SlowQuery {
+	c.operations.mu.Lock()
+	defer c.operations.mu.Unlock()
+	return slices.Clone(c.operations.slow)
+}
+
+// PoolStats returns the connection pool statistics of the underlying connection, if it
+// provides them (like *sql.DB).
+func (c *Client) PoolStats() (sql.DBStats, bool) {
+	if pool, ok := c.conn.(interface{ Stats() sql.DBStats }); ok {
+		return pool.Stats(), true
+	}
+	return sql.DBStats{}, false
+}
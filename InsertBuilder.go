@@ -0,0 +1,170 @@
+package db
+
+import (
+	"reflect"
+	"slices"
+)
+
+// InsertBuilder builds INSERT statements.
+//
+//	stmt := db.Insert("users").Columns("name", "email").Values(name, email).Returning("id")
+//
+// InsertBuilder implements IStatementBuilder, so it can be executed using ExecStatement, or
+// QueryStatement if it returns rows.
+type InsertBuilder struct {
+	table     string
+	columns   []string
+	rows      [][]any
+	query     *SelectBuilder
+	returning returning
+	err       error
+
+	// structs are the structs added by Struct by row index, mapped to values once the name
+	// mapper is known (see mapStructs)
+	structs map[int]any
+	mapper  NameMapper
+}
+
+// Insert starts an INSERT statement into the given table.
+func Insert(table string) *InsertBuilder {
+	return &InsertBuilder{table: table}
+}
+
+// Columns sets the inserted columns.
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	b.columns = columns
+	return b
+}
+
+// Values adds a row, given as one value per column. Values may be expressions (e.g. Raw).
+// Calling Values repeatedly inserts multiple rows.
+func (b *InsertBuilder) Values(values ...any) *InsertBuilder {
+	b.rows = append(b.rows, values)
+	return b
+}
+
+// Struct adds a row from the fields of a struct, mapped to columns like Query maps them
+// (`db` tags, or else field names mapped by the name mapper of the session executing the
+// statement, see WithNameMapper). Unless set explicitly, the columns are all columns mapped by
+// the struct.
+func (b *InsertBuilder) Struct(item any) *InsertBuilder {
+	if b.structs == nil {
+		b.structs = map[int]any{}
+	}
+	b.structs[len(b.rows)] = item
+	b.rows = append(b.rows, nil)
+	return b
+}
+
+// WithNameMapper sets the name mapper mapping the fields of the structs added by Struct to
+// columns (default: the name mapper of the session executing the statement, or lower-cased
+// field names if built using Build).
+func (b *InsertBuilder) WithNameMapper(mapper NameMapper) *InsertBuilder {
+	b.mapper = mapper
+	return b
+}
+
+// Select inserts the rows returned by a query (INSERT INTO ... SELECT) instead of values.
+func (b *InsertBuilder) Select(query *SelectBuilder) *InsertBuilder {
+	b.query = query
+	return b
+}
+
+// Returning returns the given columns of the inserted rows (RETURNING, or OUTPUT on SQL
+// Server). Not supported by MySQL.
+func (b *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	b.returning = columns
+	return b
+}
+
+// Build implements IStatementBuilder.
+func (b *InsertBuilder) Build(dialect IDialect) (string, []any, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	b, err := b.mapStructs(nil)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(b.columns) == 0 || (len(b.rows) == 0) == (b.query == nil) {
+		return "", nil, NewErrInvalidStatement("insert into %s requires columns and either values or a query", b.table)
+	}
+	if err := b.returning.check(dialect); err != nil {
+		return "", nil, err
+	}
+	w := newSqlWriter(dialect)
+	w.write("INSERT INTO " + dialect.QuoteIdentifier(b.table) + " (" + quoteIdentifiers(dialect, b.columns) + ")")
+	b.returning.writeOutput(w, "INSERTED")
+	if b.query != nil {
+		w.write(" ")
+		if err := b.query.writeStatement(w); err != nil {
+			return "", nil, err
+		}
+	} else if err := b.writeValues(w); err != nil {
+		return "", nil, err
+	}
+	b.returning.writeReturning(w)
+	query, args := w.result()
+	return query, args, nil
+}
+
+// mapStructs returns a copy of the builder with the structs added by Struct mapped to rows,
+// using the name mapper of the builder, or else the given one.
+func (b *InsertBuilder) mapStructs(mapper NameMapper) (*InsertBuilder, error) {
+	if len(b.structs) == 0 {
+		return b, nil
+	}
+	if b.mapper != nil {
+		mapper = b.mapper
+	}
+	c := *b
+	c.rows, c.structs = slices.Clone(b.rows), nil
+	for i, row := range b.rows {
+		item, ok := b.structs[i]
+		if !ok {
+			continue
+		}
+		values, err := argValues(item, mapper)
+		if err != nil {
+			return nil, err
+		}
+		if len(c.columns) == 0 {
+			if c.columns, err = columnsOf(reflect.Indirect(reflect.ValueOf(item)).Type(), mapper); err != nil {
+				return nil, err
+			}
+		}
+		row = make([]any, len(c.columns))
+		for j, col := range c.columns {
+			value, ok := values[col]
+			if !ok {
+				return nil, NewErrColumnMismatch("column %q is not mapped by %T", col, item)
+			}
+			row[j] = value
+		}
+		c.rows[i] = row
+	}
+	return &c, nil
+}
+
+func (b *InsertBuilder) writeValues(w *sqlWriter) error {
+	w.write(" VALUES ")
+	for i, row := range b.rows {
+		if len(row) != len(b.columns) {
+			return NewErrInvalidStatement("row %d has %d values, expected %d", i, len(row), len(b.columns))
+		}
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write("(")
+		for j, value := range row {
+			if j > 0 {
+				w.write(", ")
+			}
+			if err := w.arg(value); err != nil {
+				return err
+			}
+		}
+		w.write(")")
+	}
+	return nil
+}
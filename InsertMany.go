@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// InsertManyOptions configures InsertMany.
+type InsertManyOptions struct {
+	// Columns restricts the inserted columns (default: all columns mapped by T)
+	Columns []string
+	// Omit excludes columns, e.g. generated ids
+	Omit []string
+	// BatchSize is the maximum number of rows per statement (default: as many as the parameter
+	// limit of the dialect allows)
+	BatchSize int
+	// Returning lists further generated columns, in addition to the fields tagged as returning
+	Returning []string
+}
+
+// InsertMany inserts items into a table using multi-row INSERT statements. The columns are
+// derived from T like Query maps them (`db` tags, name mapper of the session).
+//
+// Items are split into chunks respecting the parameter limit of the session's dialect. All
+// chunks are attempted; if some fail, a BatchError reports the indices of the items of the
+// failed chunks, so they can be retried. Note that within a transaction, some engines (e.g.
+// PostgreSQL) reject all statements after the first failure.
+//
+// Columns generated by the database (serial ids, defaults, triggers) are marked by the
+// `returning` tag option (`db:"id,returning"`) or InsertManyOptions.Returning. They are not
+// inserted, but read back into items using RETURNING (OUTPUT on SQL Server). Only PostgreSQL
+// returns the rows of a multi-row INSERT in insertion order, so the other dialects insert items
+// having generated columns one by one. MySQL supports a single generated integer column only,
+// which is populated from LastInsertId.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (connection or transaction) to insert into
+//   - table: Name of the table
+//   - items: Rows to insert
+//   - opts: Options of the insert
+//
+// Returns:
+//   - int64: Total number of inserted rows
+//   - error: BatchError if chunks failed, ErrInvalidDataType if T is not a struct or no
+//     columns remain, ErrUnsupportedDialect if the generated columns can't be read back
+func InsertMany[T any](ctx context.Context, conn IWriteSession, table string, items []T, opts ...InsertManyOptions) (int64, error) {
+	var o InsertManyOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+	mapper := nameMapperOf(conn)
+	d := dialectOf(conn)
+	generated, err := generatedColumnsOf(reflect.TypeFor[T](), mapper, o.Returning)
+	if err != nil {
+		return 0, err
+	}
+	if err := generated.check(d, reflect.TypeFor[T](), true); err != nil {
+		return 0, err
+	}
+	columns := o.Columns
+	if len(columns) == 0 {
+		if columns, err = columnsOf(reflect.TypeFor[T](), mapper); err != nil {
+			return 0, err
+		}
+	}
+	columns = slices.DeleteFunc(slices.Clone(columns), func(col string) bool {
+		return slices.Contains(o.Omit, col) || slices.Contains(generated.columns, col)
+	})
+	if len(columns) == 0 {
+		return 0, NewErrInvalidDataType("no columns to insert into %s", table)
+	}
+	if policy := columnPolicyOf(conn); policy != nil {
+		var err error
+		if columns, err = policy.writableColumns(ctx, table, columns); err != nil {
+			return 0, err
+		}
+		ctx = withPolicyVerified(ctx)
+	}
+	batchSize := maxInsertRows(d, len(columns))
+	if len(generated.columns) > 0 && d.Name() != DialectPostgres {
+		// The returned rows (and MySQL's ids of a multi-row insert) can't be matched to the items
+		batchSize = 1
+	}
+	if o.BatchSize > 0 {
+		batchSize = min(batchSize, o.BatchSize)
+	}
+	clauses := newSqlWriter(d)
+	returning(generated.columns).writeOutput(clauses, "INSERTED")
+	output, _ := clauses.result()
+	prefix := fmt.Sprintf("INSERT INTO %s (%s)%s VALUES ", d.QuoteIdentifier(table), quoteIdentifiers(d, columns), output)
+	clauses = newSqlWriter(d)
+	if d.Name() != DialectMySQL {
+		returning(generated.columns).writeReturning(clauses)
+	}
+	suffix, _ := clauses.result()
+
+	var total int64
+	var batchErr BatchError
+	for start := 0; start < len(items); start += batchSize {
+		chunk := items[start:min(start+batchSize, len(items))]
+		affected, err := insertChunk(ctx, conn, d, prefix, suffix, columns, chunk, mapper, generated)
+		if err != nil {
+			for i := range chunk {
+				batchErr.Add(start+i, err)
+			}
+			continue
+		}
+		total += affected
+	}
+	return total, batchErr.ErrOrNil()
+}
+
+func insertChunk[T any](ctx context.Context, conn IWriteSession, d IDialect, prefix, suffix string, columns []string, chunk []T, mapper NameMapper, generated generatedColumns) (int64, error) {
+	tuples := make([]string, len(chunk))
+	args := make([]any, 0, len(chunk)*len(columns))
+	for i, item := range chunk {
+		values, err := argValues(item, mapper)
+		if err != nil {
+			return 0, err
+		}
+		tuples[i] = "(" + placeholders(d, len(args)+1, len(columns)) + ")"
+		for _, col := range columns {
+			value, ok := values[col]
+			if !ok {
+				return 0, NewErrColumnMismatch("column %q is not mapped by %T", col, item)
+			}
+			args = append(args, value)
+		}
+	}
+	query := prefix + strings.Join(tuples, ", ") + suffix
+	if len(generated.columns) > 0 && d.Name() != DialectMySQL {
+		return execReturning(ctx, conn, generated, query, args, chunk)
+	}
+	result, err := conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	if len(generated.columns) > 0 {
+		if err := populateInsertId(generated, result, &chunk[0]); err != nil {
+			return 0, err
+		}
+	}
+	return result.RowsAffected()
+}
+
+// maxInsertRows returns the maximum number of rows of a multi-row INSERT with the given number
+// of columns, respecting the parameter limits of the dialect.
+func maxInsertRows(d IDialect, columns int) int {
+	maxParams, maxRows := 65535, 0
+	switch d.Name() {
+	case DialectSQLite:
+		maxParams = 32766
+	case DialectSQLServer:
+		// SQL Server allows 2100 parameters and 1000 rows per VALUES clause
+		maxParams, maxRows = 2000, 1000
+	}
+	rows := max(maxParams/columns, 1)
+	if maxRows > 0 {
+		rows = min(rows, maxRows)
+	}
+	return rows
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"sync"
+)
+
+// Operation identifies the kind of database call an interceptor is invoked for.
+type Operation string
+
+const (
+	OperationQuery Operation = "query"
+	OperationExec  Operation = "exec"
+	OperationBegin Operation = "begin"
+)
+
+// StatementInfo describes an intercepted database call.
+type StatementInfo struct {
+	Operation Operation
+	// Query is the SQL text (empty for OperationBegin)
+	Query string
+	Args  []any
+}
+
+// Interceptor wraps a database call. It must invoke next to execute the call (or the next
+// interceptor) and may inspect or modify the context, measure the call or replace its error.
+type Interceptor func(ctx context.Context, stmt StatementInfo, next func(ctx context.Context) error) error
+
+// chainInterceptors invokes the interceptors around call, the first interceptor being the outermost.
+func chainInterceptors(ctx context.Context, interceptors []Interceptor, stmt StatementInfo, call func(ctx context.Context) error) error {
+	if len(interceptors) == 0 {
+		return call(ctx)
+	}
+	return interceptors[0](ctx, stmt, func(ctx context.Context) error {
+		return chainInterceptors(ctx, interceptors[1:], stmt, call)
+	})
+}
+
+// releaseScope collects the functions deferred by DeferRelease until the resources of a call
+// are released.
+type releaseScope struct {
+	operation Operation
+	mu        sync.Mutex
+	released  bool
+	fns       []func()
+}
+
+// withReleaseScope returns a context deferring the functions passed to DeferRelease for a call
+// of the given operation until release is invoked, once the rows of the query have been read
+// or the transaction has been finished.
+func withReleaseScope(ctx context.Context, operation Operation) (context.Context, func()) {
+	scope := &releaseScope{operation: operation}
+	return context.WithValue(ctx, releaseScopeContextKey, scope), func() {
+		scope.mu.Lock()
+		fns := scope.fns
+		scope.released, scope.fns = true, nil
+		scope.mu.Unlock()
+		for _, fn := range fns {
+			fn()
+		}
+	}
+}
+
+// DeferRelease invokes fn once the resources of a successful call are released, so interceptors
+// can account for the whole lifetime of a call rather than its round trip: for queries executed
+// by Query, QueryEach, QueryStream, QueryScalar, QueryMaps, EncodeJSON and EncodeArrow once
+// their rows have been read and closed, for transactions begun by ExecuteInTransaction once
+// they have been committed or rolled back. Otherwise (e.g. for rows returned by QueryContext
+// itself, or for statements), fn is invoked immediately.
+//
+// Parameters:
+//   - ctx: Context the interceptor has been invoked with
+//   - stmt: Intercepted call
+//   - fn: Function to invoke once the resources of the call are released
+func DeferRelease(ctx context.Context, stmt StatementInfo, fn func()) {
+	if scope, ok := ctx.Value(releaseScopeContextKey).(*releaseScope); ok && scope.operation == stmt.Operation {
+		scope.mu.Lock()
+		if !scope.released {
+			scope.fns = append(scope.fns, fn)
+			scope.mu.Unlock()
+			return
+		}
+		scope.mu.Unlock()
+	}
+	fn()
+}
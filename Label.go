@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// LabelStats contains the pool usage of the operations of one label (see WithLabel).
+type LabelStats struct {
+	// InFlight is the number of currently executing operations, including queries whose rows
+	// are being read (see DeferRelease)
+	InFlight int
+	// MaxInFlight is the highest number of concurrently executing operations
+	MaxInFlight int
+	// Total is the number of completed operations
+	Total int64
+	// Errors is the number of failed operations
+	Errors int64
+	// Duration is the accumulated duration of all completed operations, until their rows have
+	// been read
+	Duration time.Duration
+}
+
+// WithLabelComments prefixes every statement with a comment naming the label of its context
+// (/* label=checkout */), so labels show up in the database's own monitoring (e.g.
+// pg_stat_activity). Characters of the label other than letters, digits, '-', '_', '.' and ':'
+// are replaced by '_', so labels can't end (or, on PostgreSQL, nest) the comment. Note that
+// statements with different labels are different statements for server-side statement caches.
+func WithLabelComments() ClientOption {
+	return func(c *Client) {
+		c.labelComments = true
+	}
+}
+
+// WithSlowQueryThreshold logs operations taking at least the given duration as warning,
+// including their label and error, if they failed (default: 0 = disabled).
+func WithSlowQueryThreshold(threshold time.Duration) ClientOption {
+	return func(c *Client) {
+		c.slowQueryThreshold = threshold
+	}
+}
+
+// LabelStats returns the pool usage per label of all operations executed by the client.
+// Operations without label are accounted under the empty label.
+func (c *Client) LabelStats() map[string]LabelStats {
+	return c.labels.snapshot()
+}
+
+// labelComment prefixes the query with a comment naming the label of the context, if enabled.
+func (c *Client) labelComment(ctx context.Context, query string) string {
+	label, ok := LabelFromContext(ctx)
+	if !c.labelComments || !ok || label == "" {
+		return query
+	}
+	return "/* label=" + strings.Map(labelCommentRune, label) + " */ " + query
+}
+
+// labelCommentRune replaces the runes of a label which are unsafe within a comment.
+func labelCommentRune(r rune) rune {
+	if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_.:", r) {
+		return r
+	}
+	return '_'
+}
+
+// labelAccounting tracks the pool usage per label.
+type labelAccounting struct {
+	mu    sync.Mutex
+	stats map[string]*LabelStats
+}
+
+// start accounts the start of an operation, returning the function accounting its end.
+func (a *labelAccounting) start(label string) func(duration time.Duration, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stats == nil {
+		a.stats = map[string]*LabelStats{}
+	}
+	stats, ok := a.stats[label]
+	if !ok {
+		stats = &LabelStats{}
+		a.stats[label] = stats
+	}
+	stats.InFlight++
+	stats.MaxInFlight = max(stats.MaxInFlight, stats.InFlight)
+	return func(duration time.Duration, err error) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		stats.InFlight--
+		stats.Total++
+		stats.Duration += duration
+		if err != nil {
+			stats.Errors++
+		}
+	}
+}
+
+func (a *labelAccounting) snapshot() map[string]LabelStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result := make(map[string]LabelStats, len(a.stats))
+	for label, stats := range a.stats {
+		result[label] = *stats
+	}
+	return result
+}
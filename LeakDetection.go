@@ -0,0 +1,71 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"runtime"
+	"runtime/debug"
+	"time"
+	"weak"
+)
+
+// WithLeakDetection enables a debug mode tracking the rows returned by QueryContext and the
+// transactions started by BeginTx, to catch resource leaks exhausting the connection pool. If
+// rows are not closed, or a transaction is neither committed nor rolled back, within the
+// threshold, a warning with the stack of the call opening them is logged. Rows garbage
+// collected while still open are logged and closed.
+//
+// Every call captures a stack trace, so leak detection is meant for development and staging
+// environments. Long-running streams (see QueryStream) or transactions exceeding the threshold
+// on purpose are reported as well; choose the threshold accordingly.
+func WithLeakDetection(threshold time.Duration) ClientOption {
+	return func(c *Client) {
+		c.leakThreshold = threshold
+	}
+}
+
+// trackRows watches rows for not being closed within the leak threshold.
+func (c *Client) trackRows(rows *sql.Rows, query string) {
+	stack := debug.Stack()
+	// The timer references the rows weakly, so they can be garbage collected before it fires
+	ref := weak.Make(rows)
+	timer := time.AfterFunc(c.leakThreshold, func() {
+		if rows := ref.Value(); rows != nil && rowsOpen(rows) {
+			c.logger.Warn("rows not closed within leak threshold", "threshold", c.leakThreshold, "query", Fingerprint(query), "stack", string(stack))
+		}
+	})
+	runtime.SetFinalizer(rows, func(rows *sql.Rows) {
+		timer.Stop()
+		if rowsOpen(rows) {
+			c.logger.Warn("rows garbage collected without being closed", "query", Fingerprint(query), "stack", string(stack))
+			rows.Close()
+		}
+	})
+}
+
+// trackTx watches a transaction for not being committed or rolled back within the leak
+// threshold.
+func (c *Client) trackTx(tx *sql.Tx) {
+	// Open transactions are referenced by database/sql until they are finished, so unlike rows
+	// they can't be detected by a finalizer
+	stack := debug.Stack()
+	time.AfterFunc(c.leakThreshold, func() {
+		if txOpen(tx) {
+			c.logger.Warn("transaction not finished within leak threshold", "threshold", c.leakThreshold, "stack", string(stack))
+		}
+	})
+}
+
+// rowsOpen reports whether rows have not been closed yet, which is the only case Columns
+// succeeds in.
+func rowsOpen(rows *sql.Rows) bool {
+	_, err := rows.Columns()
+	return err == nil
+}
+
+// txOpen reports whether a transaction has neither been committed nor rolled back. *sql.Tx
+// does not expose its state, but binding a foreign statement to it fails with sql.ErrTxDone
+// only if it is done, without a round trip to the database.
+func txOpen(tx *sql.Tx) bool {
+	return !errors.Is(tx.Stmt(&sql.Stmt{}).Close(), sql.ErrTxDone)
+}
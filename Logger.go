@@ -0,0 +1,16 @@
+package db
+
+import "log/slog"
+
+// ILogger is the logging interface used by this package.
+//
+// It is satisfied by *slog.Logger, so any slog handler can be used directly.
+type ILogger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// DefaultLogger is the logger used when no logger is configured explicitly. It discards all messages.
+var DefaultLogger ILogger = slog.New(slog.DiscardHandler)
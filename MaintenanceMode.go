@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+)
+
+// MaintenanceMode configures the maintenance gate of a client (see Client.EnableMaintenance).
+type MaintenanceMode struct {
+	// Reason is reported in the errors of rejected operations (e.g. "schema migration")
+	Reason string
+	// AllowReads lets queries and read-only transactions pass, rejecting writes only
+	AllowReads bool
+}
+
+// ContextWithMaintenanceBypass returns a context whose operations pass the maintenance gate,
+// e.g. for the migration job that enabled it.
+func ContextWithMaintenanceBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, maintenanceBypassContextKey, true)
+}
+
+// EnableMaintenance switches the client into maintenance mode: until DisableMaintenance is
+// called, operations fail with ErrMaintenanceMode without reaching the database, unless their
+// context carries a bypass (see ContextWithMaintenanceBypass). With AllowReads, queries and
+// read-only transactions are still executed.
+//
+// Statements are classified using ClassifyStatement: queries starting with INSERT, UPDATE,
+// DELETE, MERGE, ... (e.g. using RETURNING) and CTEs containing such statements are writes.
+// Transactions are checked when they begin; statements executed on a running transaction
+// are not gated.
+//
+//	client.EnableMaintenance(db.MaintenanceMode{Reason: "schema migration", AllowReads: true})
+//	defer client.DisableMaintenance()
+//	err := migrate(db.ContextWithMaintenanceBypass(ctx), client)
+//
+// Parameters:
+//   - mode: Reason and reads policy of the maintenance
+func (c *Client) EnableMaintenance(mode MaintenanceMode) {
+	c.maintenance.Store(&mode)
+}
+
+// DisableMaintenance switches the client back into normal operation.
+func (c *Client) DisableMaintenance() {
+	c.maintenance.Store(nil)
+}
+
+// Maintenance returns the active maintenance mode of the client.
+func (c *Client) Maintenance() (MaintenanceMode, bool) {
+	mode := c.maintenance.Load()
+	if mode == nil {
+		return MaintenanceMode{}, false
+	}
+	return *mode, true
+}
+
+// checkMaintenance rejects an operation if the client is in maintenance mode. readOnly reports
+// whether a transaction to begin is read-only.
+func (c *Client) checkMaintenance(ctx context.Context, stmt StatementInfo, readOnly bool) error {
+	mode := c.maintenance.Load()
+	if mode == nil {
+		return nil
+	}
+	if bypass, _ := ctx.Value(maintenanceBypassContextKey).(bool); bypass {
+		return nil
+	}
+	if mode.AllowReads {
+		switch stmt.Operation {
+		case OperationQuery:
+			if !ClassifyStatement(c.dialect, stmt.Query).Writes {
+				return nil
+			}
+		case OperationBegin:
+			if readOnly {
+				return nil
+			}
+		}
+	}
+	reason := mode.Reason
+	if reason == "" {
+		reason = "maintenance"
+	}
+	return NewErrMaintenanceMode("%s rejected during %s", stmt.Operation, reason)
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaintenanceWindow is a daily time range (offsets since midnight in the clock's location)
+// during which maintenance may run. Windows may wrap around midnight (e.g. 22h to 4h).
+type MaintenanceWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Contains reports whether the given time lies within the window.
+func (w MaintenanceWindow) Contains(t time.Time) bool {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// MaintenanceOptions configures a MaintenanceRunner.
+type MaintenanceOptions struct {
+	// Tables to refresh the statistics of
+	Tables []string
+	// Optimize additionally reclaims space / defragments the tables (VACUUM, OPTIMIZE TABLE, ...)
+	Optimize bool
+	// Interval between maintenance runs (default: 1h)
+	Interval time.Duration
+	// Windows restricts maintenance to low-traffic periods (empty = always)
+	Windows []MaintenanceWindow
+	// Lock ensures that only one instance runs maintenance at a time (nil = no coordination)
+	Lock IJobLock
+	// Logger reports failed runs (default: DefaultLogger)
+	Logger ILogger
+	// Clock is used to evaluate the windows (default: DefaultClock)
+	Clock IClock
+}
+
+// MaintenanceRunner periodically refreshes table statistics (ANALYZE and friends) using
+// dialect specific statements during the configured maintenance windows.
+type MaintenanceRunner struct {
+	conn IWriteSession
+	opts MaintenanceOptions
+}
+
+// NewMaintenanceRunner creates a runner executing maintenance statements on the given session,
+// using the session's dialect.
+func NewMaintenanceRunner(conn IWriteSession, opts MaintenanceOptions) *MaintenanceRunner {
+	if opts.Logger == nil {
+		opts.Logger = DefaultLogger
+	}
+	if opts.Clock == nil {
+		opts.Clock = DefaultClock
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Hour
+	}
+	return &MaintenanceRunner{conn: conn, opts: opts}
+}
+
+// Run executes maintenance every interval while inside a maintenance window, until ctx is done.
+func (r *MaintenanceRunner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !r.inWindow() {
+			continue
+		}
+		if _, err := RunExclusive(ctx, r.opts.Lock, "dbx-maintenance", r.RunOnce); err != nil {
+			r.opts.Logger.Error("table maintenance failed", "error", err)
+		}
+	}
+}
+
+// RunOnce refreshes the statistics of all configured tables immediately, ignoring windows and lock.
+func (r *MaintenanceRunner) RunOnce(ctx context.Context) error {
+	var errs []error
+	for _, table := range r.opts.Tables {
+		statements, err := maintenanceStatements(dialectOf(r.conn), table, r.opts.Optimize)
+		if err != nil {
+			return err
+		}
+		for _, stmt := range statements {
+			if _, err := r.conn.ExecContext(ctx, stmt); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", stmt, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *MaintenanceRunner) inWindow() bool {
+	if len(r.opts.Windows) == 0 {
+		return true
+	}
+	now := r.opts.Clock.Now()
+	for _, w := range r.opts.Windows {
+		if w.Contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func maintenanceStatements(d IDialect, table string, optimize bool) ([]string, error) {
+	t := d.QuoteIdentifier(table)
+	switch d.Name() {
+	case DialectPostgres:
+		if optimize {
+			return []string{"VACUUM ANALYZE " + t}, nil
+		}
+		return []string{"ANALYZE " + t}, nil
+	case DialectMySQL:
+		if optimize {
+			return []string{"OPTIMIZE TABLE " + t, "ANALYZE TABLE " + t}, nil
+		}
+		return []string{"ANALYZE TABLE " + t}, nil
+	case DialectSQLite:
+		return []string{"ANALYZE " + t}, nil
+	case DialectSQLServer:
+		if optimize {
+			return []string{"ALTER INDEX ALL ON " + t + " REORGANIZE", "UPDATE STATISTICS " + t}, nil
+		}
+		return []string{"UPDATE STATISTICS " + t}, nil
+	}
+	return nil, NewErrUnsupportedDialect("table maintenance is not supported by %s", d.Name())
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MetricLabels identify the series an observation is recorded in.
+type MetricLabels struct {
+	Operation Operation
+	// Label is the label of the context (see WithLabel)
+	Label string
+	// Tier is the name of the timeout tier of the context (see ContextWithTimeoutTier)
+	Tier string
+	// Statement is the type of the executed statement (see ClassifyStatement, empty for
+	// transaction begins)
+	Statement StatementType
+}
+
+// IMetrics collects query and transaction statistics of a client (see WithMetrics).
+//
+// Implementations must be safe for concurrent use and should return quickly. MemoryMetrics
+// is the built-in implementation; adapters to metric systems such as Prometheus implement
+// IMetrics by updating their own collectors:
+//
+//	func (m promMetrics) ObserveQueryDuration(l db.MetricLabels, d time.Duration) {
+//		m.duration.WithLabelValues(string(l.Operation), l.Label, l.Tier, string(l.Statement)).Observe(d.Seconds())
+//	}
+type IMetrics interface {
+	// ObserveQueryDuration records the duration of a database call (query, statement or
+	// transaction begin), including failed calls
+	ObserveQueryDuration(labels MetricLabels, duration time.Duration)
+	// IncErrors counts a failed database call
+	IncErrors(labels MetricLabels)
+	// ObserveRowsReturned records the number of rows a query returned (Query, QueryEach,
+	// QueryStream, QueryScalar, QueryMaps, EncodeJSON, EncodeArrow)
+	ObserveRowsReturned(labels MetricLabels, rows int)
+	// IncTxCommit counts a committed transaction
+	IncTxCommit()
+	// IncTxRollback counts a rolled back transaction
+	IncTxRollback()
+}
+
+// WithMetrics sets the collector of the query and transaction statistics of the client.
+func WithMetrics(metrics IMetrics) ClientOption {
+	return func(c *Client) {
+		c.metrics = metrics
+	}
+}
+
+// Metrics returns the metrics collector of the client (nil if none is configured).
+func (c *Client) Metrics() IMetrics {
+	return c.metrics
+}
+
+// metricsOf returns the metrics collector configured for the given session, or nil.
+func metricsOf(conn any) IMetrics {
+	if provider, ok := conn.(interface{ Metrics() IMetrics }); ok {
+		return provider.Metrics()
+	}
+	return nil
+}
+
+// metricLabelsOf returns the labels of an operation executed with the given context.
+func metricLabelsOf(ctx context.Context, d IDialect, operation Operation, query string) MetricLabels {
+	labels := MetricLabels{Operation: operation}
+	if query != "" {
+		labels.Statement = ClassifyStatement(d, query).Type
+	}
+	labels.Label, _ = LabelFromContext(ctx)
+	if tier, ok := TimeoutTierFromContext(ctx); ok {
+		labels.Tier = tier.Name
+	}
+	return labels
+}
+
+// observeRows records the rows returned by a query, if the session collects metrics.
+func observeRows(ctx context.Context, conn any, query string, rows int) {
+	if metrics := metricsOf(conn); metrics != nil {
+		metrics.ObserveRowsReturned(metricLabelsOf(ctx, dialectOf(conn), OperationQuery, query), rows)
+	}
+}
+
+// MetricSeries contains the statistics of one series of MemoryMetrics.
+type MetricSeries struct {
+	MetricLabels
+	Calls       int64
+	Errors      int64
+	Duration    time.Duration
+	MaxDuration time.Duration
+	// Queries is the number of queries with a row count, Rows their total number of rows
+	Queries int64
+	Rows    int64
+}
+
+// MetricsSnapshot is the state of MemoryMetrics at a point in time.
+type MetricsSnapshot struct {
+	// Series are ordered by operation, label, tier and statement type
+	Series      []MetricSeries
+	TxCommits   int64
+	TxRollbacks int64
+}
+
+// MemoryMetrics is an in-memory IMetrics, accumulating the statistics per series.
+// MemoryMetrics is safe for concurrent use.
+type MemoryMetrics struct {
+	mu          sync.Mutex
+	series      map[MetricLabels]*MetricSeries
+	txCommits   int64
+	txRollbacks int64
+}
+
+// NewMemoryMetrics creates an empty in-memory metrics collector.
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{series: map[MetricLabels]*MetricSeries{}}
+}
+
+func (m *MemoryMetrics) seriesOf(labels MetricLabels) *MetricSeries {
+	s, ok := m.series[labels]
+	if !ok {
+		s = &MetricSeries{MetricLabels: labels}
+		m.series[labels] = s
+	}
+	return s
+}
+
+// ObserveQueryDuration implements IMetrics.
+func (m *MemoryMetrics) ObserveQueryDuration(labels MetricLabels, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.seriesOf(labels)
+	s.Calls++
+	s.Duration += duration
+	s.MaxDuration = max(s.MaxDuration, duration)
+}
+
+// IncErrors implements IMetrics.
+func (m *MemoryMetrics) IncErrors(labels MetricLabels) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seriesOf(labels).Errors++
+}
+
+// ObserveRowsReturned implements IMetrics.
+func (m *MemoryMetrics) ObserveRowsReturned(labels MetricLabels, rows int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.seriesOf(labels)
+	s.Queries++
+	s.Rows += int64(rows)
+}
+
+// IncTxCommit implements IMetrics.
+func (m *MemoryMetrics) IncTxCommit() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txCommits++
+}
+
+// IncTxRollback implements IMetrics.
+func (m *MemoryMetrics) IncTxRollback() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txRollbacks++
+}
+
+// Snapshot returns the accumulated statistics.
+func (m *MemoryMetrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := MetricsSnapshot{TxCommits: m.txCommits, TxRollbacks: m.txRollbacks}
+	for _, s := range m.series {
+		snapshot.Series = append(snapshot.Series, *s)
+	}
+	slices.SortFunc(snapshot.Series, func(a, b MetricSeries) int {
+		return cmp.Or(cmp.Compare(a.Operation, b.Operation), cmp.Compare(a.Label, b.Label), cmp.Compare(a.Tier, b.Tier), cmp.Compare(a.Statement, b.Statement))
+	})
+	return snapshot
+}
+
+// Reset discards the accumulated statistics.
+func (m *MemoryMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.series)
+	m.txCommits, m.txRollbacks = 0, 0
+}
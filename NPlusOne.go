@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// NPlusOneOptions configures the N+1 detector.
+type NPlusOneOptions struct {
+	// Threshold is the number of executions of the same statement with different arguments
+	// within one scope tolerated before it is reported (default: 5)
+	Threshold int
+	// Logger receives the reports (nil = DefaultLogger)
+	Logger ILogger
+}
+
+type nPlusOneStatement struct {
+	args     map[string]struct{}
+	reported bool
+}
+
+type nPlusOneScope struct {
+	mu         sync.Mutex
+	statements map[string]*nPlusOneStatement
+}
+
+// ContextWithNPlusOneScope returns a context opening a new detection scope (typically one
+// request or transaction) for NPlusOneInterceptor.
+func ContextWithNPlusOneScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, nPlusOneContextKey, &nPlusOneScope{
+		statements: map[string]*nPlusOneStatement{},
+	})
+}
+
+// NPlusOneInterceptor detects N+1 query patterns, meant to be installed in development mode.
+//
+// Within a scope (see ContextWithNPlusOneScope), executions are grouped by the fingerprint of
+// their statement (see Fingerprint). Once a statement has been executed with more distinct
+// argument lists than the threshold, a warning is logged once per scope, including the
+// stack of the caller outside of this package. Such loops can usually be replaced by a single
+// query, or batched using Coalesce and Lookup. Calls outside of a scope are not tracked.
+//
+// Parameters:
+//   - opts: Options of the detector
+//
+// Returns:
+//   - Interceptor: Interceptor to install using WithInterceptors
+func NPlusOneInterceptor(opts ...NPlusOneOptions) Interceptor {
+	var o NPlusOneOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Threshold <= 0 {
+		o.Threshold = 5
+	}
+	if o.Logger == nil {
+		o.Logger = DefaultLogger
+	}
+	return func(ctx context.Context, stmt StatementInfo, next func(ctx context.Context) error) error {
+		scope, ok := ctx.Value(nPlusOneContextKey).(*nPlusOneScope)
+		if !ok || stmt.Operation == OperationBegin || len(stmt.Args) == 0 {
+			return next(ctx)
+		}
+		fingerprint := Fingerprint(stmt.Query)
+		scope.mu.Lock()
+		s, ok := scope.statements[fingerprint]
+		if !ok {
+			s = &nPlusOneStatement{args: map[string]struct{}{}}
+			scope.statements[fingerprint] = s
+		}
+		s.args[fmt.Sprintf("%#v", stmt.Args)] = struct{}{}
+		count := len(s.args)
+		report := count > o.Threshold && !s.reported
+		if report {
+			s.reported = true
+		}
+		scope.mu.Unlock()
+		if report {
+			o.Logger.Warn("possible N+1 query detected", "fingerprint", fingerprint, "distinctArgs", count, "stack", callerStack())
+		}
+		return next(ctx)
+	}
+}
+
+// callerStack returns the stack of the innermost caller outside of this package, one frame per line.
+func callerStack() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	var sb strings.Builder
+	for {
+		frame, more := frames.Next()
+		if sb.Len() > 0 || !strings.HasPrefix(frame.Function, "github.com/uoul/go-dbx.") {
+			sb.WriteString(frame.Function + "\n\t" + frame.File + ":" + strconv.Itoa(frame.Line) + "\n")
+		}
+		if !more {
+			return sb.String()
+		}
+	}
+}
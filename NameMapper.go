@@ -0,0 +1,43 @@
+package db
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NameMapper derives the column name of a struct field without `db` tag from the field name.
+// Name mappers must be pure functions of the field name. The mappings of the built-in mappers
+// (strings.ToLower, SnakeCaseNameMapper) are cached, custom mappers are applied per query.
+type NameMapper func(fieldName string) string
+
+// DefaultNameMapper is the name mapper used when no name mapper is configured explicitly.
+// It maps field names to lower case (e.g. "CreatedAt" -> "createdat").
+var DefaultNameMapper NameMapper = strings.ToLower
+
+// SnakeCaseNameMapper maps field names to snake case (e.g. "CreatedAt" -> "created_at",
+// "UserID" -> "user_id").
+func SnakeCaseNameMapper(fieldName string) string {
+	runes := []rune(fieldName)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Start a new word at lower->upper transitions and at the end of acronyms
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				sb.WriteRune('_')
+			}
+			sb.WriteRune(unicode.ToLower(r))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// nameMapperOf returns the name mapper configured for the given session (see Client),
+// or nil if the session has no name mapper configured.
+func nameMapperOf(conn any) NameMapper {
+	if provider, ok := conn.(interface{ NameMapper() NameMapper }); ok {
+		return provider.NameMapper()
+	}
+	return nil
+}
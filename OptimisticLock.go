@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"slices"
+)
+
+// UpdateVersioned updates a row using optimistic locking, preventing lost updates without
+// holding locks between reading and writing a row. T marks its version column with the
+// `version` tag option:
+//
+//	type Account struct {
+//		ID      int64  `db:"id"`
+//		Owner   string `db:"owner"`
+//		Version int64  `db:"version,version"`
+//	}
+//
+// All mapped columns except the key columns are updated, the version is incremented, and the
+// update is restricted to the version the item has been read with:
+//
+//	UPDATE accounts SET owner = ?, version = version + 1 WHERE id = ? AND version = ?
+//
+// If no row matches (it has been modified or deleted concurrently), ErrOptimisticLock is
+// returned; the caller should reload the row and retry. On success, the version of item is
+// incremented, so it can be updated again.
+//
+// Columns tagged as returning (`db:"updated_at,returning"`, see InsertMany) are not updated,
+// but read back into item using RETURNING (OUTPUT on SQL Server; not supported by MySQL).
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (connection or transaction) to write to
+//   - table: Name of the table
+//   - item: Row to update
+//   - keyColumns: Columns identifying the row (e.g. primary key)
+//
+// Returns:
+//   - error: ErrOptimisticLock if the row has been modified concurrently, ErrInvalidDataType if
+//     T has no integer version field, ErrColumnMismatch if a key column is not mapped by T
+func UpdateVersioned[T any](ctx context.Context, conn IWriteSession, table string, item *T, keyColumns ...string) error {
+	mapper := nameMapperOf(conn)
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return NewErrInvalidDataType("expected struct, got %s", typ)
+	}
+	versionCol, versionIdx, ok := versionFieldOf(typ, "", mapper)
+	if !ok {
+		return NewErrInvalidDataType("%s has no field tagged as version", typ)
+	}
+	if len(keyColumns) == 0 {
+		return NewErrInvalidStatement("versioned update of %s requires key columns", table)
+	}
+	columns, err := columnsOf(typ, mapper)
+	if err != nil {
+		return err
+	}
+	values, err := argValues(item, mapper)
+	if err != nil {
+		return err
+	}
+	d := dialectOf(conn)
+	generated, err := generatedColumnsOf(typ, mapper, nil)
+	if err != nil {
+		return err
+	}
+	if err := generated.check(d, typ, false); err != nil {
+		return err
+	}
+	stmt := Update(table)
+	for _, col := range columns {
+		if col != versionCol && !slices.Contains(keyColumns, col) && !slices.Contains(generated.columns, col) {
+			stmt.Set(col, values[col])
+		}
+	}
+	stmt.Set(versionCol, Raw(d.QuoteIdentifier(versionCol)+" + 1"))
+	for _, col := range keyColumns {
+		value, ok := values[col]
+		if !ok {
+			return NewErrColumnMismatch("key column %q is not mapped by %s", col, typ)
+		}
+		stmt.Where(Eq(col, value))
+	}
+	version := reflect.ValueOf(item).Elem().FieldByIndex(versionIdx)
+	stmt.Where(Eq(versionCol, version.Interface()))
+	stmt.Returning(generated.columns...)
+	builder, err := enforceColumnPolicy(ctx, conn, stmt)
+	if err != nil {
+		return err
+	}
+	query, args, err := builder.Build(d)
+	if err != nil {
+		return err
+	}
+	affected, err := execUpdate(withPolicyVerified(ctx), conn, generated, query, args, item)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return NewErrOptimisticLock("%s with %s = %v has been modified or deleted concurrently", table, versionCol, version.Interface())
+	}
+	if version.CanInt() {
+		version.SetInt(version.Int() + 1)
+	} else {
+		version.SetUint(version.Uint() + 1)
+	}
+	return nil
+}
+
+// execUpdate executes an update of item, reading back its generated columns, if any.
+func execUpdate[T any](ctx context.Context, conn IWriteSession, generated generatedColumns, query string, args []any, item *T) (int64, error) {
+	if len(generated.columns) > 0 {
+		items := []T{*item}
+		affected, err := execReturning(ctx, conn, generated, query, args, items)
+		*item = items[0]
+		return affected, err
+	}
+	result, err := conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// versionFieldOf returns the column and field index of the integer field tagged as version.
+func versionFieldOf(typ reflect.Type, prefix string, mapper NameMapper) (column string, index []int, ok bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+		if !fieldType.IsExported() {
+			continue
+		}
+		if fieldType.Type.Kind() == reflect.Struct && (fieldType.Anonymous || !isLeafType(fieldType.Type)) {
+			nestedPrefix := prefix
+			if !fieldType.Anonymous {
+				nestedPrefix = columnNameOf(fieldType, mapper)
+				if prefix != "" {
+					nestedPrefix = prefix + "_" + nestedPrefix
+				}
+			}
+			if column, index, ok := versionFieldOf(fieldType.Type, nestedPrefix, mapper); ok {
+				return column, append([]int{i}, index...), true
+			}
+			continue
+		}
+		if !hasTagOption(fieldType, "version") {
+			continue
+		}
+		switch fieldType.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			return "", nil, false
+		}
+		column = columnNameOf(fieldType, mapper)
+		if prefix != "" {
+			column = prefix + "_" + column
+		}
+		return column, []int{i}, true
+	}
+	return "", nil, false
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"database/sql"
+	"reflect"
+	"sync"
+)
+
+// parallelBatchSize is the number of rows handed to a mapping worker at once
+const parallelBatchSize = 256
+
+type rawBatch struct {
+	index int
+	rows  [][]any
+}
+
+// scanParallel decouples fetching rows from mapping them into T.
+//
+// The calling goroutine fetches raw driver values, while a bounded pool of workers maps
+// batches of rows concurrently. The batch index is used to restore the original row order.
+func scanParallel[T any](rows *sql.Rows, columns []string, opts queryOptions) ([]T, error) {
+	if isPrimitiveType(reflect.TypeFor[T]()) && len(columns) != 1 {
+		return nil, NewErrInvalidDataType("expected 1 column for primitive type, got %d", len(columns))
+	}
+	defer opts.scanReport.sort()
+	batches := make(chan rawBatch, opts.workers)
+	failed := make(chan struct{})
+	var (
+		mu       sync.Mutex
+		mapped   = map[int][]T{}
+		firstErr error
+		failOnce sync.Once
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		failOnce.Do(func() {
+			firstErr = err
+			close(failed)
+		})
+	}
+	// Start mapping workers
+	for range opts.workers {
+		wg.Go(func() {
+			for batch := range batches {
+				items := make([]T, 0, len(batch.rows))
+				for i, raw := range batch.rows {
+					var item T
+					keep, err := mapRawRow(&item, columns, raw, batch.index*parallelBatchSize+i, opts)
+					if err != nil {
+						fail(err)
+						break
+					}
+					if keep {
+						items = append(items, item)
+					}
+				}
+				mu.Lock()
+				mapped[batch.index] = items
+				mu.Unlock()
+			}
+		})
+	}
+	// Fetch rows
+	pooled := getScanDest(len(columns))
+	defer putScanDest(pooled)
+	scanDest := *pooled
+	batch := rawBatch{rows: make([][]any, 0, parallelBatchSize)}
+	send := func() bool {
+		select {
+		case batches <- batch:
+			batch = rawBatch{index: batch.index + 1, rows: make([][]any, 0, parallelBatchSize)}
+			return true
+		case <-failed:
+			return false
+		}
+	}
+fetch:
+	for rows.Next() {
+		raw := make([]any, len(columns))
+		for i := range raw {
+			scanDest[i] = &raw[i]
+		}
+		if err := rows.Scan(scanDest...); err != nil {
+			fail(err)
+			break
+		}
+		batch.rows = append(batch.rows, raw)
+		if len(batch.rows) == parallelBatchSize && !send() {
+			break fetch
+		}
+	}
+	if len(batch.rows) > 0 {
+		send()
+	}
+	if err := rows.Err(); err != nil {
+		fail(err)
+	}
+	close(batches)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	// Restore row order
+	result := make([]T, 0, opts.capacity)
+	for i := 0; i < len(mapped); i++ {
+		result = append(result, mapped[i]...)
+	}
+	return result, nil
+}
+
+// mapRawRow assigns the raw values of a row to the given item.
+// If keep is false, the row has to be omitted from the result.
+func mapRawRow[T any](item *T, columns []string, raw []any, row int, opts queryOptions) (keep bool, err error) {
+	val := reflect.ValueOf(item).Elem()
+	dest := make([]any, len(columns))
+	if isPrimitiveType(val.Type()) {
+		dest[0] = item
+	} else {
+		for i, path := range scanPlanOf(val.Type(), columns, opts.nameMapper) {
+			// Unmapped columns stay nil and are skipped
+			if path != nil {
+				dest[i] = val.FieldByIndex(path).Addr().Interface()
+			}
+		}
+	}
+	keep, _, err = assignRaw(dest, columns, raw, row, opts)
+	return keep, err
+}
@@ -0,0 +1,216 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PartitionInterval is the time range covered by a single partition.
+type PartitionInterval int
+
+const (
+	PartitionDaily PartitionInterval = iota
+	PartitionMonthly
+)
+
+// partitionSuffix matches the suffix of partitions created by this package (pYYYYMMDD)
+var partitionSuffix = regexp.MustCompile(`p(\d{8})$`)
+
+// PartitionSpec declares the time-range partitions of a table partitioned by a timestamp column.
+//
+// Partitions are named after the start of their range: "<table>_pYYYYMMDD" for Postgres
+// (partitions are tables) and "pYYYYMMDD" for MySQL. Ranges are days or months in UTC,
+// regardless of the location of the times passed in: Postgres bounds carry the offset
+// (+00:00), so timestamptz columns are partitioned correctly in any session time zone, and
+// timestamp (MySQL: DATETIME) columns are expected to hold UTC times. The table itself has to be created as
+// partitioned table (PARTITION BY RANGE (col) on Postgres, PARTITION BY RANGE COLUMNS(col)
+// without MAXVALUE partition on MySQL).
+type PartitionSpec struct {
+	Table    string
+	Interval PartitionInterval
+	// Ahead is the number of future partitions created in addition to the current one
+	Ahead int
+	// Retain drops partitions whose range ended more than Retain ago (0 = keep all)
+	Retain time.Duration
+}
+
+// Maintain creates the partitions ahead of schedule and drops expired ones. It is meant to be
+// executed periodically, e.g. using RunExclusive from a scheduled job.
+func (s PartitionSpec) Maintain(ctx context.Context, conn IReadWriteSession, now time.Time) error {
+	if _, err := CreatePartitions(ctx, conn, s, now); err != nil {
+		return err
+	}
+	if s.Retain > 0 {
+		if _, err := DropPartitionsBefore(ctx, conn, s.Table, now.Add(-s.Retain), false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s PartitionSpec) start(t time.Time) time.Time {
+	t = t.UTC()
+	if s.Interval == PartitionMonthly {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// partitionBound renders the bound of a partition range as literal of the dialect: including
+// the offset on Postgres, in UTC on MySQL, whose DATETIME literals have no offset.
+func partitionBound(d IDialect, t time.Time) string {
+	if d.Name() == DialectMySQL {
+		return t.UTC().Format(time.DateTime)
+	}
+	return t.Format("2006-01-02 15:04:05-07:00")
+}
+
+func (s PartitionSpec) next(start time.Time) time.Time {
+	if s.Interval == PartitionMonthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func partitionName(d IDialect, table string, start time.Time) string {
+	if d.Name() == DialectMySQL {
+		return "p" + start.Format("20060102")
+	}
+	return table + "_p" + start.Format("20060102")
+}
+
+// CreatePartitions creates the partition containing now and the configured number of future
+// partitions. Existing partitions are skipped.
+//
+// Returns:
+//   - []string: Names of the created partitions
+//   - error: ErrUnsupportedDialect for dialects other than Postgres and MySQL
+func CreatePartitions(ctx context.Context, conn IReadWriteSession, spec PartitionSpec, now time.Time) ([]string, error) {
+	d := dialectOf(conn)
+	existing, err := ListPartitions(ctx, conn, spec.Table)
+	if err != nil {
+		return nil, err
+	}
+	var created []string
+	start := spec.start(now)
+	for range spec.Ahead + 1 {
+		end := spec.next(start)
+		name := partitionName(d, spec.Table, start)
+		if !slices.Contains(existing, unqualified(name)) {
+			var stmt string
+			switch d.Name() {
+			case DialectPostgres:
+				stmt = fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+					d.QuoteIdentifier(name), d.QuoteIdentifier(spec.Table), partitionBound(d, start), partitionBound(d, end))
+			case DialectMySQL:
+				stmt = fmt.Sprintf("ALTER TABLE %s ADD PARTITION (PARTITION %s VALUES LESS THAN ('%s'))",
+					d.QuoteIdentifier(spec.Table), d.QuoteIdentifier(name), partitionBound(d, end))
+			}
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return created, err
+			}
+			created = append(created, name)
+		}
+		start = end
+	}
+	return created, nil
+}
+
+// AttachPartition attaches an existing table as partition covering [start, end) (Postgres only).
+// The bounds are rendered including their offset.
+func AttachPartition(ctx context.Context, conn IWriteSession, table string, partition string, start time.Time, end time.Time) error {
+	d := dialectOf(conn)
+	if d.Name() != DialectPostgres {
+		return NewErrUnsupportedDialect("attaching partitions is not supported by %s", d.Name())
+	}
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ATTACH PARTITION %s FOR VALUES FROM ('%s') TO ('%s')",
+		d.QuoteIdentifier(table), d.QuoteIdentifier(partition), partitionBound(d, start), partitionBound(d, end)))
+	return err
+}
+
+// DetachPartition detaches a partition, keeping it as standalone table (Postgres only).
+func DetachPartition(ctx context.Context, conn IWriteSession, table string, partition string) error {
+	d := dialectOf(conn)
+	if d.Name() != DialectPostgres {
+		return NewErrUnsupportedDialect("detaching partitions is not supported by %s", d.Name())
+	}
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s DETACH PARTITION %s", d.QuoteIdentifier(table), d.QuoteIdentifier(partition)))
+	return err
+}
+
+// ListPartitions returns the names of all partitions of the table.
+func ListPartitions(ctx context.Context, conn IReadSession, table string) ([]string, error) {
+	d := dialectOf(conn)
+	switch d.Name() {
+	case DialectPostgres:
+		return Query[string](ctx, conn,
+			"SELECT c.relname FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid JOIN pg_class p ON p.oid = i.inhparent WHERE p.relname = $1",
+			unqualified(table))
+	case DialectMySQL:
+		return Query[string](ctx, conn,
+			"SELECT PARTITION_NAME FROM information_schema.PARTITIONS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND PARTITION_NAME IS NOT NULL",
+			table)
+	}
+	return nil, NewErrUnsupportedDialect("partitions are not supported by %s", d.Name())
+}
+
+// DropPartitionsBefore drops all partitions (created by this package) whose range ends at or
+// before the given time. The end of a partition is the start of the next one, so the newest
+// partition is never dropped. DropPartitionsBefore can be used as PartitionDropper of a
+// RetentionPolicy.
+//
+// Returns:
+//   - []string: Names of the dropped partitions (or, in dry-run mode, that would be dropped)
+//   - error: Non-nil if listing or dropping fails
+func DropPartitionsBefore(ctx context.Context, conn IReadWriteSession, table string, before time.Time, dryRun bool) ([]string, error) {
+	d := dialectOf(conn)
+	names, err := ListPartitions(ctx, conn, table)
+	if err != nil {
+		return nil, err
+	}
+	type partition struct {
+		name  string
+		start time.Time
+	}
+	var partitions []partition
+	for _, name := range names {
+		if m := partitionSuffix.FindStringSubmatch(name); m != nil {
+			start, err := time.ParseInLocation("20060102", m[1], time.UTC)
+			if err == nil {
+				partitions = append(partitions, partition{name: name, start: start})
+			}
+		}
+	}
+	slices.SortFunc(partitions, func(a, b partition) int {
+		return a.start.Compare(b.start)
+	})
+	var dropped []string
+	for i := 0; i+1 < len(partitions) && !partitions[i+1].start.After(before); i++ {
+		name := partitions[i].name
+		if !dryRun {
+			stmt := "DROP TABLE " + d.QuoteIdentifier(schemaOf(table)+name)
+			if d.Name() == DialectMySQL {
+				stmt = fmt.Sprintf("ALTER TABLE %s DROP PARTITION %s", d.QuoteIdentifier(table), d.QuoteIdentifier(name))
+			}
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return dropped, err
+			}
+		}
+		dropped = append(dropped, name)
+	}
+	return dropped, nil
+}
+
+// unqualified strips the schema from a qualified table name.
+func unqualified(table string) string {
+	return table[strings.LastIndex(table, ".")+1:]
+}
+
+// schemaOf returns the schema of a qualified table name including the trailing dot.
+func schemaOf(table string) string {
+	return table[:strings.LastIndex(table, ".")+1]
+}
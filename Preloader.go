@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PreloadFunction warms up state (caches, connections, ...) before traffic arrives.
+type PreloadFunction func(ctx context.Context, conn IReadSession) error
+
+// PreloaderOptions configures a Preloader.
+type PreloaderOptions struct {
+	// Concurrency limits the number of preload functions running at once (0 = sequential)
+	Concurrency int
+	// Timeout limits the duration of each preload function (0 = no timeout)
+	Timeout time.Duration
+	// WarmConnections is the number of pool connections opened up front, if the session
+	// supports it (e.g. *sql.DB)
+	WarmConnections int
+}
+
+type preloadTask struct {
+	name string
+	fn   PreloadFunction
+}
+
+// Preloader runs registered queries at startup to warm the query cache and the connection
+// pool, and signals readiness once it has finished.
+//
+// Preloading is best-effort: failing preload functions are reported by Run, but readiness is
+// signaled nevertheless, since the application works correctly with a cold cache.
+type Preloader struct {
+	conn      IReadSession
+	opts      PreloaderOptions
+	mu        sync.Mutex
+	tasks     []preloadTask
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+// NewPreloader creates a preloader executing its queries on the given session.
+func NewPreloader(conn IReadSession, opts ...PreloaderOptions) *Preloader {
+	p := &Preloader{
+		conn:  conn,
+		ready: make(chan struct{}),
+	}
+	if len(opts) > 0 {
+		p.opts = opts[0]
+	}
+	return p
+}
+
+// Register adds a named preload function.
+func (p *Preloader) Register(name string, fn PreloadFunction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tasks = append(p.tasks, preloadTask{name: name, fn: fn})
+}
+
+// PreloadQuery registers a query whose results are loaded into the query cache of the
+// session (see QueryCached), so later identical calls of QueryCached are served from the cache.
+func PreloadQuery[T any](p *Preloader, ttl time.Duration, query string, args ...any) {
+	p.Register(query, func(ctx context.Context, conn IReadSession) error {
+		_, err := QueryCached[T](ctx, conn, ttl, query, args...)
+		return err
+	})
+}
+
+// Run warms the connection pool, executes all registered preload functions and signals readiness.
+//
+// Returns:
+//   - error: Joined errors of all failed preload functions, prefixed with their names
+func (p *Preloader) Run(ctx context.Context) error {
+	defer p.readyOnce.Do(func() { close(p.ready) })
+	var (
+		errsMu sync.Mutex
+		errs   []error
+		wg     sync.WaitGroup
+	)
+	addErr := func(name string, err error) {
+		errsMu.Lock()
+		defer errsMu.Unlock()
+		errs = append(errs, fmt.Errorf("preload %s: %w", name, err))
+	}
+	if err := p.warmConnections(ctx); err != nil {
+		addErr("connections", err)
+	}
+	p.mu.Lock()
+	tasks := p.tasks
+	p.mu.Unlock()
+	limit := make(chan struct{}, max(p.opts.Concurrency, 1))
+	for _, task := range tasks {
+		limit <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-limit }()
+			taskCtx := ctx
+			if p.opts.Timeout > 0 {
+				var cancel context.CancelFunc
+				taskCtx, cancel = context.WithTimeout(ctx, p.opts.Timeout)
+				defer cancel()
+			}
+			if err := task.fn(taskCtx, p.conn); err != nil {
+				addErr(task.name, err)
+			}
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// Ready returns a channel that is closed once preloading has finished.
+func (p *Preloader) Ready() <-chan struct{} {
+	return p.ready
+}
+
+// IsReady reports whether preloading has finished.
+func (p *Preloader) IsReady() bool {
+	select {
+	case <-p.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// Check returns an error until preloading has finished, so it can be used as readiness check.
+func (p *Preloader) Check(ctx context.Context) error {
+	if !p.IsReady() {
+		return errors.New("preloading has not finished")
+	}
+	return nil
+}
+
+// warmConnections opens the configured number of pool connections at once and returns
+// them to the pool.
+func (p *Preloader) warmConnections(ctx context.Context) error {
+	pool, ok := p.conn.(interface {
+		Conn(ctx context.Context) (*sql.Conn, error)
+	})
+	if !ok || p.opts.WarmConnections <= 0 {
+		return nil
+	}
+	conns := make([]*sql.Conn, 0, p.opts.WarmConnections)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	for range p.opts.WarmConnections {
+		conn, err := pool.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		if err := conn.PingContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		conns = append(conns, conn)
+	}
+	return nil
+}
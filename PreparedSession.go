@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"maps"
+	"sync"
+)
+
+// IDbPreparer is implemented by sessions that can create prepared statements
+// (*sql.DB, *sql.Conn and *sql.Tx).
+type IDbPreparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// IPreparableSession is a database session supporting prepared statements.
+type IPreparableSession interface {
+	IReadWriteSession
+	IDbPreparer
+}
+
+type preparedEntry struct {
+	executions int
+	stmt       *sql.Stmt
+	// refused reports whether the database refused to prepare the statement
+	refused bool
+}
+
+// preparedSessionCapacity is the maximum number of statement texts a PreparedSession keeps
+// track of. Once reached, the counters of unprepared statements are reset, and if all entries
+// are prepared, further statements are executed unprepared.
+const preparedSessionCapacity = 1000
+
+// PreparedSession is a session that automatically switches to prepared statements for
+// queries that are executed repeatedly.
+//
+// Every statement text is counted, and once it has been executed threshold times, a prepared
+// statement is created and used for all further executions. This cuts server side parse
+// overhead for loops that cannot be expressed as a single statement. Statements that can't be
+// prepared are executed unprepared; statements the database refuses to prepare are not
+// prepared again. At most 1000 statements are tracked. PreparedSession is
+// meant to be short-lived (e.g. scoped to a transaction or a batch job) and must be closed
+// to release the prepared statements. It is safe for concurrent use.
+type PreparedSession struct {
+	session   IPreparableSession
+	threshold int
+	mu        sync.Mutex
+	entries   map[string]*preparedEntry
+}
+
+// NewPreparedSession creates a session preparing statements on the given session.
+//
+// Parameters:
+//   - session: Session (connection or transaction) to execute and prepare statements on
+//   - threshold: Number of executions after which a query is prepared. Values below 1
+//     prepare every query on its first execution.
+//
+// Returns:
+//   - *PreparedSession: Session to pass to Query and friends
+func NewPreparedSession(session IPreparableSession, threshold int) *PreparedSession {
+	return &PreparedSession{
+		session:   session,
+		threshold: max(threshold, 1),
+		entries:   map[string]*preparedEntry{},
+	}
+}
+
+// QueryContext implements IReadWriteSession.
+func (s *PreparedSession) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if stmt := s.statement(ctx, query); stmt != nil {
+		return stmt.QueryContext(ctx, args...)
+	}
+	return s.session.QueryContext(ctx, query, args...)
+}
+
+// ExecContext implements IReadWriteSession.
+func (s *PreparedSession) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if stmt := s.statement(ctx, query); stmt != nil {
+		return stmt.ExecContext(ctx, args...)
+	}
+	return s.session.ExecContext(ctx, query, args...)
+}
+
+// Close closes all prepared statements.
+func (s *PreparedSession) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for query, entry := range s.entries {
+		if entry.stmt != nil {
+			errs = append(errs, entry.stmt.Close())
+		}
+		delete(s.entries, query)
+	}
+	return errors.Join(errs...)
+}
+
+// statement returns the prepared statement for the query, or nil if the query is executed
+// unprepared: it has not yet reached the threshold, or it can't be prepared.
+func (s *PreparedSession) statement(ctx context.Context, query string) *sql.Stmt {
+	s.mu.Lock()
+	entry, ok := s.entries[query]
+	if !ok {
+		if len(s.entries) >= preparedSessionCapacity {
+			maps.DeleteFunc(s.entries, func(_ string, e *preparedEntry) bool { return e.stmt == nil })
+		}
+		if len(s.entries) >= preparedSessionCapacity {
+			s.mu.Unlock()
+			return nil
+		}
+		entry = &preparedEntry{}
+		s.entries[query] = entry
+	}
+	entry.executions++
+	if stmt := entry.stmt; stmt != nil || entry.refused || entry.executions < s.threshold {
+		s.mu.Unlock()
+		return stmt
+	}
+	s.mu.Unlock()
+	// Prepare without holding the lock, so other statements are not blocked by the round trip
+	stmt, err := s.session.PrepareContext(ctx, query)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		entry.refused = ctx.Err() == nil && preparationRefused(ClassifyError(dialectOf(s.session), err))
+		return nil
+	}
+	switch {
+	case s.entries[query] != entry:
+		// Removed meanwhile (closed or capacity reached)
+		stmt.Close()
+		return nil
+	case entry.stmt != nil:
+		// Prepared concurrently
+		stmt.Close()
+	default:
+		entry.stmt = stmt
+	}
+	return entry.stmt
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// refusingSession executes queries on a database of the arrowstub driver and refuses to
+// prepare them.
+type refusingSession struct {
+	*sql.DB
+	prepares *int
+}
+
+func (s refusingSession) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	*s.prepares++
+	return nil, errors.New("syntax error: statement can't be prepared")
+}
+
+func TestPreparedSessionFallsBackToUnpreparedExecution(t *testing.T) {
+	database, err := sql.Open("arrowstub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	prepares := 0
+	session := NewPreparedSession(refusingSession{DB: database, prepares: &prepares}, 1)
+	defer session.Close()
+	for range 3 {
+		rows, err := session.QueryContext(context.Background(), "SELECT * FROM t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Close()
+	}
+	if prepares != 1 {
+		t.Fatalf("prepared %d times, expected the refusal to be remembered", prepares)
+	}
+}
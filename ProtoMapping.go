@@ -0,0 +1,318 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WithProtoBatchSize sets the number of messages per batch sent by StreamProto (default: 100).
+func WithProtoBatchSize(messages int) QueryOption {
+	return func(o *queryOptions) {
+		o.protoBatchSize = messages
+	}
+}
+
+// ProtoFieldMap maps the columns of a struct (see ColumnValues) to the fields of a protobuf
+// message, by the field names declared in the .proto file. Columns mapped to "" are skipped.
+type ProtoFieldMap map[string]string
+
+// ProtoMapper converts structs mapped from rows into protobuf messages generated by
+// protoc-gen-go, e.g. to expose query results by a gRPC service without hand-written
+// conversion code:
+//
+//	mapper, err := db.NewProtoMapper[User, *pb.User](db.ProtoFieldMap{"created": "created_at"})
+//	message, err := mapper.Map(user)
+//
+// Columns are assigned to the message field named in the field map, or else to the field of
+// the same name; columns without matching field are skipped. Values are converted to the type
+// of the field: numbers between numeric types, time.Time into google.protobuf.Timestamp (or an
+// RFC 3339 string), time.Duration into google.protobuf.Duration and values into wrapper types
+// (e.g. google.protobuf.StringValue). NULL values (nil pointers, invalid sql.Null* values) and
+// zero times leave the field unset. Messages are populated via reflection on the generated structs, so the
+// protobuf runtime is not required. ProtoMapper is safe for concurrent use.
+type ProtoMapper[T any, M any] struct {
+	message reflect.Type
+	fields  []protoField
+}
+
+// protoField is a column assigned to a field of the message.
+type protoField struct {
+	column string
+	name   string
+	index  int
+}
+
+// NewProtoMapper creates a mapper from T into the message type M, which is a pointer to a
+// generated message struct (e.g. *pb.User).
+//
+// Parameters:
+//   - fields: Optional field map, columns not contained are mapped to the field of the same
+//     name (first element used)
+//
+// Returns:
+//   - *ProtoMapper[T, M]: The mapper
+//   - error: ErrInvalidDataType if T is not a struct, M is not a pointer to a message struct or
+//     the field map names a column or field that does not exist
+func NewProtoMapper[T any, M any](fields ...ProtoFieldMap) (*ProtoMapper[T, M], error) {
+	var fieldMap ProtoFieldMap
+	if len(fields) > 0 {
+		fieldMap = fields[0]
+	}
+	message := reflect.TypeFor[M]()
+	if message.Kind() != reflect.Pointer || message.Elem().Kind() != reflect.Struct {
+		return nil, NewErrInvalidDataType("expected pointer to protobuf message struct, got %s", message)
+	}
+	message = message.Elem()
+	protoFields := map[string]int{}
+	for i := range message.NumField() {
+		if name, ok := protoFieldName(message.Field(i)); ok {
+			protoFields[name] = i
+		}
+	}
+	columns, err := columnsOf(reflect.TypeFor[T](), nil)
+	if err != nil {
+		return nil, err
+	}
+	m := &ProtoMapper[T, M]{message: message}
+	for _, column := range columns {
+		name, mapped := fieldMap[column]
+		if !mapped {
+			name = column
+		}
+		if name == "" {
+			continue
+		}
+		index, ok := protoFields[name]
+		if !ok {
+			if mapped {
+				return nil, NewErrInvalidDataType("message %s has no field %s", message, name)
+			}
+			continue
+		}
+		m.fields = append(m.fields, protoField{column: column, name: name, index: index})
+	}
+	for column := range fieldMap {
+		if !slices.Contains(columns, column) {
+			return nil, NewErrInvalidDataType("%s has no column %s", reflect.TypeFor[T](), column)
+		}
+	}
+	return m, nil
+}
+
+// Map converts an item into a new message.
+//
+// Returns:
+//   - M: The message
+//   - error: ErrInvalidDataType if a value can't be converted to the type of its field
+func (m *ProtoMapper[T, M]) Map(item T) (M, error) {
+	values, err := columnValues(item, nil)
+	if err != nil {
+		return *new(M), err
+	}
+	message := reflect.New(m.message)
+	for _, field := range m.fields {
+		if err := assignProtoValue(message.Elem().Field(field.index), values[field.column]); err != nil {
+			return *new(M), NewErrInvalidDataType("column %s to field %s: %v", field.column, field.name, err)
+		}
+	}
+	return message.Interface().(M), nil
+}
+
+// MapAll converts items into new messages.
+//
+// Returns:
+//   - []M: The messages, in the order of the items
+//   - error: The error of the first item failing to convert
+func (m *ProtoMapper[T, M]) MapAll(items []T) ([]M, error) {
+	messages := make([]M, len(items))
+	for i, item := range items {
+		message, err := m.Map(item)
+		if err != nil {
+			return nil, err
+		}
+		messages[i] = message
+	}
+	return messages, nil
+}
+
+// StreamProto executes a query and sends its results as messages in batches, mapping the rows
+// one at a time instead of materializing the whole result set. It is meant for gRPC server
+// streaming endpoints:
+//
+//	func (s *server) ListUsers(req *pb.ListUsersRequest, stream pb.Users_ListUsersServer) error {
+//		_, err := db.StreamProto(stream.Context(), s.client, s.users, func(users []*pb.User) error {
+//			return stream.Send(&pb.ListUsersResponse{Users: users})
+//		}, "SELECT * FROM users WHERE tenant = ?", req.Tenant, db.WithProtoBatchSize(500))
+//		return err
+//	}
+//
+// Every batch is a new slice, so send may retain it.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to execute the query on
+//   - mapper: Mapper converting the results into messages
+//   - send: Function invoked for each batch, returning an error stops the query
+//   - query: SQL query string
+//   - args: Query arguments and QueryOption values
+//
+// Returns:
+//   - int: Number of sent messages
+//   - error: Non-nil if the query, a scan, the conversion of a result or send fails
+func StreamProto[T any, M any](ctx context.Context, conn IReadSession, mapper *ProtoMapper[T, M], send func(batch []M) error, query string, args ...any) (int, error) {
+	opts, args := splitQueryOptions(args)
+	if opts.nameMapper == nil {
+		opts.nameMapper = nameMapperOf(conn)
+	}
+	batchSize := opts.protoBatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	sent := 0
+	batch := make([]M, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := send(batch); err != nil {
+			return err
+		}
+		sent += len(batch)
+		batch = make([]M, 0, batchSize)
+		return nil
+	}
+	err := queryEach(ctx, conn, func(item T) error {
+		message, err := mapper.Map(item)
+		if err != nil {
+			return err
+		}
+		if batch = append(batch, message); len(batch) == batchSize {
+			return flush()
+		}
+		return nil
+	}, query, args, opts)
+	if err != nil {
+		return sent, err
+	}
+	return sent, flush()
+}
+
+// protoFieldName returns the name of a field of a generated message as declared in the .proto
+// file, parsed from its protobuf struct tag (e.g. `protobuf:"bytes,2,opt,name=user_name,proto3"`).
+func protoFieldName(field reflect.StructField) (string, bool) {
+	for part := range strings.SplitSeq(field.Tag.Get("protobuf"), ",") {
+		if name, ok := strings.CutPrefix(part, "name="); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+)
+
+// assignProtoValue converts a value to the type of a message field and assigns it.
+func assignProtoValue(dst reflect.Value, value any) error {
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil
+	}
+	if valuer, ok := value.(driver.Valuer); ok {
+		var err error
+		if value, err = valuer.Value(); err != nil {
+			return err
+		}
+	}
+	src := reflect.ValueOf(value)
+	for src.IsValid() && src.Kind() == reflect.Pointer {
+		if src.IsNil() {
+			return nil
+		}
+		src = src.Elem()
+	}
+	if !src.IsValid() || src.Type() == timeType && src.Interface().(time.Time).IsZero() {
+		return nil
+	}
+	switch dst.Kind() {
+	case reflect.Pointer:
+		message := reflect.New(dst.Type().Elem())
+		if message.Elem().Kind() != reflect.Struct {
+			// optional scalar field
+			if err := assignProtoValue(message.Elem(), src.Interface()); err != nil {
+				return err
+			}
+			dst.Set(message)
+			return nil
+		}
+		// well-known types: google.protobuf.Timestamp, Duration and the wrappers
+		seconds, nanos := message.Elem().FieldByName("Seconds"), message.Elem().FieldByName("Nanos")
+		switch {
+		case src.Type() == timeType && seconds.IsValid() && nanos.IsValid():
+			t := src.Interface().(time.Time)
+			seconds.SetInt(t.Unix())
+			nanos.SetInt(int64(t.Nanosecond()))
+		case src.Type() == durationType && seconds.IsValid() && nanos.IsValid():
+			d := src.Interface().(time.Duration)
+			seconds.SetInt(int64(d / time.Second))
+			nanos.SetInt(int64(d % time.Second))
+		case message.Elem().FieldByName("Value").IsValid():
+			if err := assignProtoValue(message.Elem().FieldByName("Value"), src.Interface()); err != nil {
+				return err
+			}
+		default:
+			return NewErrInvalidDataType("cannot assign %s to %s", src.Type(), dst.Type())
+		}
+		dst.Set(message)
+		return nil
+	case reflect.String:
+		switch {
+		case src.Type() == timeType:
+			dst.SetString(src.Interface().(time.Time).Format(time.RFC3339Nano))
+			return nil
+		case src.Kind() == reflect.String:
+			dst.SetString(src.String())
+			return nil
+		case src.Kind() == reflect.Slice && src.Type().Elem().Kind() == reflect.Uint8:
+			dst.SetString(string(src.Bytes()))
+			return nil
+		}
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			switch {
+			case src.Kind() == reflect.String:
+				dst.SetBytes([]byte(src.String()))
+				return nil
+			case src.Kind() == reflect.Slice && src.Type().Elem().Kind() == reflect.Uint8:
+				dst.SetBytes(src.Bytes())
+				return nil
+			}
+		}
+	case reflect.Bool:
+		if src.Kind() == reflect.Bool {
+			dst.SetBool(src.Bool())
+			return nil
+		}
+	case reflect.Int32, reflect.Int64, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		if isNumericKind(src.Kind()) {
+			dst.Set(src.Convert(dst.Type()))
+			return nil
+		}
+	}
+	return NewErrInvalidDataType("cannot assign %s to %s", src.Type(), dst.Type())
+}
+
+// isNumericKind reports whether values of a kind are numbers.
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -20,21 +20,39 @@ import (
 //   - ctx: Context for cancellation and timeout control
 //   - conn: Database session (connection or transaction) to execute the query on
 //   - query: SQL query string to execute
-//   - args: Variadic arguments to be used as query parameters (prevents SQL injection)
+//   - args: Variadic arguments to be used as query parameters (prevents SQL injection).
+//     QueryOption values may be mixed into the arguments to configure the query.
 //
 // Returns:
 //   - []T: Slice of results parsed from the query, empty slice if no rows match
 //   - error: Non-nil if query execution or result parsing fails
-func Query[T any](ctx context.Context, conn IDbSession, query string, args ...any) ([]T, error) {
-	rows, err := conn.QueryContext(ctx, query, args...)
+func Query[T any](ctx context.Context, conn IReadSession, query string, args ...any) ([]T, error) {
+	opts, args := splitQueryOptions(args)
+	if opts.nameMapper == nil {
+		opts.nameMapper = nameMapperOf(conn)
+	}
+	if opts.projection {
+		projected, err := projectColumns[T](query, opts.nameMapper)
+		if err != nil {
+			return nil, err
+		}
+		query = projected
+	}
+	ctx, logRows := deferStatementLog(ctx, conn)
+	ctx, release := withReleaseScope(ctx, OperationQuery)
+	defer release()
+	rows, err := conn.QueryContext(opts.context(ctx), query, args...)
 	if err != nil {
+		logRows(0, nil)
 		return nil, err
 	}
 	defer rows.Close()
-	result, err := parseDbResult[T](rows)
+	result, err := parseDbResult[T](rows, opts)
+	logRows(len(result), err)
 	if err != nil {
 		return nil, err
 	}
+	observeRows(opts.context(ctx), conn, query, len(result))
 	return result, nil
 }
 
@@ -49,7 +67,7 @@ func Query[T any](ctx context.Context, conn IDbSession, query string, args ...an
 // - Execute multiple independent queries in parallel
 // - Avoid blocking the main execution flow while waiting for database results
 // - Implement non-blocking data fetching patterns
-func QueryAsync[T any](ctx context.Context, conn IDbSession, query string, args ...any) async.Result[[]T] {
+func QueryAsync[T any](ctx context.Context, conn IReadSession, query string, args ...any) async.Result[[]T] {
 	return async.Do(
 		ctx,
 		func(ctx context.Context) ([]T, error) {
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryBudget limits the database work of a single request.
+type QueryBudget struct {
+	// MaxQueries is the maximum number of statements (0 = unlimited)
+	MaxQueries int
+	// MaxDuration is the maximum cumulative time spent in the database (0 = unlimited)
+	MaxDuration time.Duration
+}
+
+// QueryBudgetUsage reports the database work consumed from a budget.
+type QueryBudgetUsage struct {
+	Queries  int
+	Duration time.Duration
+	// CallSites counts the statements per calling function (file:line), including rejected ones
+	CallSites map[string]int
+}
+
+type queryBudgetState struct {
+	budget    QueryBudget
+	mu        sync.Mutex
+	queries   int
+	duration  time.Duration
+	callSites map[string]int
+	reported  bool
+}
+
+// ContextWithQueryBudget returns a context carrying a fresh budget for the database work of
+// one request. The budget is enforced by QueryBudgetInterceptor.
+func ContextWithQueryBudget(ctx context.Context, budget QueryBudget) context.Context {
+	return context.WithValue(ctx, queryBudgetContextKey, &queryBudgetState{
+		budget:    budget,
+		callSites: map[string]int{},
+	})
+}
+
+// QueryBudgetUsageFromContext returns the usage of the budget attached to the context.
+func QueryBudgetUsageFromContext(ctx context.Context) (QueryBudgetUsage, bool) {
+	state, ok := ctx.Value(queryBudgetContextKey).(*queryBudgetState)
+	if !ok {
+		return QueryBudgetUsage{}, false
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return QueryBudgetUsage{
+		Queries:   state.queries,
+		Duration:  state.duration,
+		CallSites: maps.Clone(state.callSites),
+	}, true
+}
+
+// QueryBudgetInterceptor enforces the budget attached to the context (see ContextWithQueryBudget).
+//
+// Statements exceeding the budget are not executed and fail with ErrQueryBudgetExceeded. The
+// first violation of a budget is logged as warning, including the statement counts per call
+// site, which usually points directly at the loop causing an N+1 regression. Calls without
+// budget in their context are passed through unchanged. Statements within transactions count
+// against the budget if executed using TxSession (see WithInterceptors).
+//
+// Parameters:
+//   - logger: Logger receiving budget violations (nil = DefaultLogger)
+//
+// Returns:
+//   - Interceptor: Interceptor to install using WithInterceptors
+func QueryBudgetInterceptor(logger ILogger) Interceptor {
+	if logger == nil {
+		logger = DefaultLogger
+	}
+	return func(ctx context.Context, stmt StatementInfo, next func(ctx context.Context) error) error {
+		state, ok := ctx.Value(queryBudgetContextKey).(*queryBudgetState)
+		if !ok || stmt.Operation == OperationBegin {
+			return next(ctx)
+		}
+		site := callSite()
+		state.mu.Lock()
+		state.callSites[site]++
+		var exceeded string
+		switch {
+		case state.budget.MaxQueries > 0 && state.queries >= state.budget.MaxQueries:
+			exceeded = "query count"
+		case state.budget.MaxDuration > 0 && state.duration >= state.budget.MaxDuration:
+			exceeded = "database time"
+		}
+		if exceeded != "" {
+			queries, duration := state.queries, state.duration
+			report := !state.reported
+			state.reported = true
+			sites := formatCallSites(state.callSites)
+			state.mu.Unlock()
+			if report {
+				logger.Warn("query budget exceeded", "limit", exceeded, "queries", queries, "duration", duration, "query", stmt.Query, "callSite", site, "callSites", sites)
+			}
+			return NewErrQueryBudgetExceeded("%s budget exceeded after %d queries (%s) at %s", exceeded, queries, duration, site)
+		}
+		state.queries++
+		state.mu.Unlock()
+		start := time.Now()
+		err := next(ctx)
+		state.mu.Lock()
+		state.duration += time.Since(start)
+		state.mu.Unlock()
+		return err
+	}
+}
+
+// callSite returns the location (function file:line) of the innermost caller outside of this package.
+func callSite() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "github.com/uoul/go-dbx.") {
+			return frame.Function + " " + frame.File + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// formatCallSites formats the statement counts per call site, most frequent first.
+func formatCallSites(sites map[string]int) string {
+	keys := slices.SortedFunc(maps.Keys(sites), func(a, b string) int {
+		return cmp.Or(sites[b]-sites[a], strings.Compare(a, b))
+	})
+	var sb strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(strconv.Itoa(sites[key]))
+		sb.WriteString("x ")
+		sb.WriteString(key)
+	}
+	return sb.String()
+}
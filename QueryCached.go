@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// QueryCached executes a SQL query like Query, caching the results in the session's cache.
+//
+// The results are cached under a key derived from the result type, the query and its
+// arguments. If the session has no cache configured (see WithCache), the query is executed
+// without caching. Cached slices are shared between callers and must not be modified. Statements
+// executed by the Client modifying the tables the query reads (see ClassifyStatement) delete the
+// cached results, once their transaction has been committed; modifications by other processes
+// are not noticed until the ttl elapsed.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to execute the query on, typically a *Client
+//   - ttl: Time the results stay cached (0 = until deleted)
+//   - query: SQL query string to execute
+//   - args: Query parameters and QueryOption values
+//
+// Returns:
+//   - []T: Slice of results, either cached or parsed from the query
+//   - error: Non-nil if query execution or result parsing fails
+func QueryCached[T any](ctx context.Context, conn IReadSession, ttl time.Duration, query string, args ...any) ([]T, error) {
+	provider, ok := conn.(interface{ Cache() ICache })
+	if !ok || provider.Cache() == nil {
+		return Query[T](ctx, conn, query, args...)
+	}
+	cache := provider.Cache()
+	key := QueryCacheKey[T](query, args...)
+	if cached, ok := cache.Get(ctx, key); ok {
+		if result, ok := cached.([]T); ok {
+			return result, nil
+		}
+	}
+	result, err := Query[T](ctx, conn, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	cache.Set(ctx, key, result, ttl)
+	if c, ok := conn.(*Client); ok {
+		c.results.add(ClassifyStatement(c.dialect, query).Tables, key, c.clock.Now(), ttl)
+	}
+	return result, nil
+}
+
+// cachedResults indexes the keys of the results cached by QueryCached by the tables they have
+// been read from, so statements modifying the tables delete them.
+type cachedResults struct {
+	mu sync.Mutex
+	// keys maps table -> key -> expiry (zero = none)
+	keys map[string]map[string]time.Time
+	// size is the number of indexed keys, sweepAt the size triggering the removal of expired keys
+	size    int
+	sweepAt int
+}
+
+// add indexes the key of a cached result read from the given tables at the given time.
+func (r *cachedResults) add(tables []string, key string, now time.Time, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = now.Add(ttl)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.keys == nil {
+		r.keys = map[string]map[string]time.Time{}
+	}
+	if r.size >= r.sweepAt {
+		r.evictExpired(now)
+		r.sweepAt = max(2*r.size, 256)
+	}
+	for _, table := range tables {
+		keys, ok := r.keys[table]
+		if !ok {
+			keys = map[string]time.Time{}
+			r.keys[table] = keys
+		}
+		if _, ok := keys[key]; !ok {
+			r.size++
+		}
+		keys[key] = expires
+	}
+}
+
+// take removes and returns the keys of the results read from the given tables.
+func (r *cachedResults) take(tables []string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []string
+	for _, table := range tables {
+		for key := range r.keys[table] {
+			result = append(result, key)
+		}
+		r.size -= len(r.keys[table])
+		delete(r.keys, table)
+	}
+	return result
+}
+
+// evictExpired removes the keys of results expired at the given time. The caller must hold
+// the lock.
+func (r *cachedResults) evictExpired(now time.Time) {
+	for table, keys := range r.keys {
+		for key, expires := range keys {
+			if !expires.IsZero() && now.After(expires) {
+				delete(keys, key)
+				r.size--
+			}
+		}
+		if len(keys) == 0 {
+			delete(r.keys, table)
+		}
+	}
+}
+
+// QueryCacheKey returns the cache key QueryCached uses for the given result type, query and
+// arguments, e.g. to invalidate cached results explicitly.
+func QueryCacheKey[T any](query string, args ...any) string {
+	_, args = splitQueryOptions(args)
+	return fmt.Sprintf("%T|%s|%#v", *new(T), query, args)
+}
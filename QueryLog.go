@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// WithQueryLog logs every statement executed by the client, including the statements executed
+// within its transactions through TxSession, with its duration, arguments and returned or
+// affected rows. Successful statements are logged at debug level, failing ones at error level.
+//
+// Arguments are rendered using the argument format of the client (see WithArgFormat), so
+// arguments marked using Sensitive, fields tagged as sensitive and arguments matched by
+// ArgFormat.Redact never end up in the log. Returned rows are logged for queries executed
+// using Query, QueryEach, QueryStream, QueryScalar, QueryMaps, EncodeJSON or EncodeArrow; the
+// duration of these queries includes reading the rows.
+func WithQueryLog(logger ILogger) ClientOption {
+	return func(c *Client) {
+		c.queryLog = logger
+	}
+}
+
+// statementLog logs executed statements. A nil *statementLog logs nothing.
+type statementLog struct {
+	logger ILogger
+	format ArgFormat
+}
+
+// statementLog returns the statement log of the client (nil if not configured).
+func (c *Client) statementLog() *statementLog {
+	if c.queryLog == nil {
+		return nil
+	}
+	return &statementLog{logger: c.queryLog, format: c.argFormat}
+}
+
+// statementLogOf returns the statement log configured for the given session, or nil.
+func statementLogOf(conn any) *statementLog {
+	if provider, ok := conn.(interface{ statementLog() *statementLog }); ok {
+		return provider.statementLog()
+	}
+	return nil
+}
+
+// pendingStatementLog defers logging a query until its rows have been read (see deferStatementLog).
+type pendingStatementLog struct {
+	recorded bool
+	stmt     StatementInfo
+	start    time.Time
+	err      error
+}
+
+// record logs an executed statement. rows is negative if the number of rows is unknown.
+func (l *statementLog) record(ctx context.Context, stmt StatementInfo, duration time.Duration, rows int64, err error) {
+	if l == nil {
+		return
+	}
+	if pending, ok := ctx.Value(statementLogContextKey).(*pendingStatementLog); ok && !pending.recorded && stmt.Operation == OperationQuery {
+		*pending = pendingStatementLog{recorded: true, stmt: stmt, start: time.Now().Add(-duration), err: err}
+		return
+	}
+	l.write(ctx, stmt, duration, rows, err)
+}
+
+func (l *statementLog) write(ctx context.Context, stmt StatementInfo, duration time.Duration, rows int64, err error) {
+	attrs := []any{"operation", stmt.Operation, "query", stmt.Query, "args", FormatArgs(stmt.Args, l.format), "duration", duration}
+	if label, ok := LabelFromContext(ctx); ok {
+		attrs = append(attrs, "label", label)
+	}
+	if rows >= 0 {
+		attrs = append(attrs, "rows", rows)
+	}
+	if err != nil {
+		l.logger.Error("database statement failed", append(attrs, "error", err)...)
+		return
+	}
+	l.logger.Debug("database statement", attrs...)
+}
+
+// affectedRows returns the rows affected by a statement, or -1 if unknown.
+func affectedRows(result sql.Result, err error) int64 {
+	if err != nil {
+		return -1
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return affected
+}
+
+// deferStatementLog defers logging the query executed with the returned context until flush
+// is called with the number of rows read and the error reading them, if the session logs
+// statements.
+func deferStatementLog(ctx context.Context, conn any) (context.Context, func(rows int, err error)) {
+	log := statementLogOf(conn)
+	if log == nil {
+		return ctx, func(int, error) {}
+	}
+	pending := &pendingStatementLog{}
+	return context.WithValue(ctx, statementLogContextKey, pending), func(rows int, err error) {
+		if !pending.recorded {
+			return
+		}
+		if pending.err != nil {
+			log.write(ctx, pending.stmt, time.Since(pending.start), -1, pending.err)
+			return
+		}
+		log.write(ctx, pending.stmt, time.Since(pending.start), int64(rows), err)
+	}
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// QueryMaps executes a SQL query and returns each row as a map of column name to value, for
+// queries whose result shape is not known at compile time (e.g. ad-hoc reports).
+//
+// The keys are the column names of the result set, exactly as they are matched against the
+// column names of struct fields by Query. Values are the values returned by the driver, except
+// that []byte values of non-binary columns are converted to string, since drivers like MySQL
+// return text as []byte. For duplicate column names, the last column wins.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (connection or transaction) to execute the query on
+//   - query: SQL query string to execute
+//   - args: Query parameters and QueryOption values (WithCapacity)
+//
+// Returns:
+//   - []map[string]any: One map per row, empty slice if no rows match
+//   - error: Non-nil if query execution or scanning fails
+func QueryMaps(ctx context.Context, conn IReadSession, query string, args ...any) ([]map[string]any, error) {
+	opts, args := splitQueryOptions(args)
+	ctx, logRows := deferStatementLog(ctx, conn)
+	ctx, release := withReleaseScope(ctx, OperationQuery)
+	defer release()
+	rows, err := conn.QueryContext(opts.context(ctx), query, args...)
+	if err != nil {
+		logRows(0, nil)
+		return nil, err
+	}
+	defer rows.Close()
+	result, err := scanMaps(rows, opts.capacity)
+	logRows(len(result), err)
+	if err != nil {
+		return nil, err
+	}
+	observeRows(opts.context(ctx), conn, query, len(result))
+	return result, nil
+}
+
+// scanMaps reads all rows as maps of column name to value.
+func scanMaps(rows *sql.Rows, capacity int) ([]map[string]any, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	binary := make([]bool, len(columnTypes))
+	for i, ct := range columnTypes {
+		binary[i] = isBinaryType(ct.DatabaseTypeName())
+	}
+	values := make([]any, len(columnTypes))
+	scanDest := make([]any, len(columnTypes))
+	for i := range values {
+		scanDest[i] = &values[i]
+	}
+	result := make([]map[string]any, 0, capacity)
+	for rows.Next() {
+		if err := rows.Scan(scanDest...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(columnTypes))
+		for i, ct := range columnTypes {
+			if b, ok := values[i].([]byte); ok && !binary[i] {
+				row[ct.Name()] = string(b)
+			} else {
+				row[ct.Name()] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// isBinaryType reports whether the database type name denotes binary data.
+func isBinaryType(name string) bool {
+	name = strings.ToUpper(name)
+	return strings.Contains(name, "BLOB") || strings.Contains(name, "BINARY") || name == "BYTEA" || name == "IMAGE"
+}
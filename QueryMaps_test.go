@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestQueryMapsObservesRows(t *testing.T) {
+	database, err := sql.Open("arrowstub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	metrics := NewMemoryMetrics()
+	client := NewClient(database, WithMetrics(metrics))
+	maps, err := QueryMaps(context.Background(), client, "SELECT * FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(maps) != len(arrowStubRows) {
+		t.Fatalf("returned %d rows, expected %d", len(maps), len(arrowStubRows))
+	}
+	if queries, rows := observedRows(metrics); queries != 1 || rows != int64(len(arrowStubRows)) {
+		t.Fatalf("observed %d queries with %d rows, expected 1 query with %d rows", queries, rows, len(arrowStubRows))
+	}
+}
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+)
+
+// QueryNamed executes a SQL query using named parameters (:name or @name), which are bound
+// from a struct or map and rewritten to the positional placeholders of the session's dialect.
+//
+//	users, err := db.QueryNamed[User](ctx, conn, "SELECT * FROM users WHERE tenant = :tenant AND age >= :age", map[string]any{"tenant": t, "age": 18})
+//
+// Placeholders within string literals, quoted identifiers and comments are kept, as are
+// PostgreSQL casts (::type) and SQL Server system variables (@@name).
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (connection or transaction) to execute the query on
+//   - query: SQL query string with named parameters
+//   - params: Map with string keys, or struct (pointer) whose fields are looked up by column
+//     name (see ColumnValues)
+//   - opts: Query options applied to the query
+//
+// Returns:
+//   - []T: Query results
+//   - error: ErrInvalidDataType if params is neither map nor struct or a parameter is missing,
+//     otherwise the error of the query
+func QueryNamed[T any](ctx context.Context, conn IReadSession, query string, params any, opts ...QueryOption) ([]T, error) {
+	query, args, err := bindNamed(dialectOf(conn), query, params, nameMapperOf(conn))
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		args = append(args, opt)
+	}
+	return Query[T](ctx, conn, query, args...)
+}
+
+// ExecNamed executes a SQL statement using named parameters (see QueryNamed).
+func ExecNamed(ctx context.Context, conn IWriteSession, query string, params any) (sql.Result, error) {
+	query, args, err := bindNamed(dialectOf(conn), query, params, nameMapperOf(conn))
+	if err != nil {
+		return nil, err
+	}
+	return Exec(ctx, conn, query, args...)
+}
+
+// bindNamed rewrites the named parameters of query to positional placeholders of the dialect
+// and returns the values in placeholder order. Repeated names are bound repeatedly.
+func bindNamed(d IDialect, query string, params any, mapper NameMapper) (string, []any, error) {
+	values, err := namedValues(params, mapper)
+	if err != nil {
+		return "", nil, err
+	}
+	return rewriteNamed(d, query, values)
+}
+
+// rewriteNamed rewrites the named parameters of query to positional placeholders of the
+// dialect, looking up their values in placeholder order.
+func rewriteNamed(d IDialect, query string, values func(name string) (any, bool)) (string, []any, error) {
+	var sb strings.Builder
+	var args []any
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(query[i+1:], closing)
+			if end < 0 {
+				end = len(query) - i - 2
+			}
+			sb.WriteString(query[i : i+end+2])
+			i += end + 1
+			continue
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			sb.WriteString(query[i : i+end])
+			i += end - 1
+			continue
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i:], "*/")
+			if end < 0 {
+				end = len(query) - i - 2
+			}
+			sb.WriteString(query[i : i+end+2])
+			i += end + 1
+			continue
+		case (c == ':' || c == '@') && i+1 < len(query) && query[i+1] == c:
+			// PostgreSQL cast (::type) or SQL Server system variable (@@name)
+			sb.WriteString(query[i : i+2])
+			i++
+			continue
+		case (c == ':' || c == '@') && i+1 < len(query) && isNameStart(query[i+1]):
+			end := i + 2
+			for end < len(query) && isNamePart(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			value, ok := values(name)
+			if !ok {
+				return "", nil, NewErrInvalidDataType("no value for named parameter %q", name)
+			}
+			args = append(args, value)
+			sb.WriteString(d.Placeholder(len(args)))
+			i = end - 1
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), args, nil
+}
+
+// namedValues returns a lookup function for the values of a map or struct.
+func namedValues(params any, mapper NameMapper) (func(name string) (any, bool), error) {
+	if m, ok := params.(map[string]any); ok {
+		return func(name string) (any, bool) {
+			value, ok := m[name]
+			return value, ok
+		}, nil
+	}
+	val := reflect.ValueOf(params)
+	if val.Kind() == reflect.Map && val.Type().Key().Kind() == reflect.String {
+		return func(name string) (any, bool) {
+			value := val.MapIndex(reflect.ValueOf(name).Convert(val.Type().Key()))
+			if !value.IsValid() {
+				return nil, false
+			}
+			return value.Interface(), true
+		}, nil
+	}
+	if params == nil {
+		return func(string) (any, bool) { return nil, false }, nil
+	}
+	columns, err := argValues(params, mapper)
+	if err != nil {
+		return nil, err
+	}
+	return func(name string) (any, bool) {
+		value, ok := columns[name]
+		return value, ok
+	}, nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isNamePart(c byte) bool {
+	return isNameStart(c) || ('0' <= c && c <= '9')
+}
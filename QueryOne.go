@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/uoul/go-async"
+)
+
+// QueryOne executes a SQL query and returns its first result. Rows are mapped one at a time
+// like by QueryEach, and reading stops after the first row (or the second one if
+// WithUniqueResult is set).
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (connection or transaction) to execute the query on
+//   - query: SQL query string to execute
+//   - args: Query parameters and QueryOption values. Pass WithUniqueResult to reject
+//     queries returning more than one row.
+//
+// Returns:
+//   - T: The first result
+//   - error: ErrNotFound if no row matches, ErrTooManyRows if more than one row matches and
+//     WithUniqueResult is set, or the error of Query
+func QueryOne[T any](ctx context.Context, conn IReadSession, query string, args ...any) (T, error) {
+	var result T
+	opts, _ := splitQueryOptions(args)
+	// The iteration stops after the first row, or the second one with WithUniqueResult, so
+	// further rows are neither read nor mapped
+	found, tooMany := false, false
+	err := QueryEach(ctx, conn, func(item T) error {
+		if found {
+			tooMany = true
+			return errStopIteration
+		}
+		result, found = item, true
+		if !opts.unique {
+			return errStopIteration
+		}
+		return nil
+	}, query, args...)
+	switch {
+	case err != nil && !errors.Is(err, errStopIteration):
+		return *new(T), err
+	case !found:
+		return result, NewErrNotFound("query returned no rows")
+	case tooMany:
+		return *new(T), NewErrTooManyRows("query returned more than one row, expected 1")
+	}
+	return result, nil
+}
+
+// QueryOneAsync executes QueryOne asynchronously.
+func QueryOneAsync[T any](ctx context.Context, conn IReadSession, query string, args ...any) async.Result[T] {
+	return async.Do(
+		ctx,
+		func(ctx context.Context) (T, error) {
+			return QueryOne[T](ctx, conn, query, args...)
+		},
+	)
+}
@@ -0,0 +1,114 @@
+package db
+
+import "context"
+
+// QueryOption configures how Query executes a statement and maps its results.
+//
+// Query options are passed along with the query arguments, e.g.
+//
+//	db.Query[User](ctx, conn, "SELECT * FROM users WHERE active = ?", true, db.WithCapacity(1000))
+//
+// They are removed from the arguments before the statement is sent to the driver.
+type QueryOption func(*queryOptions)
+
+type queryOptions struct {
+	capacity        int
+	projection      bool
+	validateColumns bool
+	workers         int
+	scanMode        ScanErrorMode
+	scanReport      *ScanReport
+	nullAsZero      bool
+	nameMapper      NameMapper
+	unique          bool
+	tier            *TimeoutTier
+	resume          *KeysetResume
+	ndjson          bool
+	jsonKeys        NameMapper
+	arrowBatchSize  int
+	protoBatchSize  int
+}
+
+// context returns the context to execute the query with, carrying the tier of the query (if any).
+func (o queryOptions) context(ctx context.Context) context.Context {
+	if o.tier == nil {
+		return ctx
+	}
+	return ContextWithTimeoutTier(ctx, *o.tier)
+}
+
+// WithCapacity pre-sizes the result slice for the given estimated row count, avoiding
+// repeated slice growth when the size of a large result set is known in advance. Negative
+// counts are treated as 0.
+func WithCapacity(rows int) QueryOption {
+	return func(o *queryOptions) {
+		o.capacity = max(rows, 0)
+	}
+}
+
+// WithProjection rewrites a leading "SELECT *" to select only the columns mapped by the
+// result type, reducing network and scan overhead on wide tables.
+func WithProjection() QueryOption {
+	return func(o *queryOptions) {
+		o.projection = true
+	}
+}
+
+// WithColumnValidation rejects result sets containing columns that are not mapped by the
+// result type with ErrColumnMismatch, instead of silently discarding them.
+func WithColumnValidation() QueryOption {
+	return func(o *queryOptions) {
+		o.validateColumns = true
+	}
+}
+
+// WithParallelMapping maps rows into the result type using the given number of worker
+// goroutines, while rows are fetched concurrently. This improves throughput for very large
+// result sets where mapping is CPU-bound (many columns, sql.Scanner codecs), but adds
+// overhead for small ones. Values are fetched as raw driver values and converted following
+// the rules of database/sql.
+func WithParallelMapping(workers int) QueryOption {
+	return func(o *queryOptions) {
+		o.workers = workers
+	}
+}
+
+// WithFieldNameMapper sets the name mapper for struct fields without `db` tag for this query,
+// overriding the name mapper of the session.
+func WithFieldNameMapper(mapper NameMapper) QueryOption {
+	return func(o *queryOptions) {
+		o.nameMapper = mapper
+	}
+}
+
+// WithUniqueResult makes QueryOne fail with ErrTooManyRows if the query returns more than
+// one row, instead of returning the first one.
+func WithUniqueResult() QueryOption {
+	return func(o *queryOptions) {
+		o.unique = true
+	}
+}
+
+// splitQueryOptions separates query options from the query arguments.
+func splitQueryOptions(args []any) (queryOptions, []any) {
+	var opts queryOptions
+	hasOptions := false
+	for _, arg := range args {
+		if _, ok := arg.(QueryOption); ok {
+			hasOptions = true
+			break
+		}
+	}
+	if !hasOptions {
+		return opts, args
+	}
+	queryArgs := make([]any, 0, len(args))
+	for _, arg := range args {
+		if opt, ok := arg.(QueryOption); ok {
+			opt(&opts)
+		} else {
+			queryArgs = append(queryArgs, arg)
+		}
+	}
+	return opts, queryArgs
+}
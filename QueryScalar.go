@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Scalar is the set of types QueryScalar scans single values into.
+type Scalar interface {
+	~bool | ~string |
+		~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64 |
+		time.Time | []byte
+}
+
+// QueryScalar executes a SQL query and returns the first column of its first row, e.g. for
+// COUNT(*), MAX(id) or EXISTS checks, without a wrapper struct:
+//
+//	count, err := db.QueryScalar[int64](ctx, conn, "SELECT COUNT(*) FROM users")
+//
+// A NULL value (e.g. MAX of an empty table) is returned as the zero value of T; use
+// QueryOne[sql.Null[T]] to distinguish NULL from zero. Further columns and rows are ignored.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (connection or transaction) to execute the query on
+//   - query: SQL query string to execute
+//   - args: Query parameters and QueryOption values
+//
+// Returns:
+//   - T: The value of the first column of the first row
+//   - error: ErrNotFound if the query returns no rows, or the error of the query or scan
+func QueryScalar[T Scalar](ctx context.Context, conn IReadSession, query string, args ...any) (T, error) {
+	var zero T
+	opts, args := splitQueryOptions(args)
+	ctx, logRows := deferStatementLog(ctx, conn)
+	ctx, release := withReleaseScope(ctx, OperationQuery)
+	defer release()
+	rows, err := conn.QueryContext(opts.context(ctx), query, args...)
+	if err != nil {
+		logRows(0, nil)
+		return zero, err
+	}
+	defer rows.Close()
+	value, read, err := scanScalar[T](rows)
+	logRows(read, err)
+	if err != nil {
+		return zero, err
+	}
+	observeRows(opts.context(ctx), conn, query, read)
+	if read == 0 {
+		return zero, NewErrNotFound("query returned no rows")
+	}
+	return value, nil
+}
+
+// scanScalar scans the first column of the first row and returns it together with the number
+// of rows read (0 or 1).
+func scanScalar[T Scalar](rows *sql.Rows) (T, int, error) {
+	var zero T
+	if !rows.Next() {
+		return zero, 0, rows.Err()
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return zero, 0, err
+	}
+	var value sql.Null[T]
+	dest := make([]any, len(columns))
+	dest[0] = &value
+	for i := 1; i < len(dest); i++ {
+		dest[i] = new(any)
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return zero, 0, err
+	}
+	return value.V, 1, rows.Close()
+}
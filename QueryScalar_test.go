@@ -0,0 +1,32 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+// observedRows returns the number of queries with a row count and their total number of rows.
+func observedRows(metrics *MemoryMetrics) (queries, rows int64) {
+	for _, series := range metrics.Snapshot().Series {
+		queries += series.Queries
+		rows += series.Rows
+	}
+	return queries, rows
+}
+
+func TestQueryScalarObservesRows(t *testing.T) {
+	database, err := sql.Open("arrowstub", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	metrics := NewMemoryMetrics()
+	client := NewClient(database, WithMetrics(metrics))
+	if _, err := QueryScalar[int64](context.Background(), client, "SELECT id FROM t"); err != nil {
+		t.Fatal(err)
+	}
+	if queries, rows := observedRows(metrics); queries != 1 || rows != 1 {
+		t.Fatalf("observed %d queries with %d rows, expected 1 query with 1 row", queries, rows)
+	}
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+// QueryStream executes a SQL query and returns an iterator over its results, mapping rows
+// one at a time instead of materializing the whole result set.
+//
+// The query is executed when the iteration starts and the rows are closed when it ends,
+// including when the loop is left early. An error (of the query, a scan or the row
+// iteration) is yielded as the last element. Query options are applied like by Query, except
+// WithCapacity and WithParallelMapping, which do not apply to streams.
+//
+//	for user, err := range db.QueryStream[User](ctx, conn, "SELECT * FROM users") {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (connection or transaction) to execute the query on
+//   - query: SQL query string to execute
+//   - args: Query parameters and QueryOption values
+//
+// Returns:
+//   - iter.Seq2[T, error]: Iterator over the results
+func QueryStream[T any](ctx context.Context, conn IReadSession, query string, args ...any) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		err := QueryEach(ctx, conn, func(item T) error {
+			if !yield(item, nil) {
+				return errStopIteration
+			}
+			return nil
+		}, query, args...)
+		if err != nil && !errors.Is(err, errStopIteration) {
+			yield(*new(T), err)
+		}
+	}
+}
+
+// errStopIteration signals QueryEach to stop, since the consumer of a stream left the loop.
+var errStopIteration = errors.New("iteration stopped")
+
+// QueryEach executes a SQL query and invokes fn for each result, mapping rows one at a time
+// instead of materializing the whole result set. Query options are applied like by Query,
+// except WithCapacity and WithParallelMapping, which do not apply to streams. With
+// WithKeysetResume, a stream losing its connection resumes after the last result.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (connection or transaction) to execute the query on
+//   - fn: Function invoked for each result, returning an error stops the iteration
+//   - query: SQL query string to execute
+//   - args: Query parameters and QueryOption values
+//
+// Returns:
+//   - error: Non-nil if the query, a scan or fn fails
+func QueryEach[T any](ctx context.Context, conn IReadSession, fn func(item T) error, query string, args ...any) error {
+	opts, args := splitQueryOptions(args)
+	if opts.nameMapper == nil {
+		opts.nameMapper = nameMapperOf(conn)
+	}
+	if opts.resume != nil {
+		return queryEachResumable(ctx, conn, fn, query, args, opts)
+	}
+	return queryEach(ctx, conn, fn, query, args, opts)
+}
+
+func queryEach[T any](ctx context.Context, conn IReadSession, fn func(item T) error, query string, args []any, opts queryOptions) (err error) {
+	if opts.projection {
+		projected, err := projectColumns[T](query, opts.nameMapper)
+		if err != nil {
+			return err
+		}
+		query = projected
+	}
+	ctx, logRows := deferStatementLog(ctx, conn)
+	ctx, release := withReleaseScope(ctx, OperationQuery)
+	defer release()
+	returned := 0
+	defer func() {
+		// Stopping the iteration early is no failure of the statement, the result stopping it
+		// has been returned as well
+		if errors.Is(err, errStopIteration) {
+			logRows(returned+1, nil)
+			return
+		}
+		logRows(returned, err)
+	}()
+	rows, err := conn.QueryContext(opts.context(ctx), query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if opts.validateColumns {
+		if err := validateColumns[T](columns, opts.nameMapper); err != nil {
+			return err
+		}
+	}
+	defer opts.scanReport.sort()
+	scan, release := newRowScanner[T](rows, columns, opts)
+	defer release()
+	for row := 0; rows.Next(); row++ {
+		var item T
+		keep, err := scan(&item, row)
+		if err != nil {
+			return err
+		}
+		if !keep {
+			continue
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+		returned++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	observeRows(opts.context(ctx), conn, query, returned)
+	return nil
+}
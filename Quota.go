@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Quota limits the database calls of one tenant or label.
+type Quota struct {
+	// MaxConcurrent is the maximum number of concurrent calls (0 = unlimited)
+	MaxConcurrent int
+	// QPS is the maximum sustained number of calls per second (0 = unlimited)
+	QPS float64
+	// Burst is the number of calls permitted at once before QPS applies (default: 1)
+	Burst int
+}
+
+// QuotaOptions configures a QuotaLimiter.
+type QuotaOptions struct {
+	// Key returns the key the quota of a call is selected by (default: the tenant attached to
+	// the context, or its label). Calls without key are not limited.
+	Key func(ctx context.Context) (string, bool)
+	// Default is the quota of keys without a quota of their own
+	Default Quota
+	// Quotas are the quotas of individual keys
+	Quotas map[string]Quota
+	// MaxWait is the maximum time a call waits for its quota before failing with
+	// ErrQuotaExceeded (0 = wait until the context is done)
+	MaxWait time.Duration
+}
+
+type quotaState struct {
+	quota    Quota
+	slots    chan struct{}
+	tokens   float64
+	refilled time.Time
+	// users is the number of calls waiting for or holding the quota, the state is evicted once
+	// it is unused and its rate quota refilled
+	users int
+}
+
+// QuotaLimiter enforces concurrency and rate quotas per tenant or label, so a single noisy
+// tenant cannot monopolize a shared connection pool in multi-tenant services:
+//
+//	limiter := db.NewQuotaLimiter(db.QuotaOptions{
+//		Default: db.Quota{MaxConcurrent: 4, QPS: 50, Burst: 10},
+//		Quotas:  map[string]db.Quota{"enterprise": {MaxConcurrent: 16}},
+//	})
+//	client := db.NewClient(database, db.WithInterceptors(limiter.Interceptor()))
+//
+// Calls exceeding their quota wait until they are permitted. A call holds its concurrency slot
+// until its resources are released (see DeferRelease): a query until its rows have been read, a
+// transaction of ExecuteInTransaction until it has been finished. Statements within such a
+// transaction are covered by its slot and not limited themselves. The state of keys is dropped
+// once they are idle, so keys may be unbounded (e.g. request ids). QuotaLimiter is safe for
+// concurrent use.
+type QuotaLimiter struct {
+	opts QuotaOptions
+	mu   sync.Mutex
+	keys map[string]*quotaState
+	// sweepAt is the number of keys triggering the eviction of idle keys
+	sweepAt int
+}
+
+// NewQuotaLimiter creates a limiter enforcing the given quotas.
+func NewQuotaLimiter(opts QuotaOptions) *QuotaLimiter {
+	if opts.Key == nil {
+		opts.Key = tenantOrLabel
+	}
+	return &QuotaLimiter{opts: opts, keys: map[string]*quotaState{}}
+}
+
+// tenantOrLabel returns the tenant attached to the context, or its label.
+func tenantOrLabel(ctx context.Context) (string, bool) {
+	if tenant, ok := TenantFromContext(ctx); ok {
+		return tenant, true
+	}
+	return LabelFromContext(ctx)
+}
+
+// Interceptor returns the interceptor enforcing the quotas, to install using WithInterceptors.
+func (l *QuotaLimiter) Interceptor() Interceptor {
+	return func(ctx context.Context, stmt StatementInfo, next func(ctx context.Context) error) error {
+		key, ok := l.opts.Key(ctx)
+		if !ok || inTransaction(ctx) {
+			return next(ctx)
+		}
+		release, err := l.acquire(ctx, key)
+		if err != nil {
+			return err
+		}
+		if err := next(ctx); err != nil {
+			release()
+			return err
+		}
+		DeferRelease(ctx, stmt, release)
+		return nil
+	}
+}
+
+// InFlight returns the number of running calls of a key (limited keys only).
+func (l *QuotaLimiter) InFlight(key string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if state, ok := l.keys[key]; ok && state.slots != nil {
+		return len(state.slots)
+	}
+	return 0
+}
+
+// state returns the state of a key, creating it on first use, and registers a user of it.
+func (l *QuotaLimiter) state(key string) *quotaState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if state, ok := l.keys[key]; ok {
+		state.users++
+		return state
+	}
+	if len(l.keys) >= l.sweepAt {
+		l.evictIdle()
+		l.sweepAt = max(2*len(l.keys), 64)
+	}
+	quota, ok := l.opts.Quotas[key]
+	if !ok {
+		quota = l.opts.Default
+	}
+	quota.Burst = max(quota.Burst, 1)
+	state := &quotaState{quota: quota, tokens: float64(quota.Burst), refilled: time.Now(), users: 1}
+	if quota.MaxConcurrent > 0 {
+		state.slots = make(chan struct{}, quota.MaxConcurrent)
+	}
+	l.keys[key] = state
+	return state
+}
+
+// evictIdle drops the states of keys without users whose rate quota has been refilled, which
+// are equal to new states. The caller must hold the lock.
+func (l *QuotaLimiter) evictIdle() {
+	now := time.Now()
+	for key, state := range l.keys {
+		refilled := state.quota.QPS <= 0 || state.tokens+now.Sub(state.refilled).Seconds()*state.quota.QPS >= float64(state.quota.Burst)
+		if state.users == 0 && refilled {
+			delete(l.keys, key)
+		}
+	}
+}
+
+// done unregisters a user of a state.
+func (l *QuotaLimiter) done(state *quotaState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state.users--
+}
+
+// acquire waits until the quota of the key permits a call, returning the function to release
+// its concurrency slot.
+func (l *QuotaLimiter) acquire(ctx context.Context, key string) (release func(), err error) {
+	state := l.state(key)
+	defer func() {
+		if err != nil {
+			l.done(state)
+		}
+	}()
+	var deadline <-chan time.Time
+	if l.opts.MaxWait > 0 {
+		timer := time.NewTimer(l.opts.MaxWait)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	if wait := l.reserve(state); wait > 0 {
+		if l.opts.MaxWait > 0 && wait > l.opts.MaxWait {
+			l.unreserve(state)
+			return nil, NewErrQuotaExceeded("rate quota of %q exceeded (%g calls per second)", key, state.quota.QPS)
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			l.unreserve(state)
+			return nil, ctx.Err()
+		}
+	}
+	if state.slots == nil {
+		return func() { l.done(state) }, nil
+	}
+	select {
+	case state.slots <- struct{}{}:
+		return func() {
+			<-state.slots
+			l.done(state)
+		}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-deadline:
+		return nil, NewErrQuotaExceeded("concurrency quota of %q exceeded (%d concurrent calls)", key, state.quota.MaxConcurrent)
+	}
+}
+
+// reserve takes a token of the rate quota of a key, returning the time until it is available.
+func (l *QuotaLimiter) reserve(state *quotaState) time.Duration {
+	if state.quota.QPS <= 0 {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	state.tokens = min(float64(state.quota.Burst), state.tokens+now.Sub(state.refilled).Seconds()*state.quota.QPS)
+	state.refilled = now
+	state.tokens--
+	if state.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-state.tokens / state.quota.QPS * float64(time.Second))
+}
+
+// unreserve returns a token of a call that has not been executed.
+func (l *QuotaLimiter) unreserve(state *quotaState) {
+	if state.quota.QPS <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state.tokens++
+}
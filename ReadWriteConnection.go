@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+)
+
+// ReplicaBalancing selects the replica a query of a ReadWriteConnection is executed on.
+type ReplicaBalancing int
+
+const (
+	// ReplicaRoundRobin distributes queries evenly across the replicas (default)
+	ReplicaRoundRobin ReplicaBalancing = iota
+	// ReplicaLeastLoaded executes queries on the replica with the fewest connections in use
+	ReplicaLeastLoaded
+)
+
+// ReadWriteOptions configures a ReadWriteConnection.
+type ReadWriteOptions struct {
+	// Balancing selects the replica queries are executed on (default: ReplicaRoundRobin)
+	Balancing ReplicaBalancing
+	// PinAfterWrite routes the queries of a session (see ReadWriteConnection.Session) to the
+	// primary once it has executed a write or begun a transaction, so it reads its own writes
+	// regardless of replication lag
+	PinAfterWrite bool
+	// Dialect classifies queries to route writes returning rows to the primary (default:
+	// DefaultDialect)
+	Dialect IDialect
+}
+
+// ReadWriteConnection splits reads and writes across a primary and its read replicas:
+// QueryContext is executed on a replica, ExecContext, BeginTx and queries modifying data (e.g.
+// INSERT ... RETURNING, see ClassifyStatement) on the primary. Without replicas, all calls are
+// executed on the primary.
+//
+//	conn := db.NewReadWriteConnection(primary, []*sql.DB{replica1, replica2})
+//	client := db.NewClient(conn, db.WithDialect(db.Postgres))
+//
+// Queries selecting for update or otherwise requiring the primary must be executed within a
+// transaction or on Primary().
+type ReadWriteConnection struct {
+	primary  *sql.DB
+	replicas []*sql.DB
+	opts     ReadWriteOptions
+	next     *atomic.Uint64
+	session  bool
+	pinned   atomic.Bool
+}
+
+// NewReadWriteConnection creates a connection executing writes on primary and reads on replicas.
+//
+// Parameters:
+//   - primary: Database executing writes and transactions
+//   - replicas: Read replicas of the primary (may be empty)
+//   - opts: Optional balancing and pinning options (first element used)
+//
+// Returns:
+//   - *ReadWriteConnection: The connection
+func NewReadWriteConnection(primary *sql.DB, replicas []*sql.DB, opts ...ReadWriteOptions) *ReadWriteConnection {
+	c := &ReadWriteConnection{primary: primary, replicas: replicas, next: &atomic.Uint64{}}
+	if len(opts) > 0 {
+		c.opts = opts[0]
+	}
+	return c
+}
+
+// Primary returns the primary database.
+func (c *ReadWriteConnection) Primary() *sql.DB {
+	return c.primary
+}
+
+// Replicas returns the read replicas.
+func (c *ReadWriteConnection) Replicas() []*sql.DB {
+	return c.replicas
+}
+
+// Session returns a connection sharing the databases and balancing state of c, for use within
+// a single unit of work (e.g. a request). If PinAfterWrite is set, the session executes all
+// queries on the primary once it has executed a write or begun a transaction. Writes executed
+// on c itself never pin it.
+func (c *ReadWriteConnection) Session() *ReadWriteConnection {
+	return &ReadWriteConnection{primary: c.primary, replicas: c.replicas, opts: c.opts, next: c.next, session: true}
+}
+
+// Pinned reports whether the session executes its queries on the primary after a write.
+func (c *ReadWriteConnection) Pinned() bool {
+	return c.pinned.Load()
+}
+
+// QueryContext implements IDbConnection, executing the query on a replica, or on the primary if
+// it modifies data.
+func (c *ReadWriteConnection) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if ClassifyStatement(c.opts.Dialect, query).Writes {
+		c.pin()
+		return c.primary.QueryContext(ctx, query, args...)
+	}
+	return c.reader().QueryContext(ctx, query, args...)
+}
+
+// ExecContext implements IWriteSession, executing the statement on the primary.
+func (c *ReadWriteConnection) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	c.pin()
+	return c.primary.ExecContext(ctx, query, args...)
+}
+
+// BeginTx implements IDbConnection, beginning the transaction on the primary.
+func (c *ReadWriteConnection) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	c.pin()
+	return c.primary.BeginTx(ctx, opts)
+}
+
+// pin pins a session to the primary, if configured.
+func (c *ReadWriteConnection) pin() {
+	if c.session && c.opts.PinAfterWrite {
+		c.pinned.Store(true)
+	}
+}
+
+// reader returns the database the next query is executed on.
+func (c *ReadWriteConnection) reader() *sql.DB {
+	if len(c.replicas) == 0 || c.pinned.Load() {
+		return c.primary
+	}
+	start := int(c.next.Add(1) % uint64(len(c.replicas)))
+	if c.opts.Balancing != ReplicaLeastLoaded {
+		return c.replicas[start]
+	}
+	// Starting at the round robin position spreads queries across equally loaded replicas
+	best, bestInUse := c.replicas[start], c.replicas[start].Stats().InUse
+	for i := 1; i < len(c.replicas) && bestInUse > 0; i++ {
+		replica := c.replicas[(start+i)%len(c.replicas)]
+		if inUse := replica.Stats().InUse; inUse < bestInUse {
+			best, bestInUse = replica, inUse
+		}
+	}
+	return best
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// ReaperTable configures the expiry of rows of a single table.
+type ReaperTable struct {
+	Table string
+	// Column holding the expiry time (default: "expires_at")
+	Column string
+	// BatchSize is the maximum number of rows deleted per statement (default: 1000)
+	BatchSize int
+}
+
+// ReaperOptions configures a Reaper.
+type ReaperOptions struct {
+	Tables []ReaperTable
+	// Interval between runs (default: 1m)
+	Interval time.Duration
+	// Jitter randomizes each interval by up to this fraction (e.g. 0.2 = ±20%), so instances
+	// started together do not delete at the same time. It is capped at 0.9, so intervals stay
+	// positive.
+	Jitter float64
+	// Lock ensures that only one instance reaps at a time (nil = no coordination)
+	Lock IJobLock
+	// Logger reports failed runs (default: DefaultLogger)
+	Logger ILogger
+	// Clock provides the time rows are compared against (default: DefaultClock)
+	Clock IClock
+}
+
+// ReaperStats contains the metrics of a Reaper.
+type ReaperStats struct {
+	Runs     int64
+	Deleted  int64
+	Failures int64
+}
+
+// Reaper deletes expired rows (expiry column < now) from the configured tables in small
+// batches. It is shared by all features storing expiring rows (key-value entries, sessions,
+// idempotency keys, ...).
+type Reaper struct {
+	conn     IWriteSession
+	opts     ReaperOptions
+	runs     atomic.Int64
+	deleted  atomic.Int64
+	failures atomic.Int64
+}
+
+// NewReaper creates a reaper deleting rows on the given session, using the session's dialect.
+func NewReaper(conn IWriteSession, opts ReaperOptions) *Reaper {
+	if opts.Logger == nil {
+		opts.Logger = DefaultLogger
+	}
+	if opts.Clock == nil {
+		opts.Clock = DefaultClock
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	opts.Jitter = min(opts.Jitter, 0.9)
+	return &Reaper{conn: conn, opts: opts}
+}
+
+// Run reaps expired rows every (jittered) interval until ctx is done.
+func (r *Reaper) Run(ctx context.Context) {
+	for {
+		timer := time.NewTimer(r.nextInterval())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if _, err := RunExclusive(ctx, r.opts.Lock, "dbx-reaper", func(ctx context.Context) error {
+			_, err := r.ReapOnce(ctx)
+			return err
+		}); err != nil {
+			r.opts.Logger.Error("reaping expired rows failed", "error", err)
+		}
+	}
+}
+
+// ReapOnce deletes all currently expired rows of all configured tables.
+//
+// Returns:
+//   - int64: Number of deleted rows
+//   - error: Joined errors of all tables that failed
+func (r *Reaper) ReapOnce(ctx context.Context) (int64, error) {
+	r.runs.Add(1)
+	now := r.opts.Clock.Now()
+	d := dialectOf(r.conn)
+	var total int64
+	var errs []error
+	for _, table := range r.opts.Tables {
+		column := table.Column
+		if column == "" {
+			column = "expires_at"
+		}
+		batchSize := table.BatchSize
+		if batchSize <= 0 {
+			batchSize = 1000
+		}
+		deleted, err := deleteInBatches(ctx, r.conn, table.Table, d.QuoteIdentifier(column)+" < "+d.Placeholder(1), batchSize, now)
+		total += deleted
+		if err != nil {
+			r.failures.Add(1)
+			errs = append(errs, fmt.Errorf("reap %s: %w", table.Table, err))
+		}
+	}
+	r.deleted.Add(total)
+	return total, errors.Join(errs...)
+}
+
+// Stats returns the metrics of the reaper.
+func (r *Reaper) Stats() ReaperStats {
+	return ReaperStats{
+		Runs:     r.runs.Load(),
+		Deleted:  r.deleted.Load(),
+		Failures: r.failures.Load(),
+	}
+}
+
+func (r *Reaper) nextInterval() time.Duration {
+	if r.opts.Jitter <= 0 {
+		return r.opts.Interval
+	}
+	factor := 1 + r.opts.Jitter*(2*rand.Float64()-1)
+	return time.Duration(float64(r.opts.Interval) * factor)
+}
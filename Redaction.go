@@ -0,0 +1,74 @@
+package db
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"slices"
+)
+
+// redactedArg is the rendering of redacted arguments.
+const redactedArg = "<redacted>"
+
+// sensitiveArg wraps an argument that must not be rendered in logs and traces.
+type sensitiveArg struct {
+	value any
+}
+
+// Value implements driver.Valuer, converting the wrapped value like database/sql converts
+// arguments by default. Clients and TxSession unwrap sensitive arguments before passing them
+// to the driver (see driverArgs), so this is only used by sessions of database/sql itself.
+func (a sensitiveArg) Value() (driver.Value, error) {
+	return driver.DefaultParameterConverter.ConvertValue(a.value)
+}
+
+// driverArgs returns the arguments passed to the driver: arguments marked using Sensitive are
+// unwrapped, so they are converted by the driver like any other argument (e.g. slices and
+// custom types of pgx). args is returned as is if it has no sensitive arguments.
+func driverArgs(args []any) []any {
+	var unwrapped []any
+	for i, arg := range args {
+		sensitive, ok := arg.(sensitiveArg)
+		if !ok {
+			continue
+		}
+		if unwrapped == nil {
+			unwrapped = slices.Clone(args)
+		}
+		unwrapped[i] = sensitive.value
+	}
+	if unwrapped == nil {
+		return args
+	}
+	return unwrapped
+}
+
+// Sensitive marks a statement argument (e.g. a password hash or personal data) as sensitive,
+// so it is rendered as <redacted> in logs and traces (see FormatArgs). Clients pass the value
+// to the driver unchanged, so the driver converts it like any other argument:
+//
+//	db.Exec(ctx, conn, "UPDATE users SET password = ? WHERE id = ?", db.Sensitive(hash), id)
+//
+// Struct fields tagged with the `sensitive` option (`db:"password,sensitive"`) are marked
+// automatically when written using InsertMany, Upsert, UpdateVersioned, InsertBuilder.Struct
+// or passed as parameters of QueryNamed.
+func Sensitive(value any) any {
+	return sensitiveArg{value: value}
+}
+
+// argValues returns the values of the mapped columns of a struct like columnValues, marking
+// the values of fields tagged as sensitive using Sensitive.
+func argValues(item any, mapper NameMapper) (map[string]any, error) {
+	values, err := columnValues(item, mapper)
+	if err != nil {
+		return nil, err
+	}
+	typ := reflect.Indirect(reflect.ValueOf(item)).Type()
+	paths := map[string][]int{}
+	collectFieldPaths(typ, "", nil, mapper, paths)
+	for col, path := range paths {
+		if _, ok := values[col]; ok && hasTagOption(typ.FieldByIndex(path), "sensitive") {
+			values[col] = Sensitive(values[col])
+		}
+	}
+	return values, nil
+}
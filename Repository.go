@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"reflect"
+)
+
+// RepositoryOptions configures a Repository.
+type RepositoryOptions struct {
+	// Table is the name of the table (default: name of T mapped by the name mapper of the session)
+	Table string
+	// KeyOption is the `db` tag option marking the primary key field, e.g. `db:"id,pk"`
+	// (default: "pk"). Without tagged field, the key column is "id".
+	KeyOption string
+	// Cascade applies cascade rules on Delete (see Cascade)
+	Cascade *Cascade
+}
+
+// Repository implements simple CRUD operations for the rows of a table mapped to T (using
+// `db` tags and the name mapper of the session, like Query), identified by a key of type K:
+//
+//	type User struct {
+//		ID   int64  `db:"id,pk"`
+//		Name string `db:"name"`
+//	}
+//	users := db.NewRepository[User, int64](client, db.RepositoryOptions{Table: "users"})
+//	alice := User{Name: "alice"}
+//	err := users.Insert(ctx, &alice) // alice.ID is set to the generated key
+//	admins, err := users.Find(ctx, db.Eq("role", "admin"))
+//
+// Complex queries are still written using Query. Items with a zero key are inserted without key
+// column, so the database generates it, and the generated key is read back into the item. If T has a version field (see UpdateVersioned), Update
+// uses optimistic locking. Repository implements IRepository, so it can be wrapped by a
+// CachedRepository.
+type Repository[T any, K comparable] struct {
+	conn    IReadWriteSession
+	table   string
+	key     string
+	keyPath []int
+	cascade *Cascade
+}
+
+// NewRepository creates a repository of the rows of a table.
+//
+// Parameters:
+//   - conn: Database session the operations are executed on (see WithSession for transactions)
+//   - opts: Optional table, key and cascade options (first element used)
+//
+// Returns:
+//   - *Repository[T, K]: The repository
+func NewRepository[T any, K comparable](conn IReadWriteSession, opts ...RepositoryOptions) *Repository[T, K] {
+	var o RepositoryOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.KeyOption == "" {
+		o.KeyOption = "pk"
+	}
+	mapper := nameMapperOf(conn)
+	if mapper == nil {
+		mapper = DefaultNameMapper
+	}
+	typ := reflect.TypeFor[T]()
+	if o.Table == "" {
+		o.Table = mapper(typ.Name())
+	}
+	r := &Repository[T, K]{conn: conn, table: o.Table, key: "id", cascade: o.Cascade}
+	paths := map[string][]int{}
+	if typ.Kind() == reflect.Struct {
+		collectFieldPaths(typ, "", nil, mapper, paths)
+	}
+	for col, path := range paths {
+		if hasTagOption(typ.FieldByIndex(path), o.KeyOption) {
+			r.key = col
+		}
+	}
+	r.keyPath = paths[r.key]
+	return r
+}
+
+// Table returns the name of the table.
+func (r *Repository[T, K]) Table() string {
+	return r.table
+}
+
+// WithSession returns a repository executing its operations on the given session, e.g. the
+// session of a transaction (see TxSession).
+func (r *Repository[T, K]) WithSession(session IReadWriteSession) *Repository[T, K] {
+	bound := *r
+	bound.conn = session
+	return &bound
+}
+
+// Find returns the rows matching all predicates (all rows without predicates).
+func (r *Repository[T, K]) Find(ctx context.Context, predicates ...Expr) ([]T, error) {
+	return QueryStatement[T](ctx, r.conn, Select().From(r.table).Where(predicates...))
+}
+
+// FindOne returns the single row matching all predicates.
+//
+// Returns:
+//   - T: The matching row
+//   - error: ErrNotFound if no row matches, ErrTooManyRows if several rows match
+func (r *Repository[T, K]) FindOne(ctx context.Context, predicates ...Expr) (T, error) {
+	items, err := QueryStatement[T](ctx, r.conn, Select().From(r.table).Where(predicates...).Limit(2))
+	if err != nil {
+		return *new(T), err
+	}
+	switch len(items) {
+	case 0:
+		return *new(T), NewErrNotFound("no row of %s matches", r.table)
+	case 1:
+		return items[0], nil
+	}
+	return *new(T), NewErrTooManyRows("several rows of %s match", r.table)
+}
+
+// Get implements IRepository, returning the row with the given key.
+func (r *Repository[T, K]) Get(ctx context.Context, id K) (T, error) {
+	return r.FindOne(ctx, Eq(r.key, id))
+}
+
+// List implements IRepository, returning all rows.
+func (r *Repository[T, K]) List(ctx context.Context) ([]T, error) {
+	return r.Find(ctx)
+}
+
+// Insert inserts a row. If its key is zero, the key column is omitted and the key generated by
+// the database is read back into item, like InsertMany reads back generated columns (on MySQL
+// only if T has no other generated columns).
+func (r *Repository[T, K]) Insert(ctx context.Context, item *T) error {
+	var opts InsertManyOptions
+	if r.keyPath != nil && reflect.ValueOf(item).Elem().FieldByIndex(r.keyPath).IsZero() {
+		opts.Returning = []string{r.key}
+	}
+	items := []T{*item}
+	if _, err := InsertMany(ctx, r.conn, r.table, items, opts); err != nil {
+		return err
+	}
+	*item = items[0]
+	return nil
+}
+
+// Create implements IRepository (see Insert).
+func (r *Repository[T, K]) Create(ctx context.Context, item *T) error {
+	return r.Insert(ctx, item)
+}
+
+// Update implements IRepository, updating all columns of the row with the key of item.
+//
+// Returns:
+//   - error: ErrNotFound if no row has the key, ErrOptimisticLock if T has a version field and
+//     the row has been modified concurrently
+func (r *Repository[T, K]) Update(ctx context.Context, item T) error {
+	mapper := nameMapperOf(r.conn)
+	if _, _, ok := versionFieldOf(reflect.TypeFor[T](), "", mapper); ok {
+		return UpdateVersioned(ctx, r.conn, r.table, &item, r.key)
+	}
+	values, err := argValues(item, mapper)
+	if err != nil {
+		return err
+	}
+	id, ok := values[r.key]
+	if !ok {
+		return NewErrColumnMismatch("key column %q is not mapped by %T", r.key, item)
+	}
+	delete(values, r.key)
+	result, err := ExecStatement(ctx, r.conn, Update(r.table).SetMap(values).Where(Eq(r.key, id)))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 && dialectOf(r.conn).Name() == DialectMySQL {
+		// MySQL reports the changed rows rather than the matched ones (unless the DSN sets
+		// clientFoundRows), so rows already holding the values of item are reported as 0
+		ids, err := QueryStatement[K](ctx, r.conn, Select(r.key).From(r.table).Where(Eq(r.key, id)).Limit(1))
+		if err != nil {
+			return err
+		}
+		affected = int64(len(ids))
+	}
+	return r.requireAffected(id, affected)
+}
+
+// Delete implements IRepository, deleting the row with the given key and applying the cascade
+// rules of the repository, if any.
+//
+// Returns:
+//   - error: ErrNotFound if no row has the key, ErrCascadeRestricted if a restricting cascade
+//     rule matches rows
+func (r *Repository[T, K]) Delete(ctx context.Context, id K) error {
+	if r.cascade != nil {
+		steps, err := r.cascade.Delete(ctx, r.conn, r.table, Eq(r.key, id))
+		if err != nil {
+			return err
+		}
+		return r.requireAffected(id, steps[len(steps)-1].Rows)
+	}
+	result, err := ExecStatement(ctx, r.conn, Delete(r.table).Where(Eq(r.key, id)))
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	return r.requireAffected(id, affected)
+}
+
+// requireAffected returns ErrNotFound if no row with the given key has been affected.
+func (r *Repository[T, K]) requireAffected(id any, affected int64) error {
+	if affected == 0 {
+		return NewErrNotFound("no row of %s with %s = %v", r.table, r.key, id)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PartitionDropper drops (or, in dry-run mode, only lists) all partitions of a table that
+// contain exclusively rows older than the given time. It returns the affected partitions.
+type PartitionDropper func(ctx context.Context, conn IReadWriteSession, table string, before time.Time, dryRun bool) ([]string, error)
+
+// RetentionPolicy declares how long the rows of a table are kept.
+type RetentionPolicy struct {
+	Table string
+	// Column holding the creation time of the rows (default: "created_at")
+	Column string
+	// KeepFor is the retention period, older rows are removed
+	KeepFor time.Duration
+	// BatchSize is the maximum number of rows deleted per statement (default: 1000)
+	BatchSize int
+	// DropPartitions removes whole partitions instead of deleting rows, for tables partitioned
+	// by Column (e.g. DropPartitionsBefore). Rows of partitions that are only partially expired
+	// are deleted in batches.
+	DropPartitions PartitionDropper
+}
+
+// RetentionReport describes the outcome of applying a retention policy.
+type RetentionReport struct {
+	Table  string
+	Cutoff time.Time
+	// Rows deleted (or, in dry-run mode, that would be deleted) in batches
+	Rows int64
+	// Partitions dropped (or, in dry-run mode, that would be dropped)
+	Partitions []string
+	DryRun     bool
+}
+
+// RetentionEngine applies declarative retention policies in safe batches.
+type RetentionEngine struct {
+	conn     IReadWriteSession
+	policies []RetentionPolicy
+	clock    IClock
+}
+
+// NewRetentionEngine creates an engine applying the given policies on the session,
+// using the session's dialect.
+func NewRetentionEngine(conn IReadWriteSession, policies ...RetentionPolicy) *RetentionEngine {
+	return &RetentionEngine{
+		conn:     conn,
+		policies: policies,
+		clock:    DefaultClock,
+	}
+}
+
+// WithClock sets the clock providing the time retention periods are counted back from
+// (default: DefaultClock).
+func (e *RetentionEngine) WithClock(clock IClock) *RetentionEngine {
+	e.clock = clock
+	return e
+}
+
+// Apply removes all rows outside the retention period of their policies.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - dryRun: If true, nothing is removed and the reports contain what would be removed
+//
+// Returns:
+//   - []RetentionReport: One report per policy
+//   - error: Joined errors of all failed policies
+func (e *RetentionEngine) Apply(ctx context.Context, dryRun bool) ([]RetentionReport, error) {
+	now := e.clock.Now()
+	reports := make([]RetentionReport, 0, len(e.policies))
+	var errs []error
+	for _, policy := range e.policies {
+		report, err := e.apply(ctx, policy, now.Add(-policy.KeepFor), dryRun)
+		reports = append(reports, report)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("retention of %s: %w", policy.Table, err))
+		}
+	}
+	return reports, errors.Join(errs...)
+}
+
+func (e *RetentionEngine) apply(ctx context.Context, policy RetentionPolicy, cutoff time.Time, dryRun bool) (RetentionReport, error) {
+	report := RetentionReport{Table: policy.Table, Cutoff: cutoff, DryRun: dryRun}
+	if policy.DropPartitions != nil {
+		partitions, err := policy.DropPartitions(ctx, e.conn, policy.Table, cutoff, dryRun)
+		report.Partitions = partitions
+		if err != nil {
+			return report, err
+		}
+	}
+	d := dialectOf(e.conn)
+	column := policy.Column
+	if column == "" {
+		column = "created_at"
+	}
+	condition := d.QuoteIdentifier(column) + " < " + d.Placeholder(1)
+	if dryRun {
+		rows, err := countExpired(ctx, e.conn, policy.Table, condition, cutoff, report.Partitions)
+		report.Rows = rows
+		return report, err
+	}
+	batchSize := policy.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	rows, err := deleteInBatches(ctx, e.conn, policy.Table, condition, batchSize, cutoff)
+	report.Rows = rows
+	return report, err
+}
+
+// countExpired counts the rows matching the condition, except for the rows of the partitions
+// that would be dropped, which are counted per partition.
+func countExpired(ctx context.Context, conn IReadWriteSession, table string, condition string, cutoff time.Time, dropped []string) (int64, error) {
+	d := dialectOf(conn)
+	t := d.QuoteIdentifier(table)
+	if len(dropped) == 0 {
+		return QueryScalar[int64](ctx, conn, "SELECT COUNT(*) FROM "+t+" WHERE "+condition, cutoff)
+	}
+	type partitionCount struct {
+		Name  string `db:"name"`
+		Count int64  `db:"count"`
+	}
+	var counts []partitionCount
+	switch d.Name() {
+	case DialectPostgres:
+		var err error
+		counts, err = Query[partitionCount](ctx, conn, "SELECT (SELECT relname FROM pg_class WHERE oid = r.tableoid) AS name, COUNT(*) AS count FROM (SELECT tableoid FROM "+t+" WHERE "+condition+") r GROUP BY r.tableoid", cutoff)
+		if err != nil {
+			return 0, err
+		}
+	case DialectMySQL:
+		partitions, err := ListPartitions(ctx, conn, table)
+		if err != nil {
+			return 0, err
+		}
+		for _, partition := range partitions {
+			if slices.Contains(dropped, partition) {
+				continue
+			}
+			count, err := QueryScalar[int64](ctx, conn, "SELECT COUNT(*) FROM "+t+" PARTITION ("+d.QuoteIdentifier(partition)+") WHERE "+condition, cutoff)
+			if err != nil {
+				return 0, err
+			}
+			counts = append(counts, partitionCount{Name: partition, Count: count})
+		}
+	default:
+		return QueryScalar[int64](ctx, conn, "SELECT COUNT(*) FROM "+t+" WHERE "+condition, cutoff)
+	}
+	var total int64
+	for _, c := range counts {
+		if !slices.Contains(dropped, c.Name) {
+			total += c.Count
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy retries operations that failed with a transient error (see ITransientError).
+//
+// The delay before each retry grows exponentially, starting with BaseDelay and capped at
+// MaxDelay. If the error carries a RetryAfter hint that is longer, the hint is used instead.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, values below 2 disable retries
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// NoRetry is the retry policy executing every operation exactly once.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// Do executes fn and retries it according to the policy while it fails with a transient error.
+//
+// Parameters:
+//   - ctx: Context for cancellation, aborting the waits between attempts
+//   - fn: Operation to execute
+//
+// Returns:
+//   - error: The error of the last attempt, or the context error if ctx is done while waiting
+func (p RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= p.MaxAttempts || !IsTransient(err) {
+			return err
+		}
+		delay := p.backoff(attempt)
+		if hint, _ := RetryAfter(err); hint > delay {
+			delay = hint
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,194 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// hasReturningClause reports whether a statement already returns rows: a RETURNING clause, or
+// an OUTPUT clause reading INSERTED or DELETED on SQL Server. Literals, quoted identifiers and
+// comments are skipped, as are columns named like the keywords (e.g. INSERT INTO jobs (output)).
+func hasReturningClause(stmt string) bool {
+	tokens := statementTokens(normalizeStatement(stmt, true))
+	for i, tok := range tokens[:max(len(tokens)-1, 0)] {
+		switch next := tokens[i+1]; tok {
+		case "returning":
+			if next != ")" && next != "," && next != ";" && next != "=" {
+				return true
+			}
+		case "output":
+			if strings.HasPrefix(next, "inserted.") || strings.HasPrefix(next, "deleted.") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExecReturning executes a data modifying statement returning rows (INSERT/UPDATE/DELETE with
+// RETURNING, or OUTPUT on SQL Server) and maps the returned rows like Query, e.g. to fetch
+// generated ids in the same round trip:
+//
+//	created, err := db.ExecReturning[User](ctx, conn, "INSERT INTO users (name) VALUES ($1)", name)
+//
+// If the statement has no RETURNING or OUTPUT clause, a RETURNING clause with the columns of
+// T is appended on PostgreSQL and SQLite. Other dialects require the clause to be given (SQL
+// Server) or do not support returning rows (MySQL). Statements built using Returning of the
+// statement builders can be executed using QueryStatement.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (connection or transaction) to execute the statement on
+//   - stmt: SQL statement to execute
+//   - args: Statement parameters and QueryOption values
+//
+// Returns:
+//   - []T: Returned rows
+//   - error: ErrUnsupportedDialect if the clause can't be appended for the dialect, otherwise
+//     the error of the statement
+func ExecReturning[T any](ctx context.Context, conn IReadWriteSession, stmt string, args ...any) ([]T, error) {
+	if !hasReturningClause(stmt) {
+		d := dialectOf(conn)
+		if d.Name() != DialectPostgres && d.Name() != DialectSQLite {
+			return nil, NewErrUnsupportedDialect("RETURNING clause can't be added for %s", d.Name())
+		}
+		columns, err := columnsOf(reflect.TypeFor[T](), nameMapperOf(conn))
+		if err != nil {
+			return nil, err
+		}
+		stmt = strings.TrimRight(strings.TrimSpace(stmt), ";") + " RETURNING " + quoteIdentifiers(d, columns)
+	}
+	return Query[T](ctx, conn, stmt, args...)
+}
+
+// returning is the RETURNING clause shared by the insert, update and delete builders.
+type returning []string
+
+// check reports dialects without support for returning rows.
+func (r returning) check(d IDialect) error {
+	if len(r) > 0 && d.Name() == DialectMySQL {
+		return NewErrUnsupportedDialect("returning rows is not supported by %s", d.Name())
+	}
+	return nil
+}
+
+// writeOutput writes the OUTPUT clause on SQL Server, reading from the pseudo table (INSERTED
+// or DELETED). It is placed before VALUES or WHERE.
+func (r returning) writeOutput(w *sqlWriter, pseudoTable string) {
+	if len(r) == 0 || w.d.Name() != DialectSQLServer {
+		return
+	}
+	w.write(" OUTPUT ")
+	for i, col := range r {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(pseudoTable + "." + quoteColumn(w.d, col))
+	}
+}
+
+// writeReturning writes the RETURNING clause at the end of the statement on dialects other than
+// SQL Server.
+func (r returning) writeReturning(w *sqlWriter) {
+	if len(r) == 0 || w.d.Name() == DialectSQLServer {
+		return
+	}
+	w.write(" RETURNING ")
+	writeColumns(w, r)
+}
+
+// generatedColumns are the columns generated by the database (serial ids, defaults, columns set
+// by triggers), which the insert and update helpers do not write, but read back into the
+// written structs. They are marked by the `returning` tag option (`db:"id,returning"`) or
+// requested using an option.
+type generatedColumns struct {
+	columns []string
+	paths   [][]int
+}
+
+// generatedColumnsOf returns the generated columns of T: the fields tagged as returning,
+// followed by the requested columns.
+func generatedColumnsOf(typ reflect.Type, mapper NameMapper, requested []string) (generatedColumns, error) {
+	var g generatedColumns
+	columns, err := columnsOf(typ, mapper)
+	if err != nil {
+		return g, err
+	}
+	paths := map[string][]int{}
+	collectFieldPaths(typ, "", nil, mapper, paths)
+	for _, col := range columns {
+		if hasTagOption(typ.FieldByIndex(paths[col]), "returning") {
+			g.columns, g.paths = append(g.columns, col), append(g.paths, paths[col])
+		}
+	}
+	for _, col := range requested {
+		path, ok := paths[col]
+		if !ok {
+			return g, NewErrColumnMismatch("returning column %q is not mapped by %s", col, typ)
+		}
+		if !slices.Contains(g.columns, col) {
+			g.columns, g.paths = append(g.columns, col), append(g.paths, path)
+		}
+	}
+	return g, nil
+}
+
+// check reports generated columns that can't be read back using the dialect. Without RETURNING
+// (MySQL), only a single integer column is read back using LastInsertId after inserts.
+func (g generatedColumns) check(d IDialect, typ reflect.Type, insert bool) error {
+	if len(g.columns) == 0 || d.Name() != DialectMySQL {
+		return nil
+	}
+	if insert && len(g.columns) == 1 {
+		switch typ.FieldByIndex(g.paths[0]).Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return nil
+		}
+	}
+	return NewErrUnsupportedDialect("returning %s is not supported by %s (only a single integer column after inserts)", strings.Join(g.columns, ", "), d.Name())
+}
+
+// execReturning executes a statement returning the generated columns of the written items
+// (RETURNING or OUTPUT clause) and populates them into the items, in the order of the returned
+// rows. The order is only guaranteed for a single item, or multi-row inserts on PostgreSQL. It
+// returns the number of returned rows.
+func execReturning[T any](ctx context.Context, conn IWriteSession, g generatedColumns, query string, args []any, items []T) (int64, error) {
+	reader, ok := conn.(IReadSession)
+	if !ok {
+		return 0, NewErrInvalidStatement("returning generated columns requires a session executing queries, got %T", conn)
+	}
+	returned, err := Query[T](ctx, reader, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	if len(returned) > len(items) {
+		return 0, NewErrColumnMismatch("statement returned %d rows for %d items", len(returned), len(items))
+	}
+	for i := range returned {
+		item, row := reflect.ValueOf(&items[i]).Elem(), reflect.ValueOf(&returned[i]).Elem()
+		for _, path := range g.paths {
+			item.FieldByIndex(path).Set(row.FieldByIndex(path))
+		}
+	}
+	return int64(len(returned)), nil
+}
+
+// populateInsertId populates the last insert id of a single row insert into the single
+// generated column of the item.
+func populateInsertId[T any](g generatedColumns, result sql.Result, item *T) error {
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	field := reflect.ValueOf(item).Elem().FieldByIndex(g.paths[0])
+	if field.CanInt() {
+		field.SetInt(id)
+	} else {
+		field.SetUint(uint64(id))
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package db
+
+import "testing"
+
+func TestHasReturningClause(t *testing.T) {
+	cases := []struct {
+		stmt      string
+		returning bool
+	}{
+		{"INSERT INTO users (name) VALUES ($1) RETURNING id", true},
+		{"update users set name = ? where id = ? returning id, \"Name\"", true},
+		{"DELETE FROM users WHERE id = $1 RETURNING *", true},
+		{"INSERT INTO users (name) OUTPUT INSERTED.id VALUES (@p1)", true},
+		{"DELETE FROM users OUTPUT deleted.[id] WHERE id = @p1", true},
+		{"WITH gone AS (DELETE FROM jobs RETURNING id) SELECT count(*) FROM gone", true},
+		{"INSERT INTO users (name) VALUES ($1)", false},
+		{"INSERT INTO jobs (output) VALUES (?)", false},
+		{"INSERT INTO jobs (id, output) VALUES (?, ?)", false},
+		{"UPDATE jobs SET output = ? WHERE id = ?", false},
+		{"INSERT INTO jobs (name) VALUES ('returning')", false},
+		{"INSERT INTO jobs (name) VALUES ('x RETURNING id')", false},
+		{`INSERT INTO jobs ("returning") VALUES ($1)`, false},
+		{"INSERT INTO jobs (`output`) VALUES (?)", false},
+		{"INSERT INTO jobs (name) VALUES (?) -- RETURNING id", false},
+		{"INSERT INTO jobs (name) /* OUTPUT INSERTED.id */ VALUES (?)", false},
+	}
+	for _, c := range cases {
+		if returning := hasReturningClause(c.stmt); returning != c.returning {
+			t.Errorf("hasReturningClause(%q) = %v, expected %v", c.stmt, returning, c.returning)
+		}
+	}
+}
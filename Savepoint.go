@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// txScope wraps the transaction of ExecuteInTransaction, so nested calls can detect and join it.
+type txScope struct {
+	conn    IDbConnection
+	tx      *sql.Tx
+	dialect IDialect
+	trace   *txTrace
+	depth   int
+	// id, started, label, callers and statements describe the transaction in the registry of
+	// active transactions
+	id         uint64
+	started    time.Time
+	label      string
+	callers    []uintptr
+	statements atomic.Int64
+}
+
+// owns reports whether the scope's transaction has been started on the given connection.
+func (s *txScope) owns(conn IDbConnection) bool {
+	if !reflect.TypeOf(conn).Comparable() {
+		return false
+	}
+	return s.conn == conn
+}
+
+// savepointStatements returns the statements to create, roll back to and release a savepoint.
+// SQL Server has no statement to release a savepoint, its release statement is empty.
+func savepointStatements(d IDialect, name string) (create, rollback, release string) {
+	name = d.QuoteIdentifier(name)
+	if d.Name() == DialectSQLServer {
+		return "SAVE TRANSACTION " + name, "ROLLBACK TRANSACTION " + name, ""
+	}
+	return "SAVEPOINT " + name, "ROLLBACK TO SAVEPOINT " + name, "RELEASE SAVEPOINT " + name
+}
+
+// executeInSavepoint executes a nested transaction function within a savepoint of the
+// enclosing transaction, rolling back to the savepoint if the function fails (or panics, if
+// panics are recovered).
+func executeInSavepoint[T any](ctx context.Context, scope *txScope, tsf TransactionScopeFunction[T], recoverPanics bool) (result T, err error) {
+	scope.depth++
+	defer func() { scope.depth-- }()
+	create, rollback, release := savepointStatements(scope.dialect, fmt.Sprintf("dbx_savepoint_%d", scope.depth))
+	if _, err := scope.tx.ExecContext(ctx, create); err != nil {
+		return *new(T), err
+	}
+	if recoverPanics {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				_, rollbackErr := scope.tx.ExecContext(ctx, rollback)
+				result, err = *new(T), NewErrTransactionPanicked(recovered, debug.Stack(), rollbackErr)
+			}
+		}()
+	}
+	hooks := &afterCommitHooks{}
+	r, err := tsf(context.WithValue(ctx, afterCommitContextKey, hooks), scope.tx)
+	if err != nil {
+		if _, rollbackErr := scope.tx.ExecContext(ctx, rollback); rollbackErr != nil {
+			return *new(T), errors.Join(err, rollbackErr)
+		}
+		return *new(T), err
+	}
+	if release != "" {
+		if _, err := scope.tx.ExecContext(ctx, release); err != nil {
+			return *new(T), err
+		}
+	}
+	if parent, ok := ctx.Value(afterCommitContextKey).(*afterCommitHooks); ok {
+		parent.merge(hooks)
+	}
+	return r, nil
+}
@@ -0,0 +1,243 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ScanCheckOptions configures the full table scan check.
+type ScanCheckOptions struct {
+	// MaxRows is the number of rows a full table scan may be estimated to read before it is
+	// reported (default: 10000)
+	MaxRows int64
+	// RequireIndex reports every full table scan filtering rows by a predicate, regardless of the
+	// size of the table. Planners prefer full scans of small tables even if an index exists, so
+	// it is meant for databases holding representative data.
+	RequireIndex bool
+	// Fail rejects reported statements with ErrFullTableScan instead of logging a warning
+	Fail bool
+	// Logger receives the reports (nil = DefaultLogger)
+	Logger ILogger
+}
+
+// FullScan is a full table scan in the plan of a statement (see ExplainFullScans).
+type FullScan struct {
+	Table string
+	// EstimatedRows is the estimated number of rows the scan reads (-1 = unknown, SQLite does
+	// not estimate rows)
+	EstimatedRows int64
+	// Filtered reports whether the scan filters rows by a predicate not served by an index
+	Filtered bool
+}
+
+// String implements fmt.Stringer.
+func (s FullScan) String() string {
+	var sb strings.Builder
+	sb.WriteString(s.Table)
+	if s.EstimatedRows >= 0 {
+		fmt.Fprintf(&sb, " (~%d rows)", s.EstimatedRows)
+	}
+	if s.Filtered {
+		sb.WriteString(" filtered without index")
+	}
+	return sb.String()
+}
+
+// ScanCheckInterceptor explains queries, updates and deletes before executing them and reports
+// full table scans estimated to read more than ScanCheckOptions.MaxRows rows, or filtering rows
+// without index if ScanCheckOptions.RequireIndex is set. It is meant to be installed in
+// development and staging environments, catching missing indexes before production:
+//
+//	client := db.NewClient(database, db.WithInterceptors(db.ScanCheckInterceptor(database, db.ScanCheckOptions{MaxRows: 1000})))
+//
+// Each statement (by its fingerprint, see Fingerprint) is explained once, using the arguments
+// of its first execution; the result is reused for later executions. Reported statements are
+// logged once as warning including the stack of the caller, or rejected with ErrFullTableScan
+// on every execution if ScanCheckOptions.Fail is set. Statements failing to be explained are
+// executed unchecked. SQL Server is not supported.
+//
+// Parameters:
+//   - conn: Database session to explain the statements on. It may be the client the
+//     interceptor is installed on, the EXPLAIN statements are not checked themselves.
+//   - opts: Options of the check
+//
+// Returns:
+//   - Interceptor: Interceptor to install using WithInterceptors
+func ScanCheckInterceptor(conn IReadSession, opts ...ScanCheckOptions) Interceptor {
+	var o ScanCheckOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.MaxRows <= 0 {
+		o.MaxRows = 10000
+	}
+	if o.Logger == nil {
+		o.Logger = DefaultLogger
+	}
+	d := dialectOf(conn)
+	var mu sync.Mutex
+	checked := map[string][]FullScan{}
+	return func(ctx context.Context, stmt StatementInfo, next func(ctx context.Context) error) error {
+		if stmt.Operation == OperationBegin || ctx.Value(scanCheckContextKey) != nil {
+			return next(ctx)
+		}
+		switch ClassifyStatement(d, stmt.Query).Type {
+		case StatementSelect, StatementUpdate, StatementDelete:
+		default:
+			return next(ctx)
+		}
+		fingerprint := Fingerprint(stmt.Query)
+		mu.Lock()
+		reported, ok := checked[fingerprint]
+		mu.Unlock()
+		if !ok {
+			scans, err := ExplainFullScans(context.WithValue(ctx, scanCheckContextKey, true), conn, stmt.Query, stmt.Args...)
+			if err != nil {
+				o.Logger.Debug("statement could not be explained, skipping scan check", "fingerprint", fingerprint, "error", err)
+			}
+			for _, scan := range scans {
+				if scan.EstimatedRows > o.MaxRows || o.RequireIndex && scan.Filtered {
+					reported = append(reported, scan)
+				}
+			}
+			mu.Lock()
+			checked[fingerprint] = reported
+			mu.Unlock()
+			if len(reported) > 0 && !o.Fail {
+				o.Logger.Warn("full table scan detected", "fingerprint", fingerprint, "scans", fmt.Sprint(reported), "stack", callerStack())
+			}
+		}
+		if len(reported) > 0 && o.Fail {
+			return NewErrFullTableScan("%s scans %v", fingerprint, reported)
+		}
+		return next(ctx)
+	}
+}
+
+// ExplainFullScans explains a statement and returns the full table scans of its plan, without
+// executing it. It uses EXPLAIN (FORMAT JSON) on Postgres, EXPLAIN on MySQL and EXPLAIN QUERY
+// PLAN on SQLite. Postgres estimates the rows read by the statistics of the table, MySQL by
+// the plan; SQLite does not estimate rows and considers every scan of a statement having a
+// WHERE clause as filtered.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to explain the statement on
+//   - query: SQL statement to explain
+//   - args: Statement arguments
+//
+// Returns:
+//   - []FullScan: Full table scans in the order of the plan, empty if there are none
+//   - error: ErrUnsupportedDialect for SQL Server, or the error of the EXPLAIN statement
+func ExplainFullScans(ctx context.Context, conn IReadSession, query string, args ...any) ([]FullScan, error) {
+	switch d := dialectOf(conn); d.Name() {
+	case DialectPostgres:
+		return explainPostgresScans(ctx, conn, query, args)
+	case DialectMySQL:
+		return explainMySQLScans(ctx, conn, query, args)
+	case DialectSQLite:
+		return explainSQLiteScans(ctx, conn, query, args)
+	default:
+		return nil, NewErrUnsupportedDialect("explaining statements is not supported by %s", d.Name())
+	}
+}
+
+// postgresPlan is a node of a plan returned by EXPLAIN (FORMAT JSON).
+type postgresPlan struct {
+	NodeType string         `json:"Node Type"`
+	Relation string         `json:"Relation Name"`
+	Rows     float64        `json:"Plan Rows"`
+	Filter   string         `json:"Filter"`
+	Plans    []postgresPlan `json:"Plans"`
+}
+
+func explainPostgresScans(ctx context.Context, conn IReadSession, query string, args []any) ([]FullScan, error) {
+	output, err := QueryScalar[string](ctx, conn, "EXPLAIN (FORMAT JSON) "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var plans []struct {
+		Plan postgresPlan `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(output), &plans); err != nil {
+		return nil, fmt.Errorf("parsing plan: %w", err)
+	}
+	var scans []FullScan
+	var walk func(node postgresPlan) error
+	walk = func(node postgresPlan) error {
+		if node.NodeType == "Seq Scan" {
+			// Plan Rows are the rows returned after filtering, the rows read are estimated by the
+			// statistics of the table (-1 if it has never been analyzed or is not found)
+			rows, err := QueryScalar[int64](ctx, conn, "SELECT COALESCE((SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass($1)), -1)", Postgres.QuoteIdentifier(node.Relation))
+			if err != nil {
+				return err
+			}
+			if rows < 0 {
+				rows = int64(node.Rows)
+			}
+			scans = append(scans, FullScan{Table: node.Relation, EstimatedRows: rows, Filtered: node.Filter != ""})
+		}
+		for _, child := range node.Plans {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, plan := range plans {
+		if err := walk(plan.Plan); err != nil {
+			return nil, err
+		}
+	}
+	return scans, nil
+}
+
+func explainMySQLScans(ctx context.Context, conn IReadSession, query string, args []any) ([]FullScan, error) {
+	rows, err := QueryMaps(ctx, conn, "EXPLAIN "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var scans []FullScan
+	for _, row := range rows {
+		if fmt.Sprint(row["type"]) != "ALL" {
+			continue
+		}
+		scan := FullScan{Table: fmt.Sprint(row["table"]), EstimatedRows: -1}
+		if err := convertAssign(&scan.EstimatedRows, row["rows"]); err != nil {
+			return nil, fmt.Errorf("parsing plan: %w", err)
+		}
+		extra, _ := row["Extra"].(string)
+		scan.Filtered = strings.Contains(extra, "Using where")
+		scans = append(scans, scan)
+	}
+	return scans, nil
+}
+
+func explainSQLiteScans(ctx context.Context, conn IReadSession, query string, args []any) ([]FullScan, error) {
+	rows, err := QueryMaps(ctx, conn, "EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+	filtered := strings.Contains(Fingerprint(query), " where ")
+	var scans []FullScan
+	for _, row := range rows {
+		// e.g. "SCAN users", but not "SCAN users USING INDEX idx_users_name" or "SEARCH ..."
+		detail, _ := row["detail"].(string)
+		table, ok := strings.CutPrefix(detail, "SCAN ")
+		if !ok || strings.Contains(table, " USING ") {
+			continue
+		}
+		if strings.HasPrefix(table, "TABLE ") {
+			// SQLite before 3.36: "SCAN TABLE users"
+			table = strings.TrimPrefix(table, "TABLE ")
+		}
+		if table, _, _ = strings.Cut(table, " "); table == "CONSTANT" || table == "SUBQUERY" || strings.HasPrefix(table, "(") {
+			continue
+		}
+		scans = append(scans, FullScan{Table: table, EstimatedRows: -1, Filtered: filtered})
+	}
+	return scans, nil
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// scanPlan maps each result column to the index path of the struct field it is scanned into
+// (see reflect.Value.FieldByIndex). Unmapped columns have a nil path.
+type scanPlan [][]int
+
+type scanPlanKey struct {
+	typ     reflect.Type
+	columns string
+	mapper  uintptr
+}
+
+// maxScanPlans bounds the number of cached scan plans, since queries may select varying columns.
+const maxScanPlans = 4096
+
+// scanPlans caches the scan plans per struct type, result columns and built-in name mapper, so
+// the reflection over a struct is done once instead of for every row.
+var (
+	scanPlans     sync.Map
+	scanPlanCount atomic.Int64
+)
+
+// builtinNameMappers are the name mappers whose plans are cached, identified by their function.
+// Custom mappers may be closures sharing their code, so their function does not identify the
+// mapping.
+var builtinNameMappers = []uintptr{
+	reflect.ValueOf(strings.ToLower).Pointer(),
+	reflect.ValueOf(SnakeCaseNameMapper).Pointer(),
+}
+
+// scanPlanOf returns the scan plan of the struct type for the given result columns. Columns
+// are resolved exactly like createFieldMap resolves them.
+func scanPlanOf(typ reflect.Type, columns []string, mapper NameMapper) scanPlan {
+	if mapper == nil {
+		mapper = DefaultNameMapper
+	}
+	key := scanPlanKey{typ: typ, columns: strings.Join(columns, "\x00"), mapper: reflect.ValueOf(mapper).Pointer()}
+	cached := slices.Contains(builtinNameMappers, key.mapper)
+	if cached {
+		if plan, ok := scanPlans.Load(key); ok {
+			return plan.(scanPlan)
+		}
+	}
+	paths := map[string][]int{}
+	collectFieldPaths(typ, "", nil, mapper, paths)
+	plan := make(scanPlan, len(columns))
+	for i, col := range columns {
+		plan[i] = paths[col]
+	}
+	if cached {
+		if scanPlanCount.Add(1) > maxScanPlans {
+			scanPlans.Clear()
+			scanPlanCount.Store(1)
+		}
+		scanPlans.Store(key, plan)
+	}
+	return plan
+}
+
+// collectFieldPaths adds the index paths of all mapped fields of a struct type, keyed by column.
+func collectFieldPaths(typ reflect.Type, prefix string, index []int, mapper NameMapper, paths map[string][]int) {
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+		// Skip unexported fields
+		if !fieldType.IsExported() {
+			continue
+		}
+		path := append(index[:len(index):len(index)], i)
+		// Handle embedded structs
+		if fieldType.Type.Kind() == reflect.Struct && fieldType.Anonymous {
+			collectFieldPaths(fieldType.Type, prefix, path, mapper, paths)
+			continue
+		}
+		// Handle non-embedded nested structs (except leaf types like time.Time)
+		if fieldType.Type.Kind() == reflect.Struct && !isLeafType(fieldType.Type) {
+			nestedPrefix := columnNameOf(fieldType, mapper)
+			if prefix != "" {
+				nestedPrefix = prefix + "_" + nestedPrefix
+			}
+			collectFieldPaths(fieldType.Type, nestedPrefix, path, mapper, paths)
+			continue
+		}
+		columnName := columnNameOf(fieldType, mapper)
+		if prefix != "" {
+			columnName = prefix + "_" + columnName
+		}
+		paths[columnName] = path
+	}
+}
+
+// scanDestinations sets the scan destinations of a row to the fields of item according to
+// the plan. Unmapped columns are scanned into dummy.
+func (p scanPlan) scanDestinations(item reflect.Value, dest []any, dummy *any) {
+	for i, path := range p {
+		if path == nil {
+			dest[i] = dummy
+		} else {
+			dest[i] = item.FieldByIndex(path).Addr().Interface()
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"cmp"
+	"database/sql"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// ScanErrorMode defines how Query reacts to a row that cannot be scanned into the result type.
+type ScanErrorMode int
+
+const (
+	// ScanAbort fails the whole query on the first scan error (default)
+	ScanAbort ScanErrorMode = iota
+	// ScanSkipRow omits rows that cannot be scanned from the result
+	ScanSkipRow
+	// ScanZeroValue keeps rows that cannot be scanned, using zero values for the failing columns
+	ScanZeroValue
+)
+
+// ScanFailure describes a row (and column, if known) that could not be scanned.
+type ScanFailure struct {
+	// Row is the 0-based index of the row within the result set
+	Row int
+	// Column is the name of the failing column, empty if it cannot be determined
+	Column string
+	Err    error
+}
+
+// ScanReport collects the scan failures that were recovered from during a query.
+type ScanReport struct {
+	mu       sync.Mutex
+	Failures []ScanFailure
+}
+
+func (r *ScanReport) add(failure ScanFailure) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Failures = append(r.Failures, failure)
+}
+
+func (r *ScanReport) sort() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	slices.SortStableFunc(r.Failures, func(a, b ScanFailure) int {
+		return cmp.Compare(a.Row, b.Row)
+	})
+}
+
+// WithScanRecovery configures how scan errors are handled, which is needed when reading
+// dirty legacy data. Recovered failures are recorded in the given report (which may be nil).
+func WithScanRecovery(mode ScanErrorMode, report *ScanReport) QueryOption {
+	return func(o *queryOptions) {
+		o.scanMode = mode
+		o.scanReport = report
+	}
+}
+
+// WithNullAsZero maps NULL values to the zero value of fields which can't represent NULL
+// (e.g. string or int64), instead of failing with ErrNullIntoNonNullable. Pointer fields and
+// sql.Null* types receive NULL values regardless of this option.
+func WithNullAsZero() QueryOption {
+	return func(o *queryOptions) {
+		o.nullAsZero = true
+	}
+}
+
+// scanRow scans the current row into dest, applying the configured recovery mode.
+// If keep is false, the row has to be omitted from the result.
+func scanRow(rows *sql.Rows, dest []any, columns []string, row int, opts queryOptions) (keep bool, err error) {
+	scanErr := rows.Scan(dest...)
+	if scanErr == nil {
+		return true, nil
+	}
+	if opts.nullAsZero || opts.scanMode == ScanAbort {
+		// NULL values are zeroed, or reported naming their column
+		if keep, handled, err := scanNulls(rows, dest, columns, row, opts); handled {
+			return keep, err
+		}
+	}
+	switch opts.scanMode {
+	case ScanSkipRow:
+		opts.scanReport.add(ScanFailure{Row: row, Err: scanErr})
+		return false, nil
+	case ScanZeroValue:
+		// Scan raw values again to determine the failing columns
+		raw := make([]any, len(dest))
+		rawDest := make([]any, len(dest))
+		for i := range raw {
+			rawDest[i] = &raw[i]
+		}
+		if err := rows.Scan(rawDest...); err != nil {
+			return false, err
+		}
+		for _, d := range dest {
+			reflect.ValueOf(d).Elem().SetZero()
+		}
+		_, failures, err := assignRaw(dest, columns, raw, row, opts)
+		if err == nil && failures == 0 {
+			opts.scanReport.add(ScanFailure{Row: row, Err: scanErr})
+		}
+		return err == nil, err
+	}
+	return false, scanErr
+}
+
+// scanNulls scans the raw values of the current row again, and assigns them to dest if the row
+// contains NULL values. handled is false if the row contains none, so the scan failed otherwise.
+func scanNulls(rows *sql.Rows, dest []any, columns []string, row int, opts queryOptions) (keep, handled bool, err error) {
+	raw := make([]any, len(dest))
+	rawDest := make([]any, len(dest))
+	for i := range raw {
+		rawDest[i] = &raw[i]
+	}
+	if err := rows.Scan(rawDest...); err != nil {
+		return false, true, err
+	}
+	if !slices.ContainsFunc(raw, func(v any) bool { return v == nil }) {
+		return false, false, nil
+	}
+	for _, d := range dest {
+		reflect.ValueOf(d).Elem().SetZero()
+	}
+	keep, _, err = assignRaw(dest, columns, raw, row, opts)
+	return keep, true, err
+}
+
+// assignRaw converts raw driver values into dest (nil entries are skipped), applying the
+// configured recovery mode. If keep is false, the row has to be omitted from the result.
+func assignRaw(dest []any, columns []string, raw []any, row int, opts queryOptions) (keep bool, failures int, err error) {
+	for i, d := range dest {
+		if d == nil {
+			continue
+		}
+		err := convertAssign(d, raw[i])
+		if err != nil && raw[i] == nil {
+			if opts.nullAsZero {
+				reflect.ValueOf(d).Elem().SetZero()
+				continue
+			}
+			err = NewErrNullIntoNonNullable("column %q is NULL, but %s can't represent it (use a pointer, a sql.Null type or WithNullAsZero)", columns[i], reflect.TypeOf(d).Elem())
+		}
+		if err == nil {
+			continue
+		}
+		switch opts.scanMode {
+		case ScanSkipRow:
+			opts.scanReport.add(ScanFailure{Row: row, Column: columns[i], Err: err})
+			return false, failures + 1, nil
+		case ScanZeroValue:
+			reflect.ValueOf(d).Elem().SetZero()
+			opts.scanReport.add(ScanFailure{Row: row, Column: columns[i], Err: err})
+			failures++
+		default:
+			return false, failures, err
+		}
+	}
+	return true, failures, nil
+}
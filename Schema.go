@@ -0,0 +1,297 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SchemaSnapshot is a driver-neutral description of the tables of a database, used to
+// recreate an empty schema (e.g. for preview environments and tests). It can be serialized
+// as JSON. Column types and default expressions are kept in the syntax of the dialect the
+// snapshot was taken from.
+type SchemaSnapshot struct {
+	Dialect string        `json:"dialect"`
+	Tables  []TableSchema `json:"tables"`
+}
+
+// TableSchema describes a table.
+type TableSchema struct {
+	Name        string             `json:"name"`
+	Columns     []ColumnSchema     `json:"columns"`
+	PrimaryKey  []string           `json:"primaryKey,omitempty"`
+	Indexes     []IndexSchema      `json:"indexes,omitempty"`
+	ForeignKeys []ForeignKeySchema `json:"foreignKeys,omitempty"`
+}
+
+// ColumnSchema describes a column of a table.
+type ColumnSchema struct {
+	Name     string  `json:"name" db:"name"`
+	Type     string  `json:"type" db:"type"`
+	Nullable bool    `json:"nullable" db:"nullable"`
+	Default  *string `json:"default,omitempty" db:"column_default"`
+}
+
+// IndexSchema describes a secondary index of a table.
+type IndexSchema struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+}
+
+// ForeignKeySchema describes a foreign key constraint of a table.
+type ForeignKeySchema struct {
+	Name              string   `json:"name"`
+	Columns           []string `json:"columns"`
+	ReferencedTable   string   `json:"referencedTable"`
+	ReferencedColumns []string `json:"referencedColumns"`
+}
+
+type schemaIndexRow struct {
+	Name    string `db:"name"`
+	Unique  bool   `db:"is_unique"`
+	Primary bool   `db:"is_primary"`
+	Column  string `db:"column_name"`
+}
+
+type schemaForeignKeyRow struct {
+	Name            string `db:"name"`
+	Column          string `db:"column_name"`
+	ReferencedTable string `db:"ref_table"`
+	ReferencedCol   string `db:"ref_column"`
+}
+
+// schemaQueries are the catalog queries of a dialect. All but tables take the table name as
+// only parameter, index and foreign key rows are ordered by name and column position.
+type schemaQueries struct {
+	tables      string
+	columns     string
+	indexes     string
+	foreignKeys string
+}
+
+var schemaCatalog = map[string]schemaQueries{
+	DialectPostgres: {
+		tables: "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name",
+		columns: `SELECT a.attname AS name, format_type(a.atttypid, a.atttypmod) AS type, NOT a.attnotnull AS nullable, pg_get_expr(d.adbin, d.adrelid) AS column_default
+			FROM pg_attribute a LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+			WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped ORDER BY a.attnum`,
+		indexes: `SELECT ic.relname AS name, i.indisunique AS is_unique, i.indisprimary AS is_primary, a.attname AS column_name
+			FROM pg_index i JOIN pg_class ic ON ic.oid = i.indexrelid
+			JOIN LATERAL unnest(i.indkey) WITH ORDINALITY k(attnum, ord) ON true
+			JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = k.attnum
+			WHERE i.indrelid = to_regclass($1) ORDER BY ic.relname, k.ord`,
+		foreignKeys: `SELECT con.conname AS name, a.attname AS column_name, rt.relname AS ref_table, ra.attname AS ref_column
+			FROM pg_constraint con
+			JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY k(col, refcol, ord) ON true
+			JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.col
+			JOIN pg_class rt ON rt.oid = con.confrelid
+			JOIN pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refcol
+			WHERE con.contype = 'f' AND con.conrelid = to_regclass($1) ORDER BY con.conname, k.ord`,
+	},
+	DialectMySQL: {
+		tables: "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME",
+		columns: `SELECT COLUMN_NAME AS name,
+				CONCAT(COLUMN_TYPE, IF(EXTRA LIKE '%auto_increment%', ' AUTO_INCREMENT', '')) AS type,
+				IS_NULLABLE = 'YES' AS nullable,
+				CASE WHEN COLUMN_DEFAULT IS NULL THEN NULL WHEN EXTRA LIKE '%DEFAULT_GENERATED%' THEN CONCAT('(', COLUMN_DEFAULT, ')') ELSE QUOTE(COLUMN_DEFAULT) END AS column_default
+			FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION`,
+		indexes: `SELECT INDEX_NAME AS name, NON_UNIQUE = 0 AS is_unique, INDEX_NAME = 'PRIMARY' AS is_primary, COLUMN_NAME AS column_name
+			FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY INDEX_NAME, SEQ_IN_INDEX`,
+		foreignKeys: `SELECT CONSTRAINT_NAME AS name, COLUMN_NAME AS column_name, REFERENCED_TABLE_NAME AS ref_table, REFERENCED_COLUMN_NAME AS ref_column
+			FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND REFERENCED_TABLE_NAME IS NOT NULL
+			ORDER BY CONSTRAINT_NAME, ORDINAL_POSITION`,
+	},
+	DialectSQLite: {
+		tables:  "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name",
+		columns: `SELECT name, type, "notnull" = 0 AS nullable, dflt_value AS column_default FROM pragma_table_info(?1) ORDER BY cid`,
+		indexes: `SELECT '' AS name, 1 AS is_unique, 1 AS is_primary, name AS column_name, pk AS ord FROM pragma_table_info(?1) WHERE pk > 0
+			UNION ALL
+			SELECT il.name, il."unique", 0, ii.name, ii.seqno FROM pragma_index_list(?1) il JOIN pragma_index_info(il.name) ii WHERE il.origin <> 'pk'
+			ORDER BY 1, 5`,
+		foreignKeys: `SELECT 'fk_' || ?1 || '_' || id AS name, "from" AS column_name, "table" AS ref_table, "to" AS ref_column
+			FROM pragma_foreign_key_list(?1) ORDER BY id, seq`,
+	},
+}
+
+// DumpSchema takes a snapshot of all tables of the current schema (Postgres) or database
+// (MySQL, SQLite), including columns, primary keys, secondary indexes and foreign keys.
+// Views, triggers, sequences and other objects are not included.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to read the catalog from
+//
+// Returns:
+//   - SchemaSnapshot: The schema description
+//   - error: ErrUnsupportedDialect for SQL Server, or the error of a catalog query
+func DumpSchema(ctx context.Context, conn IReadSession) (SchemaSnapshot, error) {
+	d := dialectOf(conn)
+	catalog, ok := schemaCatalog[d.Name()]
+	if !ok {
+		return SchemaSnapshot{}, NewErrUnsupportedDialect("schema snapshots are not supported by %s", d.Name())
+	}
+	tables, err := Query[string](ctx, conn, catalog.tables)
+	if err != nil {
+		return SchemaSnapshot{}, err
+	}
+	snapshot := SchemaSnapshot{Dialect: d.Name(), Tables: make([]TableSchema, 0, len(tables))}
+	for _, table := range tables {
+		schema := TableSchema{Name: table}
+		if schema.Columns, err = Query[ColumnSchema](ctx, conn, catalog.columns, table); err != nil {
+			return SchemaSnapshot{}, fmt.Errorf("columns of %s: %w", table, err)
+		}
+		indexes, err := Query[schemaIndexRow](ctx, conn, catalog.indexes, table)
+		if err != nil {
+			return SchemaSnapshot{}, fmt.Errorf("indexes of %s: %w", table, err)
+		}
+		for _, row := range indexes {
+			switch {
+			case row.Primary:
+				schema.PrimaryKey = append(schema.PrimaryKey, row.Column)
+			case len(schema.Indexes) > 0 && schema.Indexes[len(schema.Indexes)-1].Name == row.Name:
+				last := &schema.Indexes[len(schema.Indexes)-1]
+				last.Columns = append(last.Columns, row.Column)
+			default:
+				schema.Indexes = append(schema.Indexes, IndexSchema{Name: row.Name, Unique: row.Unique, Columns: []string{row.Column}})
+			}
+		}
+		if schema.ForeignKeys, err = foreignKeysOf(ctx, conn, catalog, table); err != nil {
+			return SchemaSnapshot{}, err
+		}
+		snapshot.Tables = append(snapshot.Tables, schema)
+	}
+	return snapshot, nil
+}
+
+// foreignKeysOf reads the foreign keys of a table from the catalog.
+func foreignKeysOf(ctx context.Context, conn IReadSession, catalog schemaQueries, table string) ([]ForeignKeySchema, error) {
+	rows, err := Query[schemaForeignKeyRow](ctx, conn, catalog.foreignKeys, table)
+	if err != nil {
+		return nil, fmt.Errorf("foreign keys of %s: %w", table, err)
+	}
+	var foreignKeys []ForeignKeySchema
+	for _, row := range rows {
+		if n := len(foreignKeys); n > 0 && foreignKeys[n-1].Name == row.Name {
+			foreignKeys[n-1].Columns = append(foreignKeys[n-1].Columns, row.Column)
+			foreignKeys[n-1].ReferencedColumns = append(foreignKeys[n-1].ReferencedColumns, row.ReferencedCol)
+			continue
+		}
+		foreignKeys = append(foreignKeys, ForeignKeySchema{
+			Name:              row.Name,
+			Columns:           []string{row.Column},
+			ReferencedTable:   row.ReferencedTable,
+			ReferencedColumns: []string{row.ReferencedCol},
+		})
+	}
+	return foreignKeys, nil
+}
+
+// ApplySchema creates the tables of a snapshot in an empty database.
+//
+// Tables are created first, followed by their indexes and foreign keys, so tables may
+// reference each other in any order. SQLite does not support adding constraints to existing
+// tables, so foreign keys are declared within CREATE TABLE there, which SQLite permits
+// before the referenced table exists.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to create the schema on (typically a transaction where DDL is
+//     transactional)
+//   - snapshot: Schema to create, usually taken from a database of the same dialect
+//
+// Returns:
+//   - error: The error of the first failing statement
+func ApplySchema(ctx context.Context, conn IWriteSession, snapshot SchemaSnapshot) error {
+	d := dialectOf(conn)
+	inlineForeignKeys := d.Name() == DialectSQLite
+	var indexes, constraints []string
+	for _, table := range snapshot.Tables {
+		stmt, err := createTableStatement(d, table, inlineForeignKeys)
+		if err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("create table %s: %w", table.Name, err)
+		}
+		for _, index := range table.Indexes {
+			unique := ""
+			if index.Unique {
+				unique = "UNIQUE "
+			}
+			name := index.Name
+			if strings.HasPrefix(name, "sqlite_autoindex_") {
+				// Names of implicit SQLite indexes are reserved
+				name = table.Name + "_" + strings.Join(index.Columns, "_") + "_key"
+			}
+			indexes = append(indexes, fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)",
+				unique, d.QuoteIdentifier(name), d.QuoteIdentifier(table.Name), quoteIdentifiers(d, index.Columns)))
+		}
+		if !inlineForeignKeys {
+			for _, fk := range table.ForeignKeys {
+				constraints = append(constraints, fmt.Sprintf("ALTER TABLE %s ADD %s", d.QuoteIdentifier(table.Name), foreignKeyClause(d, fk)))
+			}
+		}
+	}
+	for _, stmt := range append(indexes, constraints...) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+	return nil
+}
+
+func createTableStatement(d IDialect, table TableSchema, inlineForeignKeys bool) (string, error) {
+	if len(table.Columns) == 0 {
+		return "", NewErrInvalidDataType("table %s has no columns", table.Name)
+	}
+	definitions := make([]string, 0, len(table.Columns)+len(table.ForeignKeys)+1)
+	for _, col := range table.Columns {
+		typ, def := col.Type, col.Default
+		if d.Name() == DialectPostgres && def != nil && strings.HasPrefix(*def, "nextval(") {
+			// The sequence of a serial column is created along with the column
+			typ, def = serialType(typ), nil
+		}
+		definition := d.QuoteIdentifier(col.Name) + " " + typ
+		if !col.Nullable {
+			definition += " NOT NULL"
+		}
+		if def != nil {
+			definition += " DEFAULT " + *def
+		}
+		definitions = append(definitions, definition)
+	}
+	if len(table.PrimaryKey) > 0 {
+		definitions = append(definitions, "PRIMARY KEY ("+quoteIdentifiers(d, table.PrimaryKey)+")")
+	}
+	if inlineForeignKeys {
+		for _, fk := range table.ForeignKeys {
+			definitions = append(definitions, foreignKeyClause(d, fk))
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", d.QuoteIdentifier(table.Name), strings.Join(definitions, ", ")), nil
+}
+
+func foreignKeyClause(d IDialect, fk ForeignKeySchema) string {
+	return fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		d.QuoteIdentifier(fk.Name), quoteIdentifiers(d, fk.Columns), d.QuoteIdentifier(fk.ReferencedTable), quoteIdentifiers(d, fk.ReferencedColumns))
+}
+
+func serialType(typ string) string {
+	switch typ {
+	case "smallint":
+		return "smallserial"
+	case "bigint":
+		return "bigserial"
+	}
+	return "serial"
+}
+
+// quoteIdentifiers quotes the identifiers and joins them with commas.
+func quoteIdentifiers(d IDialect, names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = d.QuoteIdentifier(name)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,548 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+)
+
+type cte struct {
+	name    string
+	columns []string
+	query   IStatementBuilder
+}
+
+type join struct {
+	kind  string
+	table Expr
+	on    Expr
+}
+
+type compound struct {
+	op    string
+	query *SelectBuilder
+}
+
+// SelectBuilder builds SELECT statements, including common table expressions, window
+// functions and compound queries (UNION, INTERSECT, EXCEPT).
+//
+// Methods modify the builder and return it, so calls can be chained:
+//
+//	query, args, err := db.Select("id", "name").From("users").Where(db.Eq("active", true)).OrderBy("name").Build(db.Postgres)
+//
+// SelectBuilder implements IStatementBuilder, so it can be executed using QueryStatement, and
+// Expr, so it can be used as subquery within other statements.
+type SelectBuilder struct {
+	ctes      []cte
+	recursive bool
+	distinct  bool
+	columns   []Expr
+	from      Expr
+	joins     []join
+	where     []Expr
+	groupBy   []string
+	having    []Expr
+	orderBy   []orderTerm
+	limit     int
+	offset    int
+	compounds []compound
+	lock      string
+	lockWait  string
+}
+
+// Select starts a SELECT statement. Columns are given as strings (column references like
+// "name" or "u.*" are quoted, other strings are used verbatim) or Expr (e.g. As, Over).
+// Without columns, all columns (*) are selected.
+func Select(columns ...any) *SelectBuilder {
+	b := &SelectBuilder{limit: -1}
+	for _, col := range columns {
+		b.columns = append(b.columns, toExpr(col))
+	}
+	return b
+}
+
+// Distinct selects only distinct rows.
+func (b *SelectBuilder) Distinct() *SelectBuilder {
+	b.distinct = true
+	return b
+}
+
+// From sets the table to select from. An alias may be appended separated by a space
+// (e.g. "users u").
+func (b *SelectBuilder) From(table string) *SelectBuilder {
+	b.from = tableExpr(table)
+	return b
+}
+
+// FromSubquery selects from the result of a subquery with the given alias.
+func (b *SelectBuilder) FromSubquery(query IStatementBuilder, alias string) *SelectBuilder {
+	b.from = subqueryExpr{query: query, alias: alias}
+	return b
+}
+
+// Join adds an INNER JOIN of the table (optionally followed by an alias) on the condition.
+func (b *SelectBuilder) Join(table string, on Expr) *SelectBuilder {
+	b.joins = append(b.joins, join{kind: "JOIN", table: tableExpr(table), on: on})
+	return b
+}
+
+// LeftJoin adds a LEFT JOIN of the table (optionally followed by an alias) on the condition.
+func (b *SelectBuilder) LeftJoin(table string, on Expr) *SelectBuilder {
+	b.joins = append(b.joins, join{kind: "LEFT JOIN", table: tableExpr(table), on: on})
+	return b
+}
+
+// Where adds predicates, combined with AND (also with predicates of earlier calls).
+func (b *SelectBuilder) Where(predicates ...Expr) *SelectBuilder {
+	b.where = append(b.where, predicates...)
+	return b
+}
+
+// GroupBy adds grouping columns.
+func (b *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+// Having adds predicates on groups, combined with AND.
+func (b *SelectBuilder) Having(predicates ...Expr) *SelectBuilder {
+	b.having = append(b.having, predicates...)
+	return b
+}
+
+// OrderBy adds sort terms like "name" or "created_at DESC NULLS LAST". For compound queries,
+// the order applies to the whole result.
+func (b *SelectBuilder) OrderBy(terms ...string) *SelectBuilder {
+	for _, term := range terms {
+		b.orderBy = append(b.orderBy, orderTerm{term: term})
+	}
+	return b
+}
+
+// OrderByFold adds sort terms like OrderBy, comparing their columns case-insensitively
+// (see FoldCase), so the order is consistent across engines regardless of column collations.
+func (b *SelectBuilder) OrderByFold(terms ...string) *SelectBuilder {
+	for _, term := range terms {
+		b.orderBy = append(b.orderBy, orderTerm{term: term, fold: true})
+	}
+	return b
+}
+
+// Limit restricts the number of returned rows. For compound queries, the limit applies to the
+// whole result.
+func (b *SelectBuilder) Limit(n int) *SelectBuilder {
+	b.limit = n
+	return b
+}
+
+// Offset skips the given number of rows.
+func (b *SelectBuilder) Offset(n int) *SelectBuilder {
+	b.offset = n
+	return b
+}
+
+// ForUpdate locks the selected rows for update (FOR UPDATE, or UPDLOCK on SQL Server). Locking
+// is not supported by SQLite.
+func (b *SelectBuilder) ForUpdate() *SelectBuilder {
+	b.lock = "UPDATE"
+	return b
+}
+
+// ForShare locks the selected rows against concurrent updates (FOR SHARE, or HOLDLOCK on SQL
+// Server). Locking is not supported by SQLite.
+func (b *SelectBuilder) ForShare() *SelectBuilder {
+	b.lock = "SHARE"
+	return b
+}
+
+// SkipLocked skips rows locked by other transactions instead of waiting for them, e.g. to
+// dequeue jobs concurrently. Requires ForUpdate or ForShare.
+func (b *SelectBuilder) SkipLocked() *SelectBuilder {
+	b.lockWait = "SKIP LOCKED"
+	return b
+}
+
+// NoWait fails instead of waiting for rows locked by other transactions. Requires ForUpdate or
+// ForShare.
+func (b *SelectBuilder) NoWait() *SelectBuilder {
+	b.lockWait = "NOWAIT"
+	return b
+}
+
+// With adds a common table expression (WITH name (columns) AS (query)) to the statement.
+func (b *SelectBuilder) With(name string, query IStatementBuilder, columns ...string) *SelectBuilder {
+	b.ctes = append(b.ctes, cte{name: name, columns: columns, query: query})
+	return b
+}
+
+// WithRecursive adds a recursive common table expression, whose query (typically an anchor
+// combined with the recursive part using UnionAll) may reference the expression by name.
+func (b *SelectBuilder) WithRecursive(name string, query IStatementBuilder, columns ...string) *SelectBuilder {
+	b.recursive = true
+	return b.With(name, query, columns...)
+}
+
+// Union combines the results with the results of another query, removing duplicates.
+func (b *SelectBuilder) Union(query *SelectBuilder) *SelectBuilder {
+	return b.compound("UNION", query)
+}
+
+// UnionAll combines the results with the results of another query, keeping duplicates.
+func (b *SelectBuilder) UnionAll(query *SelectBuilder) *SelectBuilder {
+	return b.compound("UNION ALL", query)
+}
+
+// Intersect restricts the results to rows also returned by another query.
+func (b *SelectBuilder) Intersect(query *SelectBuilder) *SelectBuilder {
+	return b.compound("INTERSECT", query)
+}
+
+// Except removes the rows returned by another query from the results.
+func (b *SelectBuilder) Except(query *SelectBuilder) *SelectBuilder {
+	return b.compound("EXCEPT", query)
+}
+
+func (b *SelectBuilder) compound(op string, query *SelectBuilder) *SelectBuilder {
+	b.compounds = append(b.compounds, compound{op: op, query: query})
+	return b
+}
+
+// Build implements IStatementBuilder.
+func (b *SelectBuilder) Build(dialect IDialect) (string, []any, error) {
+	w := newSqlWriter(dialect)
+	if err := b.writeStatement(w); err != nil {
+		return "", nil, err
+	}
+	query, args := w.result()
+	return query, args, nil
+}
+
+// writeSQL implements Expr, rendering the statement as parenthesized subquery.
+func (b *SelectBuilder) writeSQL(w *sqlWriter) error {
+	w.write("(")
+	if err := b.writeStatement(w); err != nil {
+		return err
+	}
+	w.write(")")
+	return nil
+}
+
+func (b *SelectBuilder) writeStatement(w *sqlWriter) error {
+	if err := b.checkLock(w.d); err != nil {
+		return err
+	}
+	if err := b.writeWith(w); err != nil {
+		return err
+	}
+	if err := b.writeSelect(w); err != nil {
+		return err
+	}
+	for _, c := range b.compounds {
+		q := c.query
+		if len(q.ctes) > 0 || len(q.orderBy) > 0 || q.limit >= 0 || q.offset > 0 || len(q.compounds) > 0 || q.lock != "" {
+			return NewErrInvalidStatement("%s member must not have WITH, ORDER BY, LIMIT, OFFSET, locking or nested compounds", c.op)
+		}
+		w.write(" " + c.op + " ")
+		if err := q.writeSelect(w); err != nil {
+			return err
+		}
+	}
+	if err := b.writeOrderAndLimit(w); err != nil {
+		return err
+	}
+	if b.lock != "" && w.d.Name() != DialectSQLServer {
+		w.write(" FOR " + b.lock)
+		if b.lockWait != "" {
+			w.write(" " + b.lockWait)
+		}
+	}
+	return nil
+}
+
+// checkLock validates the locking clause for the dialect.
+func (b *SelectBuilder) checkLock(d IDialect) error {
+	if b.lock == "" {
+		if b.lockWait != "" {
+			return NewErrInvalidStatement("%s requires ForUpdate or ForShare", b.lockWait)
+		}
+		return nil
+	}
+	switch {
+	case d.Name() == DialectSQLite:
+		return NewErrUnsupportedDialect("row locking is not supported by %s", d.Name())
+	case len(b.compounds) > 0:
+		return NewErrInvalidStatement("row locking is not supported for compound queries")
+	case d.Name() == DialectSQLServer:
+		if _, ok := b.from.(tableExpr); !ok {
+			return NewErrInvalidStatement("row locking on %s requires selecting from a table", d.Name())
+		}
+	}
+	return nil
+}
+
+// writeLockHints writes the table hints implementing the locking clause on SQL Server.
+func (b *SelectBuilder) writeLockHints(w *sqlWriter) {
+	hints := []string{"UPDLOCK", "ROWLOCK"}
+	if b.lock == "SHARE" {
+		hints = []string{"HOLDLOCK", "ROWLOCK"}
+	}
+	switch b.lockWait {
+	case "SKIP LOCKED":
+		hints = append(hints, "READPAST")
+	case "NOWAIT":
+		hints = append(hints, "NOWAIT")
+	}
+	w.write(" WITH (" + strings.Join(hints, ", ") + ")")
+}
+
+func (b *SelectBuilder) writeWith(w *sqlWriter) error {
+	if len(b.ctes) == 0 {
+		return nil
+	}
+	w.write("WITH ")
+	if b.recursive && w.d.Name() != DialectSQLServer {
+		w.write("RECURSIVE ")
+	}
+	for i, c := range b.ctes {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(w.d.QuoteIdentifier(c.name))
+		if len(c.columns) > 0 {
+			w.write(" (" + quoteIdentifiers(w.d, c.columns) + ")")
+		}
+		w.write(" AS (")
+		if err := writeStatement(w, c.query); err != nil {
+			return err
+		}
+		w.write(")")
+	}
+	w.write(" ")
+	return nil
+}
+
+// writeSelect renders the SELECT ... HAVING part of the statement.
+func (b *SelectBuilder) writeSelect(w *sqlWriter) error {
+	w.write("SELECT ")
+	if b.distinct {
+		w.write("DISTINCT ")
+	}
+	if len(b.columns) == 0 {
+		w.write("*")
+	} else if err := w.list(b.columns, ", "); err != nil {
+		return err
+	}
+	if b.from != nil {
+		w.write(" FROM ")
+		if err := b.from.writeSQL(w); err != nil {
+			return err
+		}
+		if b.lock != "" && w.d.Name() == DialectSQLServer {
+			b.writeLockHints(w)
+		}
+	}
+	for _, j := range b.joins {
+		w.write(" " + j.kind + " ")
+		if err := j.table.writeSQL(w); err != nil {
+			return err
+		}
+		w.write(" ON ")
+		if err := j.on.writeSQL(w); err != nil {
+			return err
+		}
+	}
+	if len(b.where) > 0 {
+		w.write(" WHERE ")
+		if err := And(b.where...).writeSQL(w); err != nil {
+			return err
+		}
+	}
+	if len(b.groupBy) > 0 {
+		w.write(" GROUP BY ")
+		writeColumns(w, b.groupBy)
+	}
+	if len(b.having) > 0 {
+		w.write(" HAVING ")
+		if err := And(b.having...).writeSQL(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *SelectBuilder) writeOrderAndLimit(w *sqlWriter) error {
+	if len(b.orderBy) > 0 {
+		w.write(" ORDER BY ")
+		writeOrderTerms(w, b.orderBy)
+	}
+	if b.limit < 0 && b.offset <= 0 {
+		return nil
+	}
+	switch w.d.Name() {
+	case DialectSQLServer:
+		if len(b.orderBy) == 0 {
+			// OFFSET ... FETCH requires an ORDER BY clause
+			w.write(" ORDER BY (SELECT NULL)")
+		}
+		w.write(" OFFSET " + strconv.Itoa(max(b.offset, 0)) + " ROWS")
+		if b.limit >= 0 {
+			w.write(" FETCH NEXT " + strconv.Itoa(b.limit) + " ROWS ONLY")
+		}
+		return nil
+	case DialectPostgres:
+		if b.limit >= 0 {
+			w.write(" LIMIT " + strconv.Itoa(b.limit))
+		}
+	case DialectMySQL:
+		// MySQL does not support OFFSET without LIMIT
+		if b.limit >= 0 {
+			w.write(" LIMIT " + strconv.Itoa(b.limit))
+		} else {
+			w.write(" LIMIT 18446744073709551615")
+		}
+	default:
+		w.write(" LIMIT " + strconv.Itoa(b.limit))
+	}
+	if b.offset > 0 {
+		w.write(" OFFSET " + strconv.Itoa(b.offset))
+	}
+	return nil
+}
+
+// writeColumns writes a comma separated list of column references.
+func writeColumns(w *sqlWriter, columns []string) {
+	for i, col := range columns {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.column(col)
+	}
+}
+
+// orderTerm is a sort term, compared case-insensitively if fold is set.
+type orderTerm struct {
+	term string
+	fold bool
+}
+
+// plainOrderTerms converts sort terms given as strings.
+func plainOrderTerms(terms []string) []orderTerm {
+	result := make([]orderTerm, len(terms))
+	for i, term := range terms {
+		result[i] = orderTerm{term: term}
+	}
+	return result
+}
+
+// writeOrderTerms writes sort terms, quoting their leading column reference.
+func writeOrderTerms(w *sqlWriter, terms []orderTerm) {
+	for i, term := range terms {
+		if i > 0 {
+			w.write(", ")
+		}
+		col, rest, _ := strings.Cut(strings.TrimSpace(term.term), " ")
+		if term.fold {
+			writeFolded(w, col)
+		} else {
+			w.column(col)
+		}
+		if rest != "" {
+			w.write(" " + rest)
+		}
+	}
+}
+
+// writeStatement renders a statement built by any IStatementBuilder into w. Statements of
+// other builders are rendered with "?" placeholders, which are then renumbered.
+func writeStatement(w *sqlWriter, builder IStatementBuilder) error {
+	if b, ok := builder.(*SelectBuilder); ok {
+		return b.writeStatement(w)
+	}
+	query, args, err := builder.Build(questionMarkDialect{w.d})
+	if err != nil {
+		return err
+	}
+	return w.raw(query, args)
+}
+
+// questionMarkDialect renders "?" placeholders regardless of the wrapped dialect.
+type questionMarkDialect struct {
+	IDialect
+}
+
+// Placeholder implements IDialect.
+func (d questionMarkDialect) Placeholder(n int) string {
+	return "?"
+}
+
+type tableExpr string
+
+func (e tableExpr) writeSQL(w *sqlWriter) error {
+	table, alias, _ := strings.Cut(strings.TrimSpace(string(e)), " ")
+	w.write(w.d.QuoteIdentifier(table))
+	if alias = strings.TrimSpace(alias); alias != "" {
+		w.write(" " + w.d.QuoteIdentifier(alias))
+	}
+	return nil
+}
+
+type subqueryExpr struct {
+	query IStatementBuilder
+	alias string
+}
+
+func (e subqueryExpr) writeSQL(w *sqlWriter) error {
+	w.write("(")
+	if err := writeStatement(w, e.query); err != nil {
+		return err
+	}
+	w.write(") " + w.d.QuoteIdentifier(e.alias))
+	return nil
+}
+
+// ----------------------------------------------------------------------
+// Window functions
+// ----------------------------------------------------------------------
+
+// WindowSpec defines the window of a window function.
+type WindowSpec struct {
+	PartitionBy []string
+	// OrderBy contains sort terms like "created_at DESC"
+	OrderBy []string
+	// Frame is the frame clause, e.g. "ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW"
+	Frame string
+}
+
+type overExpr struct {
+	fn     Expr
+	window WindowSpec
+}
+
+func (e overExpr) writeSQL(w *sqlWriter) error {
+	if err := e.fn.writeSQL(w); err != nil {
+		return err
+	}
+	w.write(" OVER (")
+	sep := ""
+	if len(e.window.PartitionBy) > 0 {
+		w.write("PARTITION BY ")
+		writeColumns(w, e.window.PartitionBy)
+		sep = " "
+	}
+	if len(e.window.OrderBy) > 0 {
+		w.write(sep + "ORDER BY ")
+		writeOrderTerms(w, plainOrderTerms(e.window.OrderBy))
+		sep = " "
+	}
+	if e.window.Frame != "" {
+		w.write(sep + e.window.Frame)
+	}
+	w.write(")")
+	return nil
+}
+
+// Over applies a window function (e.g. "ROW_NUMBER()" or Raw("SUM(amount)")) over a window:
+//
+//	db.Select("id", db.As(db.Over("ROW_NUMBER()", db.WindowSpec{PartitionBy: []string{"customer_id"}, OrderBy: []string{"created_at DESC"}}), "rank"))
+func Over(fn any, window WindowSpec) Expr {
+	return overExpr{fn: toExpr(fn), window: window}
+}
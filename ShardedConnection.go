@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// ShardFunction maps a shard key to the index of one of n shards.
+type ShardFunction func(key string, n int) int
+
+// HashShard maps shard keys to shards by their FNV-1a hash, distributing keys evenly.
+func HashShard(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
+
+// ShardedConnection routes database calls to one of several shards, selected by the shard key
+// attached to the context (see ContextWithShardKey):
+//
+//	conn, err := db.NewShardedConnection(db.HashShard, shard0, shard1, shard2)
+//	ctx = db.ContextWithShardKey(ctx, customerId)
+//	orders, err := db.Query[Order](ctx, conn, "SELECT * FROM orders WHERE customer_id = $1", customerId)
+//
+// Transactions (see ExecuteInTransaction) are begun on the shard of the key, all calls within
+// them are executed on it. Nested transactions join the running transaction regardless of the
+// shard key of their context; use Shard to address a shard explicitly. QueryAllShards fans a
+// query out to all shards. ShardedConnection implements IDbConnection and is safe for
+// concurrent use.
+type ShardedConnection struct {
+	shards []IDbConnection
+	fn     ShardFunction
+}
+
+// NewShardedConnection creates a connection routing calls to the given shards.
+//
+// Parameters:
+//   - fn: Function mapping shard keys to shards (nil = HashShard)
+//   - shards: Connections of the shards, their order must not change once keys are assigned
+//
+// Returns:
+//   - *ShardedConnection: Connection to use as IDbConnection
+//   - error: ErrInvalidConfig if no shard is given
+func NewShardedConnection(fn ShardFunction, shards ...IDbConnection) (*ShardedConnection, error) {
+	if len(shards) == 0 {
+		return nil, NewErrInvalidConfig("sharded connection requires at least one shard")
+	}
+	if fn == nil {
+		fn = HashShard
+	}
+	return &ShardedConnection{shards: shards, fn: fn}, nil
+}
+
+// Shard returns the connection of the shard the given key maps to.
+func (c *ShardedConnection) Shard(key string) IDbConnection {
+	return c.shards[c.fn(key, len(c.shards))]
+}
+
+// Shards returns the connections of all shards.
+func (c *ShardedConnection) Shards() []IDbConnection {
+	return c.shards
+}
+
+// Resolve returns the connection of the shard the key attached to the context maps to.
+func (c *ShardedConnection) Resolve(ctx context.Context) (IDbConnection, error) {
+	key, ok := ShardKeyFromContext(ctx)
+	if !ok {
+		return nil, NewErrMissingShardKey("no shard key attached to context")
+	}
+	return c.Shard(key), nil
+}
+
+// QueryContext implements IDbConnection.
+func (c *ShardedConnection) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	shard, err := c.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return shard.QueryContext(ctx, query, args...)
+}
+
+// ExecContext implements IWriteSession.
+func (c *ShardedConnection) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	shard, err := c.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	session, err := writeSession(shard)
+	if err != nil {
+		return nil, err
+	}
+	return session.ExecContext(ctx, query, args...)
+}
+
+// BeginTx implements IDbConnection.
+func (c *ShardedConnection) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	shard, err := c.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return shard.BeginTx(ctx, opts)
+}
+
+// Dialect returns the dialect of the shards.
+func (c *ShardedConnection) Dialect() IDialect {
+	if len(c.shards) == 0 {
+		return dialectOf(nil)
+	}
+	return dialectOf(c.shards[0])
+}
+
+// NameMapper returns the name mapper of the shards.
+func (c *ShardedConnection) NameMapper() NameMapper {
+	if len(c.shards) == 0 {
+		return nameMapperOf(nil)
+	}
+	return nameMapperOf(c.shards[0])
+}
+
+// QueryAllShards executes a query on all shards concurrently and merges the results in shard
+// order, e.g. for reports across all customers.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Sharded connection
+//   - query: SQL query string
+//   - args: Query arguments (and QueryOptions)
+//
+// Returns:
+//   - []T: Results of all shards
+//   - error: Joined errors of all failed shards, prefixed with their index
+func QueryAllShards[T any](ctx context.Context, conn *ShardedConnection, query string, args ...any) ([]T, error) {
+	results := make([][]T, len(conn.shards))
+	errs := make([]error, len(conn.shards))
+	var wg sync.WaitGroup
+	for i, shard := range conn.shards {
+		wg.Go(func() {
+			result, err := Query[T](ctx, shard, query, args...)
+			if err != nil {
+				errs[i] = fmt.Errorf("shard %d: %w", i, err)
+				return
+			}
+			results[i] = result
+		})
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	var merged []T
+	for _, result := range results {
+		merged = append(merged, result...)
+	}
+	return merged, nil
+}
@@ -0,0 +1,431 @@
+package db
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// WithStatementCache caches up to capacity prepared statements keyed by query text: statements
+// are prepared lazily on their first execution and reused by all further Query and Exec calls,
+// which cuts the per-query parse overhead of hot paths. Once the cache is full, the least
+// recently used statement is closed. The cache is disabled if the connection of the client
+// does not support prepared statements (see IDbPreparer) or capacity is below 1.
+//
+// Statements executed within transactions are not cached. Statements the database refuses to
+// prepare (e.g. multiple statements in one query on MySQL) are executed without preparing them,
+// and remembered so they are not prepared again. Failures to prepare caused by the context or
+// by a lost connection are returned instead. Statements failing since the schema of their
+// tables changed ("cached plan must not change result type") are evicted and prepared again.
+//
+// If the connection is failover-aware (see IReconnectNotifier, e.g. FailoverConnection), the
+// statements prepared on the former connection are closed whenever it reconnects, and prepared
+// again on the new connection in the background.
+func WithStatementCache(capacity int) ClientOption {
+	return func(c *Client) {
+		preparer, ok := c.conn.(IDbPreparer)
+		if !ok || capacity < 1 {
+			return
+		}
+		c.statements = NewStatementCache(preparer, capacity)
+		// Errors are classified like the errors of the client, by its dialect or driver
+		c.statements.classify = c.translate
+		if notifier, ok := c.conn.(IReconnectNotifier); ok {
+			statements := c.statements
+			notifier.OnReconnect(func() {
+				queries := statements.invalidateConnection()
+				go func() {
+					if prepared, err := statements.prepare(context.Background(), queries, true); err != nil {
+						c.logger.Warn("warming statement cache failed", "prepared", prepared, "error", err)
+					}
+				}()
+			})
+		}
+	}
+}
+
+// StatementCache returns the prepared statement cache of the client (nil if not configured).
+func (c *Client) StatementCache() *StatementCache {
+	return c.statements
+}
+
+// StatementCacheStats contains the usage of a statement cache.
+type StatementCacheStats struct {
+	// Hits are the executions reusing a prepared statement, Misses the executions preparing one
+	Hits   int64
+	Misses int64
+	// Evictions are the statements closed to make room for others
+	Evictions int64
+	// Entries is the number of cached statements
+	Entries int
+	// Unpreparable is the number of queries remembered as refused to be prepared, which are
+	// executed without preparing them
+	Unpreparable int
+}
+
+// HitRate returns the share of hits of all executions (0 if there were no executions).
+func (s StatementCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type statementCacheEntry struct {
+	query string
+	stmt  *sql.Stmt
+	// users is the number of calls executing the statement, removed reports whether it is to be
+	// closed once they finished
+	users   int
+	removed bool
+}
+
+// StatementCache is a least recently used cache of prepared statements keyed by query text
+// (see WithStatementCache). StatementCache is safe for concurrent use.
+type StatementCache struct {
+	preparer IDbPreparer
+	capacity int
+	// classify classifies the errors of the database (default: by DefaultDialect)
+	classify func(error) error
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	// unpreparable are the queries the database refused to prepare, at most capacity
+	unpreparable map[string]bool
+	hits         int64
+	misses       int64
+	evictions    int64
+}
+
+// NewStatementCache creates an empty statement cache.
+//
+// Parameters:
+//   - preparer: Connection the statements are prepared on, typically *sql.DB
+//   - capacity: Maximum number of cached statements (at least 1)
+//
+// Returns:
+//   - *StatementCache: The statement cache
+func NewStatementCache(preparer IDbPreparer, capacity int) *StatementCache {
+	return &StatementCache{
+		preparer: preparer,
+		capacity: max(capacity, 1),
+		classify: func(err error) error {
+			return ClassifyError(DefaultDialect, err)
+		},
+		entries:      map[string]*list.Element{},
+		lru:          list.New(),
+		unpreparable: map[string]bool{},
+	}
+}
+
+// QueryContext executes a query using its cached prepared statement, preparing it if needed.
+// Queries the database refuses to prepare are executed on the session.
+func (s *StatementCache) QueryContext(ctx context.Context, session IReadSession, query string, args ...any) (*sql.Rows, error) {
+	for attempt := 0; ; attempt++ {
+		entry, err := s.acquire(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return session.QueryContext(ctx, query, args...)
+		}
+		// The rows keep the statement open until they are closed
+		rows, err := entry.stmt.QueryContext(ctx, args...)
+		s.release(entry)
+		if attempt == 0 && s.evictStale(entry, err) {
+			continue
+		}
+		return rows, err
+	}
+}
+
+// ExecContext executes a statement using its cached prepared statement, preparing it if needed.
+// Statements the database refuses to prepare are executed on the session.
+func (s *StatementCache) ExecContext(ctx context.Context, session IReadWriteSession, query string, args ...any) (sql.Result, error) {
+	for attempt := 0; ; attempt++ {
+		entry, err := s.acquire(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return session.ExecContext(ctx, query, args...)
+		}
+		result, err := entry.stmt.ExecContext(ctx, args...)
+		s.release(entry)
+		if attempt == 0 && s.evictStale(entry, err) {
+			continue
+		}
+		return result, err
+	}
+}
+
+// Invalidate closes the cached statement of a query, e.g. after the schema of its tables
+// changed. The query is prepared again on its next execution, even if the database refused to
+// prepare it before.
+func (s *StatementCache) Invalidate(query string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.unpreparable, query)
+	if elem, ok := s.entries[query]; ok {
+		return s.remove(elem)
+	}
+	return nil
+}
+
+// Clear closes all cached statements and forgets the queries refused to be prepared, e.g. after
+// migrations or a failover to another server. Running queries are not affected; their
+// statements are closed once their rows are closed.
+func (s *StatementCache) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.unpreparable)
+	var errs []error
+	for s.lru.Len() > 0 {
+		errs = append(errs, s.remove(s.lru.Front()))
+	}
+	return errors.Join(errs...)
+}
+
+// Warm prepares all cached statements again, most recently used first, and replaces the cached
+// statements with the new ones. After a failover, the statements prepared on the former server
+// would otherwise be prepared lazily on their next execution, so latency spikes while the cache
+// refills under load. Statements removed from the cache meanwhile are skipped, statements
+// failing to prepare are kept. Each statement is prepared on a single pooled connection of
+// the database; database/sql prepares it on the other pooled connections on their first
+// execution of it, so Warm saves the round trip of the first execution only.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//
+// Returns:
+//   - int: Number of prepared statements
+//   - error: Joined errors of the statements failing to prepare, or the error of the context
+func (s *StatementCache) Warm(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	queries := make([]string, 0, s.lru.Len())
+	for elem := s.lru.Front(); elem != nil; elem = elem.Next() {
+		queries = append(queries, elem.Value.(*statementCacheEntry).query)
+	}
+	s.mu.Unlock()
+	return s.prepare(ctx, queries, false)
+}
+
+// invalidateConnection closes all cached statements, since they have been prepared on a
+// connection that has been lost, and returns their queries, most recently used first.
+func (s *StatementCache) invalidateConnection() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queries := make([]string, 0, s.lru.Len())
+	for s.lru.Len() > 0 {
+		elem := s.lru.Front()
+		queries = append(queries, elem.Value.(*statementCacheEntry).query)
+		s.remove(elem)
+	}
+	// The new connection may accept statements the former one refused
+	clear(s.unpreparable)
+	return queries
+}
+
+// prepare prepares the given queries, most recently used first. If reconnected is set, the
+// statements are added to the cache (after the statements prepared meanwhile, as long as the
+// capacity permits), otherwise they replace the cached statements, skipping queries removed
+// from the cache meanwhile.
+func (s *StatementCache) prepare(ctx context.Context, queries []string, reconnected bool) (int, error) {
+	prepared := 0
+	var errs []error
+	for _, query := range queries {
+		if err := ctx.Err(); err != nil {
+			return prepared, err
+		}
+		if reconnected && s.cached(query) {
+			// Prepared on the new connection by its execution meanwhile
+			continue
+		}
+		stmt, err := s.preparer.PrepareContext(ctx, query)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", query, err))
+			continue
+		}
+		s.mu.Lock()
+		elem, ok := s.entries[query]
+		switch {
+		case ok && !reconnected:
+			previous := elem.Value.(*statementCacheEntry)
+			elem.Value = &statementCacheEntry{query: query, stmt: stmt}
+			previous.retire()
+		case !ok && reconnected && s.lru.Len() < s.capacity:
+			s.entries[query] = s.lru.PushBack(&statementCacheEntry{query: query, stmt: stmt})
+		default:
+			s.mu.Unlock()
+			stmt.Close()
+			continue
+		}
+		s.mu.Unlock()
+		prepared++
+	}
+	return prepared, errors.Join(errs...)
+}
+
+// cached reports whether the query has a cached statement.
+func (s *StatementCache) cached(query string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.entries[query]
+	return ok
+}
+
+// invalidateTables closes the cached statements referencing the given tables (see
+// ClassifyStatement), since their schema changed, and forgets the refusals to prepare them.
+func (s *StatementCache) invalidateTables(d IDialect, tables []string) {
+	references := func(query string) bool {
+		return slices.ContainsFunc(ClassifyStatement(d, query).Tables, func(table string) bool {
+			return slices.Contains(tables, table)
+		})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for elem := s.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if references(elem.Value.(*statementCacheEntry).query) {
+			s.remove(elem)
+		}
+		elem = next
+	}
+	for query := range s.unpreparable {
+		if references(query) {
+			delete(s.unpreparable, query)
+		}
+	}
+}
+
+// Stats returns the usage of the cache.
+func (s *StatementCache) Stats() StatementCacheStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return StatementCacheStats{Hits: s.hits, Misses: s.misses, Evictions: s.evictions, Entries: s.lru.Len(), Unpreparable: len(s.unpreparable)}
+}
+
+// acquire returns the cached statement of the query, preparing and caching it on first use, and
+// protects it from being closed until it is released. It returns nil if the database refuses
+// to prepare the query, and the error of preparing it if it failed otherwise (e.g. since the
+// context is done or the connection has been lost).
+func (s *StatementCache) acquire(ctx context.Context, query string) (*statementCacheEntry, error) {
+	s.mu.Lock()
+	if elem, ok := s.entries[query]; ok {
+		s.lru.MoveToFront(elem)
+		s.hits++
+		entry := elem.Value.(*statementCacheEntry)
+		entry.users++
+		s.mu.Unlock()
+		return entry, nil
+	}
+	if s.unpreparable[query] {
+		s.mu.Unlock()
+		return nil, nil
+	}
+	s.misses++
+	s.mu.Unlock()
+	// Prepare without holding the lock, so other queries are not blocked by the round trip
+	stmt, err := s.preparer.PrepareContext(ctx, query)
+	if err != nil {
+		if ctx.Err() != nil || !s.refused(err) {
+			return nil, err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if len(s.unpreparable) >= s.capacity {
+			clear(s.unpreparable)
+		}
+		s.unpreparable[query] = true
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[query]; ok {
+		// Prepared concurrently
+		stmt.Close()
+		s.lru.MoveToFront(elem)
+		entry := elem.Value.(*statementCacheEntry)
+		entry.users++
+		return entry, nil
+	}
+	entry := &statementCacheEntry{query: query, stmt: stmt, users: 1}
+	s.entries[query] = s.lru.PushFront(entry)
+	for s.lru.Len() > s.capacity {
+		s.remove(s.lru.Back())
+		s.evictions++
+	}
+	return entry, nil
+}
+
+// refused reports whether an error of preparing a statement is a refusal of the database to
+// prepare it, rather than a failure of the connection that may succeed later.
+func (s *StatementCache) refused(err error) bool {
+	return preparationRefused(s.classify(err))
+}
+
+// preparationRefused reports whether a classified error of preparing a statement is a refusal
+// of the database to prepare it, rather than a failure that may not occur again.
+func preparationRefused(err error) bool {
+	return !IsTransient(err) && !errors.Is(err, &ErrConnection{}) && !errors.Is(err, &ErrTimeout{}) &&
+		!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// evictStale evicts the statement of a call if it failed since the schema of its tables changed,
+// reporting whether the call may be repeated with a statement prepared again.
+func (s *StatementCache) evictStale(entry *statementCacheEntry, err error) bool {
+	if err == nil || !staleStatement(err) {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[entry.query]; ok && elem.Value == entry {
+		s.remove(elem)
+	}
+	return true
+}
+
+// staleStatement reports whether a prepared statement failed, since the schema of its tables
+// changed after it has been prepared (e.g. PostgreSQL's "cached plan must not change result
+// type", or MySQL's error 1615).
+func staleStatement(err error) bool {
+	if strings.Contains(err.Error(), "cached plan must not change result type") {
+		return true
+	}
+	match := mysqlErrorPattern.FindStringSubmatch(err.Error())
+	return match != nil && match[1] == "1615"
+}
+
+// release marks the end of a call executing a cached statement, closing the statement if it
+// has been removed from the cache meanwhile.
+func (s *StatementCache) release(entry *statementCacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.users--
+	if entry.removed && entry.users == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// remove removes a cached statement and retires it. The caller must hold the lock.
+func (s *StatementCache) remove(elem *list.Element) error {
+	entry := s.lru.Remove(elem).(*statementCacheEntry)
+	delete(s.entries, entry.query)
+	return entry.retire()
+}
+
+// retire marks a statement removed from the cache, closing it unless it is being executed. The
+// caller must hold the lock of the cache.
+func (e *statementCacheEntry) retire() error {
+	e.removed = true
+	if e.users > 0 {
+		return nil
+	}
+	return e.stmt.Close()
+}
@@ -0,0 +1,305 @@
+package db
+
+import (
+	"slices"
+	"strings"
+)
+
+// StatementType is the type of a SQL statement (see ClassifyStatement).
+type StatementType string
+
+const (
+	// StatementSelect is a query (SELECT, VALUES)
+	StatementSelect StatementType = "select"
+	// StatementInsert inserts rows (INSERT, REPLACE on MySQL and SQLite)
+	StatementInsert StatementType = "insert"
+	// StatementUpdate updates rows
+	StatementUpdate StatementType = "update"
+	// StatementDelete deletes rows
+	StatementDelete StatementType = "delete"
+	// StatementMerge merges rows (MERGE, UPSERT)
+	StatementMerge StatementType = "merge"
+	// StatementDDL defines schema or privileges (CREATE, ALTER, DROP, TRUNCATE, GRANT, ...)
+	StatementDDL StatementType = "ddl"
+	// StatementTransaction controls transactions (BEGIN, COMMIT, ROLLBACK, SAVEPOINT, ...)
+	StatementTransaction StatementType = "transaction"
+	// StatementOther is any other statement (SET, SHOW, EXPLAIN, CALL, ...)
+	StatementOther StatementType = "other"
+)
+
+// StatementClass is the classification of a SQL statement (see ClassifyStatement).
+type StatementClass struct {
+	// Type is the type of the statement, judged by its leading keyword (after CTEs)
+	Type StatementType
+	// Tables are the tables referenced by the statement in order of appearance, excluding CTEs.
+	// Unquoted names are lower-cased, quoted names are unquoted and schema qualified names keep
+	// their schema (e.g. "public.users").
+	Tables []string
+	// Written are the tables modified by the statement (included in Tables)
+	Written []string
+	// Writes reports whether the statement may modify data or schema, including data modifying
+	// CTEs, SELECT INTO and procedure calls
+	Writes bool
+}
+
+// statementTypes are the types of statements by their leading keyword.
+var statementTypes = map[string]StatementType{
+	"select": StatementSelect, "values": StatementSelect,
+	"insert": StatementInsert, "replace": StatementInsert,
+	"update": StatementUpdate,
+	"delete": StatementDelete,
+	"merge":  StatementMerge, "upsert": StatementMerge,
+	"create": StatementDDL, "alter": StatementDDL, "drop": StatementDDL, "truncate": StatementDDL,
+	"rename": StatementDDL, "comment": StatementDDL, "grant": StatementDDL, "revoke": StatementDDL,
+	"begin": StatementTransaction, "start": StatementTransaction, "commit": StatementTransaction,
+	"rollback": StatementTransaction, "savepoint": StatementTransaction, "release": StatementTransaction,
+	"end": StatementTransaction, "abort": StatementTransaction, "save": StatementTransaction,
+}
+
+// writingKeywords are the leading keywords of other statements which may modify data.
+var writingKeywords = map[string]bool{"copy": true, "call": true, "exec": true, "execute": true}
+
+// tableModifiers are the keywords which may precede the table name of a clause, e.g.
+// "INSERT OR REPLACE INTO t" or "DROP TABLE IF EXISTS t".
+var tableModifiers = map[string]bool{
+	"into": true, "from": true, "table": true, "only": true, "lateral": true, "if": true, "not": true,
+	"exists": true, "or": true, "replace": true, "rollback": true, "abort": true, "fail": true,
+	"ignore": true, "low_priority": true, "high_priority": true, "delayed": true, "quick": true,
+}
+
+// listTerminators are the keywords ending a comma separated list of tables (FROM a, b).
+var listTerminators = map[string]bool{
+	"where": true, "group": true, "having": true, "order": true, "limit": true, "offset": true,
+	"union": true, "intersect": true, "except": true, "on": true, "using": true, "join": true,
+	"set": true, "values": true, "returning": true, "for": true, "window": true, "fetch": true,
+	"to": true, "rename": true, "add": true, "cascade": true, "restrict": true,
+}
+
+// ClassifyStatement determines the type of a SQL statement and the tables it references, e.g.
+// to enforce policies, invalidate cached results of modified tables or label metrics:
+//
+//	class := db.ClassifyStatement(db.DefaultDialect, "DELETE FROM orders WHERE id IN (SELECT order_id FROM returns)")
+//	// class.Type == db.StatementDelete, class.Tables == [orders returns], class.Written == [orders]
+//
+// The statement is tokenized like Fingerprint, so comments and literals are ignored. The
+// classification is lexical: tables are taken from FROM, JOIN, INTO, UPDATE, USING, TABLE, VIEW
+// and REFERENCES clauses, including subqueries and CTEs, but tables referenced by views, triggers
+// or procedures are not known. The dialect decides about dialect specific statements, e.g.
+// REPLACE (MySQL and SQLite) and BEGIN blocks (SQL Server). The client classifies statements
+// for its maintenance gate (see Client.EnableMaintenance) and metrics (see MetricLabels).
+//
+// Parameters:
+//   - d: SQL dialect of the statement (DefaultDialect if nil)
+//   - query: SQL statement to classify
+//
+// Returns:
+//   - StatementClass: The type and tables of the statement (StatementOther for unknown
+//     statements)
+func ClassifyStatement(d IDialect, query string) StatementClass {
+	if d == nil {
+		d = DefaultDialect
+	}
+	c := statementClassifier{d: d, tokens: statementTokens(Fingerprint(query)), ctes: map[string]bool{}}
+	c.classify()
+	return c.class
+}
+
+// statementClassifier classifies the tokens of a statement.
+type statementClassifier struct {
+	d      IDialect
+	tokens []string
+	class  StatementClass
+	// ctes are the names of the CTEs of the statement, which are no tables
+	ctes map[string]bool
+}
+
+// classifierScope is the state of a parenthesis level of a statement.
+type classifierScope struct {
+	// query reports whether the level is a statement (top level or subquery)
+	query bool
+	// list reports whether a comma continues a list of tables, written whether they are written
+	list, written bool
+}
+
+func (c *statementClassifier) classify() {
+	scopes := []classifierScope{{query: true}}
+	var inWith, index bool
+	for i := 0; i < len(c.tokens); i++ {
+		tok, prev := c.tokens[i], c.token(i-1)
+		switch tok {
+		case "(":
+			// Subqueries and the definitions of DDL statements (e.g. REFERENCES of CREATE TABLE)
+			_, query := statementTypes[c.token(i+1)]
+			query = query || c.token(i+1) == "with" || c.class.Type == StatementDDL
+			scopes = append(scopes, classifierScope{query: query})
+			continue
+		case ")":
+			if len(scopes) > 1 {
+				scopes = scopes[:len(scopes)-1]
+			}
+			continue
+		}
+		scope := &scopes[len(scopes)-1]
+		if !scope.query {
+			continue
+		}
+		if c.class.Type == "" && len(scopes) == 1 {
+			if tok == "with" && i == 0 {
+				inWith = true
+				continue
+			}
+			typ, ok := c.statementType(i)
+			if inWith && !ok {
+				// CTE names follow WITH, RECURSIVE or the comma after the previous CTE
+				if prev == "with" || prev == "recursive" || prev == "," {
+					c.ctes[unquoteName(tok)] = true
+				}
+				continue
+			}
+			if !ok {
+				typ = StatementOther
+			}
+			c.class.Type = typ
+			c.class.Writes = writingKeywords[tok]
+		}
+		if listTerminators[tok] || tok == ";" {
+			scope.list = false
+		}
+		// Statements start the query, a subquery, a CTE or follow a previous statement
+		start := prev == "" || prev == "(" || prev == ")" || prev == ";"
+		switch {
+		case tok == ",":
+			if scope.list {
+				i = c.table(i+1, scope.written, !scope.written)
+			}
+		case start && (tok == "insert" || tok == "update" || tok == "delete" || tok == "merge" || tok == "upsert" || (tok == "replace" && c.class.Type == StatementInsert)):
+			// The target of DELETE follows FROM (optional on SQL Server and MySQL)
+			i = c.table(i+1, true, false)
+		case tok == "from" || tok == "join":
+			i = c.table(i+1, false, true)
+			scope.list, scope.written = tok == "from", false
+		case tok == "using":
+			i = c.table(i+1, false, true)
+		case tok == "references":
+			i = c.table(i+1, false, false)
+		case tok == "into":
+			i = c.table(i+1, true, false)
+		case tok == "table" || (tok == "truncate" && c.token(i+1) != "table"):
+			written := c.class.Type == StatementDDL
+			i = c.table(i+1, written, false)
+			scope.list, scope.written = written, written
+		case tok == "view" && c.class.Type == StatementDDL:
+			i = c.table(i+1, true, false)
+		case tok == "index" && c.class.Type == StatementDDL:
+			index = true
+		case tok == "on" && index && len(scopes) == 1:
+			i = c.table(i+1, true, false)
+			index = false
+		}
+	}
+	if c.class.Type == "" {
+		c.class.Type = StatementOther
+	}
+	switch c.class.Type {
+	case StatementInsert, StatementUpdate, StatementDelete, StatementMerge, StatementDDL:
+		c.class.Writes = true
+	}
+	c.class.Writes = c.class.Writes || len(c.class.Written) > 0
+}
+
+// statementType returns the type of the statement starting with the token at position i.
+func (c *statementClassifier) statementType(i int) (StatementType, bool) {
+	tok := c.tokens[i]
+	switch {
+	case tok == "replace" && c.d.Name() != DialectMySQL && c.d.Name() != DialectSQLite:
+		return "", false
+	case tok == "begin" && c.d.Name() == DialectSQLServer:
+		// BEGIN starts a block unless followed by TRAN[SACTION]
+		if next := c.token(i + 1); next != "tran" && next != "transaction" && next != "distributed" {
+			return "", false
+		}
+	}
+	typ, ok := statementTypes[tok]
+	return typ, ok
+}
+
+// token returns the token at position i ("" if out of range).
+func (c *statementClassifier) token(i int) string {
+	if i < 0 || i >= len(c.tokens) {
+		return ""
+	}
+	return c.tokens[i]
+}
+
+// table records the table named at position i, after optional modifiers. Subqueries,
+// placeholders and (if function is set) table functions are skipped. It returns the position of
+// the last consumed token.
+func (c *statementClassifier) table(i int, written, function bool) int {
+	for tableModifiers[c.token(i)] {
+		i++
+	}
+	tok := c.token(i)
+	if tok == "" || (function && c.token(i+1) == "(") {
+		return i - 1
+	}
+	if r := []rune(tok)[0]; !isIdentRune(r) && r != '"' && r != '`' && r != '[' {
+		return i - 1
+	}
+	name := unquoteName(tok)
+	if c.ctes[name] {
+		return i
+	}
+	if !slices.Contains(c.class.Tables, name) {
+		c.class.Tables = append(c.class.Tables, name)
+	}
+	if written && !slices.Contains(c.class.Written, name) {
+		c.class.Written = append(c.class.Written, name)
+	}
+	return i
+}
+
+// statementTokens splits a fingerprint into words, parentheses, commas and semicolons. Quoted
+// identifiers are kept together with the names they qualify.
+func statementTokens(fingerprint string) []string {
+	var tokens []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	runes := []rune(fingerprint)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == ' ':
+			flush()
+		case strings.ContainsRune("(),;", r):
+			flush()
+			tokens = append(tokens, string(r))
+		case r == '"' || r == '`' || r == '[':
+			closing := r
+			if r == '[' {
+				closing = ']'
+			}
+			start := i
+			for i++; i < len(runes) && runes[i] != closing; i++ {
+			}
+			current.WriteString(string(runes[start:min(i+1, len(runes))]))
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// unquoteName removes the identifier quotes of a (qualified) name.
+func unquoteName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '"' || r == '`' || r == '[' || r == ']' {
+			return -1
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// KeysetResume configures how a stream resumes after losing its connection (see
+// WithKeysetResume).
+type KeysetResume struct {
+	// Keys are the columns identifying the position within the result (ascending, unique in
+	// combination, e.g. the primary key)
+	Keys []string
+	// MaxResumes is the maximum number of resumes per stream (default: 3)
+	MaxResumes int
+	// Delay is the delay before resuming, doubled on every further resume (default: 1s)
+	Delay time.Duration
+	// Logger reports resumes (nil = DefaultLogger)
+	Logger ILogger
+}
+
+// WithKeysetResume makes QueryStream and QueryEach resume a stream that loses its connection
+// part-way, instead of failing a (possibly multi-hour) extraction.
+//
+// The stream is ordered by the keys, so the query itself must not be ordered. If the
+// connection is lost (or the query fails with a transient error), the query is executed again,
+// restricted to the rows after the last result passed to the caller:
+//
+//	SELECT * FROM (<query>) dbx_resume WHERE (keys) > (last keys) ORDER BY keys
+//
+// Results are neither repeated nor skipped, provided the keys are unique. Rows inserted or
+// modified concurrently may be returned according to their position after resuming.
+func WithKeysetResume(resume KeysetResume) QueryOption {
+	return func(o *queryOptions) {
+		o.resume = &resume
+	}
+}
+
+// fnError marks errors returned by the caller's function, which are never resumed.
+type fnError struct {
+	err error
+}
+
+func (e fnError) Error() string {
+	return e.err.Error()
+}
+
+// queryEachResumable executes a keyset ordered stream, resuming it after connection loss.
+func queryEachResumable[T any](ctx context.Context, conn IReadSession, fn func(item T) error, query string, args []any, opts queryOptions) error {
+	resume := *opts.resume
+	if len(resume.Keys) == 0 {
+		return NewErrInvalidStatement("keyset resume requires key columns")
+	}
+	if resume.MaxResumes <= 0 {
+		resume.MaxResumes = 3
+	}
+	if resume.Delay <= 0 {
+		resume.Delay = time.Second
+	}
+	if resume.Logger == nil {
+		resume.Logger = DefaultLogger
+	}
+	keyValues, err := keysetValues[T](resume.Keys, opts.nameMapper)
+	if err != nil {
+		return err
+	}
+	d := dialectOf(conn)
+	var last []any
+	delay := resume.Delay
+	for attempt := 0; ; attempt++ {
+		stmt, stmtArgs := keysetQuery(d, query, args, resume.Keys, last)
+		err := queryEach(ctx, conn, func(item T) error {
+			if err := fn(item); err != nil {
+				return fnError{err}
+			}
+			last = keyValues(item)
+			return nil
+		}, stmt, stmtArgs, opts)
+		if fnErr, ok := err.(fnError); ok {
+			return fnErr.err
+		}
+		if err == nil || attempt >= resume.MaxResumes || ctx.Err() != nil || !isConnectionLost(err) {
+			return err
+		}
+		resume.Logger.Warn("resuming stream after connection loss", "attempt", attempt+1, "position", last, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// keysetValues returns a function reading the key values of a result.
+func keysetValues[T any](keys []string, mapper NameMapper) (func(item T) []any, error) {
+	typ := reflect.TypeFor[T]()
+	if isPrimitiveType(typ) {
+		if len(keys) != 1 {
+			return nil, NewErrInvalidDataType("keyset of %s must consist of one column, got %d", typ, len(keys))
+		}
+		return func(item T) []any { return []any{item} }, nil
+	}
+	columns, err := columnsOf(typ, mapper)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if !slices.Contains(columns, key) {
+			return nil, NewErrColumnMismatch("keyset column %q is not mapped by %s", key, typ)
+		}
+	}
+	return func(item T) []any {
+		values, _ := columnValues(item, mapper)
+		result := make([]any, len(keys))
+		for i, key := range keys {
+			result[i] = values[key]
+		}
+		return result
+	}, nil
+}
+
+// keysetQuery wraps a query, ordering it by the keys and restricting it to the rows after the
+// given key values (if any). The predicate is expanded, since not all engines support row
+// value comparisons: k1 > v1 OR (k1 = v1 AND k2 > v2) ...
+func keysetQuery(d IDialect, query string, args []any, keys []string, after []any) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString("SELECT * FROM (" + query + ") dbx_resume")
+	if after != nil {
+		args = slices.Clone(args)
+		sb.WriteString(" WHERE ")
+		for i := range keys {
+			if i > 0 {
+				sb.WriteString(" OR ")
+			}
+			sb.WriteString("(")
+			for j := 0; j <= i; j++ {
+				op := " = "
+				if j == i {
+					op = " > "
+				}
+				if j > 0 {
+					sb.WriteString(" AND ")
+				}
+				args = append(args, after[j])
+				sb.WriteString(d.QuoteIdentifier(keys[j]) + op + d.Placeholder(len(args)))
+			}
+			sb.WriteString(")")
+		}
+	}
+	sb.WriteString(" ORDER BY " + quoteIdentifiers(d, keys))
+	return sb.String(), args
+}
+
+// isConnectionLost reports whether an error indicates a lost connection (or another transient
+// condition), after which re-executing a query may succeed.
+func isConnectionLost(err error) bool {
+	var netErr net.Error
+	return IsTransient(err) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.As(err, &netErr)
+}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+// TenantOpener opens the database of a tenant. It is called lazily on the first access.
+type TenantOpener func(ctx context.Context, tenant string) (*sql.DB, error)
+
+// TenantResolverOptions configures the connection pools of a TenantResolver.
+type TenantResolverOptions struct {
+	// MaxOpenConns limits the open connections per tenant (0 = unlimited)
+	MaxOpenConns int
+	// MaxIdleConns limits the idle connections per tenant (0 = database/sql default)
+	MaxIdleConns int
+	// IdleTimeout closes the database of tenants not accessed for this duration (0 = never)
+	IdleTimeout time.Duration
+	// Clock provides the time idle tenants are determined by (default: DefaultClock)
+	Clock IClock
+}
+
+type tenantEntry struct {
+	db       *sql.DB
+	lastUsed time.Time
+}
+
+// tenantOpening is the opening of a tenant database shared by concurrent calls resolving it.
+type tenantOpening struct {
+	done chan struct{}
+	db   *sql.DB
+	err  error
+}
+
+// TenantResolver routes database calls to the database of the tenant attached to the
+// context (see ContextWithTenant), for deployments with one database per customer.
+//
+// Tenant databases are opened lazily, their connection pools are limited per tenant, and
+// databases of idle tenants are closed again. A database is opened once, without blocking
+// calls of other tenants; concurrent calls of the tenant wait for it and share the result of
+// opening it. TenantResolver implements IDbConnection and
+// is safe for concurrent use.
+type TenantResolver struct {
+	open         TenantOpener
+	opts         TenantResolverOptions
+	clock        IClock
+	mu           sync.Mutex
+	tenants      map[string]*tenantEntry
+	opening      map[string]*tenantOpening
+	lastEviction time.Time
+}
+
+// NewTenantResolver creates a resolver opening tenant databases with the given function.
+//
+// Parameters:
+//   - open: Function opening the database of a tenant
+//   - opts: Optional pool options. If not provided, pools are unlimited and never evicted.
+//
+// Returns:
+//   - *TenantResolver: Resolver to use as IDbConnection
+func NewTenantResolver(open TenantOpener, opts ...TenantResolverOptions) *TenantResolver {
+	r := &TenantResolver{
+		open:    open,
+		clock:   DefaultClock,
+		tenants: map[string]*tenantEntry{},
+		opening: map[string]*tenantOpening{},
+	}
+	if len(opts) > 0 {
+		r.opts = opts[0]
+	}
+	if r.opts.Clock != nil {
+		r.clock = r.opts.Clock
+	}
+	return r
+}
+
+// Resolve returns the database of the tenant attached to the context, opening it if necessary.
+func (r *TenantResolver) Resolve(ctx context.Context) (*sql.DB, error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, NewErrMissingTenant("no tenant attached to context")
+	}
+	r.mu.Lock()
+	now := r.clock.Now()
+	r.evictIdle(now)
+	if entry, ok := r.tenants[tenant]; ok {
+		entry.lastUsed = now
+		r.mu.Unlock()
+		return entry.db, nil
+	}
+	opening, ok := r.opening[tenant]
+	if ok {
+		r.mu.Unlock()
+		select {
+		case <-opening.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return opening.db, opening.err
+	}
+	opening = &tenantOpening{done: make(chan struct{})}
+	r.opening[tenant] = opening
+	r.mu.Unlock()
+	// Open without holding the lock, so calls of other tenants are not blocked by the round trip
+	opening.db, opening.err = r.openTenant(ctx, tenant)
+	r.mu.Lock()
+	delete(r.opening, tenant)
+	if opening.err == nil {
+		r.tenants[tenant] = &tenantEntry{db: opening.db, lastUsed: r.clock.Now()}
+	}
+	r.mu.Unlock()
+	close(opening.done)
+	return opening.db, opening.err
+}
+
+// openTenant opens the database of a tenant and limits its connection pool.
+func (r *TenantResolver) openTenant(ctx context.Context, tenant string) (*sql.DB, error) {
+	db, err := r.open(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	if r.opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(r.opts.MaxOpenConns)
+	}
+	if r.opts.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(r.opts.MaxIdleConns)
+	}
+	return db, nil
+}
+
+// QueryContext implements IDbConnection.
+func (r *TenantResolver) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	db, err := r.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return db.QueryContext(ctx, query, args...)
+}
+
+// ExecContext implements IWriteSession.
+func (r *TenantResolver) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	db, err := r.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return db.ExecContext(ctx, query, args...)
+}
+
+// BeginTx implements IDbConnection.
+func (r *TenantResolver) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	db, err := r.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return db.BeginTx(ctx, opts)
+}
+
+// Tenants returns the tenants whose databases are currently open.
+func (r *TenantResolver) Tenants() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenants := make([]string, 0, len(r.tenants))
+	for tenant := range r.tenants {
+		tenants = append(tenants, tenant)
+	}
+	return tenants
+}
+
+// Close closes the databases of all tenants.
+func (r *TenantResolver) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for tenant, entry := range r.tenants {
+		errs = append(errs, entry.db.Close())
+		delete(r.tenants, tenant)
+	}
+	return errors.Join(errs...)
+}
+
+// evictIdle closes the databases of idle tenants. To keep resolving cheap, idle tenants are
+// searched at most twice per idle timeout.
+func (r *TenantResolver) evictIdle(now time.Time) {
+	if r.opts.IdleTimeout <= 0 || now.Sub(r.lastEviction) < r.opts.IdleTimeout/2 {
+		return
+	}
+	r.lastEviction = now
+	for tenant, entry := range r.tenants {
+		if now.Sub(entry.lastUsed) >= r.opts.IdleTimeout {
+			// Close waits for running queries, so it must not block resolving other tenants
+			go entry.db.Close()
+			delete(r.tenants, tenant)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// TimeoutTier is a named latency budget for database calls, standardizing deadlines across a
+// code base instead of ad-hoc timeouts at every call site.
+type TimeoutTier struct {
+	Name    string
+	Timeout time.Duration
+}
+
+// Default timeout tiers.
+var (
+	TierFast   = TimeoutTier{Name: "fast", Timeout: 250 * time.Millisecond}
+	TierNormal = TimeoutTier{Name: "normal", Timeout: 2 * time.Second}
+	TierBatch  = TimeoutTier{Name: "batch", Timeout: 5 * time.Minute}
+)
+
+// ContextWithTimeoutTier returns a context assigning the database calls executed with it to a
+// timeout tier (see TimeoutTiers).
+func ContextWithTimeoutTier(ctx context.Context, tier TimeoutTier) context.Context {
+	return context.WithValue(ctx, timeoutTierContextKey, tier)
+}
+
+// TimeoutTierFromContext returns the timeout tier attached to the context.
+func TimeoutTierFromContext(ctx context.Context) (TimeoutTier, bool) {
+	tier, ok := ctx.Value(timeoutTierContextKey).(TimeoutTier)
+	return tier, ok
+}
+
+// WithTimeoutTier assigns a query to a timeout tier, overriding the tier of the context.
+func WithTimeoutTier(tier TimeoutTier) QueryOption {
+	return func(o *queryOptions) {
+		o.tier = &tier
+	}
+}
+
+// TierObservation describes a database call executed within a timeout tier.
+type TierObservation struct {
+	Tier      string
+	Statement StatementInfo
+	Duration  time.Duration
+	Err       error
+	// DeadlineExceeded reports whether the call failed because the tier's deadline expired
+	// (as opposed to an earlier deadline of the caller)
+	DeadlineExceeded bool
+}
+
+// TierStats contains the accumulated calls of a timeout tier.
+type TierStats struct {
+	Calls            int64
+	Errors           int64
+	DeadlineExceeded int64
+	Duration         time.Duration
+	MaxDuration      time.Duration
+}
+
+// TimeoutTiersOptions configures TimeoutTiers.
+type TimeoutTiersOptions struct {
+	// Default is the tier of calls without tier (zero = such calls are passed through unchanged)
+	Default TimeoutTier
+	// Observer receives every call executed within a tier, e.g. to record tier-labeled metrics
+	// (nil = statistics only)
+	Observer func(ctx context.Context, observation TierObservation)
+}
+
+// TimeoutTiers applies the deadlines of timeout tiers to database calls and accounts the calls
+// per tier.
+//
+// The tier of a call is taken from WithTimeoutTier, the context (see ContextWithTimeoutTier)
+// or the default tier, in this order. The tier's deadline never extends an earlier deadline of
+// the caller. Query deadlines also cover reading the rows. Transactions are not bounded by
+// tiers, since the deadline of BeginTx would apply to the whole transaction.
+type TimeoutTiers struct {
+	opts  TimeoutTiersOptions
+	mu    sync.Mutex
+	stats map[string]*TierStats
+}
+
+// NewTimeoutTiers creates the middleware for timeout tiers, to be installed using
+// WithInterceptors(tiers.Interceptor()).
+//
+// Parameters:
+//   - opts: Optional default tier and observer
+//
+// Returns:
+//   - *TimeoutTiers: Middleware accounting the calls per tier
+func NewTimeoutTiers(opts ...TimeoutTiersOptions) *TimeoutTiers {
+	t := &TimeoutTiers{stats: map[string]*TierStats{}}
+	if len(opts) > 0 {
+		t.opts = opts[0]
+	}
+	return t
+}
+
+// Interceptor returns the interceptor applying the tiers.
+func (t *TimeoutTiers) Interceptor() Interceptor {
+	return func(ctx context.Context, stmt StatementInfo, next func(ctx context.Context) error) error {
+		tier, ok := TimeoutTierFromContext(ctx)
+		if !ok {
+			tier = t.opts.Default
+		}
+		if tier.Timeout <= 0 || stmt.Operation == OperationBegin {
+			return next(ctx)
+		}
+		tierCtx, cancel := context.WithTimeout(ctx, tier.Timeout)
+		start := time.Now()
+		err := next(tierCtx)
+		duration := time.Since(start)
+		if err == nil && stmt.Operation == OperationQuery {
+			// Rows are read after the call returned, the deadline keeps covering them and
+			// releases the context once it expires
+			context.AfterFunc(tierCtx, cancel)
+		} else {
+			cancel()
+		}
+		observation := TierObservation{
+			Tier:      tier.Name,
+			Statement: stmt,
+			Duration:  duration,
+			Err:       err,
+			// The caller's context is still alive, so the tier's deadline cut the call short
+			DeadlineExceeded: err != nil && ctx.Err() == nil && errors.Is(tierCtx.Err(), context.DeadlineExceeded),
+		}
+		t.record(observation)
+		if t.opts.Observer != nil {
+			t.opts.Observer(ctx, observation)
+		}
+		return err
+	}
+}
+
+func (t *TimeoutTiers) record(o TierObservation) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats, ok := t.stats[o.Tier]
+	if !ok {
+		stats = &TierStats{}
+		t.stats[o.Tier] = stats
+	}
+	stats.Calls++
+	stats.Duration += o.Duration
+	stats.MaxDuration = max(stats.MaxDuration, o.Duration)
+	if o.Err != nil {
+		stats.Errors++
+	}
+	if o.DeadlineExceeded {
+		stats.DeadlineExceeded++
+	}
+}
+
+// Stats returns the accumulated calls per tier name.
+func (t *TimeoutTiers) Stats() map[string]TierStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result := make(map[string]TierStats, len(t.stats))
+	for name, stats := range t.stats {
+		result[name] = *stats
+	}
+	return result
+}
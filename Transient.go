@@ -0,0 +1,35 @@
+package db
+
+import (
+	"errors"
+	"time"
+)
+
+// ITransientError is the contract shared by callers and retry policies for deciding
+// whether and when a failed operation should be retried.
+//
+// All typed errors of this package that represent temporary conditions (serialization
+// failures, deadlocks, connection loss, throttling, ...) implement this interface.
+type ITransientError interface {
+	error
+	// Transient reports whether retrying the operation may succeed
+	Transient() bool
+	// RetryAfter returns the minimum delay before retrying, 0 if unknown
+	RetryAfter() time.Duration
+}
+
+// IsTransient reports whether any error in err's tree is a transient error.
+func IsTransient(err error) bool {
+	var transient ITransientError
+	return errors.As(err, &transient) && transient.Transient()
+}
+
+// RetryAfter returns the retry delay hint of the first transient error in err's tree.
+// The second return value is false if err is not transient.
+func RetryAfter(err error) (time.Duration, bool) {
+	var transient ITransientError
+	if !errors.As(err, &transient) || !transient.Transient() {
+		return 0, false
+	}
+	return transient.RetryAfter(), true
+}
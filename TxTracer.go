@@ -0,0 +1,218 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+)
+
+// TxEvent describes a step of a transaction reported to a TxTracer.
+type TxEvent struct {
+	// ID identifies the transaction within the process, correlating its events
+	ID uint64
+	// Options are the options the transaction is started with (nil = driver defaults)
+	Options *sql.TxOptions
+	// Statement is the executed statement (StatementExecuted only)
+	Statement StatementInfo
+	// Duration is the duration of the step (zero for attempt events)
+	Duration time.Duration
+	// Elapsed is the time since the begin attempt
+	Elapsed time.Duration
+	// Err is the error of the step
+	Err error
+}
+
+// TxTracer receives the state transitions of transactions executed by ExecuteInTransaction,
+// e.g. to integrate APM vendors at a deeper level than interceptors. Implementations must be
+// safe for concurrent use and should return quickly. Embed NopTxTracer to implement only some
+// of the events.
+//
+// Statements are reported if they are executed through the session returned by TxSession. A
+// failing commit is reported by Committed with the error, not followed by RolledBack.
+// Calls joining a running transaction using a savepoint do not report begin, commit or
+// rollback events.
+type TxTracer interface {
+	BeginAttempt(ctx context.Context, event TxEvent)
+	Began(ctx context.Context, event TxEvent)
+	StatementExecuted(ctx context.Context, event TxEvent)
+	CommitAttempt(ctx context.Context, event TxEvent)
+	Committed(ctx context.Context, event TxEvent)
+	RolledBack(ctx context.Context, event TxEvent)
+}
+
+// NopTxTracer ignores all events.
+type NopTxTracer struct{}
+
+func (NopTxTracer) BeginAttempt(ctx context.Context, event TxEvent)      {}
+func (NopTxTracer) Began(ctx context.Context, event TxEvent)             {}
+func (NopTxTracer) StatementExecuted(ctx context.Context, event TxEvent) {}
+func (NopTxTracer) CommitAttempt(ctx context.Context, event TxEvent)     {}
+func (NopTxTracer) Committed(ctx context.Context, event TxEvent)         {}
+func (NopTxTracer) RolledBack(ctx context.Context, event TxEvent)        {}
+
+// WithTxTracer sets the tracer receiving the events of transactions started on the client.
+func WithTxTracer(tracer TxTracer) ClientOption {
+	return func(c *Client) {
+		c.txTracer = tracer
+	}
+}
+
+// TxTracer returns the transaction tracer of the client (nil if none is configured).
+func (c *Client) TxTracer() TxTracer {
+	return c.txTracer
+}
+
+// txTracerOf returns the tracer configured for the given connection (see Client), or nil.
+func txTracerOf(conn any) TxTracer {
+	if provider, ok := conn.(interface{ TxTracer() TxTracer }); ok {
+		return provider.TxTracer()
+	}
+	return nil
+}
+
+var txIds atomic.Uint64
+
+// txTrace reports the events of one transaction to a tracer. A nil *txTrace ignores all events.
+type txTrace struct {
+	tracer TxTracer
+	id     uint64
+	opts   *sql.TxOptions
+	start  time.Time
+}
+
+func newTxTrace(tracer TxTracer, opts *sql.TxOptions) *txTrace {
+	if tracer == nil {
+		return nil
+	}
+	return &txTrace{tracer: tracer, id: txIds.Add(1), opts: opts, start: time.Now()}
+}
+
+func (t *txTrace) event(stepStart time.Time, err error) TxEvent {
+	event := TxEvent{ID: t.id, Options: t.opts, Elapsed: time.Since(t.start), Err: err}
+	if !stepStart.IsZero() {
+		event.Duration = time.Since(stepStart)
+	}
+	return event
+}
+
+// step reports the attempt event (if any), executes fn and reports the completion event.
+func (t *txTrace) step(ctx context.Context, attempt, done func(TxTracer, context.Context, TxEvent), fn func() error) error {
+	if t == nil {
+		return fn()
+	}
+	if attempt != nil {
+		attempt(t.tracer, ctx, t.event(time.Time{}, nil))
+	}
+	start := time.Now()
+	err := fn()
+	done(t.tracer, ctx, t.event(start, err))
+	return err
+}
+
+// statement executes a statement of the transaction, reporting it.
+func (t *txTrace) statement(ctx context.Context, stmt StatementInfo, fn func() error) error {
+	if t == nil {
+		return fn()
+	}
+	start := time.Now()
+	err := fn()
+	event := t.event(start, err)
+	event.Statement = stmt
+	t.tracer.StatementExecuted(ctx, event)
+	return err
+}
+
+// TxSession returns a session executing statements on the transaction passed to a function by
+// ExecuteInTransaction, carrying the settings (dialect, name mapper) of the connection the
+// transaction has been started on, running its interceptors (see WithInterceptors) and
+// reporting statements to its TxTracer.
+//
+//	db.ExecuteInTransaction(ctx, client, func(ctx context.Context, tx *sql.Tx) (int, error) {
+//		return db.QueryOne[int](ctx, db.TxSession(ctx, tx), "SELECT COUNT(*) FROM users")
+//	})
+//
+// Outside of ExecuteInTransaction, the transaction is returned as is.
+func TxSession(ctx context.Context, tx *sql.Tx) IReadWriteSession {
+	scope, ok := ctx.Value(transactionContextKey).(*txScope)
+	if !ok || scope.tx != tx {
+		return tx
+	}
+	return &txSession{tx: tx, scope: scope}
+}
+
+type txSession struct {
+	tx    *sql.Tx
+	scope *txScope
+}
+
+// QueryContext implements IReadSession.
+func (s *txSession) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	stmt := StatementInfo{Operation: OperationQuery, Query: query, Args: args}
+	if err := columnPolicyOf(s.scope.conn).checkStatement(ctx, s.scope.dialect, query); err != nil {
+		return nil, err
+	}
+	s.scope.statements.Add(1)
+	start := time.Now()
+	err := chainInterceptors(ctx, interceptorsOf(s.scope.conn), stmt, func(ctx context.Context) error {
+		return s.scope.trace.statement(ctx, stmt, func() error {
+			var err error
+			rows, err = s.tx.QueryContext(ctx, query, driverArgs(args)...)
+			return err
+		})
+	})
+	s.statementLog().record(ctx, stmt, time.Since(start), -1, err)
+	if c, ok := s.scope.conn.(*Client); ok && err == nil {
+		c.invalidate(ctx, query)
+	}
+	return rows, err
+}
+
+// ExecContext implements IWriteSession.
+func (s *txSession) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	stmt := StatementInfo{Operation: OperationExec, Query: query, Args: args}
+	if err := columnPolicyOf(s.scope.conn).checkStatement(ctx, s.scope.dialect, query); err != nil {
+		return nil, err
+	}
+	s.scope.statements.Add(1)
+	start := time.Now()
+	err := chainInterceptors(ctx, interceptorsOf(s.scope.conn), stmt, func(ctx context.Context) error {
+		return s.scope.trace.statement(ctx, stmt, func() error {
+			var err error
+			result, err = s.tx.ExecContext(ctx, query, driverArgs(args)...)
+			return err
+		})
+	})
+	s.statementLog().record(ctx, stmt, time.Since(start), affectedRows(result, err), err)
+	if c, ok := s.scope.conn.(*Client); ok && err == nil {
+		c.invalidate(ctx, query)
+	}
+	return result, err
+}
+
+// Dialect returns the dialect of the connection the transaction has been started on.
+func (s *txSession) Dialect() IDialect {
+	return s.scope.dialect
+}
+
+// NameMapper returns the name mapper of the connection the transaction has been started on.
+func (s *txSession) NameMapper() NameMapper {
+	return nameMapperOf(s.scope.conn)
+}
+
+// ColumnPolicy returns the column policy of the connection the transaction has been started on.
+func (s *txSession) ColumnPolicy() *ColumnPolicy {
+	return columnPolicyOf(s.scope.conn)
+}
+
+// Metrics returns the metrics collector of the connection the transaction has been started on.
+func (s *txSession) Metrics() IMetrics {
+	return metricsOf(s.scope.conn)
+}
+
+// statementLog returns the statement log of the connection the transaction has been started on.
+func (s *txSession) statementLog() *statementLog {
+	return statementLogOf(s.scope.conn)
+}
@@ -0,0 +1,275 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// UnitOfWork collects inserts and deletes across related tables and executes them in one
+// transaction, ordered by the relations (foreign keys) between the tables, so a batch does
+// not violate constraints mid-way regardless of the order the operations are registered in:
+//
+//	uow := db.NewUnitOfWork().
+//		Relate("order_items", "orders", "products").
+//		Relate("orders", "customers")
+//	db.RegisterInsert(uow, "order_items", items...)
+//	db.RegisterInsert(uow, "orders", order)
+//	db.RegisterInsert(uow, "customers", customer)
+//	err := uow.Commit(ctx, conn)
+//
+// Deletes are executed first, referencing tables before referenced ones, followed by the
+// inserts, referenced tables before referencing ones. Operations on the same table are executed
+// in registration order; rows of self-referencing tables must be registered parents first.
+// Optional operations (e.g. best-effort enrichment writes) can be grouped under a savepoint
+// using Group, so their failure does not abort the transaction.
+// A UnitOfWork is not safe for concurrent use.
+type UnitOfWork struct {
+	// relations maps tables to the tables they reference
+	relations map[string][]string
+	// tables are the tables with registered operations, in registration order
+	tables  []string
+	inserts map[string][]func(ctx context.Context, conn IWriteSession) error
+	deletes map[string][]*DeleteBuilder
+	// savepoint is the savepoint of a group (see Group), empty for the root
+	savepoint string
+	groups    []*UnitOfWork
+	err       error
+}
+
+// NewUnitOfWork creates an empty unit of work.
+func NewUnitOfWork() *UnitOfWork {
+	return &UnitOfWork{
+		relations: map[string][]string{},
+		inserts:   map[string][]func(ctx context.Context, conn IWriteSession) error{},
+		deletes:   map[string][]*DeleteBuilder{},
+	}
+}
+
+// Group returns the group of optional operations executed within the given savepoint of the
+// transaction, after the operations of u. If an operation of the group fails, the transaction
+// is rolled back to the savepoint and continues without the group; the error is reported by
+// Err of the group. Calling Group again with the same name returns the same group.
+//
+//	enrichment := uow.Group("enrichment")
+//	db.RegisterInsert(enrichment, "order_tags", tags...)
+//	if err := uow.Commit(ctx, conn); err == nil && enrichment.Err() != nil {
+//		logger.Warn("order enrichment skipped", "error", enrichment.Err())
+//	}
+//
+// Groups share the relations of u and may be nested; the operations of a group are ordered
+// among themselves.
+func (u *UnitOfWork) Group(savepoint string) *UnitOfWork {
+	for _, group := range u.groups {
+		if group.savepoint == savepoint {
+			return group
+		}
+	}
+	group := &UnitOfWork{
+		relations: u.relations,
+		inserts:   map[string][]func(ctx context.Context, conn IWriteSession) error{},
+		deletes:   map[string][]*DeleteBuilder{},
+		savepoint: savepoint,
+	}
+	u.groups = append(u.groups, group)
+	return group
+}
+
+// Err returns the error the group has been rolled back for by the last commit, or nil.
+func (u *UnitOfWork) Err() error {
+	return u.err
+}
+
+// Relate declares that table references (has foreign keys to) the given tables.
+func (u *UnitOfWork) Relate(table string, references ...string) *UnitOfWork {
+	for _, ref := range references {
+		if !slices.Contains(u.relations[table], ref) {
+			u.relations[table] = append(u.relations[table], ref)
+		}
+	}
+	return u
+}
+
+// RelateSchema declares the relations of all foreign keys of a schema (see DumpSchema).
+func (u *UnitOfWork) RelateSchema(schema SchemaSnapshot) *UnitOfWork {
+	for _, table := range schema.Tables {
+		for _, fk := range table.ForeignKeys {
+			u.Relate(table.Name, fk.ReferencedTable)
+		}
+	}
+	return u
+}
+
+// RelateGraph declares the relations of all foreign keys of a table graph (see
+// DependencyGraph).
+func (u *UnitOfWork) RelateGraph(graph *TableGraph) *UnitOfWork {
+	for _, table := range graph.Tables() {
+		u.Relate(table, graph.References(table)...)
+	}
+	return u
+}
+
+// RegisterInsert registers inserting items into a table (see InsertMany).
+func RegisterInsert[T any](u *UnitOfWork, table string, items ...T) {
+	if len(items) == 0 {
+		return
+	}
+	u.register(table)
+	u.inserts[table] = append(u.inserts[table], func(ctx context.Context, conn IWriteSession) error {
+		_, err := InsertMany(ctx, conn, table, items)
+		return err
+	})
+}
+
+// RegisterDelete registers deleting the rows of a table matching all predicates.
+func (u *UnitOfWork) RegisterDelete(table string, predicates ...Expr) {
+	u.register(table)
+	u.deletes[table] = append(u.deletes[table], Delete(table).Where(predicates...))
+}
+
+func (u *UnitOfWork) register(table string) {
+	if !slices.Contains(u.tables, table) {
+		u.tables = append(u.tables, table)
+	}
+}
+
+// Order returns the tables with registered operations in insert order, referenced tables
+// before referencing ones (deletes use the reverse order). Relations via tables without
+// operations are taken into account.
+//
+// Returns:
+//   - []string: Tables in insert order
+//   - error: ErrInvalidStatement if the relations of the tables are cyclic
+func (u *UnitOfWork) Order() ([]string, error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var order, path []string
+	var visit func(table string) error
+	visit = func(table string) error {
+		switch state[table] {
+		case visited:
+			return nil
+		case visiting:
+			cycle := append(path[slices.Index(path, table):], table)
+			return NewErrInvalidStatement("cyclic relations between tables %s", strings.Join(cycle, " -> "))
+		}
+		state[table] = visiting
+		path = append(path, table)
+		for _, ref := range u.relations[table] {
+			// Rows of self-referencing tables are ordered by registration
+			if ref == table {
+				continue
+			}
+			if err := visit(ref); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[table] = visited
+		order = append(order, table)
+		return nil
+	}
+	for _, table := range u.tables {
+		if err := visit(table); err != nil {
+			return nil, err
+		}
+	}
+	return slices.DeleteFunc(order, func(table string) bool {
+		return !slices.Contains(u.tables, table)
+	}), nil
+}
+
+// Commit executes the registered operations in one transaction (see ExecuteInTransaction) and
+// resets the unit of work if it succeeds.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database connection to execute the operations on
+//   - opts: Optional transaction options
+//
+// Returns:
+//   - error: ErrInvalidStatement if the relations are cyclic, or the error of the first
+//     failing operation (the transaction is rolled back); failing groups do not fail the commit
+func (u *UnitOfWork) Commit(ctx context.Context, conn IDbConnection, opts ...sql.TxOptions) error {
+	order, err := u.Order()
+	if err != nil {
+		return err
+	}
+	_, err = ExecuteInTransaction(ctx, conn, func(ctx context.Context, tx *sql.Tx) (struct{}, error) {
+		return struct{}{}, u.execute(ctx, TxSession(ctx, tx), order)
+	}, opts...)
+	if err != nil {
+		return err
+	}
+	u.reset()
+	return nil
+}
+
+// execute executes the operations of the unit of work in the given order, followed by its groups.
+func (u *UnitOfWork) execute(ctx context.Context, session IReadWriteSession, order []string) error {
+	for _, table := range slices.Backward(order) {
+		for _, stmt := range u.deletes[table] {
+			if _, err := ExecStatement(ctx, session, stmt); err != nil {
+				return err
+			}
+		}
+	}
+	for _, table := range order {
+		for _, insert := range u.inserts[table] {
+			if err := insert(ctx, session); err != nil {
+				return err
+			}
+		}
+	}
+	for _, group := range u.groups {
+		if err := group.executeInSavepoint(ctx, session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executeInSavepoint executes the operations of a group within its savepoint, recording the
+// error of a failing operation. Errors leaving the transaction in an unknown state (failing
+// savepoint statements) are returned.
+func (u *UnitOfWork) executeInSavepoint(ctx context.Context, session IReadWriteSession) error {
+	u.err = nil
+	order, err := u.Order()
+	if err != nil {
+		u.err = err
+		return nil
+	}
+	create, rollback, release := savepointStatements(dialectOf(session), u.savepoint)
+	if _, err := session.ExecContext(ctx, create); err != nil {
+		return err
+	}
+	if err := u.execute(ctx, session, order); err != nil {
+		if _, rollbackErr := session.ExecContext(ctx, rollback); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		u.err = err
+		return nil
+	}
+	if release != "" {
+		if _, err := session.ExecContext(ctx, release); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reset removes the registered operations of the unit of work and its groups.
+func (u *UnitOfWork) reset() {
+	u.tables = nil
+	clear(u.inserts)
+	clear(u.deletes)
+	for _, group := range u.groups {
+		group.reset()
+	}
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"maps"
+	"slices"
+)
+
+type assignment struct {
+	column string
+	value  any
+}
+
+// UpdateBuilder builds UPDATE statements.
+//
+//	stmt := db.Update("users").Set("name", name).Set("version", db.Raw("version + 1")).Where(db.Eq("id", id))
+//
+// UpdateBuilder implements IStatementBuilder, so it can be executed using ExecStatement, or
+// QueryStatement if it returns rows.
+type UpdateBuilder struct {
+	table     string
+	set       []assignment
+	where     []Expr
+	returning returning
+}
+
+// Update starts an UPDATE statement of the given table.
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set assigns a value to a column. The value may be an expression (e.g. Raw("version + 1")).
+func (b *UpdateBuilder) Set(column string, value any) *UpdateBuilder {
+	b.set = append(b.set, assignment{column: column, value: value})
+	return b
+}
+
+// SetMap assigns the values of a map to the columns named by its keys, in key order.
+func (b *UpdateBuilder) SetMap(values map[string]any) *UpdateBuilder {
+	for _, column := range slices.Sorted(maps.Keys(values)) {
+		b.Set(column, values[column])
+	}
+	return b
+}
+
+// Where adds predicates, combined with AND. Without predicates, all rows are updated.
+func (b *UpdateBuilder) Where(predicates ...Expr) *UpdateBuilder {
+	b.where = append(b.where, predicates...)
+	return b
+}
+
+// Returning returns the given columns of the updated rows (RETURNING, or OUTPUT on SQL Server).
+// Not supported by MySQL.
+func (b *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	b.returning = columns
+	return b
+}
+
+// Build implements IStatementBuilder.
+func (b *UpdateBuilder) Build(dialect IDialect) (string, []any, error) {
+	if len(b.set) == 0 {
+		return "", nil, NewErrInvalidStatement("update of %s requires at least one assignment", b.table)
+	}
+	if err := b.returning.check(dialect); err != nil {
+		return "", nil, err
+	}
+	w := newSqlWriter(dialect)
+	w.write("UPDATE " + dialect.QuoteIdentifier(b.table) + " SET ")
+	for i, a := range b.set {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(dialect.QuoteIdentifier(a.column) + " = ")
+		if err := w.arg(a.value); err != nil {
+			return "", nil, err
+		}
+	}
+	b.returning.writeOutput(w, "INSERTED")
+	if len(b.where) > 0 {
+		w.write(" WHERE ")
+		if err := And(b.where...).writeSQL(w); err != nil {
+			return "", nil, err
+		}
+	}
+	b.returning.writeReturning(w)
+	query, args := w.result()
+	return query, args, nil
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Upsert inserts an item into a table or, if a row with the same values in the conflict
+// columns exists, updates all other columns of that row. The columns are derived from T like
+// Query maps them (`db` tags, name mapper of the session), the statement from the session's
+// dialect:
+//   - Postgres, SQLite: INSERT ... ON CONFLICT (...) DO UPDATE SET ...
+//   - MySQL: INSERT ... ON DUPLICATE KEY UPDATE ... (the conflict is detected on any unique key)
+//   - SQL Server: MERGE ... WITH (HOLDLOCK)
+//
+// Primary key columns (tagged `db:"id,pk"`) are never updated, and omitted from the insert if
+// they are zero and no conflict column, so the database generates them. Generated columns
+// (tagged `db:"col,returning"`) are neither inserted nor updated. If T has no columns to update
+// besides these and the conflict columns, existing rows are left unchanged.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session (connection or transaction) to write to
+//   - table: Name of the table
+//   - item: Row to insert or update
+//   - conflictCols: Columns of the primary key or unique constraint identifying the row
+//
+// Returns:
+//   - sql.Result: Result of the statement (affected rows are counted differently per engine)
+//   - error: ErrInvalidDataType if T is not a struct, ErrColumnMismatch if a conflict column is
+//     not mapped by T
+func Upsert[T any](ctx context.Context, conn IWriteSession, table string, item T, conflictCols []string) (sql.Result, error) {
+	mapper := nameMapperOf(conn)
+	columns, err := columnsOf(reflect.TypeFor[T](), mapper)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflictCols) == 0 {
+		return nil, NewErrInvalidStatement("upsert into %s requires conflict columns", table)
+	}
+	for _, col := range conflictCols {
+		if !slices.Contains(columns, col) {
+			return nil, NewErrColumnMismatch("conflict column %q is not mapped by %T", col, item)
+		}
+	}
+	if policy := columnPolicyOf(conn); policy != nil {
+		if columns, err = policy.writableColumns(ctx, table, columns); err != nil {
+			return nil, err
+		}
+		for _, col := range conflictCols {
+			if !slices.Contains(columns, col) {
+				return nil, policy.denied(ctx, table, col)
+			}
+		}
+	}
+	values, err := argValues(item, mapper)
+	if err != nil {
+		return nil, err
+	}
+	// Key and generated columns must not overwrite the values of existing rows
+	typ, itemValue := reflect.TypeFor[T](), reflect.ValueOf(item)
+	paths := map[string][]int{}
+	collectFieldPaths(typ, "", nil, mapper, paths)
+	var keys []string
+	columns = slices.DeleteFunc(slices.Clone(columns), func(col string) bool {
+		field := typ.FieldByIndex(paths[col])
+		if slices.Contains(conflictCols, col) {
+			return false
+		}
+		if hasTagOption(field, "pk") {
+			keys = append(keys, col)
+			return itemValue.FieldByIndex(paths[col]).IsZero()
+		}
+		return hasTagOption(field, "returning")
+	})
+	args := make([]any, len(columns))
+	for i, col := range columns {
+		args[i] = values[col]
+	}
+	updated := slices.DeleteFunc(slices.Clone(columns), func(col string) bool {
+		return slices.Contains(conflictCols, col) || slices.Contains(keys, col)
+	})
+	d := dialectOf(conn)
+	return conn.ExecContext(withPolicyVerified(ctx), upsertStatement(d, table, columns, conflictCols, updated), args...)
+}
+
+// upsertStatement renders the upsert of one row with the given columns for a dialect.
+func upsertStatement(d IDialect, table string, columns, conflictCols, updated []string) string {
+	var sb strings.Builder
+	quotedTable := d.QuoteIdentifier(table)
+	values := placeholders(d, 1, len(columns))
+	switch d.Name() {
+	case DialectSQLServer:
+		sb.WriteString("MERGE INTO " + quotedTable + " WITH (HOLDLOCK) AS target USING (VALUES (" + values + ")) AS source (" + quoteIdentifiers(d, columns) + ") ON ")
+		for i, col := range conflictCols {
+			if i > 0 {
+				sb.WriteString(" AND ")
+			}
+			sb.WriteString("target." + d.QuoteIdentifier(col) + " = source." + d.QuoteIdentifier(col))
+		}
+		if len(updated) > 0 {
+			sb.WriteString(" WHEN MATCHED THEN UPDATE SET " + assignments(d, updated, "source.", ""))
+		}
+		sb.WriteString(" WHEN NOT MATCHED THEN INSERT (" + quoteIdentifiers(d, columns) + ") VALUES (")
+		for i, col := range columns {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString("source." + d.QuoteIdentifier(col))
+		}
+		sb.WriteString(");")
+	case DialectMySQL:
+		sb.WriteString("INSERT INTO " + quotedTable + " (" + quoteIdentifiers(d, columns) + ") VALUES (" + values + ") ON DUPLICATE KEY UPDATE ")
+		if len(updated) == 0 {
+			// Assigning a column to itself leaves the row unchanged
+			col := d.QuoteIdentifier(conflictCols[0])
+			sb.WriteString(col + " = " + col)
+		} else {
+			sb.WriteString(assignments(d, updated, "VALUES(", ")"))
+		}
+	default:
+		sb.WriteString("INSERT INTO " + quotedTable + " (" + quoteIdentifiers(d, columns) + ") VALUES (" + values + ") ON CONFLICT (" + quoteIdentifiers(d, conflictCols) + ") DO ")
+		if len(updated) == 0 {
+			sb.WriteString("NOTHING")
+		} else {
+			sb.WriteString("UPDATE SET " + assignments(d, updated, "EXCLUDED.", ""))
+		}
+	}
+	return sb.String()
+}
+
+// assignments renders "col = <prefix>col<suffix>" for each column.
+func assignments(d IDialect, columns []string, prefix, suffix string) string {
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		quoted := d.QuoteIdentifier(col)
+		parts[i] = quoted + " = " + prefix + quoted + suffix
+	}
+	return strings.Join(parts, ", ")
+}
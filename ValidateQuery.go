@@ -0,0 +1,233 @@
+package db
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// selectListTerminators are the keywords ending the select list of a query.
+var selectListTerminators = map[string]bool{
+	"from": true, "into": true, "where": true, "group": true, "having": true, "order": true,
+	"limit": true, "offset": true, "union": true, "intersect": true, "except": true, "window": true,
+	"fetch": true, "for": true, ";": true,
+}
+
+// selectListModifiers are the keywords which may precede the select list.
+var selectListModifiers = map[string]bool{
+	"distinct": true, "all": true, "distinctrow": true, "straight_join": true, "sql_calc_found_rows": true,
+	"sql_no_cache": true, "high_priority": true,
+}
+
+// ValidateQuery checks the select list of a query against the columns mapped by T, catching
+// typos between SQL and db tags before the query is executed, e.g. in a unit test:
+//
+//	func TestQueries(t *testing.T) {
+//		if _, err := db.ValidateQuery[User](db.Postgres, userQuery); err != nil {
+//			t.Error(err)
+//		}
+//	}
+//
+// The column names of the select list are determined lexically: the alias of an expression,
+// or else the name of a (qualified) column. Expressions without alias are reported, since their
+// column name depends on the database. Only the first SELECT of a query is inspected (after
+// CTEs, and before UNION and the like); "*" and "t.*" select unknown columns, so they are
+// accepted and disable reporting unselected fields. Names are compared exactly, like Query maps
+// result columns: quoted identifiers as written, unquoted ones folded like the dialect folds
+// them (lower case on PostgreSQL, as written otherwise). For primitive types, a single column is
+// expected.
+//
+// Parameters:
+//   - d: SQL dialect of the query (DefaultDialect if nil)
+//   - query: SQL query to validate
+//   - opts: Query options, WithFieldNameMapper is respected
+//
+// Returns:
+//   - []string: Columns mapped by T, which are not selected by the query (e.g. to log them as
+//     warnings, as they keep their zero value)
+//   - error: ErrInvalidStatement if the query is no SELECT, ErrColumnMismatch listing the
+//     selected columns not mapped by T and the expressions without alias, or ErrInvalidDataType
+//     if T can't be mapped
+func ValidateQuery[T any](d IDialect, query string, opts ...QueryOption) ([]string, error) {
+	if d == nil {
+		d = DefaultDialect
+	}
+	var o queryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	items, err := selectList(d, query)
+	if err != nil {
+		return nil, err
+	}
+	typ := reflect.TypeFor[T]()
+	if isPrimitiveType(typ) {
+		if len(items) != 1 || items[0] == "*" {
+			return nil, NewErrColumnMismatch("%s expects a single column, query selects %d", typ, len(items))
+		}
+		return nil, nil
+	}
+	mapped, err := columnsOf(typ, o.nameMapper)
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	star := false
+	selected := map[string]bool{}
+	for _, item := range items {
+		switch {
+		case item == "*":
+			star = true
+		case strings.HasPrefix(item, "("):
+			problems = append(problems, "expression "+item+" has no alias")
+		default:
+			if !slices.Contains(mapped, item) {
+				problems = append(problems, "column "+item+" is not mapped")
+				continue
+			}
+			selected[item] = true
+		}
+	}
+	if len(problems) > 0 {
+		return nil, NewErrColumnMismatch("%s: %s", typ, strings.Join(problems, ", "))
+	}
+	if star {
+		return nil, nil
+	}
+	var unselected []string
+	for _, column := range mapped {
+		if !selected[column] {
+			unselected = append(unselected, column)
+		}
+	}
+	return unselected, nil
+}
+
+// selectList returns the column names of the select list of a query, folded like the dialect
+// folds them. Wildcards are returned as "*", expressions without alias in parentheses.
+func selectList(d IDialect, query string) ([]string, error) {
+	// Tokens keep the case of unquoted names, keywords are compared lower-cased
+	tokens := statementTokens(normalizeStatement(query, false))
+	start, depth := -1, 0
+	for i, tok := range tokens {
+		switch strings.ToLower(tok) {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case "select":
+			if depth == 0 {
+				start = i + 1
+			}
+		}
+		if start >= 0 {
+			break
+		}
+		if keyword := strings.ToLower(tok); i == 0 && keyword != "with" && keyword != "select" && keyword != "(" {
+			break
+		}
+	}
+	if start < 0 {
+		return nil, NewErrInvalidStatement("expected SELECT, got %q", query)
+	}
+	tokens = tokens[start:]
+	// Modifiers preceding the select list, e.g. DISTINCT ON (a) or TOP (10)
+	for len(tokens) > 0 && (selectListModifiers[strings.ToLower(tokens[0])] || strings.EqualFold(tokens[0], "on") || strings.EqualFold(tokens[0], "top")) {
+		modifier := strings.ToLower(tokens[0])
+		tokens = tokens[1:]
+		if modifier != "on" && modifier != "top" || len(tokens) == 0 {
+			continue
+		}
+		if tokens[0] == "(" {
+			tokens = tokens[closingParenthesis(tokens)+1:]
+		} else {
+			tokens = tokens[1:]
+		}
+	}
+	var items []string
+	var item []string
+	depth = 0
+	for _, tok := range append(tokens, ";") {
+		switch {
+		case tok == "(":
+			depth++
+		case tok == ")":
+			depth--
+		case depth == 0 && (tok == "," || selectListTerminators[strings.ToLower(tok)]):
+			if len(item) > 0 {
+				items = append(items, selectItemName(d, item))
+			}
+			item = nil
+			if tok != "," {
+				return items, nil
+			}
+			continue
+		}
+		item = append(item, tok)
+	}
+	return items, nil
+}
+
+// selectItemName returns the column name of an item of a select list.
+func selectItemName(d IDialect, item []string) string {
+	last := item[len(item)-1]
+	previous := ""
+	if len(item) > 1 {
+		previous = strings.ToLower(item[len(item)-2])
+	}
+	switch keyword := strings.ToLower(last); {
+	case len(item) == 1 && (last == "*" || strings.HasSuffix(last, ".*")):
+		return "*"
+	case !isNameToken(last):
+	case len(item) == 1:
+		return columnName(d, last)
+	case previous == "as":
+		return columnName(d, last)
+	case keyword != "end" && keyword != "null" && keyword != "true" && keyword != "false" &&
+		(previous == ")" || previous == "?" || isNameToken(previous)):
+		// Alias without AS, e.g. "count(*) total", "'x' total" or "u.name n"
+		return columnName(d, last)
+	}
+	return "(" + strings.Join(item, " ") + ")"
+}
+
+// isNameToken reports whether a token is a (qualified, optionally quoted) name.
+func isNameToken(tok string) bool {
+	r := []rune(tok)[0]
+	return r == '"' || r == '`' || r == '[' || isIdentRune(r) && !('0' <= r && r <= '9')
+}
+
+// columnName returns the last part of a qualified name as the database reports it, e.g. name of
+// u."name": quoted names as written, unquoted names folded like the dialect folds them.
+func columnName(d IDialect, name string) string {
+	if n := len(name); n > 1 && strings.ContainsRune("\"`]", rune(name[n-1])) {
+		opening := name[n-1]
+		if opening == ']' {
+			opening = '['
+		}
+		if i := strings.LastIndexByte(name[:n-1], opening); i >= 0 {
+			return name[i+1 : n-1]
+		}
+	}
+	name = name[strings.LastIndexByte(name, '.')+1:]
+	if d.Name() == DialectPostgres {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// closingParenthesis returns the index of the parenthesis closing the one at index 0.
+func closingParenthesis(tokens []string) int {
+	depth := 0
+	for i, tok := range tokens {
+		switch tok {
+		case "(":
+			depth++
+		case ")":
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
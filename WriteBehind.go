@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WriteBehindOptions configures a WriteBehind buffer.
+type WriteBehindOptions struct {
+	Table   string
+	Columns []string
+	// FlushInterval is the maximum time rows stay buffered, bounding the loss window
+	// (default: 1s)
+	FlushInterval time.Duration
+	// BatchSize is the maximum number of rows inserted per statement; reaching it triggers an
+	// early flush (default: 500). Batches are smaller if the parameters of BatchSize rows
+	// exceed the limit of the database (e.g. 65535 on PostgreSQL, 2100 on SQL Server).
+	BatchSize int
+	// MaxPending is the maximum number of buffered rows; further rows are rejected with
+	// ErrBufferFull (default: 10 * BatchSize)
+	MaxPending int
+	// Logger reports failed flushes (default: DefaultLogger)
+	Logger ILogger
+}
+
+// WriteBehindStats contains the metrics of a WriteBehind buffer.
+type WriteBehindStats struct {
+	Pending int
+	Written int64
+	// Dropped counts rows rejected by Enqueue or dropped after failed flushes
+	Dropped int64
+	Flushes int64
+	Failed  int64
+}
+
+// WriteBehind buffers rows of a low-criticality table (metrics, counters, access logs, ...) in
+// memory and inserts them in batches.
+//
+// Writes through WriteBehind are NOT durable: Enqueue returns before the row is written, and
+// rows buffered when the process crashes are lost. The loss window is bounded by
+// FlushInterval. Rows of failed flushes are kept for the next flush as long as MaxPending is
+// not exceeded, otherwise they are dropped and counted. Use Exec for all data that must not
+// be lost.
+type WriteBehind struct {
+	conn    IWriteSession
+	opts    WriteBehindOptions
+	mu      sync.Mutex
+	pending [][]any
+	closed  bool
+	flushMu sync.Mutex
+	wake    chan struct{}
+	written atomic.Int64
+	dropped atomic.Int64
+	flushes atomic.Int64
+	failed  atomic.Int64
+}
+
+// NewWriteBehind creates a write-behind buffer inserting into the given session, using the
+// session's dialect. Run has to be started to flush the buffer periodically.
+func NewWriteBehind(conn IWriteSession, opts WriteBehindOptions) *WriteBehind {
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = 10 * opts.BatchSize
+	}
+	if opts.Logger == nil {
+		opts.Logger = DefaultLogger
+	}
+	return &WriteBehind{
+		conn: conn,
+		opts: opts,
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Enqueue buffers a row, given as one value per configured column, for insertion by a later
+// flush. The values are copied, so the caller may reuse its slice.
+//
+// Returns:
+//   - error: ErrBufferFull if MaxPending rows are buffered, ErrBufferClosed after Close, or
+//     ErrInvalidDataType if the number of values does not match the columns
+func (w *WriteBehind) Enqueue(values ...any) error {
+	if len(values) != len(w.opts.Columns) {
+		return NewErrInvalidDataType("expected %d values, got %d", len(w.opts.Columns), len(values))
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return NewErrBufferClosed("write-behind buffer of %s is closed", w.opts.Table)
+	}
+	if len(w.pending) >= w.opts.MaxPending {
+		w.dropped.Add(1)
+		return NewErrBufferFull("write-behind buffer of %s is full (%d rows)", w.opts.Table, len(w.pending))
+	}
+	w.pending = append(w.pending, slices.Clone(values))
+	if len(w.pending) >= w.opts.BatchSize {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Run flushes the buffer every FlushInterval, or earlier when a batch is full, until ctx is
+// done. It then closes the buffer, flushing the remaining rows synchronously.
+func (w *WriteBehind) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return w.Close(context.WithoutCancel(ctx))
+		case <-ticker.C:
+		case <-w.wake:
+		}
+		if err := w.Flush(ctx); err != nil && ctx.Err() == nil {
+			w.opts.Logger.Warn("write-behind flush failed", "table", w.opts.Table, "error", err)
+		}
+	}
+}
+
+// Close rejects further rows and flushes all buffered rows synchronously. It must be called
+// on shutdown (unless Run has been stopped, which closes the buffer).
+func (w *WriteBehind) Close(ctx context.Context) error {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	return w.Flush(ctx)
+}
+
+// Flush inserts all buffered rows in batches.
+//
+// Returns:
+//   - error: Error of the first failing batch; its rows and all later ones are kept
+func (w *WriteBehind) Flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	w.mu.Lock()
+	rows := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+	batchSize := min(w.opts.BatchSize, maxInsertRows(dialectOf(w.conn), max(len(w.opts.Columns), 1)))
+	for len(rows) > 0 {
+		batch := rows[:min(len(rows), batchSize)]
+		w.flushes.Add(1)
+		if err := w.insert(ctx, batch); err != nil {
+			w.failed.Add(1)
+			w.requeue(rows)
+			return err
+		}
+		w.written.Add(int64(len(batch)))
+		rows = rows[len(batch):]
+	}
+	return nil
+}
+
+// Stats returns the metrics of the buffer.
+func (w *WriteBehind) Stats() WriteBehindStats {
+	w.mu.Lock()
+	pending := len(w.pending)
+	w.mu.Unlock()
+	return WriteBehindStats{
+		Pending: pending,
+		Written: w.written.Load(),
+		Dropped: w.dropped.Load(),
+		Flushes: w.flushes.Load(),
+		Failed:  w.failed.Load(),
+	}
+}
+
+// requeue puts the rows of a failed flush back in front of the buffer, dropping the oldest
+// rows exceeding MaxPending.
+func (w *WriteBehind) requeue(rows [][]any) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = slices.Concat(rows, w.pending)
+	if excess := len(w.pending) - w.opts.MaxPending; excess > 0 {
+		w.pending = w.pending[excess:]
+		w.dropped.Add(int64(excess))
+	}
+}
+
+func (w *WriteBehind) insert(ctx context.Context, batch [][]any) error {
+	d := dialectOf(w.conn)
+	tuples := make([]string, len(batch))
+	args := make([]any, 0, len(batch)*len(w.opts.Columns))
+	for i, row := range batch {
+		tuples[i] = "(" + placeholders(d, len(args)+1, len(row)) + ")"
+		args = append(args, row...)
+	}
+	stmt := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		d.QuoteIdentifier(w.opts.Table), quoteIdentifiers(d, w.opts.Columns), strings.Join(tuples, ", "))
+	_, err := w.conn.ExecContext(ctx, stmt, args...)
+	return err
+}
@@ -0,0 +1,23 @@
+module github.com/uoul/go-dbx/cmd/dbxgen
+
+go 1.25.4
+
+require (
+	github.com/uoul/go-dbx v0.0.0
+	modernc.org/sqlite v1.34.5
+)
+
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	github.com/uoul/go-async v1.0.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	modernc.org/libc v1.55.3 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
+)
+
+replace github.com/uoul/go-dbx => ../..
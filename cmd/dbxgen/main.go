@@ -0,0 +1,25 @@
+// Command dbxgen generates Go structs mapping the tables of a database schema. See
+// dbxgen.Run for its flags.
+//
+// The command links the pure Go SQLite driver (-driver sqlite). It is a module of its own, so
+// the driver is no dependency of go-dbx. For other databases, build a command importing their
+// driver and calling dbxgen.Run (see dbxgen.Run), or read a schema snapshot (-schema).
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/uoul/go-dbx/dbxgen"
+	_ "modernc.org/sqlite"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := dbxgen.Run(ctx, os.Args[1:], os.Stdout); err != nil {
+		stop()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,141 @@
+package dbadmin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// HandlerOptions configures the admin handler.
+type HandlerOptions struct {
+	// Breaker is the circuit breaker protecting the client (see db.NewCircuitBreaker), whose
+	// state is reported (nil = not reported)
+	Breaker *db.CircuitBreaker
+}
+
+// Snapshot is the live state of a client, as served by the admin handler.
+type Snapshot struct {
+	Pool         *sql.DBStats             `json:"pool,omitempty"`
+	Breaker      db.BreakerState          `json:"breaker,omitempty"`
+	Cache        *CacheSnapshot           `json:"cache,omitempty"`
+	Labels       map[string]db.LabelStats `json:"labels"`
+	InFlight     []db.InFlightOperation   `json:"inFlight"`
+	SlowQueries  []db.SlowQuery           `json:"slowQueries"`
+	Transactions []db.ActiveTransaction   `json:"transactions"`
+}
+
+// CacheSnapshot contains the cache statistics including the hit rate.
+type CacheSnapshot struct {
+	db.CacheStats
+	HitRate float64
+}
+
+// NewHandler creates an http.Handler exposing the live state of a client as JSON, a small
+// dashboard for operators. Mount it on an internal (authenticated) listener only, since it
+// reveals the structure of the executed statements:
+//
+//	mux.Handle("/debug/dbx/", http.StripPrefix("/debug/dbx", dbadmin.NewHandler(client)))
+//
+// Endpoints:
+//   - GET /: Complete Snapshot
+//   - GET /pool: Connection pool statistics (if the connection is a pool like *sql.DB)
+//   - GET /breaker: State of the circuit breaker (if HandlerOptions.Breaker is set)
+//   - GET /cache: Hit rate of the client's cache (if the cache reports statistics)
+//   - GET /labels: Pool usage per label (see db.WithLabel)
+//   - GET /inflight: Currently executing operations with durations and labels, including
+//     queries whose rows are being read
+//   - GET /slow: Recent slow queries (see db.WithSlowQueryThreshold)
+//   - GET /transactions: Currently open transactions of the process with their callers (see
+//     db.ActiveTransactions)
+//
+// Statements are reported as fingerprints (see db.Fingerprint), never with arguments.
+//
+// Parameters:
+//   - client: Client to inspect
+//   - opts: Optional handler options
+//
+// Returns:
+//   - http.Handler: Handler serving the endpoints
+func NewHandler(client *db.Client, opts ...HandlerOptions) http.Handler {
+	var o HandlerOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, snapshot(client, o))
+	})
+	mux.HandleFunc("GET /pool", func(w http.ResponseWriter, r *http.Request) {
+		stats, ok := client.PoolStats()
+		if !ok {
+			http.Error(w, "connection does not provide pool statistics", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, stats)
+	})
+	mux.HandleFunc("GET /breaker", func(w http.ResponseWriter, r *http.Request) {
+		if o.Breaker == nil {
+			http.Error(w, "no circuit breaker configured", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, o.Breaker.State())
+	})
+	mux.HandleFunc("GET /cache", func(w http.ResponseWriter, r *http.Request) {
+		stats := cacheSnapshot(client)
+		if stats == nil {
+			http.Error(w, "cache does not provide statistics", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, stats)
+	})
+	mux.HandleFunc("GET /labels", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, client.LabelStats())
+	})
+	mux.HandleFunc("GET /inflight", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, client.InFlight())
+	})
+	mux.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, client.SlowQueries())
+	})
+	mux.HandleFunc("GET /transactions", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, db.ActiveTransactions())
+	})
+	return mux
+}
+
+func snapshot(client *db.Client, o HandlerOptions) Snapshot {
+	s := Snapshot{
+		Cache:        cacheSnapshot(client),
+		Labels:       client.LabelStats(),
+		InFlight:     client.InFlight(),
+		SlowQueries:  client.SlowQueries(),
+		Transactions: db.ActiveTransactions(),
+	}
+	if stats, ok := client.PoolStats(); ok {
+		s.Pool = &stats
+	}
+	if o.Breaker != nil {
+		s.Breaker = o.Breaker.State()
+	}
+	return s
+}
+
+func cacheSnapshot(client *db.Client) *CacheSnapshot {
+	cache, ok := client.Cache().(interface{ Stats() db.CacheStats })
+	if !ok {
+		return nil
+	}
+	stats := cache.Stats()
+	return &CacheSnapshot{CacheStats: stats, HitRate: stats.HitRate()}
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(value); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,349 @@
+package dbbackup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// Options configures the database and tools of a backup, restore or export.
+type Options struct {
+	// Dialect selects the tools (db.DialectPostgres: pg_dump/psql, db.DialectMySQL:
+	// mysqldump/mysql)
+	Dialect string
+	// Host of the database server (empty = tool default)
+	Host string
+	// Port of the database server (0 = tool default)
+	Port int
+	// User to connect as (empty = tool default)
+	User string
+	// Password of the user, passed to the tool by environment, never as argument (empty = tool
+	// default, e.g. ~/.pgpass)
+	Password string
+	// Database to back up or restore
+	Database string
+	// Tables restricts a backup to the given tables (empty = all tables)
+	Tables []string
+	// Binary is the path of the tool (empty = looked up in PATH)
+	Binary string
+	// Args are additional arguments passed to the tool
+	Args []string
+	// Progress is called with the number of transferred bytes while the tool runs, and once on
+	// completion (nil = no progress reports)
+	Progress func(Progress)
+	// ProgressInterval is the minimum interval between progress reports (default: 1s)
+	ProgressInterval time.Duration
+}
+
+// String implements fmt.Stringer, redacting the password, so options can be logged safely.
+func (o Options) String() string {
+	password := ""
+	if o.Password != "" {
+		password = "xxxxx"
+	}
+	return fmt.Sprintf("Options{Dialect: %q, Host: %q, Port: %d, User: %q, Password: %q, Database: %q, Tables: %q, Binary: %q, Args: %q}",
+		o.Dialect, o.Host, o.Port, o.User, password, o.Database, o.Tables, o.Binary, o.Args)
+}
+
+// GoString implements fmt.GoStringer, so %#v does not reveal the password either.
+func (o Options) GoString() string {
+	return "dbbackup." + o.String()
+}
+
+// Progress reports the state of a running backup, restore or export.
+type Progress struct {
+	Bytes   int64
+	Elapsed time.Duration
+	Done    bool
+}
+
+// Backup describes a completed backup or export, e.g. to be stored along with it.
+type Backup struct {
+	Dialect  string
+	Database string
+	Started  time.Time
+	Duration time.Duration
+	// Bytes is the size of the backup
+	Bytes int64
+	// SHA256 is the hex encoded checksum of the backup
+	SHA256 string
+}
+
+// Completion markers written by the tools at the end of a successful plain text dump.
+const (
+	postgresDumpComplete = "-- PostgreSQL database dump complete"
+	mysqlDumpComplete    = "-- Dump completed"
+)
+
+// Dump creates a logical backup (plain SQL) using pg_dump or mysqldump and streams it to w,
+// e.g. a file or an object storage upload. MySQL dumps are taken with --single-transaction,
+// consistent for InnoDB tables without locking them.
+//
+// The backup is verified once the tool completes: the tool must exit successfully and the dump
+// must end with the tool's completion marker, so truncated dumps are detected. The returned
+// Backup contains the size and checksum, allowing to Verify the stored copy later on.
+//
+// Parameters:
+//   - ctx: Context, cancelling kills the tool
+//   - w: Writer receiving the dump
+//   - opts: Database and tool options
+//
+// Returns:
+//   - Backup: Size and checksum of the dump
+//   - error: ErrBackupFailed if the tool fails, ErrVerificationFailed if the dump is incomplete
+func Dump(ctx context.Context, w io.Writer, opts Options) (Backup, error) {
+	var args []string
+	switch opts.Dialect {
+	case db.DialectPostgres:
+		args = append(postgresArgs(opts), "--no-password")
+		for _, table := range opts.Tables {
+			args = append(args, "--table", table)
+		}
+	case db.DialectMySQL:
+		args = append(mysqlArgs(opts), "--single-transaction")
+	default:
+		return Backup{}, db.NewErrUnsupportedDialect("backups are not supported for dialect %q", opts.Dialect)
+	}
+	args = append(args, opts.Args...)
+	if opts.Dialect == db.DialectMySQL {
+		args = append(append(args, opts.Database), opts.Tables...)
+	}
+	backup, tail, err := stream(ctx, opts, tool(opts, "pg_dump", "mysqldump"), args, nil, w)
+	if err != nil {
+		return backup, err
+	}
+	marker := postgresDumpComplete
+	if opts.Dialect == db.DialectMySQL {
+		marker = mysqlDumpComplete
+	}
+	if !bytes.Contains(tail, []byte(marker)) {
+		return backup, NewErrVerificationFailed("dump of %s does not end with %q, it may be truncated", opts.Database, marker)
+	}
+	return backup, nil
+}
+
+// Restore restores a plain SQL backup created by Dump using psql or mysql, reading it from r.
+// On Postgres, the backup is restored in a single transaction and stops at the first error.
+//
+// Parameters:
+//   - ctx: Context, cancelling kills the tool
+//   - r: Reader providing the dump
+//   - opts: Database and tool options (Tables is ignored)
+//
+// Returns:
+//   - Backup: Size and checksum of the restored dump
+//   - error: ErrBackupFailed if the tool fails
+func Restore(ctx context.Context, r io.Reader, opts Options) (Backup, error) {
+	var args []string
+	switch opts.Dialect {
+	case db.DialectPostgres:
+		args = append(postgresArgs(opts), "--no-password", "--quiet", "--single-transaction", "--set", "ON_ERROR_STOP=1")
+	case db.DialectMySQL:
+		args = mysqlArgs(opts)
+	default:
+		return Backup{}, db.NewErrUnsupportedDialect("restores are not supported for dialect %q", opts.Dialect)
+	}
+	args = append(args, opts.Args...)
+	if opts.Dialect == db.DialectMySQL {
+		args = append(args, opts.Database)
+	}
+	backup, _, err := stream(ctx, opts, tool(opts, "psql", "mysql"), args, r, io.Discard)
+	return backup, err
+}
+
+// ExportCSV exports a table of a Postgres database using COPY ... TO STDOUT (run by psql) and
+// streams it to w as CSV with a header row. Unlike Dump, no schema is exported, but the export
+// is fast and can be imported by other tools.
+//
+// Parameters:
+//   - ctx: Context, cancelling kills the tool
+//   - w: Writer receiving the CSV
+//   - table: Table to export, optionally qualified by its schema
+//   - opts: Database and tool options (Tables is ignored)
+//
+// Returns:
+//   - Backup: Size and checksum of the export
+//   - error: ErrBackupFailed if the tool fails
+func ExportCSV(ctx context.Context, w io.Writer, table string, opts Options) (Backup, error) {
+	if opts.Dialect != db.DialectPostgres {
+		return Backup{}, db.NewErrUnsupportedDialect("COPY exports are not supported for dialect %q", opts.Dialect)
+	}
+	copyStmt := "COPY " + db.Postgres.QuoteIdentifier(table) + " TO STDOUT WITH (FORMAT csv, HEADER)"
+	args := append(postgresArgs(opts), "--no-password", "--quiet", "--set", "ON_ERROR_STOP=1", "--command", copyStmt)
+	backup, _, err := stream(ctx, opts, tool(opts, "psql", "mysql"), append(args, opts.Args...), nil, w)
+	return backup, err
+}
+
+// Verify checks a stored backup against the size and checksum recorded by Dump or ExportCSV.
+//
+// Parameters:
+//   - r: Reader providing the stored backup
+//   - backup: Backup returned when the backup has been created
+//
+// Returns:
+//   - error: ErrVerificationFailed if the backup differs
+func Verify(r io.Reader, backup Backup) error {
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return err
+	}
+	if n != backup.Bytes {
+		return NewErrVerificationFailed("backup has %d bytes, expected %d", n, backup.Bytes)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != backup.SHA256 {
+		return NewErrVerificationFailed("backup has checksum %s, expected %s", sum, backup.SHA256)
+	}
+	return nil
+}
+
+func tool(opts Options, postgres, mysql string) string {
+	if opts.Binary != "" {
+		return opts.Binary
+	}
+	if opts.Dialect == db.DialectMySQL {
+		return mysql
+	}
+	return postgres
+}
+
+func postgresArgs(opts Options) []string {
+	var args []string
+	if opts.Host != "" {
+		args = append(args, "--host", opts.Host)
+	}
+	if opts.Port != 0 {
+		args = append(args, "--port", strconv.Itoa(opts.Port))
+	}
+	if opts.User != "" {
+		args = append(args, "--username", opts.User)
+	}
+	return append(args, "--dbname", opts.Database)
+}
+
+func mysqlArgs(opts Options) []string {
+	var args []string
+	if opts.Host != "" {
+		args = append(args, "--host", opts.Host)
+	}
+	if opts.Port != 0 {
+		args = append(args, "--port", strconv.Itoa(opts.Port))
+	}
+	if opts.User != "" {
+		args = append(args, "--user", opts.User)
+	}
+	return args
+}
+
+// stream runs a tool, passing stdin (if any) and copying its output to stdout while measuring
+// it. It returns the end of the output, so completion markers can be checked.
+func stream(ctx context.Context, opts Options, binary string, args []string, stdin io.Reader, stdout io.Writer) (Backup, []byte, error) {
+	backup := Backup{Dialect: opts.Dialect, Database: opts.Database, Started: time.Now()}
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Env = os.Environ()
+	if opts.Password != "" {
+		if opts.Dialect == db.DialectMySQL {
+			cmd.Env = append(cmd.Env, "MYSQL_PWD="+opts.Password)
+		} else {
+			cmd.Env = append(cmd.Env, "PGPASSWORD="+opts.Password)
+		}
+	}
+	m := newMeter(opts, backup.Started)
+	stderr := &tailBuffer{size: 4096}
+	cmd.Stderr = stderr
+	if stdin != nil {
+		// Restores measure the consumed input
+		cmd.Stdin = io.TeeReader(stdin, m)
+		cmd.Stdout = stdout
+	} else {
+		cmd.Stdout = io.MultiWriter(stdout, m)
+	}
+	err := cmd.Run()
+	backup.Duration = time.Since(backup.Started)
+	backup.Bytes, backup.SHA256 = m.done()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) || ctx.Err() != nil {
+			return backup, nil, NewErrBackupFailed(strings.TrimSpace(stderr.String()), "%s failed: %v", binary, err)
+		}
+		return backup, nil, err
+	}
+	return backup, m.tail.Bytes(), nil
+}
+
+// meter counts and hashes the transferred bytes, reporting the progress.
+type meter struct {
+	opts       Options
+	start      time.Time
+	lastReport time.Time
+	bytes      int64
+	hash       hash.Hash
+	tail       tailBuffer
+}
+
+func newMeter(opts Options, start time.Time) *meter {
+	if opts.ProgressInterval <= 0 {
+		opts.ProgressInterval = time.Second
+	}
+	return &meter{opts: opts, start: start, lastReport: start, hash: sha256.New(), tail: tailBuffer{size: 256}}
+}
+
+// Write implements io.Writer.
+func (m *meter) Write(p []byte) (int, error) {
+	m.bytes += int64(len(p))
+	m.hash.Write(p)
+	m.tail.Write(p)
+	if m.opts.Progress != nil && time.Since(m.lastReport) >= m.opts.ProgressInterval {
+		m.lastReport = time.Now()
+		m.opts.Progress(Progress{Bytes: m.bytes, Elapsed: time.Since(m.start)})
+	}
+	return len(p), nil
+}
+
+func (m *meter) done() (int64, string) {
+	if m.opts.Progress != nil {
+		m.opts.Progress(Progress{Bytes: m.bytes, Elapsed: time.Since(m.start), Done: true})
+	}
+	return m.bytes, hex.EncodeToString(m.hash.Sum(nil))
+}
+
+// tailBuffer keeps the last size bytes written to it.
+type tailBuffer struct {
+	mu   sync.Mutex
+	size int
+	buf  []byte
+}
+
+// Write implements io.Writer.
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.size {
+		b.buf = append(b.buf[:0], b.buf[len(b.buf)-b.size:]...)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Clone(b.buf)
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.Bytes())
+}
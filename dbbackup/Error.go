@@ -0,0 +1,45 @@
+package dbbackup
+
+import "fmt"
+
+// ----------------------------------------------------------------------
+// ErrBackupFailed
+// ----------------------------------------------------------------------
+type ErrBackupFailed struct {
+	Message string
+	// Stderr is the end of the error output of the tool
+	Stderr string
+}
+
+// Error implements error.
+func (e ErrBackupFailed) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("ErrBackupFailed: %s", e.Message)
+	}
+	return fmt.Sprintf("ErrBackupFailed: %s: %s", e.Message, e.Stderr)
+}
+
+func NewErrBackupFailed(stderr string, format string, args ...any) error {
+	return &ErrBackupFailed{
+		Message: fmt.Sprintf(format, args...),
+		Stderr:  stderr,
+	}
+}
+
+// ----------------------------------------------------------------------
+// ErrVerificationFailed
+// ----------------------------------------------------------------------
+type ErrVerificationFailed struct {
+	Message string
+}
+
+// Error implements error.
+func (e ErrVerificationFailed) Error() string {
+	return fmt.Sprintf("ErrVerificationFailed: %s", e.Message)
+}
+
+func NewErrVerificationFailed(format string, args ...any) error {
+	return &ErrVerificationFailed{
+		Message: fmt.Sprintf(format, args...),
+	}
+}
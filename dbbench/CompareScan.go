@@ -0,0 +1,122 @@
+package dbbench
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// ScanFunction is a hand-written scan of a single row into a value of type T.
+type ScanFunction[T any] func(rows *sql.Rows) (T, error)
+
+// Result contains the measured cost of one scanning strategy, normalized per row.
+type Result struct {
+	Rows         int
+	NsPerRow     float64
+	AllocsPerRow float64
+	BytesPerRow  float64
+}
+
+// String implements fmt.Stringer.
+func (r Result) String() string {
+	return fmt.Sprintf("%10.1f ns/row %8.2f allocs/row %10.1f B/row", r.NsPerRow, r.AllocsPerRow, r.BytesPerRow)
+}
+
+// Report compares the tag-based mapper against a hand-written scan of the same result set.
+type Report struct {
+	Mapper      Result
+	Handwritten Result
+}
+
+// Overhead returns the time spent by the mapper relative to the hand-written scan (1.0 = equal).
+func (r Report) Overhead() float64 {
+	if r.Handwritten.NsPerRow == 0 {
+		return 0
+	}
+	return r.Mapper.NsPerRow / r.Handwritten.NsPerRow
+}
+
+// String implements fmt.Stringer.
+func (r Report) String() string {
+	return fmt.Sprintf("mapper:      %s\nhandwritten: %s\noverhead:    %.2fx", r.Mapper, r.Handwritten, r.Overhead())
+}
+
+// CompareScan benchmarks db.Query[T] against a hand-written scan for the same query.
+//
+// Both strategies execute the query repeatedly using testing.Benchmark, so the measurement
+// includes the driver round trip. Since this part is identical for both strategies, the
+// difference between the results quantifies the overhead of the reflection based mapper.
+// CompareScan can be used from regular programs as well as from benchmarks and tests.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to execute the query on
+//   - scan: Hand-written scan of a single row, used as baseline
+//   - query: SQL query producing the result set to measure
+//   - args: Query parameters
+//
+// Returns:
+//   - Report: Per row measurements of both strategies
+//   - error: Non-nil if the query or one of the scans fails
+func CompareScan[T any](ctx context.Context, conn db.IReadSession, scan ScanFunction[T], query string, args ...any) (Report, error) {
+	var err error
+	mapper := measure(func() (int, error) {
+		result, err := db.Query[T](ctx, conn, query, args...)
+		return len(result), err
+	}, &err)
+	if err != nil {
+		return Report{}, err
+	}
+	handwritten := measure(func() (int, error) {
+		return scanAll(ctx, conn, scan, query, args...)
+	}, &err)
+	if err != nil {
+		return Report{}, err
+	}
+	return Report{Mapper: mapper, Handwritten: handwritten}, nil
+}
+
+func measure(run func() (int, error), errOut *error) Result {
+	var rows int
+	br := testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			n, err := run()
+			if err != nil {
+				*errOut = err
+				return
+			}
+			rows = n
+		}
+	})
+	if *errOut != nil || rows == 0 || br.N == 0 {
+		return Result{Rows: rows}
+	}
+	perRow := float64(br.N) * float64(rows)
+	return Result{
+		Rows:         rows,
+		NsPerRow:     float64(br.T.Nanoseconds()) / perRow,
+		AllocsPerRow: float64(br.MemAllocs) / perRow,
+		BytesPerRow:  float64(br.MemBytes) / perRow,
+	}
+}
+
+func scanAll[T any](ctx context.Context, conn db.IReadSession, scan ScanFunction[T], query string, args ...any) (int, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	result := []T{}
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return 0, err
+		}
+		result = append(result, item)
+	}
+	return len(result), rows.Err()
+}
@@ -0,0 +1,148 @@
+package dbotel
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/uoul/go-dbx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the tracer of this package.
+const instrumentationName = "github.com/uoul/go-dbx/dbotel"
+
+// Attribute keys set on the spans.
+const (
+	AttributeSystem       = attribute.Key("db.system")
+	AttributeStatement    = attribute.Key("db.statement")
+	AttributeRowsReturned = attribute.Key("db.rows_returned")
+	AttributeRowsAffected = attribute.Key("db.rows_affected")
+)
+
+// tracer returns the tracer of the globally registered provider (see otel.SetTracerProvider).
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// systemOf returns the OpenTelemetry database system of the dialect of a session.
+func systemOf(conn any) string {
+	if provider, ok := conn.(interface{ Dialect() db.IDialect }); ok {
+		return dialectSystem(provider.Dialect())
+	}
+	return dialectSystem(db.DefaultDialect)
+}
+
+// dialectSystem returns the OpenTelemetry database system of a dialect.
+func dialectSystem(dialect db.IDialect) string {
+	switch dialect.Name() {
+	case db.DialectPostgres:
+		return "postgresql"
+	case db.DialectSQLServer:
+		return "mssql"
+	default:
+		return dialect.Name()
+	}
+}
+
+// start starts a client span of a database call.
+func start(ctx context.Context, name string, system string, query string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{AttributeSystem.String(system)}
+	if query != "" {
+		attrs = append(attrs, AttributeStatement.String(query))
+	}
+	return tracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// end records the error of a database call (if any) and ends its span.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Query executes db.Query within a span carrying the statement and the number of returned rows.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control, carrying the parent span
+//   - conn: Database session (connection or transaction) to execute the query on
+//   - query: SQL query string to execute
+//   - args: Query parameters and options (see db.Query)
+//
+// Returns:
+//   - []T: Results of the query
+//   - error: Error of db.Query
+func Query[T any](ctx context.Context, conn db.IReadSession, query string, args ...any) (result []T, err error) {
+	ctx, span := start(ctx, "db.Query", systemOf(conn), query)
+	defer func() { end(span, err) }()
+	result, err = db.Query[T](ctx, conn, query, args...)
+	span.SetAttributes(AttributeRowsReturned.Int(len(result)))
+	return result, err
+}
+
+// Exec executes db.Exec within a span carrying the statement and the number of affected rows.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control, carrying the parent span
+//   - conn: Database session (connection or transaction) to execute the statement on
+//   - query: SQL statement to execute
+//   - args: Statement parameters
+//
+// Returns:
+//   - sql.Result: Result of the statement
+//   - error: Error of db.Exec
+func Exec(ctx context.Context, conn db.IWriteSession, query string, args ...any) (result sql.Result, err error) {
+	ctx, span := start(ctx, "db.Exec", systemOf(conn), query)
+	defer func() { end(span, err) }()
+	result, err = db.Exec(ctx, conn, query, args...)
+	if err == nil {
+		if affected, err := result.RowsAffected(); err == nil {
+			span.SetAttributes(AttributeRowsAffected.Int64(affected))
+		}
+	}
+	return result, err
+}
+
+// ExecuteInTransaction executes db.ExecuteInTransaction within a span. The context passed to
+// tsf carries the span, so spans of the calls within the transaction (e.g. using Query or
+// the hooks of this package) become its children and the trace continues across the
+// transaction boundary.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control, carrying the parent span
+//   - conn: Database connection to start the transaction on
+//   - tsf: Function to execute within the transaction
+//   - opts: Optional transaction options
+//
+// Returns:
+//   - T: Result of tsf
+//   - error: Error of db.ExecuteInTransaction
+func ExecuteInTransaction[T any](ctx context.Context, conn db.IDbConnection, tsf db.TransactionScopeFunction[T], opts ...sql.TxOptions) (result T, err error) {
+	ctx, span := start(ctx, "db.ExecuteInTransaction", systemOf(conn), "")
+	defer func() { end(span, err) }()
+	return db.ExecuteInTransaction(ctx, conn, tsf, opts...)
+}
+
+// Interceptor returns an interceptor creating a span for every call of a db.Client or
+// db.DbConnection:
+//
+//	client := db.NewClient(database, db.WithDialect(db.Postgres), db.WithInterceptors(dbotel.Interceptor(db.Postgres)))
+//	conn := db.NewDbConnection(database, dbotel.Interceptor(db.Postgres))
+//
+// Parameters:
+//   - dialect: Dialect of the database, determining the db.system attribute
+//
+// Returns:
+//   - db.Interceptor: Interceptor to install using db.WithInterceptors or db.NewDbConnection
+func Interceptor(dialect db.IDialect) db.Interceptor {
+	system := dialectSystem(dialect)
+	return func(ctx context.Context, stmt db.StatementInfo, next func(ctx context.Context) error) (err error) {
+		ctx, span := start(ctx, "db."+string(stmt.Operation), system, stmt.Query)
+		defer func() { end(span, err) }()
+		return next(ctx)
+	}
+}
@@ -0,0 +1,33 @@
+package dbtest
+
+import (
+	"strings"
+	"testing"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// AssertQuery validates the select list of a query against the columns mapped by T (see
+// db.ValidateQuery), without executing it. Selected columns not mapped by T and expressions
+// without alias fail the test; columns of T not selected by the query are logged as warnings.
+//
+// Parameters:
+//   - t: Test handle used to report failures
+//   - d: SQL dialect of the query (db.DefaultDialect if nil)
+//   - query: SQL query to validate
+//   - opts: Query options, db.WithFieldNameMapper is respected
+//
+// Returns:
+//   - bool: True if every selected column is mapped by T
+func AssertQuery[T any](t testing.TB, d db.IDialect, query string, opts ...db.QueryOption) bool {
+	t.Helper()
+	unselected, err := db.ValidateQuery[T](d, query, opts...)
+	if err != nil {
+		t.Errorf("AssertQuery: %q: %v", query, err)
+		return false
+	}
+	if len(unselected) > 0 {
+		t.Logf("AssertQuery: warning: %q does not select %s", query, strings.Join(unselected, ", "))
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// AssertOptions configures how AssertTable compares query results to the expected rows.
+type AssertOptions struct {
+	// Args are passed as query parameters to the assertion query
+	Args []any
+	// IgnoreColumns lists columns excluded from the comparison (e.g. generated ids or timestamps)
+	IgnoreColumns []string
+	// Unordered compares rows as a multiset, ignoring the order returned by the database
+	Unordered bool
+}
+
+// AssertTable executes the given query and compares the results with the expected rows.
+//
+// Results are compared column by column, using the same column names Query maps for T
+// (including the name mapper of the session). If the results differ, the test is marked as
+// failed and a row-level diff is reported, listing changed columns as well as missing and
+// unexpected rows. Types Query scans from a single column (see db.IsPrimitiveType, e.g.
+// sql.NullString) are compared as a single column named "value".
+//
+// Parameters:
+//   - t: Test handle used to report failures
+//   - conn: Database session to execute the query on
+//   - query: SQL query selecting the rows to verify
+//   - expected: Expected rows
+//   - opts: Optional comparison options. If not provided, rows are compared in order
+//     with all columns.
+//
+// Returns:
+//   - bool: True if the query results match the expected rows
+func AssertTable[T any](t testing.TB, conn db.IReadSession, query string, expected []T, opts ...AssertOptions) bool {
+	t.Helper()
+	var o AssertOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	actual, err := db.Query[T](context.Background(), conn, query, o.Args...)
+	if err != nil {
+		t.Errorf("AssertTable: query failed: %v", err)
+		return false
+	}
+	var mapper db.NameMapper
+	if provider, ok := conn.(interface{ NameMapper() db.NameMapper }); ok {
+		mapper = provider.NameMapper()
+	}
+	expectedRows, err := toRows(expected, mapper, o.IgnoreColumns)
+	if err != nil {
+		t.Errorf("AssertTable: invalid expected rows: %v", err)
+		return false
+	}
+	actualRows, err := toRows(actual, mapper, o.IgnoreColumns)
+	if err != nil {
+		t.Errorf("AssertTable: invalid actual rows: %v", err)
+		return false
+	}
+	var diff []string
+	if o.Unordered {
+		diff = diffUnordered(expectedRows, actualRows)
+	} else {
+		diff = diffOrdered(expectedRows, actualRows)
+	}
+	if len(diff) > 0 {
+		t.Errorf("AssertTable: result of %q does not match (-expected +actual):\n%s", query, strings.Join(diff, "\n"))
+		return false
+	}
+	return true
+}
+
+type row struct {
+	columns []string
+	values  map[string]string
+}
+
+func (r row) String() string {
+	parts := make([]string, 0, len(r.columns))
+	for _, col := range r.columns {
+		parts = append(parts, col+"="+r.values[col])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func toRows[T any](items []T, mapper db.NameMapper, ignore []string) ([]row, error) {
+	result := make([]row, 0, len(items))
+	for _, item := range items {
+		var values map[string]any
+		if !db.IsPrimitiveType(reflect.TypeFor[T]()) {
+			v, err := db.ColumnValues(item, db.WithFieldNameMapper(mapper))
+			if err != nil {
+				return nil, err
+			}
+			values = v
+		} else {
+			values = map[string]any{"value": item}
+		}
+		r := row{values: make(map[string]string, len(values))}
+		for col, val := range values {
+			if slices.Contains(ignore, col) {
+				continue
+			}
+			r.columns = append(r.columns, col)
+			r.values[col] = formatValue(val)
+		}
+		slices.Sort(r.columns)
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+func formatValue(val any) string {
+	switch v := val.(type) {
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case *time.Time:
+		if v == nil {
+			return "<nil>"
+		}
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		return fmt.Sprintf("%q", v)
+	}
+	rv := reflect.ValueOf(val)
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return "<nil>"
+	}
+	// Leaf types such as sql.NullString are compared by the value they write to the database
+	if valuer, ok := val.(driver.Valuer); ok {
+		if dv, err := valuer.Value(); err == nil {
+			return formatValue(dv)
+		}
+	}
+	if rv.Kind() == reflect.Pointer {
+		return "&" + formatValue(rv.Elem().Interface())
+	}
+	return fmt.Sprintf("%#v", val)
+}
+
+func diffOrdered(expected, actual []row) []string {
+	var diff []string
+	for i := 0; i < max(len(expected), len(actual)); i++ {
+		switch {
+		case i >= len(actual):
+			diff = append(diff, fmt.Sprintf("row %d: - %s", i, expected[i]))
+		case i >= len(expected):
+			diff = append(diff, fmt.Sprintf("row %d: + %s", i, actual[i]))
+		default:
+			for _, col := range expected[i].columns {
+				if e, a := expected[i].values[col], actual[i].values[col]; e != a {
+					diff = append(diff, fmt.Sprintf("row %d, column %s: - %s + %s", i, col, e, a))
+				}
+			}
+		}
+	}
+	return diff
+}
+
+func diffUnordered(expected, actual []row) []string {
+	var diff []string
+	remaining := make(map[string]int, len(actual))
+	for _, r := range actual {
+		remaining[r.String()]++
+	}
+	for _, r := range expected {
+		if key := r.String(); remaining[key] > 0 {
+			remaining[key]--
+		} else {
+			diff = append(diff, fmt.Sprintf("- %s", r))
+		}
+	}
+	for _, r := range actual {
+		if key := r.String(); remaining[key] > 0 {
+			remaining[key]--
+			diff = append(diff, fmt.Sprintf("+ %s", r))
+		}
+	}
+	return diff
+}
@@ -0,0 +1,31 @@
+package dbtest
+
+import (
+	"database/sql"
+	"testing"
+
+	db "github.com/uoul/go-dbx"
+)
+
+func TestToRowsComparesLeafTypesAsSingleValues(t *testing.T) {
+	rows, err := toRows([]sql.NullString{{String: "a", Valid: true}, {}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].String() != `{value="a"}` {
+		t.Fatalf("got %v, expected a single value column per row", rows)
+	}
+}
+
+func TestToRowsUsesNameMapper(t *testing.T) {
+	type account struct {
+		CreatedBy string
+	}
+	rows, err := toRows([]account{{CreatedBy: "admin"}}, db.SnakeCaseNameMapper, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].String() != `{created_by="admin"}` {
+		t.Fatalf("got %v, expected column created_by", rows)
+	}
+}
@@ -0,0 +1,123 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"math/rand/v2"
+	"regexp"
+	"sync"
+	"time"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// ChaosRule describes a fault that is injected into matching statements.
+type ChaosRule struct {
+	// Match selects the statements the rule applies to. Transactions are matched
+	// using the statement "BEGIN". A nil pattern matches every statement.
+	Match *regexp.Regexp
+	// Probability of the rule firing for a matching statement (0 < p <= 1).
+	// A value of 0 lets the rule fire for every matching statement.
+	Probability float64
+	// Latency is added before the statement is executed (or the fault is returned)
+	Latency time.Duration
+	// Drop simulates a dropped connection by returning driver.ErrBadConn
+	Drop bool
+	// Err is returned instead of executing the statement, e.g. a driver error with a specific code
+	Err error
+}
+
+// ChaosConnection is a db.IDbConnection decorator injecting faults for resilience testing.
+//
+// Every statement is checked against the configured rules in order. All matching rules add
+// their latency, and the first matching rule with a fault (Drop or Err) aborts the statement.
+// Randomness is derived from the given seed, so test runs are reproducible.
+type ChaosConnection struct {
+	conn  db.IDbConnection
+	rules []ChaosRule
+	mu    sync.Mutex
+	rand  *rand.Rand
+}
+
+// NewChaosConnection wraps the given connection with the provided fault-injection rules.
+//
+// Parameters:
+//   - conn: Connection to forward statements to
+//   - seed: Seed for the random source deciding whether probabilistic rules fire
+//   - rules: Rules to apply to every statement
+//
+// Returns:
+//   - *ChaosConnection: Connection injecting the configured faults
+func NewChaosConnection(conn db.IDbConnection, seed uint64, rules ...ChaosRule) *ChaosConnection {
+	return &ChaosConnection{
+		conn:  conn,
+		rules: rules,
+		rand:  rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+// QueryContext implements db.IDbConnection.
+func (c *ChaosConnection) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if err := c.inject(ctx, query); err != nil {
+		return nil, err
+	}
+	return c.conn.QueryContext(ctx, query, args...)
+}
+
+// ExecContext implements db.IWriteSession, if the wrapped connection does.
+func (c *ChaosConnection) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if err := c.inject(ctx, query); err != nil {
+		return nil, err
+	}
+	session, ok := c.conn.(db.IWriteSession)
+	if !ok {
+		return nil, db.NewErrInvalidConfig("connection %T does not implement ExecContext", c.conn)
+	}
+	return session.ExecContext(ctx, query, args...)
+}
+
+// BeginTx implements db.IDbConnection.
+func (c *ChaosConnection) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if err := c.inject(ctx, "BEGIN"); err != nil {
+		return nil, err
+	}
+	return c.conn.BeginTx(ctx, opts)
+}
+
+func (c *ChaosConnection) inject(ctx context.Context, statement string) error {
+	var latency time.Duration
+	var fault error
+	for _, rule := range c.rules {
+		if rule.Match != nil && !rule.Match.MatchString(statement) {
+			continue
+		}
+		if rule.Probability > 0 && !c.roll(rule.Probability) {
+			continue
+		}
+		latency += rule.Latency
+		if fault == nil {
+			if rule.Drop {
+				fault = driver.ErrBadConn
+			} else if rule.Err != nil {
+				fault = rule.Err
+			}
+		}
+	}
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return fault
+}
+
+func (c *ChaosConnection) roll(probability float64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rand.Float64() < probability
+}
@@ -0,0 +1,309 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// ConformanceOptions configures RunConformance.
+type ConformanceOptions struct {
+	// GoldenDir contains the expected SQL of each case (<case>.sql). If empty, the golden SQL
+	// shipped with this package is used for the built-in dialects, other dialects skip the
+	// golden cases.
+	GoldenDir string
+	// Update writes the generated SQL to GoldenDir instead of comparing it
+	Update bool
+	// Conn executes the live cases against a database (nil = skip). The cases create and
+	// drop the table dbx_conformance.
+	Conn db.IDbConnection
+}
+
+// conformanceTable is the table used by the conformance cases
+const conformanceTable = "dbx_conformance"
+
+type conformanceCase struct {
+	name  string
+	build func() db.IStatementBuilder
+}
+
+// conformanceCases are the statements rendered by the golden cases and executed by the live cases.
+var conformanceCases = []conformanceCase{
+	{"select_basic", func() db.IStatementBuilder {
+		return db.Select("id").From(conformanceTable).Where(db.Ne("name", "x"), db.In("id", 1, 2, 3)).OrderBy("id DESC")
+	}},
+	{"select_limit_offset", func() db.IStatementBuilder {
+		return db.Select("id").From(conformanceTable).OrderBy("id").Limit(2).Offset(1)
+	}},
+	{"select_cte_window", func() db.IStatementBuilder {
+		ranked := db.Select("id", db.As(db.Over("ROW_NUMBER()", db.WindowSpec{OrderBy: []string{"score DESC"}}), "rank")).From(conformanceTable)
+		return db.Select("id").With("ranked", ranked).From("ranked").Where(db.Eq("rank", 1))
+	}},
+	{"select_compound", func() db.IStatementBuilder {
+		return db.Select("id").From(conformanceTable).Where(db.Eq("id", 1)).
+			UnionAll(db.Select("id").From(conformanceTable).Where(db.Eq("id", 2))).OrderBy("id")
+	}},
+	{"select_subquery", func() db.IStatementBuilder {
+		return db.Select("id").From(conformanceTable+" c").Where(
+			db.InSubquery("id", db.Select("id").From(conformanceTable).Where(db.Gt("score", 10))),
+			db.Exists(db.Select(db.Value(1)).From(conformanceTable+" o").Where(db.Eq("o.id", db.Col("c.id")))),
+		).OrderBy("id")
+	}},
+	{"select_for_update", func() db.IStatementBuilder {
+		return db.Select("id").From(conformanceTable).Where(db.Eq("id", 1)).ForUpdate().SkipLocked()
+	}},
+	{"insert_returning", func() db.IStatementBuilder {
+		return db.Insert(conformanceTable).Columns("id", "name", "score").Values(4, "d", 40).Returning("id")
+	}},
+	{"update_returning", func() db.IStatementBuilder {
+		return db.Update(conformanceTable).Set("score", db.Raw("score + ?", 1)).Where(db.Eq("id", 4)).Returning("score")
+	}},
+	{"delete", func() db.IStatementBuilder {
+		return db.Delete(conformanceTable).Where(db.Eq("id", 4))
+	}},
+}
+
+// RunConformance verifies a dialect implementation, so third parties adding dialects (or
+// changing the built-in ones) can check that statements are rendered and executed correctly.
+//
+// The suite consists of three groups of subtests:
+//   - identifiers: Checks the basic contract of IDialect (quoting, placeholders)
+//   - golden: Compares the SQL generated by the statement builders to golden files
+//   - live: Executes the statements against a database, if a connection is configured
+//
+// Cases failing with ErrUnsupportedDialect are expected to fail for every session of the
+// dialect; their golden SQL is the error message and they are skipped by the live cases. The
+// module dbtest/sqlite runs the suite against SQLite.
+//
+// Parameters:
+//   - t: Test handle used to report failures
+//   - dialect: Dialect to verify
+//   - opts: Optional golden files and database connection
+func RunConformance(t *testing.T, dialect db.IDialect, opts ...ConformanceOptions) {
+	t.Helper()
+	var o ConformanceOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	t.Run("identifiers", func(t *testing.T) {
+		checkIdentifiers(t, dialect)
+	})
+	t.Run("golden", func(t *testing.T) {
+		for _, c := range conformanceCases {
+			t.Run(c.name, func(t *testing.T) {
+				checkGolden(t, dialect, c, o)
+			})
+		}
+	})
+	t.Run("live", func(t *testing.T) {
+		if o.Conn == nil {
+			t.Skip("no connection configured")
+		}
+		runLive(t, db.NewClient(o.Conn, db.WithDialect(dialect)))
+	})
+}
+
+func checkIdentifiers(t *testing.T, d db.IDialect) {
+	if d.Name() == "" {
+		t.Errorf("Name() is empty")
+	}
+	plain := d.QuoteIdentifier("users")
+	if plain == "users" || !strings.Contains(plain, "users") {
+		t.Errorf("QuoteIdentifier(%q) = %q, expected a quoted identifier", "users", plain)
+	}
+	if qualified, expected := d.QuoteIdentifier("app.users"), d.QuoteIdentifier("app")+"."+plain; qualified != expected {
+		t.Errorf("QuoteIdentifier(%q) = %q, expected %q", "app.users", qualified, expected)
+	}
+	seen := map[string]int{}
+	for n := 1; n <= 10; n++ {
+		p := d.Placeholder(n)
+		if p == "" {
+			t.Errorf("Placeholder(%d) is empty", n)
+		}
+		seen[p]++
+	}
+	// Placeholders are either all equal (e.g. "?") or distinct per position (e.g. "$1")
+	if len(seen) != 1 && len(seen) != 10 {
+		t.Errorf("Placeholder(1..10) returns %d distinct placeholders, expected 1 or 10", len(seen))
+	}
+}
+
+func checkGolden(t *testing.T, d db.IDialect, c conformanceCase, o ConformanceOptions) {
+	actual := renderCase(d, c)
+	if o.GoldenDir == "" {
+		expected, ok := builtinGolden[d.Name()][c.name]
+		if !ok {
+			t.Skipf("no golden SQL for dialect %s", d.Name())
+		}
+		if actual != expected {
+			t.Errorf("SQL of %s does not match\nexpected: %s\nactual:   %s", c.name, expected, actual)
+		}
+		return
+	}
+	path := filepath.Join(o.GoldenDir, c.name+".sql")
+	if o.Update {
+		if err := os.MkdirAll(o.GoldenDir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(actual+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file (run with Update to create it): %v", err)
+	}
+	if actual != strings.TrimSuffix(string(expected), "\n") {
+		t.Errorf("SQL of %s does not match %s\nexpected: %s\nactual:   %s", c.name, path, strings.TrimSpace(string(expected)), actual)
+	}
+}
+
+// renderCase returns the SQL and arguments of the case, or the build error.
+func renderCase(d db.IDialect, c conformanceCase) string {
+	query, args, err := c.build().Build(d)
+	if err != nil {
+		return "ERROR: " + err.Error()
+	}
+	return fmt.Sprintf("%s -- %v", query, args)
+}
+
+func runLive(t *testing.T, conn *db.Client) {
+	ctx := context.Background()
+	if _, err := db.Exec(ctx, conn, fmt.Sprintf("CREATE TABLE %s (id INT PRIMARY KEY, name VARCHAR(100), score INT)",
+		conn.Dialect().QuoteIdentifier(conformanceTable))); err != nil {
+		t.Fatalf("creating %s: %v", conformanceTable, err)
+	}
+	t.Cleanup(func() {
+		db.Exec(ctx, conn, "DROP TABLE "+conn.Dialect().QuoteIdentifier(conformanceTable))
+	})
+	seed := db.Insert(conformanceTable).Columns("id", "name", "score").Values(1, "a", 30).Values(2, "b", 20).Values(3, "c", 10)
+	if _, err := db.ExecStatement(ctx, conn, seed); err != nil {
+		t.Fatalf("seeding %s: %v", conformanceTable, err)
+	}
+	expected := map[string][]int{
+		"select_basic":        {3, 2, 1},
+		"select_limit_offset": {2, 3},
+		"select_cte_window":   {1},
+		"select_compound":     {1, 2},
+		"select_subquery":     {1, 2},
+		"select_for_update":   {1},
+		"insert_returning":    {4},
+		"update_returning":    {41},
+		"delete":              nil,
+	}
+	for _, c := range conformanceCases {
+		t.Run(c.name, func(t *testing.T) {
+			stmt := c.build()
+			if _, _, err := stmt.Build(conn.Dialect()); errors.As(err, new(*db.ErrUnsupportedDialect)) {
+				t.Skip(err)
+			}
+			if c.name == "delete" {
+				if _, err := db.ExecStatement(ctx, conn, stmt); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			// Locking clauses require a transaction on some engines
+			actual, err := db.ExecuteInTransaction(ctx, conn, func(ctx context.Context, tx *sql.Tx) ([]int, error) {
+				return db.QueryStatement[int](ctx, withDialect{tx, conn.Dialect()}, stmt)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fmt.Sprint(actual) != fmt.Sprint(expected[c.name]) {
+				t.Errorf("%s returned %v, expected %v", c.name, actual, expected[c.name])
+			}
+		})
+	}
+	t.Run("savepoint", func(t *testing.T) {
+		_, err := db.ExecuteInTransaction(ctx, conn, func(ctx context.Context, tx *sql.Tx) (struct{}, error) {
+			_, err := db.ExecuteInTransaction(ctx, conn, func(ctx context.Context, tx *sql.Tx) (struct{}, error) {
+				if _, err := db.ExecStatement(ctx, withDialect{tx, conn.Dialect()}, db.Delete(conformanceTable)); err != nil {
+					return struct{}{}, err
+				}
+				return struct{}{}, errors.New("rollback to savepoint")
+			})
+			if err == nil {
+				return struct{}{}, errors.New("nested transaction did not fail")
+			}
+			return struct{}{}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		count, err := db.QueryOne[int](ctx, conn, "SELECT COUNT(*) FROM "+conn.Dialect().QuoteIdentifier(conformanceTable))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 3 {
+			t.Errorf("%d rows after rollback to savepoint, expected 3", count)
+		}
+	})
+}
+
+// withDialect attaches a dialect to a transaction, so statements are built for it.
+type withDialect struct {
+	*sql.Tx
+	dialect db.IDialect
+}
+
+// Dialect returns the attached dialect.
+func (s withDialect) Dialect() db.IDialect {
+	return s.dialect
+}
+
+// builtinGolden contains the expected SQL of the conformance cases for the built-in dialects.
+var builtinGolden = map[string]map[string]string{
+	db.DialectPostgres: {
+		"select_basic":        `SELECT "id" FROM "dbx_conformance" WHERE ("name" <> $1 AND "id" IN ($2, $3, $4)) ORDER BY "id" DESC -- [x 1 2 3]`,
+		"select_limit_offset": `SELECT "id" FROM "dbx_conformance" ORDER BY "id" LIMIT 2 OFFSET 1 -- []`,
+		"select_cte_window":   `WITH "ranked" AS (SELECT "id", ROW_NUMBER() OVER (ORDER BY "score" DESC) AS "rank" FROM "dbx_conformance") SELECT "id" FROM "ranked" WHERE "rank" = $1 -- [1]`,
+		"select_compound":     `SELECT "id" FROM "dbx_conformance" WHERE "id" = $1 UNION ALL SELECT "id" FROM "dbx_conformance" WHERE "id" = $2 ORDER BY "id" -- [1 2]`,
+		"select_subquery":     `SELECT "id" FROM "dbx_conformance" "c" WHERE ("id" IN (SELECT "id" FROM "dbx_conformance" WHERE "score" > $1) AND EXISTS (SELECT $2 FROM "dbx_conformance" "o" WHERE "o"."id" = "c"."id")) ORDER BY "id" -- [10 1]`,
+		"select_for_update":   `SELECT "id" FROM "dbx_conformance" WHERE "id" = $1 FOR UPDATE SKIP LOCKED -- [1]`,
+		"insert_returning":    `INSERT INTO "dbx_conformance" ("id", "name", "score") VALUES ($1, $2, $3) RETURNING "id" -- [4 d 40]`,
+		"update_returning":    `UPDATE "dbx_conformance" SET "score" = score + $1 WHERE "id" = $2 RETURNING "score" -- [1 4]`,
+		"delete":              `DELETE FROM "dbx_conformance" WHERE "id" = $1 -- [4]`,
+	},
+	db.DialectMySQL: {
+		"select_basic":        "SELECT `id` FROM `dbx_conformance` WHERE (`name` <> ? AND `id` IN (?, ?, ?)) ORDER BY `id` DESC -- [x 1 2 3]",
+		"select_limit_offset": "SELECT `id` FROM `dbx_conformance` ORDER BY `id` LIMIT 2 OFFSET 1 -- []",
+		"select_cte_window":   "WITH `ranked` AS (SELECT `id`, ROW_NUMBER() OVER (ORDER BY `score` DESC) AS `rank` FROM `dbx_conformance`) SELECT `id` FROM `ranked` WHERE `rank` = ? -- [1]",
+		"select_compound":     "SELECT `id` FROM `dbx_conformance` WHERE `id` = ? UNION ALL SELECT `id` FROM `dbx_conformance` WHERE `id` = ? ORDER BY `id` -- [1 2]",
+		"select_subquery":     "SELECT `id` FROM `dbx_conformance` `c` WHERE (`id` IN (SELECT `id` FROM `dbx_conformance` WHERE `score` > ?) AND EXISTS (SELECT ? FROM `dbx_conformance` `o` WHERE `o`.`id` = `c`.`id`)) ORDER BY `id` -- [10 1]",
+		"select_for_update":   "SELECT `id` FROM `dbx_conformance` WHERE `id` = ? FOR UPDATE SKIP LOCKED -- [1]",
+		"insert_returning":    `ERROR: ErrUnsupportedDialect: returning rows is not supported by mysql`,
+		"update_returning":    `ERROR: ErrUnsupportedDialect: returning rows is not supported by mysql`,
+		"delete":              "DELETE FROM `dbx_conformance` WHERE `id` = ? -- [4]",
+	},
+	db.DialectSQLite: {
+		"select_basic":        `SELECT "id" FROM "dbx_conformance" WHERE ("name" <> ? AND "id" IN (?, ?, ?)) ORDER BY "id" DESC -- [x 1 2 3]`,
+		"select_limit_offset": `SELECT "id" FROM "dbx_conformance" ORDER BY "id" LIMIT 2 OFFSET 1 -- []`,
+		"select_cte_window":   `WITH "ranked" AS (SELECT "id", ROW_NUMBER() OVER (ORDER BY "score" DESC) AS "rank" FROM "dbx_conformance") SELECT "id" FROM "ranked" WHERE "rank" = ? -- [1]`,
+		"select_compound":     `SELECT "id" FROM "dbx_conformance" WHERE "id" = ? UNION ALL SELECT "id" FROM "dbx_conformance" WHERE "id" = ? ORDER BY "id" -- [1 2]`,
+		"select_subquery":     `SELECT "id" FROM "dbx_conformance" "c" WHERE ("id" IN (SELECT "id" FROM "dbx_conformance" WHERE "score" > ?) AND EXISTS (SELECT ? FROM "dbx_conformance" "o" WHERE "o"."id" = "c"."id")) ORDER BY "id" -- [10 1]`,
+		"select_for_update":   `ERROR: ErrUnsupportedDialect: row locking is not supported by sqlite`,
+		"insert_returning":    `INSERT INTO "dbx_conformance" ("id", "name", "score") VALUES (?, ?, ?) RETURNING "id" -- [4 d 40]`,
+		"update_returning":    `UPDATE "dbx_conformance" SET "score" = score + ? WHERE "id" = ? RETURNING "score" -- [1 4]`,
+		"delete":              `DELETE FROM "dbx_conformance" WHERE "id" = ? -- [4]`,
+	},
+	db.DialectSQLServer: {
+		"select_basic":        `SELECT [id] FROM [dbx_conformance] WHERE ([name] <> @p1 AND [id] IN (@p2, @p3, @p4)) ORDER BY [id] DESC -- [x 1 2 3]`,
+		"select_limit_offset": `SELECT [id] FROM [dbx_conformance] ORDER BY [id] OFFSET 1 ROWS FETCH NEXT 2 ROWS ONLY -- []`,
+		"select_cte_window":   `WITH [ranked] AS (SELECT [id], ROW_NUMBER() OVER (ORDER BY [score] DESC) AS [rank] FROM [dbx_conformance]) SELECT [id] FROM [ranked] WHERE [rank] = @p1 -- [1]`,
+		"select_compound":     `SELECT [id] FROM [dbx_conformance] WHERE [id] = @p1 UNION ALL SELECT [id] FROM [dbx_conformance] WHERE [id] = @p2 ORDER BY [id] -- [1 2]`,
+		"select_subquery":     `SELECT [id] FROM [dbx_conformance] [c] WHERE ([id] IN (SELECT [id] FROM [dbx_conformance] WHERE [score] > @p1) AND EXISTS (SELECT @p2 FROM [dbx_conformance] [o] WHERE [o].[id] = [c].[id])) ORDER BY [id] -- [10 1]`,
+		"select_for_update":   `SELECT [id] FROM [dbx_conformance] WITH (UPDLOCK, ROWLOCK, READPAST) WHERE [id] = @p1 -- [1]`,
+		"insert_returning":    `INSERT INTO [dbx_conformance] ([id], [name], [score]) OUTPUT INSERTED.[id] VALUES (@p1, @p2, @p3) -- [4 d 40]`,
+		"update_returning":    `UPDATE [dbx_conformance] SET [score] = score + @p1 OUTPUT INSERTED.[score] WHERE [id] = @p2 -- [1 4]`,
+		"delete":              `DELETE FROM [dbx_conformance] WHERE [id] = @p1 -- [4]`,
+	},
+}
@@ -0,0 +1,41 @@
+package dbtest
+
+import (
+	"sync"
+	"time"
+)
+
+// FixedClock is a deterministic db.IClock for tests.
+//
+// It always returns the configured time until it is moved explicitly using Set or Advance.
+// FixedClock is safe for concurrent use.
+type FixedClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFixedClock creates a clock that returns the given time.
+func NewFixedClock(now time.Time) *FixedClock {
+	return &FixedClock{now: now}
+}
+
+// Now implements db.IClock.
+func (c *FixedClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set moves the clock to the given time.
+func (c *FixedClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance moves the clock forward by the given duration.
+func (c *FixedClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
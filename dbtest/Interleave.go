@@ -0,0 +1,254 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// InterleaveOptions configures Interleave.
+type InterleaveOptions struct {
+	// TxOptions are the options both transactions are started with (e.g. the isolation level
+	// under test)
+	TxOptions sql.TxOptions
+	// WaitTimeout is the time a transaction waits at a sync point for the other one, before
+	// assuming the other one is blocked by a lock held by the waiting one, on databases whose
+	// lock waits are not detected (see Interleave, default: 1s)
+	WaitTimeout time.Duration
+	// Timeout bounds the whole interleaving: the context of the steps is canceled and waiting
+	// transactions fail with its error once it has passed (default: 30s)
+	Timeout time.Duration
+}
+
+// TxStep is the body of a transaction run by Interleave. Calling sync blocks until the other
+// transaction calls sync as well (or has finished), so the statements before and after the
+// sync points of both transactions are executed in a deterministic order.
+type TxStep func(ctx context.Context, tx *sql.Tx, sync func()) error
+
+// Interleave deliberately interleaves two concurrent transactions on the connection pool to
+// reproduce isolation anomalies (e.g. write skew) and serialization failures
+// deterministically, for verifying retry logic and isolation assumptions:
+//
+//	// Write skew: both read that two doctors are on call, then each takes one off call
+//	errA, errB := dbtest.Interleave(ctx, conn,
+//		func(ctx context.Context, tx *sql.Tx, sync func()) error {
+//			onCall, _ := db.QueryScalar[int](ctx, tx, "SELECT COUNT(*) FROM doctors WHERE on_call")
+//			sync()
+//			if onCall > 1 {
+//				_, err := tx.ExecContext(ctx, "UPDATE doctors SET on_call = false WHERE id = 1")
+//				return err
+//			}
+//			return nil
+//		},
+//		func(ctx context.Context, tx *sql.Tx, sync func()) error { ... same for id = 2 ... },
+//		dbtest.InterleaveOptions{TxOptions: sql.TxOptions{Isolation: sql.LevelSerializable}},
+//	)
+//	// Under serializable isolation, errB is a serialization failure (see db.IsTransient)
+//
+// Both transactions are started before either step runs. After its step, a transaction syncs
+// once more; then a commits before b. A transaction whose step fails is rolled back.
+//
+// A transaction blocked by a lock of the other one can't reach its sync point or commit, so the
+// other one stops waiting for it: at a sync point, and for the commit of a before b. On
+// PostgreSQL and MySQL (if conn is a client of that dialect), blocked transactions are detected
+// by polling the lock waits of the database on a third connection of the pool; on other
+// databases, a transaction is assumed to be blocked if it does not reach the sync point within
+// InterleaveOptions.WaitTimeout. If the interleaving does not finish within
+// InterleaveOptions.Timeout, e.g. as the steps block each other in a way the database does not
+// detect as deadlock, the steps are canceled and the transactions fail.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Connection to start the transactions on (its pool must allow two connections, three
+//     on PostgreSQL and MySQL)
+//   - a: Step of the first transaction
+//   - b: Step of the second transaction
+//   - opts: Optional configuration
+//
+// Returns:
+//   - errA: Error of the first transaction (begin, step or commit)
+//   - errB: Error of the second transaction (begin, step or commit)
+func Interleave(ctx context.Context, conn db.IDbConnection, a, b TxStep, opts ...InterleaveOptions) (errA, errB error) {
+	o := InterleaveOptions{}
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.WaitTimeout <= 0 {
+		o.WaitTimeout = time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+	txA, errA := conn.BeginTx(ctx, &o.TxOptions)
+	if errA != nil {
+		return errA, nil
+	}
+	txB, errB := conn.BeginTx(ctx, &o.TxOptions)
+	if errB != nil {
+		return errors.Join(errA, txA.Rollback()), errB
+	}
+	blockedA, err := lockWaitDetector(ctx, conn, txA)
+	if err != nil {
+		return errors.Join(err, txA.Rollback()), errors.Join(err, txB.Rollback())
+	}
+	blockedB, err := lockWaitDetector(ctx, conn, txB)
+	if err != nil {
+		return errors.Join(err, txA.Rollback()), errors.Join(err, txB.Rollback())
+	}
+	points := &syncPoint{timeout: o.WaitTimeout}
+	committedA := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		defer close(committedA)
+		errA = runStep(ctx, txA, a, points, nil, blockedB)
+	})
+	wg.Go(func() {
+		errB = runStep(ctx, txB, b, points, committedA, blockedA)
+	})
+	wg.Wait()
+	return errA, errB
+}
+
+// runStep runs the step of a transaction and commits it, after the other transaction has
+// committed if after is not nil, unless the other transaction is blocked (as reported by
+// otherBlocked, see lockWaitDetector).
+func runStep(ctx context.Context, tx *sql.Tx, step TxStep, points *syncPoint, after <-chan struct{}, otherBlocked func(ctx context.Context) bool) error {
+	wait := func() { points.wait(ctx, otherBlocked) }
+	err := step(ctx, tx, wait)
+	if err == nil {
+		wait()
+	}
+	// The other transaction must not wait for this one anymore
+	points.finish()
+	if after != nil {
+		awaitUnlessBlocked(ctx, after, otherBlocked)
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		if rollbackErr := tx.Rollback(); !errors.Is(rollbackErr, sql.ErrTxDone) {
+			// Unless rolled back already by database/sql, as the context is done
+			err = errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockWaitPollInterval is the interval the lock waits of a blocked transaction are polled in.
+const lockWaitPollInterval = 10 * time.Millisecond
+
+// lockWaitDetector returns a function reporting whether a transaction is waiting for a lock,
+// polling the lock waits of PostgreSQL and MySQL on conn. It returns nil for other databases,
+// whose lock waits are not detected.
+func lockWaitDetector(ctx context.Context, conn db.IDbConnection, tx *sql.Tx) (func(ctx context.Context) bool, error) {
+	provider, ok := conn.(interface{ Dialect() db.IDialect })
+	if !ok {
+		return nil, nil
+	}
+	var sessionQuery, waitQuery string
+	switch provider.Dialect().Name() {
+	case db.DialectPostgres:
+		sessionQuery = "SELECT pg_backend_pid()"
+		waitQuery = "SELECT COUNT(*) FROM pg_stat_activity WHERE pid = $1 AND wait_event_type = 'Lock'"
+	case db.DialectMySQL:
+		sessionQuery = "SELECT CONNECTION_ID()"
+		waitQuery = "SELECT COUNT(*) FROM information_schema.innodb_trx WHERE trx_mysql_thread_id = ? AND trx_state = 'LOCK WAIT'"
+	default:
+		return nil, nil
+	}
+	session, err := db.QueryScalar[int64](ctx, tx, sessionQuery)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) bool {
+		waits, err := db.QueryScalar[int64](ctx, conn, waitQuery, session)
+		return err == nil && waits > 0
+	}, nil
+}
+
+// awaitUnlessBlocked waits until done is closed, the context is done or blocked reports the
+// awaited transaction to be blocked (if not nil).
+func awaitUnlessBlocked(ctx context.Context, done <-chan struct{}, blocked func(ctx context.Context) bool) {
+	var poll <-chan time.Time
+	if blocked != nil {
+		ticker := time.NewTicker(lockWaitPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-poll:
+			if blocked(ctx) {
+				return
+			}
+		}
+	}
+}
+
+// syncPoint is a reusable barrier of two parties. Once a party has finished, the other one
+// does not wait anymore.
+type syncPoint struct {
+	mu       sync.Mutex
+	waiting  chan struct{}
+	finished bool
+	timeout  time.Duration
+}
+
+// wait waits for the other party, unless it is blocked: as reported by otherBlocked, or else if
+// it does not arrive within the timeout of the sync point.
+func (p *syncPoint) wait(ctx context.Context, otherBlocked func(ctx context.Context) bool) {
+	p.mu.Lock()
+	if p.finished {
+		p.mu.Unlock()
+		return
+	}
+	if p.waiting != nil {
+		// The other party is waiting, release it
+		close(p.waiting)
+		p.waiting = nil
+		p.mu.Unlock()
+		return
+	}
+	arrived := make(chan struct{})
+	p.waiting = arrived
+	p.mu.Unlock()
+	if otherBlocked != nil {
+		awaitUnlessBlocked(ctx, arrived, otherBlocked)
+	} else {
+		timer := time.NewTimer(p.timeout)
+		defer timer.Stop()
+		select {
+		case <-arrived:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+	// Unless arrived, the other party is blocked, most likely by a lock this party holds
+	p.mu.Lock()
+	if p.waiting == arrived {
+		p.waiting = nil
+	}
+	p.mu.Unlock()
+}
+
+func (p *syncPoint) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finished = true
+	if p.waiting != nil {
+		close(p.waiting)
+		p.waiting = nil
+	}
+}
@@ -0,0 +1,52 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// errRollbackTx makes ExecuteInTransaction roll back the transaction of RunInRollbackTx.
+var errRollbackTx = errors.New("rollback test transaction")
+
+// RunInRollbackTx runs a test within a transaction that is rolled back at the end, so
+// integration tests are isolated from each other without truncating tables:
+//
+//	func TestRegister(t *testing.T) {
+//		dbtest.RunInRollbackTx(t, client, func(ctx context.Context, tx db.IReadWriteSession) {
+//			err := service.Register(ctx, "alice") // uses db.ExecuteInTransaction(ctx, client, ...)
+//			...
+//			dbtest.AssertTable(t, tx, "SELECT name FROM users", []string{"alice"})
+//		})
+//	}
+//
+// The transaction is started using db.ExecuteInTransaction, so it propagates via the context
+// passed to fn: transactions of the code under test started with that context on the same
+// connection join the test transaction using a savepoint, and their commits (including
+// db.AfterCommit hooks) never take effect. Statements executed on the connection outside of
+// such transactions are isolated only if conn is a client created with
+// db.WithAmbientTransactions, which executes them on the transaction carried by their context;
+// otherwise execute them on tx. The transaction is rolled back as
+// well if fn fails the test using t.FailNow or panics.
+//
+// Parameters:
+//   - t: Test handle used to report failures
+//   - conn: Connection to start the transaction on
+//   - fn: Test body, receiving the context carrying the transaction and a session executing
+//     statements on it
+//   - opts: Optional transaction options (first element used)
+func RunInRollbackTx(t testing.TB, conn db.IDbConnection, fn func(ctx context.Context, tx db.IReadWriteSession), opts ...sql.TxOptions) {
+	t.Helper()
+	_, err := db.ExecuteInTransaction(t.Context(), conn, func(ctx context.Context, tx *sql.Tx) (struct{}, error) {
+		fn(ctx, db.TxSession(ctx, tx))
+		return struct{}{}, errRollbackTx
+	}, opts...)
+	// Failing to begin or to roll back the transaction returns another or a joined error
+	var joined interface{ Unwrap() []error }
+	if !errors.Is(err, errRollbackTx) || errors.As(err, &joined) {
+		t.Errorf("RunInRollbackTx: %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	db "github.com/uoul/go-dbx"
+	"github.com/uoul/go-dbx/dbtest"
+	_ "modernc.org/sqlite"
+)
+
+func TestConformance(t *testing.T) {
+	database, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "conformance.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	dbtest.RunConformance(t, db.SQLite, dbtest.ConformanceOptions{Conn: database})
+}
@@ -0,0 +1,6 @@
+// Package sqlite runs the conformance suite of dbtest (see dbtest.RunConformance) against
+// SQLite, using the pure Go driver. It is a module of its own, so the driver is no dependency
+// of go-dbx:
+//
+//	cd dbtest/sqlite && go test ./...
+package sqlite
@@ -0,0 +1,361 @@
+// Package dbxgen generates Go structs mapping the tables of a database, the inverse of the
+// mapping performed by go-dbx: every table becomes a struct whose fields are tagged with the
+// column names, so it can be used with db.Query, db.Repository and the builders right away.
+package dbxgen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"go/token"
+	"slices"
+	"strings"
+	"unicode"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// NullStyle selects the types of the fields of nullable columns.
+type NullStyle int
+
+const (
+	// NullPointer maps nullable columns to pointers (e.g. *string)
+	NullPointer NullStyle = iota
+	// NullTypes maps nullable columns to the sql.Null* types (e.g. sql.NullString)
+	NullTypes
+)
+
+// AuditStruct describes a struct embedded into the structs of all tables having all of its
+// columns, e.g. the timestamps maintained by triggers in every table.
+type AuditStruct struct {
+	// Name of the generated struct type (e.g. "Audit")
+	Name string
+	// Columns of the struct (e.g. "created_at", "updated_at"), the types of the fields are
+	// taken from the first table having them
+	Columns []string
+}
+
+// Options configures the generated code.
+type Options struct {
+	// Package is the name of the package of the generated file (default: "models")
+	Package string
+	// Tables restricts the generated structs to the given tables (empty = all tables)
+	Tables []string
+	// Null selects the types of nullable columns (default: NullPointer)
+	Null NullStyle
+	// Audit optionally describes a struct embedded into the structs instead of its columns
+	Audit *AuditStruct
+	// TypeName returns the struct name of a table (default: the table name in PascalCase, e.g.
+	// "order_items" becomes "OrderItems")
+	TypeName func(table string) string
+	// Types overrides the Go type of column types, keyed by the lower case column type without
+	// size (e.g. "numeric": "decimal.Decimal"). Types of other packages require the import to be
+	// added to the generated file by hand.
+	Types map[string]string
+}
+
+// GenerateFromDatabase reads the tables of a database (see db.DumpSchema) and generates the
+// structs mapping them.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to read the catalog from
+//   - opts: Optional configuration (first element used)
+//
+// Returns:
+//   - []byte: The formatted Go source file
+//   - error: The error of db.DumpSchema or Generate
+func GenerateFromDatabase(ctx context.Context, conn db.IReadSession, opts ...Options) ([]byte, error) {
+	snapshot, err := db.DumpSchema(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	return Generate(snapshot, opts...)
+}
+
+// Generate generates a Go source file containing one struct per table of a schema snapshot.
+// Fields are named after the columns in PascalCase and tagged with the column name (primary
+// key columns with the "pk" option used by db.Repository). Nullable columns are mapped to
+// pointers or sql.Null* types (see NullStyle), except for []byte and json.RawMessage fields,
+// which represent NULL by nil. Column types are mapped per dialect, unknown types are mapped to
+// string.
+//
+// Parameters:
+//   - snapshot: Tables to generate structs for
+//   - opts: Optional configuration (first element used)
+//
+// Returns:
+//   - []byte: The formatted Go source file
+//   - error: Non-nil if a table of Options.Tables does not exist, the names of two tables or two
+//     columns of a table map to the same identifier, or the audit struct clashes with the struct
+//     of a table or a field of a struct embedding it
+func Generate(snapshot db.SchemaSnapshot, opts ...Options) ([]byte, error) {
+	o := Options{}
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Package == "" {
+		o.Package = "models"
+	}
+	if o.TypeName == nil {
+		o.TypeName = identifier
+	}
+	if o.Audit != nil && !token.IsIdentifier(o.Audit.Name) {
+		return nil, fmt.Errorf("dbxgen: audit struct name %q is no identifier", o.Audit.Name)
+	}
+	tables := snapshot.Tables
+	if len(o.Tables) > 0 {
+		tables = nil
+		for _, name := range o.Tables {
+			index := slices.IndexFunc(snapshot.Tables, func(t db.TableSchema) bool { return t.Name == name })
+			if index < 0 {
+				return nil, fmt.Errorf("dbxgen: table %s does not exist", name)
+			}
+			tables = append(tables, snapshot.Tables[index])
+		}
+	}
+	g := generator{opts: o, dialect: snapshot.Dialect, imports: map[string]bool{}}
+	var audit []field
+	names := map[string]string{}
+	var body bytes.Buffer
+	for _, table := range tables {
+		name := o.TypeName(table.Name)
+		if !token.IsIdentifier(name) {
+			return nil, fmt.Errorf("dbxgen: struct name %q of table %s is no identifier", name, table.Name)
+		}
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("dbxgen: tables %s and %s both map to %s", other, table.Name, name)
+		}
+		names[name] = table.Name
+		fields, err := g.fields(table)
+		if err != nil {
+			return nil, err
+		}
+		embedded := false
+		if o.Audit != nil && hasColumns(fields, o.Audit.Columns) {
+			if audit == nil {
+				for _, column := range o.Audit.Columns {
+					audit = append(audit, fields[slices.IndexFunc(fields, func(f field) bool { return f.column == column })])
+				}
+			}
+			fields = slices.DeleteFunc(fields, func(f field) bool { return slices.Contains(o.Audit.Columns, f.column) })
+			if i := slices.IndexFunc(fields, func(f field) bool { return f.name == o.Audit.Name }); i >= 0 {
+				return nil, fmt.Errorf("dbxgen: column %s of %s clashes with the embedded %s", fields[i].column, table.Name, o.Audit.Name)
+			}
+			embedded = true
+		}
+		fmt.Fprintf(&body, "\n// %s maps the table %s.\ntype %s struct {\n", name, table.Name, name)
+		if embedded {
+			fmt.Fprintf(&body, "%s\n", o.Audit.Name)
+		}
+		writeFields(&body, fields)
+		body.WriteString("}\n")
+	}
+	if audit != nil {
+		if table, ok := names[o.Audit.Name]; ok {
+			return nil, fmt.Errorf("dbxgen: audit struct %s clashes with the struct of table %s", o.Audit.Name, table)
+		}
+		fmt.Fprintf(&body, "\n// %s holds the audit columns embedded into the tables having them.\ntype %s struct {\n", o.Audit.Name, o.Audit.Name)
+		writeFields(&body, audit)
+		body.WriteString("}\n")
+	}
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by dbxgen. DO NOT EDIT.\n\npackage %s\n", o.Package)
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			imports = append(imports, path)
+		}
+		slices.Sort(imports)
+		src.WriteString("\nimport (\n")
+		for _, path := range imports {
+			fmt.Fprintf(&src, "%q\n", path)
+		}
+		src.WriteString(")\n")
+	}
+	src.Write(body.Bytes())
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("dbxgen: formatting generated code: %w", err)
+	}
+	return formatted, nil
+}
+
+// field is a struct field generated for a column.
+type field struct {
+	name   string
+	typ    string
+	column string
+	pk     bool
+}
+
+// generator maps the columns of tables to fields, collecting the imports they require.
+type generator struct {
+	opts    Options
+	dialect string
+	imports map[string]bool
+}
+
+// fields maps the columns of a table to fields.
+func (g *generator) fields(table db.TableSchema) ([]field, error) {
+	fields := make([]field, 0, len(table.Columns))
+	columns := map[string]string{}
+	for _, column := range table.Columns {
+		name := identifier(column.Name)
+		if other, ok := columns[name]; ok {
+			return nil, fmt.Errorf("dbxgen: columns %s and %s of %s both map to %s", other, column.Name, table.Name, name)
+		}
+		columns[name] = column.Name
+		pk := slices.Contains(table.PrimaryKey, column.Name)
+		// SQLite reports INTEGER PRIMARY KEY columns as nullable, though they never are
+		fields = append(fields, field{name: name, typ: g.goType(column.Type, column.Nullable && !pk), column: column.Name, pk: pk})
+	}
+	return fields, nil
+}
+
+// goType returns the type of the field of a column.
+func (g *generator) goType(columnType string, nullable bool) string {
+	base := strings.ToLower(strings.TrimSpace(columnType))
+	if typ, ok := g.opts.Types[baseType(base)]; ok {
+		if nullable {
+			return "*" + typ
+		}
+		return typ
+	}
+	typ := g.builtinType(base)
+	switch {
+	case !nullable, typ == "[]byte", typ == "json.RawMessage":
+	case g.opts.Null == NullTypes:
+		g.imports["database/sql"] = true
+		switch typ {
+		case "int64":
+			return "sql.NullInt64"
+		case "int32":
+			return "sql.NullInt32"
+		case "int16":
+			return "sql.NullInt16"
+		case "float64":
+			return "sql.NullFloat64"
+		case "bool":
+			return "sql.NullBool"
+		case "time.Time":
+			return "sql.NullTime"
+		case "string":
+			return "sql.NullString"
+		}
+		return "sql.Null[" + typ + "]"
+	default:
+		typ = "*" + typ
+	}
+	switch strings.TrimPrefix(typ, "*") {
+	case "time.Time":
+		g.imports["time"] = true
+	case "json.RawMessage":
+		g.imports["encoding/json"] = true
+	}
+	return typ
+}
+
+// builtinType maps a lower case column type to a Go type.
+func (g *generator) builtinType(columnType string) string {
+	typ := baseType(columnType)
+	if g.dialect == db.DialectMySQL && typ == "tinyint" && strings.HasPrefix(columnType, "tinyint(1)") {
+		return "bool"
+	}
+	if strings.HasSuffix(columnType, "[]") || typ == "array" {
+		// Postgres arrays are scanned as their text representation by most drivers
+		return "string"
+	}
+	switch typ {
+	case "bool", "boolean", "bit":
+		return "bool"
+	case "smallint", "int2", "smallserial", "serial2":
+		return "int16"
+	case "int", "integer", "int4", "serial", "serial4", "mediumint":
+		if g.dialect == db.DialectSQLite {
+			// SQLite integers are 64 bit regardless of the declared type
+			return "int64"
+		}
+		return "int32"
+	case "bigint", "int8", "bigserial", "serial8", "tinyint", "year":
+		return "int64"
+	case "real", "float", "float4", "float8", "double", "double precision", "smallmoney", "money":
+		return "float64"
+	case "date", "datetime", "datetime2", "smalldatetime", "datetimeoffset", "timestamp",
+		"timestamp with time zone", "timestamp without time zone", "timestamptz":
+		return "time.Time"
+	case "json", "jsonb":
+		return "json.RawMessage"
+	case "blob", "tinyblob", "mediumblob", "longblob", "bytea", "binary", "varbinary", "image":
+		return "[]byte"
+	}
+	if g.dialect == db.DialectSQLite {
+		// Type affinity of SQLite for declared types not listed above
+		switch {
+		case strings.Contains(typ, "int"):
+			return "int64"
+		case strings.Contains(typ, "real"), strings.Contains(typ, "floa"), strings.Contains(typ, "doub"):
+			return "float64"
+		}
+	}
+	// text, character types, numeric/decimal (kept exact), uuid, enums, time of day and others
+	return "string"
+}
+
+// baseType strips size, precision and modifiers from a lower case column type, e.g.
+// "varchar(255)" becomes "varchar" and "int unsigned auto_increment" becomes "int".
+func baseType(columnType string) string {
+	typ, _, _ := strings.Cut(columnType, "(")
+	typ = strings.TrimSpace(typ)
+	for _, modifier := range []string{" auto_increment", " zerofill", " unsigned", " signed"} {
+		typ = strings.TrimSuffix(typ, modifier)
+	}
+	return typ
+}
+
+// hasColumns reports whether fields contain all columns.
+func hasColumns(fields []field, columns []string) bool {
+	for _, column := range columns {
+		if !slices.ContainsFunc(fields, func(f field) bool { return f.column == column }) {
+			return false
+		}
+	}
+	return len(columns) > 0
+}
+
+// writeFields writes the declarations of fields, tagged with their column names.
+func writeFields(buf *bytes.Buffer, fields []field) {
+	for _, f := range fields {
+		tag := f.column
+		if f.pk {
+			tag += ",pk"
+		}
+		fmt.Fprintf(buf, "%s %s `db:%q`\n", f.name, f.typ, tag)
+	}
+}
+
+// identifier converts a table or column name into an exported Go identifier in PascalCase,
+// e.g. "order_items" becomes "OrderItems". Characters not allowed in identifiers separate
+// words; names starting with a digit are prefixed by "X".
+func identifier(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			b.WriteRune(r)
+		default:
+			upper = true
+		}
+	}
+	id := b.String()
+	if id == "" || unicode.IsDigit([]rune(id)[0]) {
+		id = "X" + id
+	}
+	return id
+}
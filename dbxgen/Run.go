@@ -0,0 +1,147 @@
+package dbxgen
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// dialects are the dialects selectable by the -dialect flag.
+var dialects = map[string]db.IDialect{
+	db.DialectPostgres: db.Postgres,
+	db.DialectMySQL:    db.MySQL,
+	db.DialectSQLite:   db.SQLite,
+}
+
+// Run implements the dbxgen command. The command reads the tables either from a database
+// (-driver, -dsn and -dialect) or from a schema snapshot serialized as JSON (-schema, see
+// db.DumpSchema), and writes the generated file to -o or stdout:
+//
+//	dbxgen -schema schema.json -package models -null sql -audit Audit:created_at,updated_at -o models/tables.go
+//
+// The dbxgen command of this repository links the SQLite driver only. To read from other
+// databases, build a command importing their driver:
+//
+//	package main
+//
+//	import (
+//		"context"
+//		"os"
+//
+//		_ "github.com/jackc/pgx/v5/stdlib"
+//		"github.com/uoul/go-dbx/dbxgen"
+//	)
+//
+//	func main() {
+//		if err := dbxgen.Run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+//			os.Exit(1)
+//		}
+//	}
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - args: Command line arguments, without the program name
+//   - stdout: Writer the generated file is written to if no output file is given
+//
+// Returns:
+//   - error: Non-nil if the arguments are invalid, reading the tables or generating fails; the
+//     error has been printed to stderr already
+func Run(ctx context.Context, args []string, stdout io.Writer) error {
+	err := run(ctx, args, stdout)
+	if err != nil && err != flag.ErrHelp {
+		fmt.Fprintln(os.Stderr, "dbxgen:", strings.TrimPrefix(err.Error(), "dbxgen: "))
+	}
+	return err
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("dbxgen", flag.ContinueOnError)
+	driverName := flags.String("driver", "", "name of the registered database/sql driver")
+	dsn := flags.String("dsn", "", "data source name of the database")
+	dialectName := flags.String("dialect", "", "dialect of the database: postgres, mysql or sqlite (default: the dialect of the driver)")
+	schema := flags.String("schema", "", "schema snapshot (JSON) to read the tables from instead of a database")
+	pkg := flags.String("package", "models", "package name of the generated file")
+	tables := flags.String("tables", "", "comma separated tables to generate structs for (default: all)")
+	null := flags.String("null", "pointer", "types of nullable columns: pointer or sql")
+	audit := flags.String("audit", "", "struct embedded instead of its columns, e.g. Audit:created_at,updated_at")
+	output := flags.String("o", "", "output file (default: stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	opts := Options{Package: *pkg}
+	if *tables != "" {
+		opts.Tables = strings.Split(*tables, ",")
+	}
+	switch *null {
+	case "pointer":
+		opts.Null = NullPointer
+	case "sql":
+		opts.Null = NullTypes
+	default:
+		return fmt.Errorf("invalid -null %q, expected pointer or sql", *null)
+	}
+	if *audit != "" {
+		name, columns, ok := strings.Cut(*audit, ":")
+		if !ok || name == "" || columns == "" {
+			return fmt.Errorf("invalid -audit %q, expected Name:column,column", *audit)
+		}
+		opts.Audit = &AuditStruct{Name: name, Columns: strings.Split(columns, ",")}
+	}
+	snapshot, err := readSchema(ctx, *schema, *driverName, *dsn, *dialectName)
+	if err != nil {
+		return err
+	}
+	src, err := Generate(snapshot, opts)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*output, src, 0o644)
+}
+
+// readSchema reads the tables from a snapshot file, or else from a database.
+func readSchema(ctx context.Context, file, driverName, dsn, dialectName string) (db.SchemaSnapshot, error) {
+	var snapshot db.SchemaSnapshot
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return snapshot, err
+		}
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return snapshot, fmt.Errorf("parsing %s: %w", file, err)
+		}
+		return snapshot, nil
+	}
+	if driverName == "" || dsn == "" {
+		return snapshot, fmt.Errorf("either -schema or -driver and -dsn are required")
+	}
+	if !slices.Contains(sql.Drivers(), driverName) {
+		return snapshot, fmt.Errorf("driver %q is not linked into this command, see the documentation of dbxgen.Run", driverName)
+	}
+	dialect, ok := dialects[dialectName]
+	if dialectName == "" {
+		dialect, ok = db.DriverDialect(driverName)
+		if !ok || dialects[dialect.Name()] == nil {
+			return snapshot, fmt.Errorf("the dialect of driver %q is unknown, use -dialect postgres, mysql or sqlite", driverName)
+		}
+	} else if !ok {
+		return snapshot, fmt.Errorf("unsupported dialect %q, expected postgres, mysql or sqlite", dialectName)
+	}
+	client, database, err := db.OpenClient(db.Config{DriverName: driverName, DSN: dsn, Dialect: dialect})
+	if err != nil {
+		return snapshot, err
+	}
+	defer database.Close()
+	return db.DumpSchema(ctx, client)
+}
@@ -0,0 +1,159 @@
+package mock
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"time"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// ArgMatcher matches a query argument, e.g. a generated timestamp the test can't predict.
+type ArgMatcher func(arg any) bool
+
+// AnyArg matches every argument.
+func AnyArg() ArgMatcher {
+	return func(any) bool {
+		return true
+	}
+}
+
+// Expectation is a call the Mock expects, together with its scripted result. Expectations are
+// configured by chaining their methods:
+//
+//	m.ExpectExec("UPDATE users").WithArgs("alice", 1).WillReturnResult(0, 1)
+type Expectation struct {
+	operation db.Operation
+	pattern   *regexp.Regexp
+	args      []any
+	checkArgs bool
+	rows      *Rows
+	result    driver.Result
+	err       error
+	delay     time.Duration
+	times     int
+	calls     int
+}
+
+// WithArgs restricts the expectation to calls with the given arguments. Arguments are compared
+// after conversion to driver values (so 1 matches int64(1)), ArgMatcher values match by
+// function.
+func (e *Expectation) WithArgs(args ...any) *Expectation {
+	e.args = args
+	e.checkArgs = true
+	return e
+}
+
+// WillReturnRows sets the rows a query returns (default: no rows).
+func (e *Expectation) WillReturnRows(rows *Rows) *Expectation {
+	e.rows = rows
+	return e
+}
+
+// WillReturnResult sets the result of a statement (default: 0 for both values).
+func (e *Expectation) WillReturnResult(lastInsertId, rowsAffected int64) *Expectation {
+	e.result = result{lastInsertId: lastInsertId, rowsAffected: rowsAffected}
+	return e
+}
+
+// WillReturnError makes the call fail with the given error, e.g. a driver error to test the
+// error handling of the code under test. Note that database/sql retries calls failing with
+// driver.ErrBadConn, so they are answered by the following expectations as well.
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// WillDelayFor delays the call, e.g. to test timeouts. The delay is aborted if the context of
+// the call is done.
+func (e *Expectation) WillDelayFor(delay time.Duration) *Expectation {
+	e.delay = delay
+	return e
+}
+
+// Times sets how often the call is expected (default: 1). A value of 0 allows any number of
+// calls, including none.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// String describes the expectation for error messages.
+func (e *Expectation) String() string {
+	description := string(e.operation)
+	if e.pattern != nil {
+		description = fmt.Sprintf("%s matching %q", e.operation, e.pattern)
+	}
+	if e.checkArgs {
+		description += fmt.Sprintf(" with args %v", e.args)
+	}
+	return description
+}
+
+// exhausted reports whether the expectation can't match further calls.
+func (e *Expectation) exhausted() bool {
+	return e.times > 0 && e.calls >= e.times
+}
+
+// met reports whether the expectation has been called as often as expected.
+func (e *Expectation) met() bool {
+	return e.times == 0 || e.calls >= e.times
+}
+
+// matches reports whether the expectation matches a call.
+func (e *Expectation) matches(stmt db.StatementInfo) bool {
+	if e.operation != stmt.Operation || e.exhausted() {
+		return false
+	}
+	if e.pattern == nil {
+		return true
+	}
+	if !e.pattern.MatchString(stmt.Query) {
+		return false
+	}
+	if !e.checkArgs {
+		return true
+	}
+	if len(e.args) != len(stmt.Args) {
+		return false
+	}
+	for i, expected := range e.args {
+		if matcher, ok := expected.(ArgMatcher); ok {
+			if !matcher(stmt.Args[i]) {
+				return false
+			}
+			continue
+		}
+		if !reflect.DeepEqual(driverValue(expected), driverValue(stmt.Args[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// driverValue converts an argument into a driver value for comparison, or returns it
+// unchanged if it can't be converted.
+func driverValue(arg any) any {
+	if converted, err := driver.DefaultParameterConverter.ConvertValue(arg); err == nil {
+		return converted
+	}
+	return arg
+}
+
+// result implements driver.Result.
+type result struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+// LastInsertId implements driver.Result.
+func (r result) LastInsertId() (int64, error) {
+	return r.lastInsertId, nil
+}
+
+// RowsAffected implements driver.Result.
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
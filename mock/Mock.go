@@ -0,0 +1,370 @@
+// Package mock provides an in-memory fake database for unit testing code built on go-dbx,
+// without a database server or a driver.
+package mock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	db "github.com/uoul/go-dbx"
+)
+
+const (
+	// OperationCommit is the operation Calls reports for commits
+	OperationCommit db.Operation = "commit"
+	// OperationRollback is the operation Calls reports for rollbacks
+	OperationRollback db.Operation = "rollback"
+)
+
+// Options configures a Mock.
+type Options struct {
+	// Dialect is the dialect the mock reports to the functions of go-dbx (default:
+	// db.DefaultDialect)
+	Dialect db.IDialect
+}
+
+// Mock is an in-memory db.IDbConnection for unit tests. It records all calls, answers queries
+// and statements with the results scripted per query pattern, and verifies that all expected
+// calls have been made:
+//
+//	m := mock.New()
+//	defer m.Verify(t)
+//	m.ExpectQuery("FROM users WHERE tenant").WithArgs("acme").WillReturnRows(mock.RowsOf(User{Id: 1, Name: "alice"}))
+//	m.ExpectExec("UPDATE users").WillReturnError(errors.New("connection reset"))
+//
+//	users, err := db.Query[User](ctx, m, "SELECT * FROM users WHERE tenant = ?", "acme")
+//
+// Calls are matched against the expectations in declaration order; the first expectation
+// matching the call, which has not been called as often as expected, answers it. Queries and
+// statements without a matching expectation fail. Transactions always succeed unless an
+// expectation (see ExpectBegin, ExpectCommit and ExpectRollback) scripts an error, so code using
+// db.ExecuteInTransaction can be tested without declaring them. Mock is safe for concurrent use.
+type Mock struct {
+	opts         Options
+	db           *sql.DB
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []db.StatementInfo
+	unexpected   []db.StatementInfo
+}
+
+// New creates a mock without expectations.
+//
+// Parameters:
+//   - opts: Optional configuration (first element used)
+//
+// Returns:
+//   - *Mock: The mock
+func New(opts ...Options) *Mock {
+	m := &Mock{opts: Options{Dialect: db.DefaultDialect}}
+	if len(opts) > 0 && opts[0].Dialect != nil {
+		m.opts.Dialect = opts[0].Dialect
+	}
+	m.db = sql.OpenDB(connector{mock: m})
+	return m
+}
+
+// ExpectQuery expects a query matching the given regular expression.
+func (m *Mock) ExpectQuery(pattern string) *Expectation {
+	return m.expect(&Expectation{operation: db.OperationQuery, pattern: regexp.MustCompile(pattern)})
+}
+
+// ExpectExec expects a statement not returning rows matching the given regular expression.
+func (m *Mock) ExpectExec(pattern string) *Expectation {
+	return m.expect(&Expectation{operation: db.OperationExec, pattern: regexp.MustCompile(pattern)})
+}
+
+// ExpectBegin expects a transaction to be started.
+func (m *Mock) ExpectBegin() *Expectation {
+	return m.expect(&Expectation{operation: db.OperationBegin})
+}
+
+// ExpectCommit expects a transaction to be committed.
+func (m *Mock) ExpectCommit() *Expectation {
+	return m.expect(&Expectation{operation: OperationCommit})
+}
+
+// ExpectRollback expects a transaction to be rolled back.
+func (m *Mock) ExpectRollback() *Expectation {
+	return m.expect(&Expectation{operation: OperationRollback})
+}
+
+func (m *Mock) expect(e *Expectation) *Expectation {
+	e.times = 1
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// Calls returns all calls in the order they have been made. Transactions are recorded with
+// the operations db.OperationBegin, OperationCommit and OperationRollback.
+func (m *Mock) Calls() []db.StatementInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.calls)
+}
+
+// ExpectationsWereMet verifies that all expectations have been called as often as expected and
+// no unexpected query or statement has been made.
+//
+// Returns:
+//   - error: Joined errors describing every unmet expectation and unexpected call, or nil
+func (m *Mock) ExpectationsWereMet() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var errs []error
+	for _, e := range m.expectations {
+		if !e.met() {
+			errs = append(errs, fmt.Errorf("mock: expected %s to be called %d times, was called %d times", e, e.times, e.calls))
+		}
+	}
+	for _, stmt := range m.unexpected {
+		errs = append(errs, unexpectedCall(stmt))
+	}
+	return errors.Join(errs...)
+}
+
+// Verify marks the test as failed if the expectations were not met (see ExpectationsWereMet).
+func (m *Mock) Verify(t testing.TB) {
+	t.Helper()
+	if err := m.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// DB returns the *sql.DB backed by the mock, e.g. for code requiring it.
+func (m *Mock) DB() *sql.DB {
+	return m.db
+}
+
+// Dialect returns the configured dialect.
+func (m *Mock) Dialect() db.IDialect {
+	return m.opts.Dialect
+}
+
+// Close closes the *sql.DB backed by the mock.
+func (m *Mock) Close() error {
+	return m.db.Close()
+}
+
+// QueryContext implements db.IDbConnection.
+func (m *Mock) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return m.db.QueryContext(ctx, query, args...)
+}
+
+// ExecContext implements db.IWriteSession.
+func (m *Mock) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return m.db.ExecContext(ctx, query, args...)
+}
+
+// BeginTx implements db.IDbConnection.
+func (m *Mock) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return m.db.BeginTx(ctx, opts)
+}
+
+// call records a call and returns the expectation answering it. Transactions without matching
+// expectation are answered by nil.
+func (m *Mock) call(ctx context.Context, stmt db.StatementInfo) (*Expectation, error) {
+	m.mu.Lock()
+	m.calls = append(m.calls, stmt)
+	index := slices.IndexFunc(m.expectations, func(e *Expectation) bool { return e.matches(stmt) })
+	if index < 0 {
+		defer m.mu.Unlock()
+		if stmt.Operation != db.OperationQuery && stmt.Operation != db.OperationExec {
+			return nil, nil
+		}
+		m.unexpected = append(m.unexpected, stmt)
+		return nil, unexpectedCall(stmt)
+	}
+	e := m.expectations[index]
+	e.calls++
+	m.mu.Unlock()
+	if e.delay > 0 {
+		timer := time.NewTimer(e.delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e, nil
+}
+
+func unexpectedCall(stmt db.StatementInfo) error {
+	return fmt.Errorf("mock: unexpected %s %q with args %v", stmt.Operation, stmt.Query, stmt.Args)
+}
+
+// connector implements driver.Connector, connecting the *sql.DB to the mock.
+type connector struct {
+	mock *Mock
+}
+
+// Connect implements driver.Connector.
+func (c connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{mock: c.mock}, nil
+}
+
+// Driver implements driver.Connector.
+func (c connector) Driver() driver.Driver {
+	return mockDriver{}
+}
+
+// mockDriver implements driver.Driver. Connections are created by the connector only.
+type mockDriver struct{}
+
+// Open implements driver.Driver.
+func (mockDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("mock: connections can't be opened by name")
+}
+
+// conn implements the driver connection, answering all calls with the expectations of the mock.
+type conn struct {
+	mock *Mock
+}
+
+// Prepare implements driver.Conn.
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query}, nil
+}
+
+// Close implements driver.Conn.
+func (c *conn) Close() error {
+	return nil
+}
+
+// Begin implements driver.Conn.
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (c *conn) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	if _, err := c.mock.call(ctx, db.StatementInfo{Operation: db.OperationBegin}); err != nil {
+		return nil, err
+	}
+	return tx{mock: c.mock}, nil
+}
+
+// CheckNamedValue implements driver.NamedValueChecker, passing all arguments to the mock
+// unchanged.
+func (c *conn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	e, err := c.mock.call(ctx, db.StatementInfo{Operation: db.OperationQuery, Query: query, Args: argsOf(args)})
+	if err != nil {
+		return nil, err
+	}
+	if e.rows == nil {
+		return &driverRows{rows: NewRows()}, nil
+	}
+	if e.rows.err != nil {
+		return nil, e.rows.err
+	}
+	return &driverRows{rows: e.rows}, nil
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, err := c.mock.call(ctx, db.StatementInfo{Operation: db.OperationExec, Query: query, Args: argsOf(args)})
+	if err != nil {
+		return nil, err
+	}
+	if e.result == nil {
+		return result{}, nil
+	}
+	return e.result, nil
+}
+
+// argsOf returns the arguments of a call as passed by the caller.
+func argsOf(args []driver.NamedValue) []any {
+	values := make([]any, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+		if arg.Name != "" {
+			values[i] = sql.Named(arg.Name, arg.Value)
+		}
+	}
+	return values
+}
+
+// stmt implements driver.Stmt for prepared statements, which are answered on execution.
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+// Close implements driver.Stmt.
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput implements driver.Stmt.
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+// Exec implements driver.Stmt.
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValuesOf(args))
+}
+
+// Query implements driver.Stmt.
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValuesOf(args))
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (s *stmt) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func namedValuesOf(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return named
+}
+
+// tx implements driver.Tx, recording commits and rollbacks.
+type tx struct {
+	mock *Mock
+}
+
+// Commit implements driver.Tx.
+func (t tx) Commit() error {
+	_, err := t.mock.call(context.Background(), db.StatementInfo{Operation: OperationCommit})
+	return err
+}
+
+// Rollback implements driver.Tx.
+func (t tx) Rollback() error {
+	_, err := t.mock.call(context.Background(), db.StatementInfo{Operation: OperationRollback})
+	return err
+}
@@ -0,0 +1,106 @@
+package mock
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// Rows is the result set a query expectation returns.
+type Rows struct {
+	columns []string
+	values  [][]driver.Value
+	err     error
+}
+
+// NewRows creates an empty result set with the given columns.
+func NewRows(columns ...string) *Rows {
+	return &Rows{columns: columns}
+}
+
+// AddRow appends a row with one value per column. Values are converted like query arguments
+// (see driver.DefaultParameterConverter), so driver.Valuer types are supported.
+func (r *Rows) AddRow(values ...any) *Rows {
+	if r.err != nil {
+		return r
+	}
+	if len(values) != len(r.columns) {
+		r.err = fmt.Errorf("mock: row has %d values, expected %d", len(values), len(r.columns))
+		return r
+	}
+	row := make([]driver.Value, len(values))
+	for i, value := range values {
+		converted, err := driver.DefaultParameterConverter.ConvertValue(value)
+		if err != nil {
+			r.err = fmt.Errorf("mock: column %s: %w", r.columns[i], err)
+			return r
+		}
+		row[i] = converted
+	}
+	r.values = append(r.values, row)
+	return r
+}
+
+// RowsOf creates a result set from the given values, so queries return them when mapped into T
+// again:
+//
+//	m.ExpectQuery("FROM users").WillReturnRows(mock.RowsOf(User{Id: 1, Name: "alice"}))
+//
+// Structs are converted into one column per mapped field (see db.Columns), other types into a
+// single column named "value". Pointers to structs are not supported.
+func RowsOf[T any](values ...T) *Rows {
+	typ := reflect.TypeFor[T]()
+	if _, err := driver.DefaultParameterConverter.ConvertValue(reflect.Zero(typ).Interface()); err == nil || typ.Kind() != reflect.Struct {
+		rows := NewRows("value")
+		for _, value := range values {
+			rows.AddRow(value)
+		}
+		return rows
+	}
+	columns, err := db.Columns[T]()
+	if err != nil {
+		return &Rows{err: fmt.Errorf("mock: %w", err)}
+	}
+	rows := NewRows(columns...)
+	for _, value := range values {
+		fields, err := db.ColumnValues(value)
+		if err != nil {
+			return &Rows{err: fmt.Errorf("mock: %w", err)}
+		}
+		row := make([]any, len(columns))
+		for i, column := range columns {
+			row[i] = fields[column]
+		}
+		rows.AddRow(row...)
+	}
+	return rows
+}
+
+// driverRows implements driver.Rows for a scripted result set.
+type driverRows struct {
+	rows *Rows
+	next int
+}
+
+// Columns implements driver.Rows.
+func (r *driverRows) Columns() []string {
+	return r.rows.columns
+}
+
+// Close implements driver.Rows.
+func (r *driverRows) Close() error {
+	return nil
+}
+
+// Next implements driver.Rows.
+func (r *driverRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows.values) {
+		return io.EOF
+	}
+	copy(dest, r.rows.values[r.next])
+	r.next++
+	return nil
+}
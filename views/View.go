@@ -0,0 +1,169 @@
+package views
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	db "github.com/uoul/go-dbx"
+)
+
+// Param declares a parameter of a view.
+type Param struct {
+	Name string
+	// Required rejects queries without a value for this parameter
+	Required bool
+	// Default is used if no value is given for an optional parameter
+	Default any
+}
+
+// Definition declares a named read model: a SQL query whose rows are mapped into T.
+type Definition struct {
+	// SQL is the query text, written for the dialect of the sessions the view is queried on.
+	// Its positional placeholders are bound to Params in declaration order.
+	SQL    string
+	Params []Param
+	// CacheTTL caches the results in the session's cache (see db.QueryCached) for the given
+	// time (0 = no caching)
+	CacheTTL time.Duration
+	// Description documents the view, e.g. for generated reports
+	Description string
+}
+
+// View is a registered read model with result type T.
+type View[T any] struct {
+	name string
+	def  Definition
+	err  error
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]any{}
+)
+
+// Register declares a named view with result type T, centralizing (reporting) SQL in one
+// place. Registering a name again replaces the previous view.
+//
+// Parameters:
+//   - name: Unique name of the view
+//   - def: SQL text and parameters of the view
+func Register[T any](name string, def Definition) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = &View[T]{name: name, def: def}
+}
+
+// Get returns the view registered under the given name. Lookup errors (unknown name, or a
+// view registered with a different result type) are returned by the methods of the view,
+// so calls can be chained:
+//
+//	summaries, err := views.Get[UserSummary]("user_summary").Query(ctx, conn, views.Args{"since": since})
+func Get[T any](name string) *View[T] {
+	mu.RLock()
+	entry, ok := registry[name]
+	mu.RUnlock()
+	if !ok {
+		return &View[T]{name: name, err: db.NewErrNotFound("view %q is not registered", name)}
+	}
+	view, ok := entry.(*View[T])
+	if !ok {
+		return &View[T]{name: name, err: db.NewErrInvalidDataType("view %q is not registered with result type %s", name, reflect.TypeFor[T]())}
+	}
+	return view
+}
+
+// Names returns the sorted names of all registered views.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return slices.Sorted(maps.Keys(registry))
+}
+
+// Args are the parameter values of a view query, keyed by parameter name.
+type Args map[string]any
+
+// Name returns the name of the view.
+func (v *View[T]) Name() string {
+	return v.name
+}
+
+// Definition returns the definition of the view.
+func (v *View[T]) Definition() Definition {
+	return v.def
+}
+
+// Query executes the view and returns its rows.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout control
+//   - conn: Database session to execute the view on
+//   - args: Parameter values, bound in the order of the declared parameters
+//   - opts: Query options applied to the query
+//
+// Returns:
+//   - []T: Rows of the view
+//   - error: ErrNotFound for unknown views, ErrInvalidDataType for missing required or
+//     undeclared parameters, or the error of the query
+func (v *View[T]) Query(ctx context.Context, conn db.IReadSession, args Args, opts ...db.QueryOption) ([]T, error) {
+	if v.err != nil {
+		return nil, v.err
+	}
+	bound, err := v.bind(args)
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		bound = append(bound, opt)
+	}
+	if v.def.CacheTTL > 0 {
+		return db.QueryCached[T](ctx, conn, v.def.CacheTTL, v.def.SQL, bound...)
+	}
+	return db.Query[T](ctx, conn, v.def.SQL, bound...)
+}
+
+// QueryOne executes the view and returns its first row (see db.QueryOne).
+func (v *View[T]) QueryOne(ctx context.Context, conn db.IReadSession, args Args, opts ...db.QueryOption) (T, error) {
+	var zero T
+	result, err := v.Query(ctx, conn, args, opts...)
+	if err != nil {
+		return zero, err
+	}
+	if len(result) == 0 {
+		return zero, db.NewErrNotFound("view %q returned no rows", v.name)
+	}
+	return result[0], nil
+}
+
+func (v *View[T]) bind(args Args) ([]any, error) {
+	for name := range args {
+		if !v.declares(name) {
+			return nil, db.NewErrInvalidDataType("view %q has no parameter %q", v.name, name)
+		}
+	}
+	bound := make([]any, len(v.def.Params))
+	for i, param := range v.def.Params {
+		value, ok := args[param.Name]
+		switch {
+		case ok:
+			bound[i] = value
+		case param.Required:
+			return nil, db.NewErrInvalidDataType("view %q requires parameter %q", v.name, param.Name)
+		default:
+			bound[i] = param.Default
+		}
+	}
+	return bound, nil
+}
+
+func (v *View[T]) declares(name string) bool {
+	for _, param := range v.def.Params {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}